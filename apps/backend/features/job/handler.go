@@ -7,6 +7,10 @@ import (
 	"errors"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
 
 	"qurio/apps/backend/internal/middleware"
 )
@@ -19,13 +23,46 @@ func NewHandler(s *Service) *Handler {
 	return &Handler{service: s}
 }
 
+// maxListLimit caps how many jobs one page of the listing returns.
+const maxListLimit = 100
+
+// List returns failed jobs, newest first. source_id and since (RFC 3339)
+// narrow the listing; limit and offset page it. Without limit every matching
+// job is returned.
 func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	correlationID := middleware.GetCorrelationID(ctx)
+	query := r.URL.Query()
 
 	slog.InfoContext(ctx, "listing failed jobs", "correlationId", correlationID)
 
-	jobs, err := h.service.List(ctx)
+	f := ListFilter{SourceID: query.Get("source_id")}
+	if f.SourceID != "" {
+		if _, err := uuid.Parse(f.SourceID); err != nil {
+			h.writeError(ctx, w, "VALIDATION_ERROR", "source_id must be a UUID", http.StatusBadRequest)
+			return
+		}
+	}
+	if since := query.Get("since"); since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			h.writeError(ctx, w, "VALIDATION_ERROR", "since must be an RFC 3339 timestamp", http.StatusBadRequest)
+			return
+		}
+		f.Since = t
+	}
+	if l := query.Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 {
+			f.Limit = min(parsed, maxListLimit)
+		}
+	}
+	if o := query.Get("offset"); o != "" {
+		if parsed, err := strconv.Atoi(o); err == nil && parsed > 0 {
+			f.Offset = parsed
+		}
+	}
+
+	jobs, total, err := h.service.ListPage(ctx, f)
 	if err != nil {
 		slog.ErrorContext(ctx, "failed to list jobs", "error", err, "correlationId", correlationID)
 		h.writeError(ctx, w, "INTERNAL_ERROR", err.Error(), http.StatusInternalServerError)
@@ -39,7 +76,7 @@ func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	resp := map[string]interface{}{
 		"data": jobs,
-		"meta": map[string]int{"count": len(jobs)},
+		"meta": map[string]int{"count": len(jobs), "total": total, "limit": f.Limit, "offset": f.Offset},
 	}
 	if err := json.NewEncoder(w).Encode(resp); err != nil {
 		slog.ErrorContext(ctx, "failed to encode response", "error", err)
@@ -70,6 +107,40 @@ func (h *Handler) Retry(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// RetryAll republishes every failed job. Jobs that fail again are listed in
+// the response and stay in the failed list.
+func (h *Handler) RetryAll(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	correlationID := middleware.GetCorrelationID(ctx)
+
+	slog.InfoContext(ctx, "retrying all failed jobs", "correlationId", correlationID)
+
+	retried, err := h.service.RetryAll(ctx)
+	failures := []RetryFailure{}
+	if err != nil {
+		var partial *RetryAllError
+		if !errors.As(err, &partial) {
+			slog.ErrorContext(ctx, "failed to retry jobs", "error", err, "correlationId", correlationID)
+			h.writeError(ctx, w, "INTERNAL_ERROR", err.Error(), http.StatusInternalServerError)
+			return
+		}
+		failures = partial.Failures
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	resp := map[string]interface{}{
+		"data": map[string]interface{}{
+			"retried":  retried,
+			"failed":   len(failures),
+			"failures": failures,
+		},
+	}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		slog.ErrorContext(ctx, "failed to encode response", "error", err)
+	}
+}
+
 func (h *Handler) writeError(ctx context.Context, w http.ResponseWriter, code, message string, status int) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
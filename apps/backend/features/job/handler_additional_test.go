@@ -23,7 +23,7 @@ func TestHandler_List_ServiceError(t *testing.T) {
 	svc := job.NewService(mockRepo, nil, logger)
 	handler := job.NewHandler(svc)
 
-	mockRepo.On("List", mock.Anything).Return(nil, errors.New("database error"))
+	mockRepo.On("List", mock.Anything, job.ListFilter{}).Return(nil, errors.New("database error"))
 
 	req := httptest.NewRequest("GET", "/jobs", nil)
 	w := httptest.NewRecorder()
@@ -41,7 +41,7 @@ func TestHandler_List_EmptyList(t *testing.T) {
 	handler := job.NewHandler(svc)
 
 	// Return nil slice
-	mockRepo.On("List", mock.Anything).Return(nil, nil)
+	mockRepo.On("List", mock.Anything, job.ListFilter{}).Return(nil, nil)
 
 	req := httptest.NewRequest("GET", "/jobs", nil)
 	w := httptest.NewRecorder()
@@ -176,3 +176,160 @@ func TestService_Retry_DeleteError(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, "delete failed", err.Error())
 }
+
+func TestService_RetryAll_PartialFailure(t *testing.T) {
+	mockRepo := new(MockRepo)
+	mockPub := new(MockPublisher)
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	svc := job.NewService(mockRepo, mockPub, logger)
+
+	webPayload := []byte(`{"type": "web", "url": "http://example.com"}`)
+	filePayload := []byte(`{"type": "file", "path": "/tmp/a.pdf"}`)
+	mockRepo.On("List", mock.Anything, job.ListFilter{}).Return([]job.Job{
+		{ID: "web-job", Payload: webPayload},
+		{ID: "file-job", Payload: filePayload},
+	}, nil)
+	mockPub.On("Publish", config.TopicIngestWeb, webPayload).Return(nil)
+	mockPub.On("Publish", config.TopicIngestFile, filePayload).Return(errors.New("nsq unavailable"))
+	mockRepo.On("Delete", mock.Anything, "web-job").Return(nil)
+
+	retried, err := svc.RetryAll(context.Background())
+
+	assert.Equal(t, 1, retried)
+	var partial *job.RetryAllError
+	if assert.ErrorAs(t, err, &partial) {
+		assert.Equal(t, []job.RetryFailure{{JobID: "file-job", Error: "nsq unavailable"}}, partial.Failures)
+	}
+	// The failed job is kept for a later retry
+	mockRepo.AssertNotCalled(t, "Delete", mock.Anything, "file-job")
+	mockPub.AssertExpectations(t)
+}
+
+func TestHandler_RetryAll(t *testing.T) {
+	mockRepo := new(MockRepo)
+	mockPub := new(MockPublisher)
+	svc := job.NewService(mockRepo, mockPub, slog.Default())
+	handler := job.NewHandler(svc)
+
+	mockRepo.On("List", mock.Anything, job.ListFilter{}).Return([]job.Job{
+		{ID: "ok", Payload: []byte(`{"type": "web"}`)},
+		{ID: "bad", Payload: []byte(`not json`)},
+	}, nil)
+	mockPub.On("Publish", config.TopicIngestWeb, mock.Anything).Return(nil)
+	mockRepo.On("Delete", mock.Anything, "ok").Return(nil)
+
+	w := httptest.NewRecorder()
+	handler.RetryAll(w, httptest.NewRequest("POST", "/jobs/retry-all", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"retried":1`)
+	assert.Contains(t, w.Body.String(), `"failed":1`)
+	assert.Contains(t, w.Body.String(), `"job_id":"bad"`)
+}
+
+func TestHandler_RetryAll_ListError(t *testing.T) {
+	mockRepo := new(MockRepo)
+	handler := job.NewHandler(job.NewService(mockRepo, new(MockPublisher), slog.Default()))
+	mockRepo.On("List", mock.Anything, job.ListFilter{}).Return(nil, errors.New("db down"))
+
+	w := httptest.NewRecorder()
+	handler.RetryAll(w, httptest.NewRequest("POST", "/jobs/retry-all", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestService_RetryDue_CountsAttemptAndKeepsJob(t *testing.T) {
+	mockRepo := new(MockRepo)
+	mockPub := new(MockPublisher)
+	svc := job.NewService(mockRepo, mockPub, slog.Default())
+	svc.SetAutoRetry(3, time.Minute)
+
+	// Second attempt used up, its backoff has passed
+	due := time.Now().Add(-time.Second)
+	payload := []byte(`{"type":"web","url":"http://example.com"}`)
+	mockRepo.On("ClaimDue", mock.Anything, mock.Anything, 100).Return([]job.Job{
+		{ID: "j1", Payload: payload, Attempts: 2, NextRetryAt: &due},
+	}, nil)
+	mockPub.On("Publish", config.TopicIngestWeb, payload).Return(nil)
+	mockRepo.On("MarkRetried", mock.Anything, "j1").Return(nil)
+
+	retried, err := svc.RetryDue(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, retried)
+	// The job stays recorded so a repeat failure of the page keeps counting
+	mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_RetryDue_PublishFailureHandsJobBack(t *testing.T) {
+	mockRepo := new(MockRepo)
+	mockPub := new(MockPublisher)
+	svc := job.NewService(mockRepo, mockPub, slog.Default())
+	svc.SetAutoRetry(3, time.Minute)
+
+	due := time.Now().Add(-time.Second)
+	mockRepo.On("ClaimDue", mock.Anything, mock.Anything, 100).Return([]job.Job{
+		{ID: "j1", Payload: []byte(`{"type":"web"}`), NextRetryAt: &due},
+	}, nil)
+	mockPub.On("Publish", config.TopicIngestWeb, mock.Anything).Return(errors.New("nsq down"))
+	mockRepo.On("ScheduleRetry", mock.Anything, "j1", mock.Anything).Return(nil)
+
+	retried, err := svc.RetryDue(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, retried)
+	mockRepo.AssertNotCalled(t, "MarkRetried", mock.Anything, mock.Anything)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_RetryDue_GivesUpAfterMaxAttempts(t *testing.T) {
+	mockRepo := new(MockRepo)
+	mockPub := new(MockPublisher)
+	svc := job.NewService(mockRepo, mockPub, slog.Default())
+	svc.SetAutoRetry(3, time.Minute)
+
+	mockRepo.On("ClaimDue", mock.Anything, mock.Anything, 100).Return([]job.Job{
+		{ID: "spent", Payload: []byte(`{"type":"web"}`), Attempts: 3},
+	}, nil)
+	mockRepo.On("MarkPermanentlyFailed", mock.Anything, "spent").Return(nil)
+
+	retried, err := svc.RetryDue(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, retried)
+	mockPub.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_RetryDue_SchedulesNewFailure(t *testing.T) {
+	mockRepo := new(MockRepo)
+	mockPub := new(MockPublisher)
+	svc := job.NewService(mockRepo, mockPub, slog.Default())
+	svc.SetAutoRetry(3, time.Minute)
+
+	created := time.Now()
+	mockRepo.On("ClaimDue", mock.Anything, mock.Anything, 100).Return([]job.Job{
+		{ID: "fresh", Payload: []byte(`{"type":"web"}`), Attempts: 1, CreatedAt: created},
+	}, nil)
+	// One attempt used, so the wait is doubled
+	mockRepo.On("ScheduleRetry", mock.Anything, "fresh", created.Add(2*time.Minute)).Return(nil)
+
+	retried, err := svc.RetryDue(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, retried)
+	mockPub.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_RetryDue_Disabled(t *testing.T) {
+	mockRepo := new(MockRepo)
+	svc := job.NewService(mockRepo, new(MockPublisher), slog.Default())
+
+	retried, err := svc.RetryDue(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, retried)
+	mockRepo.AssertNotCalled(t, "ClaimDue", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -3,10 +3,12 @@ package job_test
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -24,8 +26,8 @@ func (m *MockRepo) Save(ctx context.Context, j *job.Job) error {
 	return args.Error(0)
 }
 
-func (m *MockRepo) List(ctx context.Context) ([]job.Job, error) {
-	args := m.Called(ctx)
+func (m *MockRepo) List(ctx context.Context, f job.ListFilter) ([]job.Job, error) {
+	args := m.Called(ctx, f)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -45,11 +47,44 @@ func (m *MockRepo) Delete(ctx context.Context, id string) error {
 	return args.Error(0)
 }
 
+func (m *MockRepo) CountMatching(ctx context.Context, f job.ListFilter) (int, error) {
+	args := m.Called(ctx, f)
+	return args.Int(0), args.Error(1)
+}
+
 func (m *MockRepo) Count(ctx context.Context) (int, error) {
 	args := m.Called(ctx)
 	return args.Int(0), args.Error(1)
 }
 
+func (m *MockRepo) Resolve(ctx context.Context, sourceID, url string) error {
+	args := m.Called(ctx, sourceID, url)
+	return args.Error(0)
+}
+
+func (m *MockRepo) ClaimDue(ctx context.Context, now time.Time, limit int) ([]job.Job, error) {
+	args := m.Called(ctx, now, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]job.Job), args.Error(1)
+}
+
+func (m *MockRepo) ScheduleRetry(ctx context.Context, id string, at time.Time) error {
+	args := m.Called(ctx, id, at)
+	return args.Error(0)
+}
+
+func (m *MockRepo) MarkRetried(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepo) MarkPermanentlyFailed(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
 // MockPublisher
 type MockPublisher struct {
 	mock.Mock
@@ -65,7 +100,7 @@ func TestHandler_List(t *testing.T) {
 	svc := job.NewService(mockRepo, nil, slog.Default()) // nil nsq
 	handler := job.NewHandler(svc)
 
-	mockRepo.On("List", mock.Anything).Return([]job.Job{}, nil)
+	mockRepo.On("List", mock.Anything, job.ListFilter{}).Return([]job.Job{}, nil)
 
 	req := httptest.NewRequest("GET", "/jobs", nil)
 	w := httptest.NewRecorder()
@@ -116,3 +151,58 @@ func TestHandler_Retry(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 	mockPub.AssertExpectations(t)
 }
+
+func TestHandler_List_SourceFilter(t *testing.T) {
+	mockRepo := new(MockRepo)
+	handler := job.NewHandler(job.NewService(mockRepo, nil, slog.Default()))
+
+	sourceID := "7f3c1a2e-5b4d-4c6e-9a8b-1d2e3f4a5b6c"
+	mockRepo.On("List", mock.Anything, job.ListFilter{SourceID: sourceID}).
+		Return([]job.Job{{ID: "j1", SourceID: sourceID}}, nil)
+
+	w := httptest.NewRecorder()
+	handler.List(w, httptest.NewRequest("GET", "/jobs/failed?source_id="+sourceID, nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Data []job.Job      `json:"data"`
+		Meta map[string]int `json:"meta"`
+	}
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Len(t, resp.Data, 1)
+	assert.Equal(t, 1, resp.Meta["total"])
+	mockRepo.AssertExpectations(t)
+}
+
+func TestHandler_List_Pagination(t *testing.T) {
+	mockRepo := new(MockRepo)
+	handler := job.NewHandler(job.NewService(mockRepo, nil, slog.Default()))
+
+	since := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	f := job.ListFilter{Since: since, Limit: 2, Offset: 4}
+	mockRepo.On("List", mock.Anything, f).Return([]job.Job{{ID: "j5"}, {ID: "j6"}}, nil)
+	mockRepo.On("CountMatching", mock.Anything, f).Return(7, nil)
+
+	w := httptest.NewRecorder()
+	handler.List(w, httptest.NewRequest("GET", "/jobs/failed?since=2026-03-01T00:00:00Z&limit=2&offset=4", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Data []job.Job      `json:"data"`
+		Meta map[string]int `json:"meta"`
+	}
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Len(t, resp.Data, 2)
+	assert.Equal(t, map[string]int{"count": 2, "total": 7, "limit": 2, "offset": 4}, resp.Meta)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestHandler_List_InvalidParams(t *testing.T) {
+	handler := job.NewHandler(job.NewService(new(MockRepo), nil, slog.Default()))
+
+	for _, q := range []string{"source_id=not-a-uuid", "since=yesterday"} {
+		w := httptest.NewRecorder()
+		handler.List(w, httptest.NewRequest("GET", "/jobs/failed?"+q, nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code, q)
+	}
+}
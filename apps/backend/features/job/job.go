@@ -5,12 +5,28 @@ import (
 	"time"
 )
 
+// Job statuses. A failed job is retried automatically until it has used its
+// attempts; it is then permanently failed and only retried by hand. A job is
+// retrying from the moment a retry is claimed until its page fails again or
+// succeeds, which removes it.
+const (
+	StatusFailed            = "failed"
+	StatusRetrying          = "retrying"
+	StatusPermanentlyFailed = "permanently_failed"
+)
+
 type Job struct {
-	ID        string          `json:"id"`
-	SourceID  string          `json:"source_id"`
-	Handler   string          `json:"handler"`
-	Payload   json.RawMessage `json:"payload"`
-	Error     string          `json:"error"`
-	Retries   int             `json:"retries"`
-	CreatedAt time.Time       `json:"created_at"`
+	ID       string `json:"id"`
+	SourceID string `json:"source_id"`
+	// URL is the page the job is for. Repeat failures of the same page update
+	// its job rather than adding another, so attempts keep counting.
+	URL         string          `json:"url,omitempty"`
+	Handler     string          `json:"handler"`
+	Payload     json.RawMessage `json:"payload"`
+	Error       string          `json:"error"`
+	Retries     int             `json:"retries"`
+	Attempts    int             `json:"attempts"`
+	NextRetryAt *time.Time      `json:"next_retry_at,omitempty"`
+	Status      string          `json:"status"`
+	CreatedAt   time.Time       `json:"created_at"`
 }
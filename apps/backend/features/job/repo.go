@@ -4,14 +4,36 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
 )
 
+// ListFilter narrows and pages the failed jobs listing. The zero value lists
+// every job, newest first.
+type ListFilter struct {
+	SourceID string
+	// Since keeps jobs created at or after it.
+	Since time.Time
+	// Limit caps the number of jobs returned, starting at Offset. Zero
+	// returns them all.
+	Limit  int
+	Offset int
+}
+
 type Repository interface {
 	Save(ctx context.Context, job *Job) error
-	List(ctx context.Context) ([]Job, error)
+	List(ctx context.Context, f ListFilter) ([]Job, error)
+	CountMatching(ctx context.Context, f ListFilter) (int, error)
 	Get(ctx context.Context, id string) (*Job, error)
 	Delete(ctx context.Context, id string) error
 	Count(ctx context.Context) (int, error)
+	Resolve(ctx context.Context, sourceID, url string) error
+	ClaimDue(ctx context.Context, now time.Time, limit int) ([]Job, error)
+	ScheduleRetry(ctx context.Context, id string, at time.Time) error
+	MarkRetried(ctx context.Context, id string) error
+	MarkPermanentlyFailed(ctx context.Context, id string) error
 }
 
 type PostgresRepo struct {
@@ -22,44 +44,86 @@ func NewPostgresRepo(db *sql.DB) *PostgresRepo {
 	return &PostgresRepo{db: db}
 }
 
-func (r *PostgresRepo) Save(ctx context.Context, job *Job) error {
-	query := `INSERT INTO failed_jobs (source_id, handler, payload, error) VALUES ($1, $2, $3, $4) RETURNING id, created_at, retries`
-	return r.db.QueryRowContext(ctx, query, job.SourceID, job.Handler, job.Payload, job.Error).Scan(&job.ID, &job.CreatedAt, &job.Retries)
-}
-
-func (r *PostgresRepo) List(ctx context.Context) ([]Job, error) {
-	query := `SELECT id, source_id, handler, payload, error, retries, created_at FROM failed_jobs ORDER BY created_at DESC`
-	rows, err := r.db.QueryContext(ctx, query)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
+const jobColumns = `id, source_id, url, handler, payload, error, retries, attempts, next_retry_at, status, created_at`
 
-	var jobs []Job
-	for rows.Next() {
-		var j Job
-		var payload []byte
-		if err := rows.Scan(&j.ID, &j.SourceID, &j.Handler, &payload, &j.Error, &j.Retries, &j.CreatedAt); err != nil {
-			return nil, err
-		}
-		j.Payload = json.RawMessage(payload)
-		jobs = append(jobs, j)
-	}
-	return jobs, nil
+type rowScanner interface {
+	Scan(dest ...interface{}) error
 }
 
-func (r *PostgresRepo) Get(ctx context.Context, id string) (*Job, error) {
+func scanJob(row rowScanner) (*Job, error) {
 	j := &Job{}
 	var payload []byte
-	query := `SELECT id, source_id, handler, payload, error, retries, created_at FROM failed_jobs WHERE id = $1`
-	err := r.db.QueryRowContext(ctx, query, id).Scan(&j.ID, &j.SourceID, &j.Handler, &payload, &j.Error, &j.Retries, &j.CreatedAt)
-	if err != nil {
+	var nextRetryAt sql.NullTime
+	if err := row.Scan(&j.ID, &j.SourceID, &j.URL, &j.Handler, &payload, &j.Error, &j.Retries, &j.Attempts, &nextRetryAt, &j.Status, &j.CreatedAt); err != nil {
 		return nil, err
 	}
 	j.Payload = json.RawMessage(payload)
+	if nextRetryAt.Valid {
+		j.NextRetryAt = &nextRetryAt.Time
+	}
 	return j, nil
 }
 
+// Save records a failed job. A job with a URL replaces the one already
+// recorded for that page, keeping its attempts, and is due for retry again.
+func (r *PostgresRepo) Save(ctx context.Context, job *Job) error {
+	query := `INSERT INTO failed_jobs (source_id, url, handler, payload, error, attempts) VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (source_id, url) WHERE url <> '' DO UPDATE SET
+			handler = EXCLUDED.handler, payload = EXCLUDED.payload, error = EXCLUDED.error,
+			status = 'failed', next_retry_at = NULL, created_at = NOW()
+		RETURNING id, created_at, retries, attempts, status`
+	return r.db.QueryRowContext(ctx, query, job.SourceID, job.URL, job.Handler, job.Payload, job.Error, job.Attempts).
+		Scan(&job.ID, &job.CreatedAt, &job.Retries, &job.Attempts, &job.Status)
+}
+
+// Resolve removes the job recorded for a page, once the page has succeeded.
+func (r *PostgresRepo) Resolve(ctx context.Context, sourceID, url string) error {
+	query := `DELETE FROM failed_jobs WHERE source_id = $1 AND url = $2 AND url <> ''`
+	_, err := r.db.ExecContext(ctx, query, sourceID, url)
+	return err
+}
+
+// jobConditions builds the WHERE clause and its arguments for f.
+func jobConditions(f ListFilter) (string, []interface{}) {
+	var conds []string
+	var args []interface{}
+	if f.SourceID != "" {
+		args = append(args, f.SourceID)
+		conds = append(conds, fmt.Sprintf("source_id = $%d", len(args)))
+	}
+	if !f.Since.IsZero() {
+		args = append(args, f.Since)
+		conds = append(conds, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+func (r *PostgresRepo) List(ctx context.Context, f ListFilter) ([]Job, error) {
+	where, args := jobConditions(f)
+	query := `SELECT ` + jobColumns + ` FROM failed_jobs` + where + ` ORDER BY created_at DESC, id DESC`
+	if f.Limit > 0 {
+		args = append(args, f.Limit, f.Offset)
+		query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+	}
+	return r.query(ctx, query, args...)
+}
+
+// CountMatching counts the jobs f selects, ignoring its paging.
+func (r *PostgresRepo) CountMatching(ctx context.Context, f ListFilter) (int, error) {
+	where, args := jobConditions(f)
+	var count int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM failed_jobs`+where, args...).Scan(&count)
+	return count, err
+}
+
+func (r *PostgresRepo) Get(ctx context.Context, id string) (*Job, error) {
+	query := `SELECT ` + jobColumns + ` FROM failed_jobs WHERE id = $1`
+	return scanJob(r.db.QueryRowContext(ctx, query, id))
+}
+
 func (r *PostgresRepo) Delete(ctx context.Context, id string) error {
 	query := `DELETE FROM failed_jobs WHERE id = $1`
 	_, err := r.db.ExecContext(ctx, query, id)
@@ -72,3 +136,69 @@ func (r *PostgresRepo) Count(ctx context.Context) (int, error) {
 	err := r.db.QueryRowContext(ctx, query).Scan(&count)
 	return count, err
 }
+
+// claimLease is how long a claimed job may stay retrying. A job still
+// retrying after it, because its republished task was lost or the process
+// that claimed it stopped before handing it back, is due again.
+const claimLease = 2 * time.Hour
+
+// ClaimDue marks up to limit jobs as retrying and returns them, oldest first:
+// failed jobs that are unscheduled or whose retry time has passed, and
+// retrying jobs whose claim is older than claimLease. Rows are locked and
+// skipped if already locked, so concurrent callers never claim the same job.
+// Permanently failed jobs are never due.
+func (r *PostgresRepo) ClaimDue(ctx context.Context, now time.Time, limit int) ([]Job, error) {
+	query := `UPDATE failed_jobs SET status = $1, claimed_at = $3
+		WHERE id IN (
+			SELECT id FROM failed_jobs
+			WHERE (status = $2 AND (next_retry_at IS NULL OR next_retry_at <= $3))
+				OR (status = $1 AND (claimed_at IS NULL OR claimed_at <= $5))
+			ORDER BY created_at LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + jobColumns
+	jobs, err := r.query(ctx, query, StatusRetrying, StatusFailed, now, limit, now.Add(-claimLease))
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(jobs, func(a, b Job) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return jobs, nil
+}
+
+// ScheduleRetry hands a claimed job back as failed, due again at at.
+func (r *PostgresRepo) ScheduleRetry(ctx context.Context, id string, at time.Time) error {
+	query := `UPDATE failed_jobs SET status = $1, next_retry_at = $2 WHERE id = $3`
+	_, err := r.db.ExecContext(ctx, query, StatusFailed, at, id)
+	return err
+}
+
+// MarkRetried counts an attempt for a claimed job whose task was republished.
+func (r *PostgresRepo) MarkRetried(ctx context.Context, id string) error {
+	query := `UPDATE failed_jobs SET attempts = attempts + 1, next_retry_at = NULL WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, query, id)
+	return err
+}
+
+func (r *PostgresRepo) MarkPermanentlyFailed(ctx context.Context, id string) error {
+	query := `UPDATE failed_jobs SET status = $1, next_retry_at = NULL WHERE id = $2`
+	_, err := r.db.ExecContext(ctx, query, StatusPermanentlyFailed, id)
+	return err
+}
+
+func (r *PostgresRepo) query(ctx context.Context, query string, args ...interface{}) ([]Job, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []Job
+	for rows.Next() {
+		j, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, *j)
+	}
+	return jobs, rows.Err()
+}
@@ -60,7 +60,7 @@ func TestJobRepo_Integration(t *testing.T) {
 	require.NoError(t, err)
 
 	// 3. Verify List Ordering (DESC)
-	jobs, err := jobRepo.List(ctx)
+	jobs, err := jobRepo.List(ctx, job.ListFilter{})
 	require.NoError(t, err)
 	require.Len(t, jobs, 2)
 	assert.Equal(t, j2.ID, jobs[0].ID, "Newest job should be first")
@@ -110,7 +110,7 @@ func TestJobRepo_Empty(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, 0, count)
 
-	list, err := jobRepo.List(ctx)
+	list, err := jobRepo.List(ctx, job.ListFilter{})
 	require.NoError(t, err)
 	assert.Empty(t, list)
 }
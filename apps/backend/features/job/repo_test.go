@@ -28,13 +28,41 @@ func TestPostgresRepo_Save(t *testing.T) {
 		}
 
 		mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO failed_jobs")).
-			WithArgs(j.SourceID, j.Handler, j.Payload, j.Error).
-			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "retries"}).AddRow("1", time.Now(), 0))
+			WithArgs(j.SourceID, "", j.Handler, j.Payload, j.Error, 0).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "retries", "attempts", "status"}).AddRow("1", time.Now(), 0, 0, job.StatusFailed))
 
 		err := repo.Save(context.Background(), j)
 		assert.NoError(t, err)
 		assert.Equal(t, "1", j.ID)
+		assert.Equal(t, job.StatusFailed, j.Status)
 	})
+
+	t.Run("Repeat failure of a page keeps its attempts", func(t *testing.T) {
+		j := &job.Job{SourceID: "src1", URL: "http://example.com", Handler: "handler", Payload: json.RawMessage(`{}`), Error: "again"}
+
+		mock.ExpectQuery(`INSERT INTO failed_jobs .+ ON CONFLICT \(source_id, url\) WHERE url <> '' DO UPDATE SET`).
+			WithArgs("src1", "http://example.com", "handler", j.Payload, "again", 0).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "retries", "attempts", "status"}).AddRow("1", time.Now(), 0, 2, job.StatusFailed))
+
+		assert.NoError(t, repo.Save(context.Background(), j))
+		assert.Equal(t, 2, j.Attempts)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresRepo_Resolve(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := job.NewPostgresRepo(db)
+
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM failed_jobs WHERE source_id = $1 AND url = $2")).
+		WithArgs("src1", "http://example.com").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, repo.Resolve(context.Background(), "src1", "http://example.com"))
+	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
 func TestPostgresRepo_List(t *testing.T) {
@@ -45,14 +73,42 @@ func TestPostgresRepo_List(t *testing.T) {
 	repo := job.NewPostgresRepo(db)
 
 	t.Run("Success", func(t *testing.T) {
-		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, source_id, handler, payload, error, retries, created_at FROM failed_jobs")).
-			WillReturnRows(sqlmock.NewRows([]string{"id", "source_id", "handler", "payload", "error", "retries", "created_at"}).
-				AddRow("1", "src1", "h", []byte(`{}`), "e", 0, time.Now()))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, source_id, url, handler, payload, error, retries, attempts, next_retry_at, status, created_at FROM failed_jobs")).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "source_id", "url", "handler", "payload", "error", "retries", "attempts", "next_retry_at", "status", "created_at"}).
+				AddRow("1", "src1", "", "h", []byte(`{}`), "e", 0, 0, nil, job.StatusFailed, time.Now()))
 
-		jobs, err := repo.List(context.Background())
+		jobs, err := repo.List(context.Background(), job.ListFilter{})
 		assert.NoError(t, err)
 		assert.Len(t, jobs, 1)
 	})
+
+	t.Run("Filtered and paged", func(t *testing.T) {
+		since := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		mock.ExpectQuery(regexp.QuoteMeta("FROM failed_jobs WHERE source_id = $1 AND created_at >= $2 ORDER BY created_at DESC, id DESC LIMIT $3 OFFSET $4")).
+			WithArgs("src1", since, 10, 20).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "source_id", "url", "handler", "payload", "error", "retries", "attempts", "next_retry_at", "status", "created_at"}))
+
+		jobs, err := repo.List(context.Background(), job.ListFilter{SourceID: "src1", Since: since, Limit: 10, Offset: 20})
+		assert.NoError(t, err)
+		assert.Empty(t, jobs)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresRepo_CountMatching(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := job.NewPostgresRepo(db)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM failed_jobs WHERE source_id = $1")).
+		WithArgs("src1").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+	count, err := repo.CountMatching(context.Background(), job.ListFilter{SourceID: "src1", Limit: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
 }
 
 func TestPostgresRepo_Get(t *testing.T) {
@@ -63,10 +119,10 @@ func TestPostgresRepo_Get(t *testing.T) {
 	repo := job.NewPostgresRepo(db)
 
 	t.Run("Success", func(t *testing.T) {
-		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, source_id, handler, payload, error, retries, created_at FROM failed_jobs WHERE id = $1")).
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, source_id, url, handler, payload, error, retries, attempts, next_retry_at, status, created_at FROM failed_jobs WHERE id = $1")).
 			WithArgs("1").
-			WillReturnRows(sqlmock.NewRows([]string{"id", "source_id", "handler", "payload", "error", "retries", "created_at"}).
-				AddRow("1", "src1", "h", []byte(`{}`), "e", 0, time.Now()))
+			WillReturnRows(sqlmock.NewRows([]string{"id", "source_id", "url", "handler", "payload", "error", "retries", "attempts", "next_retry_at", "status", "created_at"}).
+				AddRow("1", "src1", "", "h", []byte(`{}`), "e", 0, 0, nil, job.StatusFailed, time.Now()))
 
 		j, err := repo.Get(context.Background(), "1")
 		assert.NoError(t, err)
@@ -103,3 +159,60 @@ func TestPostgresRepo_Count(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, 5, count)
 }
+
+func TestPostgresRepo_ClaimDue(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := job.NewPostgresRepo(db)
+	now := time.Now()
+	next := now.Add(-time.Minute)
+
+	// Retrying jobs claimed more than the lease ago are reclaimed
+	mock.ExpectQuery(`UPDATE failed_jobs SET status = \$1, claimed_at = \$3\s+WHERE id IN \(\s+SELECT id FROM failed_jobs\s+WHERE \(status = \$2 AND \(next_retry_at IS NULL OR next_retry_at <= \$3\)\)\s+OR \(status = \$1 AND \(claimed_at IS NULL OR claimed_at <= \$5\)\)\s+ORDER BY created_at LIMIT \$4\s+FOR UPDATE SKIP LOCKED`).
+		WithArgs(job.StatusRetrying, job.StatusFailed, now, 100, now.Add(-2*time.Hour)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "source_id", "url", "handler", "payload", "error", "retries", "attempts", "next_retry_at", "status", "created_at"}).
+			AddRow("2", "src1", "", "h", []byte(`{}`), "e", 0, 0, nil, job.StatusRetrying, now).
+			AddRow("1", "src1", "", "h", []byte(`{}`), "e", 0, 2, next, job.StatusRetrying, next))
+
+	jobs, err := repo.ClaimDue(context.Background(), now, 100)
+	assert.NoError(t, err)
+	if assert.Len(t, jobs, 2) {
+		// Oldest first
+		assert.Equal(t, "1", jobs[0].ID)
+		assert.Equal(t, 2, jobs[0].Attempts)
+		assert.Equal(t, next, *jobs[0].NextRetryAt)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepo_MarkRetried(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := job.NewPostgresRepo(db)
+
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE failed_jobs SET attempts = attempts + 1, next_retry_at = NULL WHERE id = $1")).
+		WithArgs("1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, repo.MarkRetried(context.Background(), "1"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepo_MarkPermanentlyFailed(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := job.NewPostgresRepo(db)
+
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE failed_jobs SET status = $1, next_retry_at = NULL WHERE id = $2")).
+		WithArgs(job.StatusPermanentlyFailed, "1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, repo.MarkPermanentlyFailed(context.Background(), "1"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -18,14 +18,42 @@ type Service struct {
 	repo   Repository
 	pub    EventPublisher
 	logger *slog.Logger
+
+	maxAttempts int
+	retryBase   time.Duration
 }
 
 func NewService(repo Repository, pub EventPublisher, logger *slog.Logger) *Service {
 	return &Service{repo: repo, pub: pub, logger: logger}
 }
 
+// SetAutoRetry lets RetryDue republish a failed job up to maxAttempts times,
+// waiting base after the failure and doubling the wait for every attempt
+// already used. Zero attempts disables automatic retries.
+func (s *Service) SetAutoRetry(maxAttempts int, base time.Duration) {
+	s.maxAttempts = maxAttempts
+	s.retryBase = base
+}
+
 func (s *Service) List(ctx context.Context) ([]Job, error) {
-	return s.repo.List(ctx)
+	return s.repo.List(ctx, ListFilter{})
+}
+
+// ListPage returns the jobs f selects along with how many match in total,
+// regardless of paging.
+func (s *Service) ListPage(ctx context.Context, f ListFilter) ([]Job, int, error) {
+	jobs, err := s.repo.List(ctx, f)
+	if err != nil {
+		return nil, 0, err
+	}
+	if f.Limit <= 0 {
+		return jobs, len(jobs), nil
+	}
+	total, err := s.repo.CountMatching(ctx, f)
+	if err != nil {
+		return nil, 0, err
+	}
+	return jobs, total, nil
 }
 
 func (s *Service) Retry(ctx context.Context, id string) error {
@@ -38,6 +66,153 @@ func (s *Service) Retry(ctx context.Context, id string) error {
 		return err
 	}
 
+	if err := s.resend(ctx, job); err != nil {
+		return err
+	}
+
+	s.logger.Info("job retry successful", "job_id", id)
+	return nil
+}
+
+// RetryFailure records a job RetryAll could not retry.
+type RetryFailure struct {
+	JobID string `json:"job_id"`
+	Error string `json:"error"`
+}
+
+// RetryAllError is returned by RetryAll when some jobs could not be retried.
+// Those jobs stay in the failed list.
+type RetryAllError struct {
+	Failures []RetryFailure
+}
+
+func (e *RetryAllError) Error() string {
+	return fmt.Sprintf("%d jobs could not be retried", len(e.Failures))
+}
+
+// RetryAll republishes every failed job and deletes the ones that were
+// published. A job that fails does not stop the others; the count of retried
+// jobs is returned alongside a *RetryAllError listing the failures.
+func (s *Service) RetryAll(ctx context.Context) (int, error) {
+	jobs, err := s.repo.List(ctx, ListFilter{})
+	if err != nil {
+		return 0, err
+	}
+	s.logger.Info("bulk job retry started", "count", len(jobs))
+
+	retried := 0
+	var failures []RetryFailure
+	for i := range jobs {
+		if err := s.resend(ctx, &jobs[i]); err != nil {
+			if ctx.Err() != nil {
+				return retried, ctx.Err()
+			}
+			failures = append(failures, RetryFailure{JobID: jobs[i].ID, Error: err.Error()})
+			continue
+		}
+		retried++
+	}
+
+	s.logger.Info("bulk job retry finished", "retried", retried, "failed", len(failures))
+	if len(failures) > 0 {
+		return retried, &RetryAllError{Failures: failures}
+	}
+	return retried, nil
+}
+
+// retryDueBatch bounds how many jobs one RetryDue call handles.
+const retryDueBatch = 100
+
+// RetryDue republishes failed jobs whose backoff has elapsed and returns how
+// many were republished. Jobs that have used all their attempts are marked
+// permanently failed instead. Republished jobs stay recorded, with the attempt
+// counted, until their page fails again or succeeds. It is meant to be called
+// periodically, from any number of processes, and does nothing when automatic
+// retries are disabled.
+func (s *Service) RetryDue(ctx context.Context) (int, error) {
+	if s.maxAttempts <= 0 {
+		return 0, nil
+	}
+
+	now := time.Now()
+	jobs, err := s.repo.ClaimDue(ctx, now, retryDueBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	retried := 0
+	for i := range jobs {
+		j := jobs[i]
+		if j.Attempts >= s.maxAttempts {
+			s.logger.Warn("job out of retry attempts, giving up", "job_id", j.ID, "attempts", j.Attempts)
+			if err := s.repo.MarkPermanentlyFailed(ctx, j.ID); err != nil {
+				s.logger.Error("failed to mark job permanently failed", "job_id", j.ID, "error", err)
+			}
+			continue
+		}
+
+		// A new failure is scheduled rather than retried on the spot
+		if j.NextRetryAt == nil {
+			at := j.CreatedAt.Add(s.backoff(j.Attempts))
+			if at.After(now) {
+				if err := s.repo.ScheduleRetry(ctx, j.ID, at); err != nil {
+					s.logger.Error("failed to schedule job retry", "job_id", j.ID, "error", err)
+				}
+				continue
+			}
+		}
+
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(j.Payload, &fields); err != nil {
+			s.logger.Error("job payload cannot be retried", "job_id", j.ID, "error", err)
+			if err := s.repo.MarkPermanentlyFailed(ctx, j.ID); err != nil {
+				s.logger.Error("failed to mark job permanently failed", "job_id", j.ID, "error", err)
+			}
+			continue
+		}
+
+		if err := s.publish(ctx, &j); err != nil {
+			if ctx.Err() != nil {
+				return retried, ctx.Err()
+			}
+			// Nothing was published, so the attempt is not used up
+			if err := s.repo.ScheduleRetry(ctx, j.ID, now.Add(s.backoff(j.Attempts))); err != nil {
+				s.logger.Error("failed to schedule job retry", "job_id", j.ID, "error", err)
+			}
+			continue
+		}
+		if err := s.repo.MarkRetried(ctx, j.ID); err != nil {
+			s.logger.Error("failed to count job retry", "job_id", j.ID, "error", err)
+		}
+		s.logger.Info("job retried automatically", "job_id", j.ID, "attempt", j.Attempts+1)
+		retried++
+	}
+	return retried, nil
+}
+
+// backoff is how long to wait before the retry after attempts earlier ones.
+func (s *Service) backoff(attempts int) time.Duration {
+	return s.retryBase << attempts
+}
+
+// resend publishes a job's payload to the topic it came from and deletes the
+// job once published.
+func (s *Service) resend(ctx context.Context, job *Job) error {
+	if err := s.publish(ctx, job); err != nil {
+		return err
+	}
+
+	// Delete Job
+	if err := s.repo.Delete(ctx, job.ID); err != nil {
+		s.logger.Error("failed to delete job", "job_id", job.ID, "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// publish sends a job's payload to the topic it came from.
+func (s *Service) publish(ctx context.Context, job *Job) error {
 	// Determine topic from payload
 	var payloadMap map[string]interface{}
 	if err := json.Unmarshal(job.Payload, &payloadMap); err != nil {
@@ -50,7 +225,7 @@ func (s *Service) Retry(ctx context.Context, id string) error {
 		topic = config.TopicIngestFile
 	}
 
-	// 2. Publish to NSQ with timeout
+	// Publish to NSQ with timeout
 	done := make(chan error, 1)
 	go func() {
 		done <- s.pub.Publish(topic, job.Payload)
@@ -59,23 +234,15 @@ func (s *Service) Retry(ctx context.Context, id string) error {
 	select {
 	case err := <-done:
 		if err != nil {
-			s.logger.Error("failed to publish job", "job_id", id, "error", err)
+			s.logger.Error("failed to publish job", "job_id", job.ID, "error", err)
 			return err
 		}
 	case <-time.After(5 * time.Second):
-		s.logger.Error("timeout waiting for NSQ publish", "job_id", id)
+		s.logger.Error("timeout waiting for NSQ publish", "job_id", job.ID)
 		return fmt.Errorf("timeout waiting for NSQ publish")
 	case <-ctx.Done():
 		return ctx.Err()
 	}
-
-	// 3. Delete Job
-	if err := s.repo.Delete(ctx, id); err != nil {
-		s.logger.Error("failed to delete job", "job_id", id, "error", err)
-		return err
-	}
-
-	s.logger.Info("job retry successful", "job_id", id)
 	return nil
 }
 
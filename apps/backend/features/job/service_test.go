@@ -61,7 +61,7 @@ func TestRetry_Timeout(t *testing.T) {
 }
 
 func (m *MockRepoService) Count(ctx context.Context) (int, error) { return 10, nil }
-func (m *MockRepoService) List(ctx context.Context) ([]Job, error) {
+func (m *MockRepoService) List(ctx context.Context, f ListFilter) ([]Job, error) {
 	return []Job{{ID: "1"}, {ID: "2"}}, nil
 }
 
@@ -112,10 +112,10 @@ type MockJobRepoForTopic struct {
 func (m *MockJobRepoForTopic) Get(ctx context.Context, id string) (*Job, error) {
 	return &Job{ID: id, Payload: m.Payload}, nil
 }
-func (m *MockJobRepoForTopic) Delete(ctx context.Context, id string) error { return nil }
-func (m *MockJobRepoForTopic) List(ctx context.Context) ([]Job, error)     { return nil, nil }
-func (m *MockJobRepoForTopic) Count(ctx context.Context) (int, error)      { return 0, nil }
-func (m *MockJobRepoForTopic) Save(ctx context.Context, job *Job) error    { return nil }
+func (m *MockJobRepoForTopic) Delete(ctx context.Context, id string) error           { return nil }
+func (m *MockJobRepoForTopic) List(ctx context.Context, f ListFilter) ([]Job, error) { return nil, nil }
+func (m *MockJobRepoForTopic) Count(ctx context.Context) (int, error)                { return 0, nil }
+func (m *MockJobRepoForTopic) Save(ctx context.Context, job *Job) error              { return nil }
 
 func TestRetry_TopicSelection(t *testing.T) {
 	pub := &MockPublisher{}
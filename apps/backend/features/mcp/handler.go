@@ -1,12 +1,17 @@
 package mcp
 
 import (
+	"bytes"
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"maps"
 	"net/http"
+	"slices"
 
 	"qurio/apps/backend/features/source"
 	"qurio/apps/backend/internal/retrieval"
@@ -19,19 +24,44 @@ type Retriever interface {
 
 type SourceManager interface {
 	List(ctx context.Context) ([]source.Source, error)
-	GetPages(ctx context.Context, id string) ([]source.SourcePage, error)
+	ListByIDs(ctx context.Context, ids []string) ([]source.Source, error)
+	Get(ctx context.Context, id string, limit, offset int, includeChunks bool) (*source.SourceDetail, error)
+	ListPages(ctx context.Context, id, cursor string, limit int) ([]source.SourcePage, string, error)
 }
 
 type Handler struct {
 	retriever Retriever
 	sourceMgr SourceManager
+	profile   string
+
+	// maxSearchChars caps the total qurio_search output; 0 = unlimited
+	maxSearchChars int
 }
 
 func NewHandler(r Retriever, s SourceManager) *Handler {
 	return &Handler{
 		retriever: r,
 		sourceMgr: s,
+		profile:   ProfileGeneral,
+	}
+}
+
+// SetProfile selects the deployment profile that tool descriptions are
+// written for. Unknown profiles fall back to ProfileGeneral.
+func (h *Handler) SetProfile(profile string) {
+	if _, ok := searchDescriptions[profile]; !ok {
+		slog.Warn("unknown MCP profile, using general", "profile", profile)
+		profile = ProfileGeneral
 	}
+	h.profile = profile
+}
+
+// SetMaxSearchChars caps the total size of a qurio_search response. Results
+// that would push the output past n characters are omitted and counted in a
+// closing note instead. The first result is always returned. 0 disables the
+// budget.
+func (h *Handler) SetMaxSearchChars(n int) {
+	h.maxSearchChars = n
 }
 
 // JSON-RPC Request types
@@ -51,8 +81,16 @@ type SearchArgs struct {
 	Query    string                 `json:"query"`
 	Alpha    *float32               `json:"alpha,omitempty"`
 	Limit    *int                   `json:"limit,omitempty"`
+	Offset   *int                   `json:"offset,omitempty"`
 	SourceID *string                `json:"source_id,omitempty"`
 	Filters  map[string]interface{} `json:"filters,omitempty"`
+
+	SnippetsPerPage *int     `json:"snippets_per_page,omitempty"`
+	MetadataOnly    bool     `json:"metadata_only,omitempty"`
+	Exact           bool     `json:"exact,omitempty"`
+	Diversity       *float32 `json:"diversity,omitempty"`
+	Dedupe          bool     `json:"dedupe,omitempty"`
+	IncludeWarnings *bool    `json:"include_warnings,omitempty"`
 }
 
 type FetchPageArgs struct {
@@ -95,6 +133,12 @@ const (
 	ErrInternal       = -32603
 )
 
+// qurio_list_pages batch sizes.
+const (
+	defaultListPagesLimit = 50
+	maxListPagesLimit     = 200
+)
+
 // ProcessRequest processes the JSON-RPC request and returns a response.
 // Returns nil if no response should be sent (e.g. for notifications).
 func (h *Handler) ProcessRequest(ctx context.Context, req JSONRPCRequest) *JSONRPCResponse {
@@ -105,7 +149,8 @@ func (h *Handler) ProcessRequest(ctx context.Context, req JSONRPCRequest) *JSONR
 			Result: map[string]interface{}{
 				"protocolVersion": "2024-11-05",
 				"capabilities": map[string]interface{}{
-					"tools": map[string]interface{}{},
+					"tools":     map[string]interface{}{},
+					"resources": map[string]interface{}{},
 				},
 				"serverInfo": map[string]interface{}{
 					"name":    "qurio-mcp",
@@ -120,6 +165,23 @@ func (h *Handler) ProcessRequest(ctx context.Context, req JSONRPCRequest) *JSONR
 		return nil
 	}
 
+	if req.Method == "ping" {
+		// Liveness check: respond with an empty result
+		return &JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Result:  map[string]interface{}{},
+		}
+	}
+
+	if req.Method == "resources/list" {
+		return h.listResources(ctx, req)
+	}
+
+	if req.Method == "resources/read" {
+		return h.readResource(ctx, req)
+	}
+
 	if req.Method == "tools/list" {
 		return &JSONRPCResponse{
 			JSONRPC: "2.0",
@@ -127,30 +189,8 @@ func (h *Handler) ProcessRequest(ctx context.Context, req JSONRPCRequest) *JSONR
 			Result: ListToolsResult{
 				Tools: []Tool{
 					{
-						Name: "qurio_search",
-						Description: `Search & Exploration tool. Performs a hybrid search (Keyword + Vector). Use this for specific questions, finding code snippets, or exploring topics across known sources.
-
-ARGUMENT GUIDE:
-
-[Alpha: Hybrid Search Balance]
-- 0.0 (Keyword): Use for Error Codes ("0x8004"), IDs ("550e8400"), or unique strings.
-- 0.3 (Mostly Keyword): Use for specific function names ("handle_web_task") where exact match matters but context helps.
-- 0.5 (Hybrid - Default): Safe bet for general queries like "database configuration".
-- 1.0 (Vector): Use for conceptual "How do I..." questions (e.g. "stop server" matches "shutdown").
-
-[Limit: Result Count]
-- Default: 10
-- Recommended: 5-15 (Prevent context bloat)
-- Max: 50
-
-[Filters: Metadata Filtering]
-- type: Filter by content type (e.g., "code", "prose", "api", "config").
-- language: Filter by language (e.g., "go", "python", "json").
-
-USAGE EXAMPLES:
-- Specific: search(query="webhook signature", alpha=0.3)
-- Conceptual: search(query="how to handle errors", alpha=1.0)
-- Filtered: search(query="User struct", filters={"type": "code", "language": "go"})`,
+						Name:        "qurio_search",
+						Description: searchDescription(h.profile),
 						InputSchema: map[string]interface{}{
 							"type": "object",
 							"properties": map[string]interface{}{
@@ -170,13 +210,45 @@ USAGE EXAMPLES:
 									"minimum":     1,
 									"maximum":     50,
 								},
+								"offset": map[string]interface{}{
+									"type":        "integer",
+									"description": fmt.Sprintf("Number of results to skip, for paging through a broad query (default 0). offset + limit may not exceed %d.", retrieval.MaxSearchWindow),
+									"minimum":     0,
+								},
 								"source_id": map[string]string{
 									"type":        "string",
 									"description": "Filter results by source ID",
 								},
+								"include_warnings": map[string]interface{}{
+									"type":        "boolean",
+									"description": "Note when a matched source is still indexing and results may be incomplete (default true).",
+								},
+								"exact": map[string]interface{}{
+									"type":        "boolean",
+									"description": "Run alpha exactly as given, bypassing the server's minimum alpha (default false).",
+								},
+								"diversity": map[string]interface{}{
+									"type":        "number",
+									"description": "Diversify results (MMR lambda): 1.0 ranks by relevance only, lower values favour results unlike those already returned. Omit for normal ranking.",
+									"minimum":     0.0,
+									"maximum":     1.0,
+								},
+								"dedupe": map[string]interface{}{
+									"type":        "boolean",
+									"description": "Collapse results with identical content, e.g. boilerplate repeated across pages, into one (default false).",
+								},
+								"metadata_only": map[string]interface{}{
+									"type":        "boolean",
+									"description": "Return result metadata without content (default false).",
+								},
+								"snippets_per_page": map[string]interface{}{
+									"type":        "integer",
+									"description": "Max snippets returned per page (default 1).",
+									"minimum":     1,
+								},
 								"filters": map[string]interface{}{
 									"type":        "object",
-									"description": "Metadata filters (e.g. type='code', language='go')",
+									"description": "Metadata filters (e.g. type='code', language='go', metadata={'team': 'payments'}). Numeric and date properties take ranges, e.g. chunkIndex={'gte': 0, 'lte': 2}",
 								},
 							},
 							"required": []string{"query"},
@@ -184,7 +256,7 @@ USAGE EXAMPLES:
 					},
 					{
 						Name: "qurio_list_sources",
-						Description: `Discovery tool. Lists all available documentation sets (sources) currently indexed. Use this at the start of a session to understand what documentation is available.
+						Description: `Discovery tool. Lists all available documentation sets (sources) currently indexed. Use this at the start of a session to understand what documentation is available. Sources are ordered by name, then ID.
 
 USAGE EXAMPLE:
 qurio_list_sources()`,
@@ -193,12 +265,30 @@ qurio_list_sources()`,
 							"properties": map[string]interface{}{},
 						},
 					},
+					{
+						Name: "qurio_get_source",
+						Description: `Status tool. Returns one source's metadata and ingestion progress: status, type, url, max_depth, total_chunks and how many pages are completed, pending or failed. Use this to check whether a source has finished indexing instead of scanning qurio_list_sources.
+
+USAGE EXAMPLE:
+qurio_get_source(source_id="src_stripe_api")`,
+						InputSchema: map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"source_id": map[string]string{
+									"type":        "string",
+									"description": "The ID of the source",
+								},
+							},
+							"required": []string{"source_id"},
+						},
+					},
 					{
 						Name: "qurio_list_pages",
-						Description: `Navigation tool. Lists all individual pages/documents within a specific source. Use this to find the exact URL of a document when a search query is too broad or to browse the table of contents.
+						Description: `Navigation tool. Lists the individual pages/documents within a specific source. Use this to find the exact URL of a document when a search query is too broad or to browse the table of contents. Pages are returned in batches (50 by default, at most 200) in a stable order; when more remain, the result ends with a next_cursor to pass back as cursor.
 
 USAGE EXAMPLE:
-qurio_list_pages(source_id="src_stripe_api")`,
+qurio_list_pages(source_id="src_stripe_api")
+qurio_list_pages(source_id="src_stripe_api", cursor="<next_cursor>")`,
 						InputSchema: map[string]interface{}{
 							"type": "object",
 							"properties": map[string]interface{}{
@@ -206,6 +296,14 @@ qurio_list_pages(source_id="src_stripe_api")`,
 									"type":        "string",
 									"description": "The ID of the source",
 								},
+								"limit": map[string]string{
+									"type":        "integer",
+									"description": "Maximum pages to return (default 50, max 200)",
+								},
+								"cursor": map[string]string{
+									"type":        "string",
+									"description": "next_cursor from a previous qurio_list_pages call",
+								},
 							},
 							"required": []string{"source_id"},
 						},
@@ -267,6 +365,32 @@ read_page(url="https://docs.stripe.com/webhooks/signatures")`,
 				return &resp
 			}
 
+			if args.Offset != nil {
+				limit := 10
+				if args.Limit != nil {
+					limit = *args.Limit
+				}
+				if *args.Offset < 0 || *args.Offset+limit > retrieval.MaxSearchWindow {
+					resp := makeErrorResponse(req.ID, ErrInvalidParams, fmt.Sprintf("Offset must be at least 0, and offset + limit at most %d", retrieval.MaxSearchWindow))
+					return &resp
+				}
+			}
+
+			if args.Diversity != nil && (*args.Diversity < 0.0 || *args.Diversity > 1.0) {
+				resp := makeErrorResponse(req.ID, ErrInvalidParams, "Diversity must be between 0.0 and 1.0")
+				return &resp
+			}
+
+			if err := retrieval.ValidateFilters(args.Filters); err != nil {
+				resp := makeErrorResponse(req.ID, ErrInvalidParams, "Invalid filters: "+err.Error())
+				return &resp
+			}
+
+			// Source-scoped endpoints search only their source
+			if scope := sourceScope(ctx); scope != "" {
+				args.SourceID = &scope
+			}
+
 			if args.SourceID != nil && *args.SourceID != "" {
 				if args.Filters == nil {
 					args.Filters = make(map[string]interface{})
@@ -274,10 +398,20 @@ read_page(url="https://docs.stripe.com/webhooks/signatures")`,
 				args.Filters["sourceId"] = *args.SourceID
 			}
 
+			snippetsPerPage := 1
+			if args.SnippetsPerPage != nil && *args.SnippetsPerPage > 0 {
+				snippetsPerPage = *args.SnippetsPerPage
+			}
+
 			opts := &retrieval.SearchOptions{
-				Alpha:   args.Alpha,
-				Limit:   args.Limit,
-				Filters: args.Filters,
+				Alpha:           args.Alpha,
+				Limit:           args.Limit,
+				Offset:          args.Offset,
+				Filters:         args.Filters,
+				SnippetsPerPage: &snippetsPerPage,
+				Exact:           args.Exact,
+				Diversity:       args.Diversity,
+				Dedupe:          args.Dedupe,
 			}
 			results, err := h.retriever.Search(ctx, args.Query, opts)
 			if err != nil {
@@ -287,39 +421,58 @@ read_page(url="https://docs.stripe.com/webhooks/signatures")`,
 			}
 
 			var textResult string
+			if args.IncludeWarnings == nil || *args.IncludeWarnings {
+				textResult = h.indexingWarnings(ctx, results, args.SourceID)
+			}
 			if len(results) == 0 {
-				textResult = "No results found."
+				textResult += "No results found."
 			} else {
+				omitted := 0
 				for i, res := range results {
-					textResult += fmt.Sprintf("Result %d (Score: %.2f):\n", i+1, res.Score)
+					entry := fmt.Sprintf("Result %d (Score: %.2f):\n", i+1, res.Score)
 					if res.Title != "" {
-						textResult += fmt.Sprintf("Title: %s\n", res.Title)
+						entry += fmt.Sprintf("Title: %s\n", res.Title)
 					}
 					if res.SourceName != "" {
-						textResult += fmt.Sprintf("Source: %s\n", res.SourceName)
+						entry += fmt.Sprintf("Source: %s\n", res.SourceName)
 					}
 					if res.URL != "" {
-						textResult += fmt.Sprintf("URL: %s\n", res.URL)
+						entry += fmt.Sprintf("URL: %s\n", res.URL)
+					}
+					if res.DuplicateCount > 0 {
+						entry += fmt.Sprintf("(appears on %d pages)\n", res.DuplicateCount+1)
 					}
 					// Extract Type, Language, and SourceID from explicit fields
 					if res.Type != "" {
-						textResult += fmt.Sprintf("Type: %s\n", res.Type)
+						entry += fmt.Sprintf("Type: %s\n", res.Type)
 					}
 					if res.Language != "" {
-						textResult += fmt.Sprintf("Language: %s\n", res.Language)
+						entry += fmt.Sprintf("Language: %s\n", res.Language)
 					}
 					if res.SourceID != "" {
-						textResult += fmt.Sprintf("SourceID: %s\n", res.SourceID)
+						entry += fmt.Sprintf("SourceID: %s\n", res.SourceID)
 					}
 
-					textResult += fmt.Sprintf("Content:\n```\n%s\n```\n", res.Content)
+					if !args.MetadataOnly {
+						entry += fmt.Sprintf("Content:\n```\n%s\n```\n", res.Content)
+					}
 
 					// Optional: Show other metadata
 					// if len(res.Metadata) > 0 {
 					// 	meta, _ := json.Marshal(res.Metadata)
 					// 	txtResult += fmt.Sprintf("Metadata: %s\n", string(meta))
 					// }
-					textResult += "\n---\n"
+					entry += "\n---\n"
+
+					if h.maxSearchChars > 0 && i > 0 && len(textResult)+len(entry) > h.maxSearchChars {
+						omitted = len(results) - i
+						break
+					}
+					textResult += entry
+				}
+
+				if omitted > 0 {
+					textResult += fmt.Sprintf("\n%d more results omitted; refine your query or use filters.\n", omitted)
 				}
 
 				textResult += "\nUse qurio_read_page(url=\"...\") to read the full content of any result.\n"
@@ -409,9 +562,95 @@ read_page(url="https://docs.stripe.com/webhooks/signatures")`,
 			}
 		}
 
+		if params.Name == "qurio_get_source" {
+			var args struct {
+				SourceID string `json:"source_id"`
+			}
+			if err := json.Unmarshal(params.Arguments, &args); err != nil {
+				slog.Warn("invalid get_source arguments", "error", err)
+				resp := makeErrorResponse(req.ID, ErrInvalidParams, "Invalid arguments")
+				return &resp
+			}
+			if args.SourceID == "" {
+				resp := makeErrorResponse(req.ID, ErrInvalidParams, "source_id is required")
+				return &resp
+			}
+
+			detail, err := h.sourceMgr.Get(ctx, args.SourceID, 0, 0, false)
+			if err != nil {
+				text := "Error: " + err.Error()
+				if errors.Is(err, sql.ErrNoRows) {
+					text = "Source not found: " + args.SourceID
+				} else {
+					slog.Error("get_source failed", "error", err)
+				}
+				return &JSONRPCResponse{
+					JSONRPC: "2.0",
+					ID:      req.ID,
+					Result: ToolResult{
+						Content: []ToolContent{{Type: "text", Text: text}},
+						IsError: true,
+					},
+				}
+			}
+
+			type SourceStatus struct {
+				ID             string  `json:"id"`
+				Name           string  `json:"name"`
+				Status         string  `json:"status"`
+				Type           string  `json:"type"`
+				URL            string  `json:"url"`
+				MaxDepth       int     `json:"max_depth"`
+				TotalChunks    int     `json:"total_chunks"`
+				PagesTotal     int     `json:"pages_total"`
+				PagesCompleted int     `json:"pages_completed"`
+				PagesPending   int     `json:"pages_pending"`
+				PagesFailed    int     `json:"pages_failed"`
+				Completion     float64 `json:"completion"` // completed / total pages
+			}
+
+			status := SourceStatus{
+				ID:             detail.ID,
+				Name:           detail.Name,
+				Status:         detail.Status,
+				Type:           detail.Type,
+				URL:            detail.URL,
+				MaxDepth:       detail.MaxDepth,
+				TotalChunks:    detail.TotalChunks,
+				PagesCompleted: detail.PageCounts["completed"],
+				PagesPending:   detail.PageCounts["pending"] + detail.PageCounts["processing"] + detail.PageCounts["held"],
+				PagesFailed:    detail.PageCounts["failed"],
+			}
+			for _, n := range detail.PageCounts {
+				status.PagesTotal += n
+			}
+			if status.PagesTotal > 0 {
+				status.Completion = float64(status.PagesCompleted) / float64(status.PagesTotal)
+			}
+
+			jsonBytes, err := json.MarshalIndent(status, "", "  ")
+			if err != nil {
+				slog.Error("failed to marshal source", "error", err)
+				resp := makeErrorResponse(req.ID, ErrInternal, "Error marshalling source")
+				return &resp
+			}
+
+			return &JSONRPCResponse{
+				JSONRPC: "2.0",
+				ID:      req.ID,
+				Result: ToolResult{
+					Content: []ToolContent{
+						{Type: "text", Text: string(jsonBytes)},
+					},
+				},
+			}
+		}
+
 		if params.Name == "qurio_list_pages" {
 			type ListPagesArgs struct {
 				SourceID string `json:"source_id"`
+				Limit    int    `json:"limit"`
+				Cursor   string `json:"cursor"`
 			}
 			var args ListPagesArgs
 			if err := json.Unmarshal(params.Arguments, &args); err != nil {
@@ -425,7 +664,18 @@ read_page(url="https://docs.stripe.com/webhooks/signatures")`,
 				return &resp
 			}
 
-			pages, err := h.sourceMgr.GetPages(ctx, args.SourceID)
+			limit := args.Limit
+			if limit <= 0 {
+				limit = defaultListPagesLimit
+			} else if limit > maxListPagesLimit {
+				limit = maxListPagesLimit
+			}
+
+			pages, nextCursor, err := h.sourceMgr.ListPages(ctx, args.SourceID, args.Cursor, limit)
+			if errors.Is(err, source.ErrInvalidCursor) {
+				resp := makeErrorResponse(req.ID, ErrInvalidParams, "Invalid cursor")
+				return &resp
+			}
 			if err != nil {
 				slog.Error("list_pages failed", "error", err)
 				return &JSONRPCResponse{
@@ -476,12 +726,17 @@ read_page(url="https://docs.stripe.com/webhooks/signatures")`,
 				}
 			}
 
+			text := string(jsonBytes)
+			if nextCursor != "" {
+				text += fmt.Sprintf("\n\nnext_cursor: %s; call qurio_list_pages again with this cursor to continue.", nextCursor)
+			}
+
 			return &JSONRPCResponse{
 				JSONRPC: "2.0",
 				ID:      req.ID,
 				Result: ToolResult{
 					Content: []ToolContent{
-						{Type: "text", Text: string(jsonBytes)},
+						{Type: "text", Text: text},
 					},
 				},
 			}
@@ -513,23 +768,7 @@ read_page(url="https://docs.stripe.com/webhooks/signatures")`,
 				}
 			}
 
-			var textResult string
-			if len(results) == 0 {
-				textResult = "No content found for URL."
-			} else {
-				title := ""
-				if len(results) > 0 {
-					title = results[0].Title
-				}
-				textResult = fmt.Sprintf("Page: %s\nURL: %s\n\n", title, args.URL)
-				for _, res := range results {
-					if res.Type == "code" {
-						textResult += fmt.Sprintf("--- Code (%s) ---\n%s\n\n", res.Language, res.Content)
-					} else {
-						textResult += fmt.Sprintf("```\n%s\n```\n\n", res.Content)
-					}
-				}
-			}
+			textResult := formatPage(args.URL, results)
 
 			slog.Info("tool execution completed", "tool", "qurio_read_page", "chunk_count", len(results)) // #nosec G706 -- len() result is int, not tainted
 
@@ -554,6 +793,40 @@ read_page(url="https://docs.stripe.com/webhooks/signatures")`,
 	return &resp
 }
 
+type sourceScopeKey struct{}
+
+// withSourceScope binds every qurio_search in ctx to one source, as served by
+// /mcp/sources/{id}.
+func withSourceScope(ctx context.Context, sourceID string) context.Context {
+	return context.WithValue(ctx, sourceScopeKey{}, sourceID)
+}
+
+func sourceScope(ctx context.Context) string {
+	id, _ := ctx.Value(sourceScopeKey{}).(string)
+	return id
+}
+
+// formatPage renders a page's chunks as read by qurio_read_page, dropping the
+// text each chunk repeats from the one before it.
+func formatPage(url string, results []retrieval.SearchResult) string {
+	if len(results) == 0 {
+		return "No content found for URL."
+	}
+	text := fmt.Sprintf("Page: %s\nURL: %s\n\n", results[0].Title, url)
+	for _, res := range results {
+		content := res.Content
+		if res.Overlap > 0 && res.Overlap < len(content) {
+			content = content[res.Overlap:]
+		}
+		if res.Type == "code" {
+			text += fmt.Sprintf("--- Code (%s) ---\n%s\n\n", res.Language, content)
+		} else {
+			text += fmt.Sprintf("```\n%s\n```\n\n", content)
+		}
+	}
+	return text
+}
+
 func makeErrorResponse(id interface{}, code int, message string) JSONRPCResponse {
 	return JSONRPCResponse{
 		JSONRPC: "2.0",
@@ -576,16 +849,61 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// We do NOT use a loop here to avoid sending multiple objects (NDJSON) which breaks
 	// strict JSON parsers (like in Gemini CLI).
 
-	var req JSONRPCRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		if err != io.EOF {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		slog.Warn("mcp read error", "error", err)
+		h.writeError(w, nil, ErrParse, "Parse error")
+		return
+	}
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return
+	}
+
+	ctx := r.Context()
+	if id := r.PathValue("id"); id != "" {
+		ctx = withSourceScope(ctx, id)
+	}
+
+	// JSON-RPC 2.0 batch: an array of requests answered by an array of
+	// responses, without entries for notifications
+	if body[0] == '[' {
+		var reqs []JSONRPCRequest
+		if err := json.Unmarshal(body, &reqs); err != nil {
 			slog.Warn("mcp decode error", "error", err)
 			h.writeError(w, nil, ErrParse, "Parse error")
+			return
+		}
+		if len(reqs) == 0 {
+			h.writeError(w, nil, ErrInvalidRequest, "Invalid Request: empty batch")
+			return
+		}
+
+		resps := make([]*JSONRPCResponse, 0, len(reqs))
+		for _, req := range reqs {
+			if resp := h.ProcessRequest(ctx, req); resp != nil {
+				resps = append(resps, resp)
+			}
+		}
+		if len(resps) == 0 {
+			// Only notifications (no response)
+			w.WriteHeader(http.StatusOK)
+			return
 		}
+		if err := json.NewEncoder(w).Encode(resps); err != nil {
+			slog.Error("mcp encode error", "error", err)
+		}
+		return
+	}
+
+	var req JSONRPCRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		slog.Warn("mcp decode error", "error", err)
+		h.writeError(w, nil, ErrParse, "Parse error")
 		return
 	}
 
-	resp := h.ProcessRequest(r.Context(), req)
+	resp := h.ProcessRequest(ctx, req)
 	if resp != nil {
 		if err := json.NewEncoder(w).Encode(resp); err != nil {
 			slog.Error("mcp encode error", "error", err)
@@ -609,3 +927,47 @@ func (h *Handler) writeError(w http.ResponseWriter, id interface{}, code int, me
 		slog.Error("failed to write error response", "error", err)
 	}
 }
+
+// indexingWarnings returns a note for every source among the results (or the
+// requested source) that is still being ingested, so callers know the results
+// may be incomplete. Lookup failures are logged and yield no warnings.
+func (h *Handler) indexingWarnings(ctx context.Context, results []retrieval.SearchResult, sourceID *string) string {
+	if h.sourceMgr == nil {
+		return ""
+	}
+
+	matched := make(map[string]bool)
+	if sourceID != nil && *sourceID != "" {
+		matched[*sourceID] = true
+	}
+	for _, res := range results {
+		if res.SourceID != "" {
+			matched[res.SourceID] = true
+		}
+	}
+	if len(matched) == 0 {
+		return ""
+	}
+
+	sources, err := h.sourceMgr.ListByIDs(ctx, slices.Sorted(maps.Keys(matched)))
+	if err != nil {
+		slog.Warn("failed to look up sources for search warnings", "error", err)
+		return ""
+	}
+
+	var warnings string
+	for _, s := range sources {
+		if s.Status != "in_progress" {
+			continue
+		}
+		name := s.Name
+		if name == "" {
+			name = s.URL
+		}
+		warnings += fmt.Sprintf("Note: source %s is still indexing; results may be incomplete.\n", name)
+	}
+	if warnings != "" {
+		warnings += "\n"
+	}
+	return warnings
+}
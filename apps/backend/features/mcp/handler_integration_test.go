@@ -66,7 +66,7 @@ func TestMCPHandler_Integration(t *testing.T) {
 	retrievalSvc := retrieval.NewService(embedder, vectorStore, nil, settingsSvc, nil)
 	sourceRepo := source.NewPostgresRepo(s.DB)
 
-	handler := mcp.NewHandler(retrievalSvc, sourceRepo)
+	handler := mcp.NewHandler(retrievalSvc, source.NewService(sourceRepo, nil, nil, nil))
 
 	// 2. Seed Data
 	src := &source.Source{
@@ -32,8 +32,16 @@ func (m *mockSourceMgr) List(ctx context.Context) ([]source.Source, error) {
 	return []source.Source{}, nil
 }
 
-func (m *mockSourceMgr) GetPages(ctx context.Context, id string) ([]source.SourcePage, error) {
-	return []source.SourcePage{}, nil
+func (m *mockSourceMgr) ListByIDs(ctx context.Context, ids []string) ([]source.Source, error) {
+	return []source.Source{}, nil
+}
+
+func (m *mockSourceMgr) Get(ctx context.Context, id string, limit, offset int, includeChunks bool) (*source.SourceDetail, error) {
+	return &source.SourceDetail{}, nil
+}
+
+func (m *mockSourceMgr) ListPages(ctx context.Context, id, cursor string, limit int) ([]source.SourcePage, string, error) {
+	return []source.SourcePage{}, "", nil
 }
 
 func TestServeHTTP_Streaming(t *testing.T) {
@@ -89,3 +97,55 @@ func TestServeHTTP_Error(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestServeHTTP_Batch(t *testing.T) {
+	handler := NewHandler(&mockRetriever{}, &mockSourceMgr{})
+
+	reqBody := ` [
+		{"jsonrpc":"2.0","method":"ping","id":1},
+		{"jsonrpc":"2.0","method":"notifications/initialized"},
+		{"jsonrpc":"2.0","method":"bogus","id":2}
+	]`
+	req := httptest.NewRequest("POST", "/mcp", strings.NewReader(reqBody))
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var resps []JSONRPCResponse
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&resps))
+	// The notification gets no entry
+	if assert.Len(t, resps, 2) {
+		assert.Equal(t, float64(1), resps[0].ID)
+		assert.Nil(t, resps[0].Error)
+		assert.Equal(t, float64(2), resps[1].ID)
+		assert.NotNil(t, resps[1].Error)
+	}
+}
+
+func TestServeHTTP_Batch_OnlyNotifications(t *testing.T) {
+	handler := NewHandler(&mockRetriever{}, &mockSourceMgr{})
+
+	req := httptest.NewRequest("POST", "/mcp", strings.NewReader(`[{"jsonrpc":"2.0","method":"notifications/initialized"}]`))
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Body.String())
+}
+
+func TestServeHTTP_Batch_Empty(t *testing.T) {
+	handler := NewHandler(&mockRetriever{}, &mockSourceMgr{})
+
+	req := httptest.NewRequest("POST", "/mcp", strings.NewReader(`[]`))
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	var resp JSONRPCResponse
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	if errMap, ok := resp.Error.(map[string]interface{}); assert.True(t, ok) {
+		assert.Equal(t, float64(ErrInvalidRequest), errMap["code"])
+	}
+}
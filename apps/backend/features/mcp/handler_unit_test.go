@@ -1,8 +1,14 @@
 package mcp_test
 
 import (
+	"bytes"
 	"context"
+	"database/sql"
 	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"qurio/apps/backend/features/mcp"
@@ -47,12 +53,28 @@ func (m *MockSourceManager) List(ctx context.Context) ([]source.Source, error) {
 	return args.Get(0).([]source.Source), args.Error(1)
 }
 
-func (m *MockSourceManager) GetPages(ctx context.Context, id string) ([]source.SourcePage, error) {
+func (m *MockSourceManager) ListByIDs(ctx context.Context, ids []string) ([]source.Source, error) {
+	args := m.Called(ctx, ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]source.Source), args.Error(1)
+}
+
+func (m *MockSourceManager) Get(ctx context.Context, id string, limit, offset int, includeChunks bool) (*source.SourceDetail, error) {
 	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).([]source.SourcePage), args.Error(1)
+	return args.Get(0).(*source.SourceDetail), args.Error(1)
+}
+
+func (m *MockSourceManager) ListPages(ctx context.Context, id, cursor string, limit int) ([]source.SourcePage, string, error) {
+	args := m.Called(ctx, id, cursor, limit)
+	if args.Get(0) == nil {
+		return nil, "", args.Error(2)
+	}
+	return args.Get(0).([]source.SourcePage), args.String(1), args.Error(2)
 }
 
 func TestProcessRequest_Initialize(t *testing.T) {
@@ -76,6 +98,7 @@ func TestProcessRequest_Initialize(t *testing.T) {
 	result := resp.Result.(map[string]interface{})
 	assert.Equal(t, "2024-11-05", result["protocolVersion"])
 	assert.NotNil(t, result["capabilities"])
+	assert.Contains(t, result["capabilities"], "resources")
 	assert.NotNil(t, result["serverInfo"])
 }
 
@@ -95,6 +118,28 @@ func TestProcessRequest_NotificationsInitialized(t *testing.T) {
 	assert.Nil(t, resp)
 }
 
+func TestProcessRequest_Ping(t *testing.T) {
+	mockRetriever := new(MockRetriever)
+	mockSourceMgr := new(MockSourceManager)
+	handler := mcp.NewHandler(mockRetriever, mockSourceMgr)
+
+	req := mcp.JSONRPCRequest{
+		JSONRPC: "2.0",
+		Method:  "ping",
+		ID:      "ping-1",
+	}
+
+	resp := handler.ProcessRequest(context.Background(), req)
+
+	assert.NotNil(t, resp)
+	assert.Equal(t, "ping-1", resp.ID)
+	assert.Nil(t, resp.Error)
+
+	encoded, err := json.Marshal(resp)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"jsonrpc":"2.0","result":{},"id":"ping-1"}`, string(encoded))
+}
+
 func TestProcessRequest_ToolsList(t *testing.T) {
 	mockRetriever := new(MockRetriever)
 	mockSourceMgr := new(MockSourceManager)
@@ -114,7 +159,7 @@ func TestProcessRequest_ToolsList(t *testing.T) {
 	assert.NotNil(t, resp.Result)
 
 	result := resp.Result.(mcp.ListToolsResult)
-	assert.Len(t, result.Tools, 4)
+	assert.Len(t, result.Tools, 5)
 
 	toolNames := make([]string, len(result.Tools))
 	for i, tool := range result.Tools {
@@ -122,10 +167,44 @@ func TestProcessRequest_ToolsList(t *testing.T) {
 	}
 	assert.Contains(t, toolNames, "qurio_search")
 	assert.Contains(t, toolNames, "qurio_list_sources")
+	assert.Contains(t, toolNames, "qurio_get_source")
 	assert.Contains(t, toolNames, "qurio_list_pages")
 	assert.Contains(t, toolNames, "qurio_read_page")
 }
 
+func TestProcessRequest_ToolsList_Profile(t *testing.T) {
+	searchDescription := func(h *mcp.Handler) string {
+		resp := h.ProcessRequest(context.Background(), mcp.JSONRPCRequest{JSONRPC: "2.0", Method: "tools/list", ID: 1})
+		for _, tool := range resp.Result.(mcp.ListToolsResult).Tools {
+			if tool.Name == "qurio_search" {
+				return tool.Description
+			}
+		}
+		t.Fatal("qurio_search not listed")
+		return ""
+	}
+
+	general := mcp.NewHandler(new(MockRetriever), new(MockSourceManager))
+	general.SetProfile(mcp.ProfileGeneral)
+	generalDesc := searchDescription(general)
+	assert.True(t, strings.HasPrefix(generalDesc, "Search & Exploration tool."))
+	assert.Contains(t, generalDesc, "[Alpha: Hybrid Search Balance]")
+
+	// The default is the general profile
+	assert.Equal(t, generalDesc, searchDescription(mcp.NewHandler(new(MockRetriever), new(MockSourceManager))))
+
+	code := mcp.NewHandler(new(MockRetriever), new(MockSourceManager))
+	code.SetProfile(mcp.ProfileCode)
+	codeDesc := searchDescription(code)
+	assert.True(t, strings.HasPrefix(codeDesc, "Code search tool."))
+	assert.Contains(t, codeDesc, "identifiers")
+	assert.Less(t, len(codeDesc), len(generalDesc))
+
+	unknown := mcp.NewHandler(new(MockRetriever), new(MockSourceManager))
+	unknown.SetProfile("legal")
+	assert.Equal(t, generalDesc, searchDescription(unknown))
+}
+
 func TestProcessRequest_QuriSearch_Success(t *testing.T) {
 	mockRetriever := new(MockRetriever)
 	mockSourceMgr := new(MockSourceManager)
@@ -143,6 +222,7 @@ func TestProcessRequest_QuriSearch_Success(t *testing.T) {
 	}
 
 	mockRetriever.On("Search", mock.Anything, "test query", mock.Anything).Return(searchResults, nil)
+	mockSourceMgr.On("ListByIDs", mock.Anything, []string{"src1"}).Return([]source.Source{{ID: "src1", Status: "completed"}}, nil)
 
 	args := map[string]interface{}{
 		"query": "test query",
@@ -254,6 +334,79 @@ func TestProcessRequest_QuriSearch_InvalidAlpha(t *testing.T) {
 	}
 }
 
+func TestProcessRequest_QuriSearch_Offset(t *testing.T) {
+	t.Run("passed to retriever", func(t *testing.T) {
+		mockRetriever := new(MockRetriever)
+		handler := mcp.NewHandler(mockRetriever, new(MockSourceManager))
+
+		mockRetriever.On("Search", mock.Anything, "test", mock.MatchedBy(func(opts *retrieval.SearchOptions) bool {
+			return opts.Offset != nil && *opts.Offset == 20
+		})).Return([]retrieval.SearchResult{}, nil)
+
+		argsJSON, _ := json.Marshal(map[string]interface{}{"query": "test", "offset": 20, "limit": 10})
+		paramsJSON, _ := json.Marshal(mcp.CallParams{Name: "qurio_search", Arguments: argsJSON})
+		resp := handler.ProcessRequest(context.Background(), mcp.JSONRPCRequest{JSONRPC: "2.0", Method: "tools/call", Params: paramsJSON, ID: 1})
+
+		assert.NotNil(t, resp)
+		assert.Nil(t, resp.Error)
+		mockRetriever.AssertExpectations(t)
+	})
+
+	for _, tt := range []struct {
+		name   string
+		offset int
+		limit  int
+	}{
+		{"negative", -1, 10},
+		{"past the window", retrieval.MaxSearchWindow - 5, 10},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := mcp.NewHandler(new(MockRetriever), new(MockSourceManager))
+
+			argsJSON, _ := json.Marshal(map[string]interface{}{"query": "test", "offset": tt.offset, "limit": tt.limit})
+			paramsJSON, _ := json.Marshal(mcp.CallParams{Name: "qurio_search", Arguments: argsJSON})
+			resp := handler.ProcessRequest(context.Background(), mcp.JSONRPCRequest{JSONRPC: "2.0", Method: "tools/call", Params: paramsJSON, ID: 2})
+
+			assert.NotNil(t, resp)
+			errMap := resp.Error.(map[string]interface{})
+			assert.Equal(t, mcp.ErrInvalidParams, errMap["code"])
+			assert.Contains(t, errMap["message"], "Offset must be at least 0")
+		})
+	}
+}
+
+func TestProcessRequest_QuriSearch_Diversity(t *testing.T) {
+	t.Run("passed to retriever", func(t *testing.T) {
+		mockRetriever := new(MockRetriever)
+		handler := mcp.NewHandler(mockRetriever, new(MockSourceManager))
+
+		mockRetriever.On("Search", mock.Anything, "test", mock.MatchedBy(func(opts *retrieval.SearchOptions) bool {
+			return opts.Diversity != nil && *opts.Diversity == 0.3
+		})).Return([]retrieval.SearchResult{}, nil)
+
+		argsJSON, _ := json.Marshal(map[string]interface{}{"query": "test", "diversity": 0.3})
+		paramsJSON, _ := json.Marshal(mcp.CallParams{Name: "qurio_search", Arguments: argsJSON})
+		resp := handler.ProcessRequest(context.Background(), mcp.JSONRPCRequest{JSONRPC: "2.0", Method: "tools/call", Params: paramsJSON, ID: 1})
+
+		assert.NotNil(t, resp)
+		assert.Nil(t, resp.Error)
+		mockRetriever.AssertExpectations(t)
+	})
+
+	t.Run("out of range", func(t *testing.T) {
+		handler := mcp.NewHandler(new(MockRetriever), new(MockSourceManager))
+
+		argsJSON, _ := json.Marshal(map[string]interface{}{"query": "test", "diversity": 1.5})
+		paramsJSON, _ := json.Marshal(mcp.CallParams{Name: "qurio_search", Arguments: argsJSON})
+		resp := handler.ProcessRequest(context.Background(), mcp.JSONRPCRequest{JSONRPC: "2.0", Method: "tools/call", Params: paramsJSON, ID: 2})
+
+		assert.NotNil(t, resp)
+		errMap := resp.Error.(map[string]interface{})
+		assert.Equal(t, mcp.ErrInvalidParams, errMap["code"])
+		assert.Contains(t, errMap["message"], "Diversity must be between 0.0 and 1.0")
+	})
+}
+
 func TestProcessRequest_QuriSearch_WithFilters(t *testing.T) {
 	mockRetriever := new(MockRetriever)
 	mockSourceMgr := new(MockSourceManager)
@@ -294,6 +447,135 @@ func TestProcessRequest_QuriSearch_WithFilters(t *testing.T) {
 	mockRetriever.AssertExpectations(t)
 }
 
+func TestProcessRequest_QuriSearch_RejectsInvalidFilters(t *testing.T) {
+	mockRetriever := new(MockRetriever)
+	mockSourceMgr := new(MockSourceManager)
+	handler := mcp.NewHandler(mockRetriever, mockSourceMgr)
+
+	args := map[string]interface{}{
+		"query": "test",
+		"filters": map[string]interface{}{
+			"chunkIndex": map[string]interface{}{"from": 1},
+		},
+	}
+	argsJSON, _ := json.Marshal(args)
+	paramsJSON, _ := json.Marshal(mcp.CallParams{Name: "qurio_search", Arguments: argsJSON})
+	req := mcp.JSONRPCRequest{JSONRPC: "2.0", Method: "tools/call", Params: paramsJSON, ID: 6}
+
+	resp := handler.ProcessRequest(context.Background(), req)
+
+	assert.NotNil(t, resp)
+	errMap := resp.Error.(map[string]interface{})
+	assert.Equal(t, mcp.ErrInvalidParams, errMap["code"])
+	assert.Contains(t, errMap["message"], `filter "chunkIndex"`)
+	mockRetriever.AssertNotCalled(t, "Search", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestProcessRequest_QuriSearch_MetadataOnly(t *testing.T) {
+	mockRetriever := new(MockRetriever)
+	mockSourceMgr := new(MockSourceManager)
+	handler := mcp.NewHandler(mockRetriever, mockSourceMgr)
+
+	mockRetriever.On("Search", mock.Anything, "webhooks", mock.Anything).Return([]retrieval.SearchResult{
+		{
+			Content: "SECRET BODY TEXT",
+			Score:   0.87,
+			Title:   "Webhook Signatures",
+			URL:     "https://docs.example.com/webhooks",
+			Type:    "prose",
+		},
+	}, nil)
+
+	argsJSON, _ := json.Marshal(map[string]interface{}{
+		"query":         "webhooks",
+		"metadata_only": true,
+	})
+	paramsJSON, _ := json.Marshal(mcp.CallParams{Name: "qurio_search", Arguments: argsJSON})
+
+	resp := handler.ProcessRequest(context.Background(), mcp.JSONRPCRequest{
+		JSONRPC: "2.0",
+		Method:  "tools/call",
+		Params:  paramsJSON,
+		ID:      20,
+	})
+
+	assert.NotNil(t, resp)
+	assert.Nil(t, resp.Error)
+	text := resp.Result.(mcp.ToolResult).Content[0].Text
+	assert.NotContains(t, text, "SECRET BODY TEXT")
+	assert.NotContains(t, text, "Content:")
+	assert.Contains(t, text, "Score: 0.87")
+	assert.Contains(t, text, "Title: Webhook Signatures")
+	assert.Contains(t, text, "URL: https://docs.example.com/webhooks")
+	assert.Contains(t, text, "Type: prose")
+	assert.Contains(t, text, "qurio_read_page")
+}
+
+func TestProcessRequest_QuriSearch_OutputBudget(t *testing.T) {
+	mockRetriever := new(MockRetriever)
+	handler := mcp.NewHandler(mockRetriever, new(MockSourceManager))
+	handler.SetMaxSearchChars(5000)
+
+	results := make([]retrieval.SearchResult, 50)
+	for i := range results {
+		results[i] = retrieval.SearchResult{
+			Content: strings.Repeat("x", 1000),
+			Score:   0.5,
+			URL:     fmt.Sprintf("https://docs.example.com/page-%d", i),
+		}
+	}
+	mockRetriever.On("Search", mock.Anything, "big", mock.Anything).Return(results, nil)
+
+	argsJSON, _ := json.Marshal(map[string]interface{}{"query": "big", "limit": 50})
+	paramsJSON, _ := json.Marshal(mcp.CallParams{Name: "qurio_search", Arguments: argsJSON})
+
+	resp := handler.ProcessRequest(context.Background(), mcp.JSONRPCRequest{
+		JSONRPC: "2.0",
+		Method:  "tools/call",
+		Params:  paramsJSON,
+		ID:      21,
+	})
+
+	assert.NotNil(t, resp)
+	assert.Nil(t, resp.Error)
+	text := resp.Result.(mcp.ToolResult).Content[0].Text
+	shown := strings.Count(text, "Result ")
+	assert.Equal(t, 4, shown)
+	assert.NotContains(t, text, "page-4\n")
+	assert.Contains(t, text, fmt.Sprintf("%d more results omitted; refine your query or use filters.", 50-shown))
+	// The note and footer are the only overflow past the budget
+	assert.Less(t, len(text), 5000+200)
+}
+
+func TestProcessRequest_QuriSearch_Dedupe(t *testing.T) {
+	mockRetriever := new(MockRetriever)
+	handler := mcp.NewHandler(mockRetriever, new(MockSourceManager))
+
+	mockRetriever.On("Search", mock.Anything, "feedback", mock.MatchedBy(func(opts *retrieval.SearchOptions) bool {
+		return opts.Dedupe
+	})).Return([]retrieval.SearchResult{
+		{Content: "Was this page helpful?", Score: 0.9, URL: "https://docs.example.com/c", DuplicateCount: 19},
+		{Content: "Webhooks retry for three days.", Score: 0.7, URL: "https://docs.example.com/b"},
+	}, nil)
+
+	argsJSON, _ := json.Marshal(map[string]interface{}{"query": "feedback", "dedupe": true})
+	paramsJSON, _ := json.Marshal(mcp.CallParams{Name: "qurio_search", Arguments: argsJSON})
+
+	resp := handler.ProcessRequest(context.Background(), mcp.JSONRPCRequest{
+		JSONRPC: "2.0",
+		Method:  "tools/call",
+		Params:  paramsJSON,
+		ID:      22,
+	})
+
+	assert.NotNil(t, resp)
+	assert.Nil(t, resp.Error)
+	text := resp.Result.(mcp.ToolResult).Content[0].Text
+	assert.Contains(t, text, "(appears on 20 pages)")
+	assert.Equal(t, 1, strings.Count(text, "appears on"))
+	mockRetriever.AssertExpectations(t)
+}
+
 func TestProcessRequest_QuriSearch_WithSourceID(t *testing.T) {
 	mockRetriever := new(MockRetriever)
 	mockSourceMgr := new(MockSourceManager)
@@ -303,6 +585,7 @@ func TestProcessRequest_QuriSearch_WithSourceID(t *testing.T) {
 	mockRetriever.On("Search", mock.Anything, "test", mock.MatchedBy(func(opts *retrieval.SearchOptions) bool {
 		return opts.Filters != nil && opts.Filters["sourceId"] == sourceID
 	})).Return([]retrieval.SearchResult{}, nil)
+	mockSourceMgr.On("ListByIDs", mock.Anything, []string{sourceID}).Return([]source.Source{}, nil)
 
 	args := map[string]interface{}{
 		"query":     "test",
@@ -330,6 +613,49 @@ func TestProcessRequest_QuriSearch_WithSourceID(t *testing.T) {
 	mockRetriever.AssertExpectations(t)
 }
 
+func TestServeHTTP_SourceScopedEndpoint(t *testing.T) {
+	mockRetriever := new(MockRetriever)
+	mockSourceMgr := new(MockSourceManager)
+	handler := mcp.NewHandler(mockRetriever, mockSourceMgr)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/mcp", handler.ServeHTTP)
+	mux.HandleFunc("/mcp/sources/{id}", handler.ServeHTTP)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	var searched []map[string]interface{}
+	mockRetriever.On("Search", mock.Anything, "webhooks", mock.Anything).Run(func(args mock.Arguments) {
+		searched = append(searched, args.Get(2).(*retrieval.SearchOptions).Filters)
+	}).Return([]retrieval.SearchResult{}, nil)
+	mockSourceMgr.On("ListByIDs", mock.Anything, mock.Anything).Return([]source.Source{}, nil)
+
+	call := func(path string, args map[string]interface{}) {
+		argsJSON, _ := json.Marshal(args)
+		paramsJSON, _ := json.Marshal(mcp.CallParams{Name: "qurio_search", Arguments: argsJSON})
+		body, _ := json.Marshal(mcp.JSONRPCRequest{JSONRPC: "2.0", Method: "tools/call", Params: paramsJSON, ID: 1})
+
+		resp, err := http.Post(server.URL+path, "application/json", bytes.NewReader(body))
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		var rpc mcp.JSONRPCResponse
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&rpc))
+		assert.Nil(t, rpc.Error)
+	}
+
+	// Scoped session: filtered to the bound source, even over an explicit source_id
+	call("/mcp/sources/src1", map[string]interface{}{"query": "webhooks"})
+	call("/mcp/sources/src1", map[string]interface{}{"query": "webhooks", "source_id": "src2"})
+	// Unscoped endpoint is unchanged
+	call("/mcp", map[string]interface{}{"query": "webhooks"})
+
+	if assert.Len(t, searched, 3) {
+		assert.Equal(t, "src1", searched[0]["sourceId"])
+		assert.Equal(t, "src1", searched[1]["sourceId"])
+		assert.NotContains(t, searched[2], "sourceId")
+	}
+}
+
 func TestProcessRequest_QuriSearch_SearchError(t *testing.T) {
 	mockRetriever := new(MockRetriever)
 	mockSourceMgr := new(MockSourceManager)
@@ -504,7 +830,7 @@ func TestProcessRequest_QuriListPages_Success(t *testing.T) {
 	pages := []source.SourcePage{
 		{ID: "page1", URL: "http://example.com/page1"},
 	}
-	mockSourceMgr.On("GetPages", mock.Anything, "src1").Return(pages, nil)
+	mockSourceMgr.On("ListPages", mock.Anything, "src1", "", 50).Return(pages, "", nil)
 
 	args := map[string]interface{}{
 		"source_id": "src1",
@@ -536,6 +862,131 @@ func TestProcessRequest_QuriListPages_Success(t *testing.T) {
 	mockSourceMgr.AssertExpectations(t)
 }
 
+func TestProcessRequest_QuriListPages_NextCursor(t *testing.T) {
+	mockRetriever := new(MockRetriever)
+	mockSourceMgr := new(MockSourceManager)
+	handler := mcp.NewHandler(mockRetriever, mockSourceMgr)
+
+	pages := []source.SourcePage{
+		{ID: "page2", URL: "http://example.com/page2"},
+	}
+	// Oversized limits are capped at 200
+	mockSourceMgr.On("ListPages", mock.Anything, "src1", "abc", 200).Return(pages, "def", nil)
+
+	argsJSON, _ := json.Marshal(map[string]interface{}{
+		"source_id": "src1",
+		"cursor":    "abc",
+		"limit":     1000,
+	})
+	paramsJSON, _ := json.Marshal(mcp.CallParams{Name: "qurio_list_pages", Arguments: argsJSON})
+
+	resp := handler.ProcessRequest(context.Background(), mcp.JSONRPCRequest{
+		JSONRPC: "2.0",
+		Method:  "tools/call",
+		Params:  paramsJSON,
+		ID:      13,
+	})
+
+	assert.NotNil(t, resp)
+	assert.Nil(t, resp.Error)
+
+	result := resp.Result.(mcp.ToolResult)
+	assert.False(t, result.IsError)
+	assert.Contains(t, result.Content[0].Text, "page2")
+	assert.Contains(t, result.Content[0].Text, "next_cursor: def; call qurio_list_pages again with this cursor to continue.")
+
+	mockSourceMgr.AssertExpectations(t)
+}
+
+func TestProcessRequest_QuriListPages_InvalidCursor(t *testing.T) {
+	mockRetriever := new(MockRetriever)
+	mockSourceMgr := new(MockSourceManager)
+	handler := mcp.NewHandler(mockRetriever, mockSourceMgr)
+
+	mockSourceMgr.On("ListPages", mock.Anything, "src1", "bogus", 50).Return(nil, "", source.ErrInvalidCursor)
+
+	argsJSON, _ := json.Marshal(map[string]interface{}{
+		"source_id": "src1",
+		"cursor":    "bogus",
+	})
+	paramsJSON, _ := json.Marshal(mcp.CallParams{Name: "qurio_list_pages", Arguments: argsJSON})
+
+	resp := handler.ProcessRequest(context.Background(), mcp.JSONRPCRequest{
+		JSONRPC: "2.0",
+		Method:  "tools/call",
+		Params:  paramsJSON,
+		ID:      14,
+	})
+
+	assert.NotNil(t, resp)
+	assert.NotNil(t, resp.Error)
+
+	errMap := resp.Error.(map[string]interface{})
+	assert.Equal(t, mcp.ErrInvalidParams, errMap["code"])
+	assert.Contains(t, errMap["message"], "Invalid cursor")
+}
+
+func TestProcessRequest_QurioGetSource(t *testing.T) {
+	callGetSource := func(handler *mcp.Handler, args map[string]interface{}) *mcp.JSONRPCResponse {
+		argsJSON, _ := json.Marshal(args)
+		paramsJSON, _ := json.Marshal(mcp.CallParams{Name: "qurio_get_source", Arguments: argsJSON})
+		return handler.ProcessRequest(context.Background(), mcp.JSONRPCRequest{
+			JSONRPC: "2.0",
+			Method:  "tools/call",
+			Params:  paramsJSON,
+			ID:      20,
+		})
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		mockSourceMgr := new(MockSourceManager)
+		handler := mcp.NewHandler(new(MockRetriever), mockSourceMgr)
+		mockSourceMgr.On("Get", mock.Anything, "src1").Return(&source.SourceDetail{
+			Source:      source.Source{ID: "src1", Name: "Docs", Status: "in_progress", Type: "web", URL: "http://example.com", MaxDepth: 2},
+			TotalChunks: 120,
+			PageCounts:  map[string]int{"completed": 6, "pending": 1, "processing": 1, "failed": 2},
+		}, nil)
+
+		resp := callGetSource(handler, map[string]interface{}{"source_id": "src1"})
+		assert.Nil(t, resp.Error)
+		result := resp.Result.(mcp.ToolResult)
+		assert.False(t, result.IsError)
+
+		var got map[string]interface{}
+		assert.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &got))
+		assert.Equal(t, "in_progress", got["status"])
+		assert.Equal(t, "web", got["type"])
+		assert.Equal(t, "http://example.com", got["url"])
+		assert.EqualValues(t, 2, got["max_depth"])
+		assert.EqualValues(t, 120, got["total_chunks"])
+		assert.EqualValues(t, 10, got["pages_total"])
+		assert.EqualValues(t, 6, got["pages_completed"])
+		assert.EqualValues(t, 2, got["pages_pending"])
+		assert.EqualValues(t, 2, got["pages_failed"])
+		assert.InDelta(t, 0.6, got["completion"], 1e-9)
+	})
+
+	t.Run("Not Found", func(t *testing.T) {
+		mockSourceMgr := new(MockSourceManager)
+		handler := mcp.NewHandler(new(MockRetriever), mockSourceMgr)
+		mockSourceMgr.On("Get", mock.Anything, "nope").Return(nil, sql.ErrNoRows)
+
+		resp := callGetSource(handler, map[string]interface{}{"source_id": "nope"})
+		result := resp.Result.(mcp.ToolResult)
+		assert.True(t, result.IsError)
+		assert.Contains(t, result.Content[0].Text, "Source not found")
+	})
+
+	t.Run("Missing Source ID", func(t *testing.T) {
+		handler := mcp.NewHandler(new(MockRetriever), new(MockSourceManager))
+
+		resp := callGetSource(handler, map[string]interface{}{})
+		errMap := resp.Error.(map[string]interface{})
+		assert.Equal(t, mcp.ErrInvalidParams, errMap["code"])
+		assert.Contains(t, errMap["message"], "source_id is required")
+	})
+}
+
 func TestProcessRequest_QuriListPages_MissingSourceID(t *testing.T) {
 	mockRetriever := new(MockRetriever)
 	mockSourceMgr := new(MockSourceManager)
@@ -572,7 +1023,7 @@ func TestProcessRequest_QuriListPages_Empty(t *testing.T) {
 	mockSourceMgr := new(MockSourceManager)
 	handler := mcp.NewHandler(mockRetriever, mockSourceMgr)
 
-	mockSourceMgr.On("GetPages", mock.Anything, "src1").Return([]source.SourcePage{}, nil)
+	mockSourceMgr.On("ListPages", mock.Anything, "src1", "", 50).Return([]source.SourcePage{}, "", nil)
 
 	args := map[string]interface{}{
 		"source_id": "src1",
@@ -607,7 +1058,7 @@ func TestProcessRequest_QuriListPages_Error(t *testing.T) {
 	mockSourceMgr := new(MockSourceManager)
 	handler := mcp.NewHandler(mockRetriever, mockSourceMgr)
 
-	mockSourceMgr.On("GetPages", mock.Anything, "src1").Return(nil, assert.AnError)
+	mockSourceMgr.On("ListPages", mock.Anything, "src1", "", 50).Return(nil, "", assert.AnError)
 
 	args := map[string]interface{}{
 		"source_id": "src1",
@@ -685,6 +1136,29 @@ func TestProcessRequest_QuriReadPage_Success(t *testing.T) {
 	mockRetriever.AssertExpectations(t)
 }
 
+func TestProcessRequest_QuriReadPage_DropsOverlap(t *testing.T) {
+	mockRetriever := new(MockRetriever)
+	mockSourceMgr := new(MockSourceManager)
+	handler := mcp.NewHandler(mockRetriever, mockSourceMgr)
+
+	chunks := []retrieval.SearchResult{
+		{Content: "# Auth\n\nEvery request needs a token.", Title: "Page Title", Type: "prose"},
+		{Content: "needs a token. Tokens expire after one hour.", Type: "prose", Overlap: len("needs a token. ")},
+	}
+	mockRetriever.On("GetChunksByURL", mock.Anything, "http://example.com").Return(chunks, nil)
+
+	argsJSON, _ := json.Marshal(map[string]interface{}{"url": "http://example.com"})
+	paramsJSON, _ := json.Marshal(mcp.CallParams{Name: "qurio_read_page", Arguments: argsJSON})
+	req := mcp.JSONRPCRequest{JSONRPC: "2.0", Method: "tools/call", Params: paramsJSON, ID: 17}
+
+	resp := handler.ProcessRequest(context.Background(), req)
+
+	assert.NotNil(t, resp)
+	text := resp.Result.(mcp.ToolResult).Content[0].Text
+	assert.Contains(t, text, "```\nTokens expire after one hour.\n```")
+	assert.Equal(t, 1, strings.Count(text, "needs a token."))
+}
+
 func TestProcessRequest_QuriReadPage_MissingURL(t *testing.T) {
 	mockRetriever := new(MockRetriever)
 	mockSourceMgr := new(MockSourceManager)
@@ -853,6 +1327,7 @@ func TestProcessRequest_QuriSearch_ContentWithURLs(t *testing.T) {
 	}
 
 	mockRetriever.On("Search", mock.Anything, "docker compose", mock.Anything).Return(searchResults, nil)
+	mockSourceMgr.On("ListByIDs", mock.Anything, []string{"src1"}).Return([]source.Source{{ID: "src1", Status: "completed"}}, nil)
 
 	args := map[string]interface{}{"query": "docker compose"}
 	argsJSON, _ := json.Marshal(args)
@@ -916,3 +1391,37 @@ func TestProcessRequest_QuriReadPage_ContentWithURLs(t *testing.T) {
 
 	mockRetriever.AssertExpectations(t)
 }
+
+func TestProcessRequest_QuriSearch_InProgressSourceWarning(t *testing.T) {
+	mockRetriever := new(MockRetriever)
+	mockSourceMgr := new(MockSourceManager)
+	handler := mcp.NewHandler(mockRetriever, mockSourceMgr)
+
+	searchResults := []retrieval.SearchResult{
+		{Content: "Partial content", Title: "Intro", Score: 0.9, SourceID: "src1"},
+		{Content: "Done content", Title: "Guide", Score: 0.8, SourceID: "src2"},
+	}
+	mockRetriever.On("Search", mock.Anything, "setup", mock.Anything).Return(searchResults, nil)
+	// Only the sources among the results are looked up
+	mockSourceMgr.On("ListByIDs", mock.Anything, []string{"src1", "src2"}).Return([]source.Source{
+		{ID: "src1", Name: "Crawling Docs", Status: "in_progress"},
+		{ID: "src2", Name: "Finished Docs", Status: "completed"},
+	}, nil)
+
+	call := func(args map[string]interface{}) string {
+		argsJSON, _ := json.Marshal(args)
+		paramsJSON, _ := json.Marshal(mcp.CallParams{Name: "qurio_search", Arguments: argsJSON})
+		resp := handler.ProcessRequest(context.Background(), mcp.JSONRPCRequest{JSONRPC: "2.0", Method: "tools/call", Params: paramsJSON, ID: 1})
+		assert.Nil(t, resp.Error)
+		return resp.Result.(mcp.ToolResult).Content[0].Text
+	}
+
+	text := call(map[string]interface{}{"query": "setup"})
+	assert.Contains(t, text, "Note: source Crawling Docs is still indexing; results may be incomplete.")
+	assert.NotContains(t, text, "Finished Docs is still indexing")
+	assert.NotContains(t, text, "Other Docs")
+
+	text = call(map[string]interface{}{"query": "setup", "include_warnings": false})
+	assert.NotContains(t, text, "still indexing")
+	mockSourceMgr.AssertNotCalled(t, "List", mock.Anything)
+}
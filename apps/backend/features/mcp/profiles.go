@@ -0,0 +1,81 @@
+package mcp
+
+// Deployment profiles tailor the qurio_search description to what a
+// deployment indexes, so agents aren't given guidance for content it never
+// holds.
+const (
+	ProfileGeneral = "general"
+	ProfileCode    = "code"
+	ProfileAPI     = "api"
+)
+
+var searchDescriptions = map[string]string{
+	ProfileGeneral: generalSearchDescription,
+	ProfileCode:    codeSearchDescription,
+	ProfileAPI:     apiSearchDescription,
+}
+
+// searchDescription returns the qurio_search description for profile.
+func searchDescription(profile string) string {
+	if d, ok := searchDescriptions[profile]; ok {
+		return d
+	}
+	return generalSearchDescription
+}
+
+const generalSearchDescription = `Search & Exploration tool. Performs a hybrid search (Keyword + Vector). Use this for specific questions, finding code snippets, or exploring topics across known sources.
+
+ARGUMENT GUIDE:
+
+[Alpha: Hybrid Search Balance]
+- 0.0 (Keyword): Use for Error Codes ("0x8004"), IDs ("550e8400"), or unique strings.
+- 0.3 (Mostly Keyword): Use for specific function names ("handle_web_task") where exact match matters but context helps.
+- 0.5 (Hybrid - Default): Safe bet for general queries like "database configuration".
+- 1.0 (Vector): Use for conceptual "How do I..." questions (e.g. "stop server" matches "shutdown").
+- The server may raise a low alpha to a configured minimum. Set exact=true to force a pure keyword search.
+
+[Limit: Result Count]
+- Default: 10
+- Recommended: 5-15 (Prevent context bloat)
+- Max: 50
+
+[Snippets Per Page]
+- Default: 1 (one best snippet per page)
+- Raise (e.g. 2-3) to see several relevant passages from a rich page. Limit still bounds the total.
+
+[Metadata Only]
+- metadata_only=true returns title, URL, score and type per result without content. Use it to find matching pages cheaply, then read them with qurio_read_page.
+
+[Filters: Metadata Filtering]
+- type: Filter by content type (e.g., "code", "prose", "api", "config").
+- language: Filter by language (e.g., "go", "python", "json").
+- metadata: Filter by custom source metadata (e.g., {"team": "payments"}).
+
+USAGE EXAMPLES:
+- Specific: search(query="webhook signature", alpha=0.3)
+- Conceptual: search(query="how to handle errors", alpha=1.0)
+- Filtered: search(query="User struct", filters={"type": "code", "language": "go"})`
+
+const codeSearchDescription = `Code search tool. Performs a hybrid search (Keyword + Vector) over indexed source code and code samples. Use this to find definitions, usages and examples.
+
+ARGUMENT GUIDE:
+- alpha: 0.0-0.3 for identifiers, function names and error strings; 0.5 (default) for mixed queries; 1.0 for "how is X implemented" questions. Set exact=true to keep a low alpha as given.
+- limit: default 10, max 50.
+- filters: language (e.g. "go", "python") and type ("code", "config").
+- metadata_only=true lists matching pages without content; read them with qurio_read_page.
+
+USAGE EXAMPLES:
+- search(query="NewClient", alpha=0.0, filters={"language": "go"})
+- search(query="retry with backoff", alpha=1.0, filters={"type": "code"})`
+
+const apiSearchDescription = `API reference search tool. Performs a hybrid search (Keyword + Vector) over indexed API documentation. Use this to find endpoints, parameters, request/response schemas and error codes.
+
+ARGUMENT GUIDE:
+- alpha: 0.0 for paths ("/v1/charges"), error codes and field names; 0.5 (default) for mixed queries; 1.0 for "how do I..." questions. Set exact=true to keep a low alpha as given.
+- limit: default 10, max 50.
+- filters: type ("api", "config", "code") and metadata (e.g. {"team": "payments"}).
+- metadata_only=true lists matching pages without content; read them with qurio_read_page.
+
+USAGE EXAMPLES:
+- search(query="POST /v1/refunds", alpha=0.0)
+- search(query="how to paginate list endpoints", alpha=1.0, filters={"type": "api"})`
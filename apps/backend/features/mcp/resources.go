@@ -0,0 +1,154 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+)
+
+// ErrResourceNotFound is the MCP error code for an unknown resource URI.
+const ErrResourceNotFound = -32002
+
+// resourceScheme prefixes page resource URIs: qurio://<sourceId>/<pageId>.
+const resourceScheme = "qurio://"
+
+type Resource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+type ListResourcesResult struct {
+	Resources []Resource `json:"resources"`
+}
+
+type ReadResourceParams struct {
+	URI string `json:"uri"`
+}
+
+type ResourceContent struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text"`
+}
+
+type ReadResourceResult struct {
+	Contents []ResourceContent `json:"contents"`
+}
+
+func resourceURI(sourceID, pageID string) string {
+	return resourceScheme + sourceID + "/" + pageID
+}
+
+// parseResourceURI splits a qurio://<sourceId>/<pageId> URI.
+func parseResourceURI(uri string) (sourceID, pageID string, ok bool) {
+	rest, found := strings.CutPrefix(uri, resourceScheme)
+	if !found {
+		return "", "", false
+	}
+	sourceID, pageID, found = strings.Cut(rest, "/")
+	if !found || sourceID == "" || pageID == "" || strings.Contains(pageID, "/") {
+		return "", "", false
+	}
+	return sourceID, pageID, true
+}
+
+// listResources exposes every page of every source as a resource.
+func (h *Handler) listResources(ctx context.Context, req JSONRPCRequest) *JSONRPCResponse {
+	sources, err := h.sourceMgr.List(ctx)
+	if err != nil {
+		slog.Error("resources/list failed", "error", err)
+		resp := makeErrorResponse(req.ID, ErrInternal, "Failed to list sources")
+		return &resp
+	}
+
+	resources := []Resource{}
+	for _, src := range sources {
+		for cursor := ""; ; {
+			pages, next, err := h.sourceMgr.ListPages(ctx, src.ID, cursor, maxListPagesLimit)
+			if err != nil {
+				slog.Error("resources/list failed", "error", err, "source_id", src.ID)
+				resp := makeErrorResponse(req.ID, ErrInternal, "Failed to list pages")
+				return &resp
+			}
+			for _, p := range pages {
+				resources = append(resources, Resource{
+					URI:         resourceURI(src.ID, p.ID),
+					Name:        p.URL,
+					Description: src.Name,
+					MimeType:    "text/markdown",
+				})
+			}
+			if next == "" {
+				break
+			}
+			cursor = next
+		}
+	}
+
+	return &JSONRPCResponse{
+		JSONRPC: "2.0",
+		ID:      req.ID,
+		Result:  ListResourcesResult{Resources: resources},
+	}
+}
+
+// readResource resolves a page resource URI to its URL and returns the page
+// content assembled from its chunks.
+func (h *Handler) readResource(ctx context.Context, req JSONRPCRequest) *JSONRPCResponse {
+	var params ReadResourceParams
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		resp := makeErrorResponse(req.ID, ErrInvalidParams, "Invalid params")
+		return &resp
+	}
+	sourceID, pageID, ok := parseResourceURI(params.URI)
+	if !ok {
+		resp := makeErrorResponse(req.ID, ErrInvalidParams, "Invalid resource URI: "+params.URI)
+		return &resp
+	}
+
+	pageURL := ""
+	for cursor := ""; pageURL == ""; {
+		pages, next, err := h.sourceMgr.ListPages(ctx, sourceID, cursor, maxListPagesLimit)
+		if err != nil {
+			slog.Error("resources/read failed", "error", err, "source_id", sourceID)
+			resp := makeErrorResponse(req.ID, ErrInternal, "Failed to resolve resource")
+			return &resp
+		}
+		for _, p := range pages {
+			if p.ID == pageID {
+				pageURL = p.URL
+				break
+			}
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	if pageURL == "" {
+		resp := makeErrorResponse(req.ID, ErrResourceNotFound, "Resource not found: "+params.URI)
+		return &resp
+	}
+
+	results, err := h.retriever.GetChunksByURL(ctx, pageURL)
+	if err != nil {
+		slog.Error("resources/read failed", "error", err, "url", pageURL)
+		resp := makeErrorResponse(req.ID, ErrInternal, "Failed to read resource")
+		return &resp
+	}
+
+	return &JSONRPCResponse{
+		JSONRPC: "2.0",
+		ID:      req.ID,
+		Result: ReadResourceResult{
+			Contents: []ResourceContent{{
+				URI:      params.URI,
+				MimeType: "text/markdown",
+				Text:     formatPage(pageURL, results),
+			}},
+		},
+	}
+}
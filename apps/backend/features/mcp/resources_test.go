@@ -0,0 +1,101 @@
+package mcp_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"qurio/apps/backend/features/mcp"
+	"qurio/apps/backend/features/source"
+	"qurio/apps/backend/internal/retrieval"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessRequest_ResourcesList(t *testing.T) {
+	mockRetriever := new(MockRetriever)
+	mockSourceMgr := new(MockSourceManager)
+	handler := mcp.NewHandler(mockRetriever, mockSourceMgr)
+
+	mockSourceMgr.On("List", mock.Anything).Return([]source.Source{{ID: "src1", Name: "Docs"}}, nil)
+	mockSourceMgr.On("ListPages", mock.Anything, "src1", "", 200).
+		Return([]source.SourcePage{{ID: "p1", URL: "http://example.com/a"}}, "next", nil)
+	mockSourceMgr.On("ListPages", mock.Anything, "src1", "next", 200).
+		Return([]source.SourcePage{{ID: "p2", URL: "http://example.com/b"}}, "", nil)
+
+	resp := handler.ProcessRequest(context.Background(), mcp.JSONRPCRequest{
+		JSONRPC: "2.0",
+		Method:  "resources/list",
+		ID:      1,
+	})
+
+	require.NotNil(t, resp)
+	assert.Nil(t, resp.Error)
+	result := resp.Result.(mcp.ListResourcesResult)
+	assert.Equal(t, []mcp.Resource{
+		{URI: "qurio://src1/p1", Name: "http://example.com/a", Description: "Docs", MimeType: "text/markdown"},
+		{URI: "qurio://src1/p2", Name: "http://example.com/b", Description: "Docs", MimeType: "text/markdown"},
+	}, result.Resources)
+	mockSourceMgr.AssertExpectations(t)
+}
+
+func TestProcessRequest_ResourcesRead(t *testing.T) {
+	mockRetriever := new(MockRetriever)
+	mockSourceMgr := new(MockSourceManager)
+	handler := mcp.NewHandler(mockRetriever, mockSourceMgr)
+
+	mockSourceMgr.On("ListPages", mock.Anything, "src1", "", 200).
+		Return([]source.SourcePage{{ID: "p1", URL: "http://example.com/a"}, {ID: "p2", URL: "http://example.com/b"}}, "", nil)
+	mockRetriever.On("GetChunksByURL", mock.Anything, "http://example.com/b").
+		Return([]retrieval.SearchResult{{Content: "Webhook guide", Title: "Webhooks", Type: "prose"}}, nil)
+
+	params, _ := json.Marshal(mcp.ReadResourceParams{URI: "qurio://src1/p2"})
+	resp := handler.ProcessRequest(context.Background(), mcp.JSONRPCRequest{
+		JSONRPC: "2.0",
+		Method:  "resources/read",
+		Params:  params,
+		ID:      2,
+	})
+
+	require.NotNil(t, resp)
+	assert.Nil(t, resp.Error)
+	result := resp.Result.(mcp.ReadResourceResult)
+	require.Len(t, result.Contents, 1)
+	assert.Equal(t, "qurio://src1/p2", result.Contents[0].URI)
+	assert.Contains(t, result.Contents[0].Text, "Page: Webhooks")
+	assert.Contains(t, result.Contents[0].Text, "Webhook guide")
+}
+
+func TestProcessRequest_ResourcesRead_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		uri      string
+		wantCode int
+	}{
+		{"Bad Scheme", "http://example.com/a", mcp.ErrInvalidParams},
+		{"Missing Page", "qurio://src1", mcp.ErrInvalidParams},
+		{"Unknown Page", "qurio://src1/nope", mcp.ErrResourceNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSourceMgr := new(MockSourceManager)
+			mockSourceMgr.On("ListPages", mock.Anything, "src1", "", 200).
+				Return([]source.SourcePage{{ID: "p1", URL: "http://example.com/a"}}, "", nil)
+			handler := mcp.NewHandler(new(MockRetriever), mockSourceMgr)
+
+			params, _ := json.Marshal(mcp.ReadResourceParams{URI: tt.uri})
+			resp := handler.ProcessRequest(context.Background(), mcp.JSONRPCRequest{
+				JSONRPC: "2.0",
+				Method:  "resources/read",
+				Params:  params,
+				ID:      3,
+			})
+
+			require.NotNil(t, resp)
+			errMap := resp.Error.(map[string]interface{})
+			assert.Equal(t, tt.wantCode, errMap["code"])
+		})
+	}
+}
@@ -10,55 +10,101 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 
 	"qurio/apps/backend/internal/middleware"
+	"qurio/apps/backend/internal/worker"
 )
 
 type Handler struct {
 	service         *Service
 	uploadDir       string
 	maxUploadSizeMB int64
+	events          *worker.EventBus
 }
 
 func NewHandler(service *Service, uploadDir string, maxUploadSizeMB int64) *Handler {
 	return &Handler{service: service, uploadDir: uploadDir, maxUploadSizeMB: maxUploadSizeMB}
 }
 
+// SetEventBus enables the per-source SSE event stream.
+func (h *Handler) SetEventBus(b *worker.EventBus) {
+	h.events = b
+}
+
 func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		Type       string   `json:"type"`
-		URL        string   `json:"url"`
-		MaxDepth   int      `json:"max_depth"`
-		Exclusions []string `json:"exclusions"`
-		Name       string   `json:"name"`
+		Type               string            `json:"type"`
+		URL                string            `json:"url"`
+		MaxDepth           int               `json:"max_depth"`
+		Exclusions         []string          `json:"exclusions"`
+		Name               string            `json:"name"`
+		Metadata           map[string]string `json:"metadata"`
+		EmbedTitlePrefix   bool              `json:"embed_title_prefix"`
+		CrawlDelayMs       int               `json:"crawl_delay_ms"`
+		KeywordOnlyTypes   []string          `json:"keyword_only_types"`
+		RestrictToSeedPath bool              `json:"restrict_to_seed_path"`
+		EmbeddingModel     string            `json:"embedding_model"`
+		CrawlSubdomains    bool              `json:"crawl_subdomains"`
+		UseSitemap         bool              `json:"use_sitemap"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.writeError(r.Context(), w, "VALIDATION_ERROR", err.Error(), http.StatusBadRequest)
 		return
 	}
 
+	var problems []fieldError
 	if req.URL == "" {
-		h.writeError(r.Context(), w, "VALIDATION_ERROR", "URL is required", http.StatusBadRequest)
-		return
+		problems = append(problems, fieldError{Field: "url", Message: "URL is required"})
 	}
-
 	if req.Name == "" {
-		h.writeError(r.Context(), w, "VALIDATION_ERROR", "Name is required", http.StatusBadRequest)
+		problems = append(problems, fieldError{Field: "name", Message: "Name is required"})
+	}
+	if req.Type != "" && !IsValidType(req.Type) {
+		problems = append(problems, fieldError{Field: "type", Message: fmt.Sprintf("unsupported type %q", req.Type)})
+	}
+	if req.MaxDepth < 0 {
+		problems = append(problems, fieldError{Field: "max_depth", Message: "max_depth must not be negative"})
+	}
+	if req.CrawlDelayMs < 0 || req.CrawlDelayMs > MaxCrawlDelayMs {
+		problems = append(problems, fieldError{Field: "crawl_delay_ms", Message: fmt.Sprintf("crawl_delay_ms must be between 0 and %d", MaxCrawlDelayMs)})
+	}
+	for i, ex := range req.Exclusions {
+		if _, err := regexp.Compile(ex); err != nil {
+			problems = append(problems, fieldError{Field: fmt.Sprintf("exclusions[%d]", i), Message: err.Error()})
+		}
+	}
+	req.EmbeddingModel = strings.TrimSpace(req.EmbeddingModel)
+	if req.EmbeddingModel != "" && !h.service.IsKnownEmbeddingModel(req.EmbeddingModel) {
+		problems = append(problems, fieldError{Field: "embedding_model", Message: fmt.Sprintf("unknown embedding model %q", req.EmbeddingModel)})
+	}
+	if len(problems) > 0 {
+		h.writeValidationErrors(r.Context(), w, problems)
 		return
 	}
 
 	src := &Source{
-		Type:       req.Type,
-		URL:        req.URL,
-		MaxDepth:   req.MaxDepth,
-		Exclusions: req.Exclusions,
-		Name:       req.Name,
+		Type:               req.Type,
+		URL:                req.URL,
+		MaxDepth:           req.MaxDepth,
+		Exclusions:         req.Exclusions,
+		Name:               req.Name,
+		Metadata:           req.Metadata,
+		EmbedTitlePrefix:   req.EmbedTitlePrefix,
+		CrawlDelayMs:       req.CrawlDelayMs,
+		KeywordOnlyTypes:   req.KeywordOnlyTypes,
+		RestrictToSeedPath: req.RestrictToSeedPath,
+		EmbeddingModel:     req.EmbeddingModel,
+		CrawlSubdomains:    req.CrawlSubdomains,
+		UseSitemap:         req.UseSitemap,
 	}
 	if err := h.service.Create(r.Context(), src); err != nil {
 		if err.Error() == "duplicate detected" {
@@ -166,8 +212,57 @@ func (h *Handler) Upload(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// Page window bounds for List.
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
+// List returns a page of sources, optionally filtered by ?status=, ?type= and
+// ?q= (a substring of the URL or name) and ordered by ?sort=name|created_at
+// with ?order=asc|desc. ?limit= (default 20, at most 100) and ?offset= select
+// the page. As for jobs, meta.count is the length of the page and meta.total
+// the number of matching sources.
 func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
-	sources, err := h.service.List(r.Context())
+	query := r.URL.Query()
+	f := ListFilter{
+		Status: query.Get("status"),
+		Type:   query.Get("type"),
+		Query:  strings.TrimSpace(query.Get("q")),
+		Sort:   query.Get("sort"),
+		Limit:  defaultListLimit,
+	}
+	if l := query.Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 {
+			f.Limit = min(parsed, maxListLimit)
+		}
+	}
+	if o := query.Get("offset"); o != "" {
+		if parsed, err := strconv.Atoi(o); err == nil && parsed > 0 {
+			f.Offset = parsed
+		}
+	}
+
+	var problems []fieldError
+	if f.Type != "" && !IsValidType(f.Type) {
+		problems = append(problems, fieldError{Field: "type", Message: fmt.Sprintf("unsupported type %q", f.Type)})
+	}
+	if f.Sort != "" && f.Sort != "name" && f.Sort != "created_at" {
+		problems = append(problems, fieldError{Field: "sort", Message: "sort must be name or created_at"})
+	}
+	switch query.Get("order") {
+	case "", "asc":
+	case "desc":
+		f.Desc = true
+	default:
+		problems = append(problems, fieldError{Field: "order", Message: "order must be asc or desc"})
+	}
+	if len(problems) > 0 {
+		h.writeValidationErrors(r.Context(), w, problems)
+		return
+	}
+
+	sources, total, err := h.service.ListPage(r.Context(), f)
 	if err != nil {
 		h.writeError(r.Context(), w, "INTERNAL_ERROR", err.Error(), http.StatusInternalServerError)
 		return
@@ -181,7 +276,7 @@ func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	resp := map[string]interface{}{
 		"data": sources,
-		"meta": map[string]int{"count": len(sources)},
+		"meta": map[string]int{"count": len(sources), "total": total, "limit": f.Limit, "offset": f.Offset},
 	}
 	if err := json.NewEncoder(w).Encode(resp); err != nil {
 		slog.Error("failed to encode response", "error", err)
@@ -214,6 +309,87 @@ func (h *Handler) ReSync(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+// maxUpdateDepth caps the crawl depth a source can be reconfigured to.
+const maxUpdateDepth = 10
+
+// UpdateConfig changes a source's max_depth and exclusions in place.
+func (h *Handler) UpdateConfig(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	var req struct {
+		MaxDepth   int      `json:"max_depth"`
+		Exclusions []string `json:"exclusions"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(r.Context(), w, "VALIDATION_ERROR", err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var problems []fieldError
+	if req.MaxDepth < 0 || req.MaxDepth > maxUpdateDepth {
+		problems = append(problems, fieldError{Field: "max_depth", Message: fmt.Sprintf("max_depth must be between 0 and %d", maxUpdateDepth)})
+	}
+	for i, ex := range req.Exclusions {
+		if _, err := regexp.Compile(ex); err != nil {
+			problems = append(problems, fieldError{Field: fmt.Sprintf("exclusions[%d]", i), Message: err.Error()})
+		}
+	}
+	if len(problems) > 0 {
+		h.writeValidationErrors(r.Context(), w, problems)
+		return
+	}
+
+	if err := h.service.UpdateConfig(r.Context(), id, req.MaxDepth, req.Exclusions); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			h.writeError(r.Context(), w, "NOT_FOUND", "Source not found", http.StatusNotFound)
+			return
+		}
+		h.writeError(r.Context(), w, "INTERNAL_ERROR", err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// Pause stops an in-progress crawl from enqueueing further pages.
+func (h *Handler) Pause(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if err := h.service.Pause(r.Context(), id); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			h.writeError(r.Context(), w, "NOT_FOUND", "Source not found", http.StatusNotFound)
+		case errors.Is(err, ErrInvalidState):
+			h.writeError(r.Context(), w, "CONFLICT", "Only in-progress sources can be paused", http.StatusConflict)
+		default:
+			h.writeError(r.Context(), w, "INTERNAL_ERROR", err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// Resume continues a paused crawl from its pending pages.
+func (h *Handler) Resume(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	queued, err := h.service.Resume(r.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			h.writeError(r.Context(), w, "NOT_FOUND", "Source not found", http.StatusNotFound)
+		case errors.Is(err, ErrInvalidState):
+			h.writeError(r.Context(), w, "CONFLICT", "Only paused sources can be resumed", http.StatusConflict)
+		default:
+			slog.Error("resume failed", "error", err, "source_id", id, "queued", queued) // #nosec G706
+			h.writeError(r.Context(), w, "INTERNAL_ERROR", err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]int{"queued": queued}}); err != nil {
+		slog.Error("failed to encode response", "error", err)
+	}
+}
+
 func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 
@@ -269,6 +445,265 @@ func (h *Handler) GetPages(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// PageErrors lists failed-page error messages across all sources (or one,
+// via ?source_id=) grouped with counts and sample URLs.
+func (h *Handler) PageErrors(w http.ResponseWriter, r *http.Request) {
+	limit := 50
+	offset := 0
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil {
+			limit = parsed
+		}
+	}
+	if o := r.URL.Query().Get("offset"); o != "" {
+		if parsed, err := strconv.Atoi(o); err == nil {
+			offset = parsed
+		}
+	}
+
+	groups, err := h.service.GroupPageErrors(r.Context(), r.URL.Query().Get("source_id"), limit, offset)
+	if err != nil {
+		h.writeError(r.Context(), w, "INTERNAL_ERROR", err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"data": groups,
+		"meta": map[string]int{"count": len(groups), "limit": limit, "offset": offset},
+	}); err != nil {
+		slog.Error("failed to encode response", "error", err)
+	}
+}
+
+// Import bulk-loads pre-chunked NDJSON content into a source, embedding any
+// records that arrive without a vector.
+func (h *Handler) Import(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxUploadSizeMB<<20)
+
+	res, err := h.service.Import(r.Context(), id, r.Body)
+	if err != nil {
+		var lineErr *ImportLineError
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			h.writeError(r.Context(), w, "NOT_FOUND", "Source not found", http.StatusNotFound)
+		case errors.Is(err, ErrImportDisabled):
+			h.writeError(r.Context(), w, "NOT_IMPLEMENTED", err.Error(), http.StatusNotImplemented)
+		case errors.As(err, &tooLarge):
+			h.writeError(r.Context(), w, "BAD_REQUEST", "Import too large", http.StatusRequestEntityTooLarge)
+		case errors.As(err, &lineErr):
+			h.writeError(r.Context(), w, "VALIDATION_ERROR", fmt.Sprintf("%v (%d records stored)", err, res.Stored), http.StatusBadRequest)
+		default:
+			slog.Error("import failed", "error", err, "source_id", id, "stored", res.Stored) // #nosec G706
+			h.writeError(r.Context(), w, "INTERNAL_ERROR", err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{"data": res}); err != nil {
+		slog.Error("failed to encode response", "error", err)
+	}
+}
+
+// Export streams a source's chunks as NDJSON in the format Import accepts.
+func (h *Handler) Export(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	sw := &startedWriter{ResponseWriter: w}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="source-%s.jsonl"`, url.PathEscape(id)))
+	if err := h.service.Export(r.Context(), id, sw); err != nil {
+		if sw.started {
+			// The status is already sent; a truncated body is all we can signal
+			slog.Error("export failed mid-stream", "error", err, "source_id", id) // #nosec G706
+			return
+		}
+		w.Header().Del("Content-Disposition")
+		if errors.Is(err, sql.ErrNoRows) {
+			h.writeError(r.Context(), w, "NOT_FOUND", "Source not found", http.StatusNotFound)
+			return
+		}
+		h.writeError(r.Context(), w, "INTERNAL_ERROR", err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// startedWriter records whether any of the response body has been written.
+type startedWriter struct {
+	http.ResponseWriter
+	started bool
+}
+
+func (w *startedWriter) Write(p []byte) (int, error) {
+	w.started = true
+	return w.ResponseWriter.Write(p)
+}
+
+// ReembedMissing republishes embed tasks for a source's chunks that have no
+// vector. With ?all=true every chunk is re-embedded from its stored text.
+func (h *Handler) ReembedMissing(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	all, _ := strconv.ParseBool(r.URL.Query().Get("all"))
+
+	queued, err := h.service.ReembedMissing(r.Context(), id, all)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			h.writeError(r.Context(), w, "NOT_FOUND", "Source not found", http.StatusNotFound)
+		case errors.Is(err, ErrReembedDisabled):
+			h.writeError(r.Context(), w, "NOT_IMPLEMENTED", err.Error(), http.StatusNotImplemented)
+		default:
+			slog.Error("re-embed failed", "error", err, "source_id", id, "queued", queued) // #nosec G706
+			h.writeError(r.Context(), w, "INTERNAL_ERROR", err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]int{"queued": queued}}); err != nil {
+		slog.Error("failed to encode response", "error", err)
+	}
+}
+
+// TestConfig reports which candidate links a crawl of seed_url would enqueue
+// with the given settings, and why the others would be skipped. Nothing is
+// persisted or published.
+func (h *Handler) TestConfig(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		SeedURL        string   `json:"seed_url"`
+		CandidateLinks []string `json:"candidate_links"`
+		MaxDepth       int      `json:"max_depth"`
+		Exclusions     []string `json:"exclusions"`
+		Inclusions     []string `json:"inclusions"`
+		Scope          string   `json:"scope"` // "host" (default) or "seed_path"
+
+		CrawlSubdomains bool `json:"crawl_subdomains"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(r.Context(), w, "VALIDATION_ERROR", err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var problems []fieldError
+	seed, err := url.Parse(req.SeedURL)
+	if req.SeedURL == "" || err != nil || seed.Host == "" {
+		problems = append(problems, fieldError{Field: "seed_url", Message: "seed_url must be an absolute URL"})
+	}
+	if req.Scope != "" && req.Scope != "host" && req.Scope != "seed_path" {
+		problems = append(problems, fieldError{Field: "scope", Message: fmt.Sprintf("unsupported scope %q", req.Scope)})
+	}
+	if req.MaxDepth < 0 {
+		problems = append(problems, fieldError{Field: "max_depth", Message: "max_depth must not be negative"})
+	}
+	for i, ex := range req.Exclusions {
+		if _, err := regexp.Compile(ex); err != nil {
+			problems = append(problems, fieldError{Field: fmt.Sprintf("exclusions[%d]", i), Message: err.Error()})
+		}
+	}
+	inclusions := make([]*regexp.Regexp, 0, len(req.Inclusions))
+	for i, in := range req.Inclusions {
+		re, err := regexp.Compile(in)
+		if err != nil {
+			problems = append(problems, fieldError{Field: fmt.Sprintf("inclusions[%d]", i), Message: err.Error()})
+			continue
+		}
+		inclusions = append(inclusions, re)
+	}
+	if len(problems) > 0 {
+		h.writeValidationErrors(r.Context(), w, problems)
+		return
+	}
+
+	decisions := worker.ExplainLinks(seed.Host, req.CandidateLinks, 0, req.MaxDepth, req.Exclusions, req.CrawlSubdomains)
+
+	prefix := ""
+	if req.Scope == "seed_path" {
+		prefix = worker.SeedPathPrefix(req.SeedURL)
+	}
+	enqueued := 0
+	for i, d := range decisions {
+		if d.Reason != worker.LinkOK {
+			continue
+		}
+		if u, _ := url.Parse(d.URL); prefix != "" && !strings.HasPrefix(u.Path, prefix) {
+			decisions[i].Reason = "out_of_scope"
+			continue
+		}
+		if len(inclusions) > 0 && !matchesAny(inclusions, d.URL) {
+			decisions[i].Reason = "not_included"
+			continue
+		}
+		enqueued++
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"data": decisions,
+		"meta": map[string]int{"count": len(decisions), "enqueued": enqueued},
+	}); err != nil {
+		slog.Error("failed to encode response", "error", err)
+	}
+}
+
+func matchesAny(patterns []*regexp.Regexp, s string) bool {
+	for _, re := range patterns {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Events streams ingestion events for a source as Server-Sent Events until
+// the client disconnects.
+func (h *Handler) Events(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	flusher, ok := w.(http.Flusher)
+	if !ok || h.events == nil {
+		h.writeError(r.Context(), w, "UNAVAILABLE", "Event streaming not supported", http.StatusServiceUnavailable)
+		return
+	}
+
+	events, unsubscribe, err := h.events.Subscribe(id)
+	if err != nil {
+		w.Header().Set("Retry-After", "5")
+		h.writeError(r.Context(), w, "UNAVAILABLE", err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(15 * time.Second)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": ping\n\n")
+			flusher.Flush()
+		case ev := <-events:
+			data, err := json.Marshal(ev)
+			if err != nil {
+				slog.Error("failed to encode event", "error", err)
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, data)
+			flusher.Flush()
+		}
+	}
+}
+
 func (h *Handler) writeError(ctx context.Context, w http.ResponseWriter, code, message string, status int) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -285,3 +720,29 @@ func (h *Handler) writeError(ctx context.Context, w http.ResponseWriter, code, m
 		slog.Error("failed to encode error response", "error", err)
 	}
 }
+
+// fieldError describes a single invalid request field.
+type fieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// writeValidationErrors reports every invalid field at once. The first
+// problem doubles as the top-level message for clients that only read that.
+func (h *Handler) writeValidationErrors(ctx context.Context, w http.ResponseWriter, problems []fieldError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+
+	resp := map[string]interface{}{
+		"error": map[string]interface{}{
+			"code":    "VALIDATION_ERROR",
+			"message": problems[0].Message,
+			"details": problems,
+		},
+		"correlationId": middleware.GetCorrelationID(ctx),
+	}
+
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		slog.Error("failed to encode error response", "error", err)
+	}
+}
@@ -122,7 +122,8 @@ func TestHandler_List_Empty(t *testing.T) {
 	svc := source.NewService(mockRepo, nil, nil, nil)
 	handler := source.NewHandler(svc, t.TempDir(), 50)
 
-	mockRepo.On("List", mock.Anything).Return([]source.Source{}, nil)
+	mockRepo.On("List", mock.Anything, source.ListFilter{Limit: 20}).Return([]source.Source{}, nil)
+	mockRepo.On("Count", mock.Anything).Return(0, nil)
 
 	req := httptest.NewRequest("GET", "/sources", nil)
 	w := httptest.NewRecorder()
@@ -137,7 +138,7 @@ func TestHandler_List_ServiceError(t *testing.T) {
 	svc := source.NewService(mockRepo, nil, nil, nil)
 	handler := source.NewHandler(svc, t.TempDir(), 50)
 
-	mockRepo.On("List", mock.Anything).Return(nil, errors.New("db error"))
+	mockRepo.On("List", mock.Anything, source.ListFilter{Limit: 20}).Return(nil, errors.New("db error"))
 
 	req := httptest.NewRequest("GET", "/sources", nil)
 	w := httptest.NewRecorder()
@@ -2,12 +2,17 @@ package source_test
 
 import (
 	"bytes"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"qurio/apps/backend/features/source"
+	"qurio/apps/backend/internal/config"
+	"qurio/apps/backend/internal/settings"
 )
 
 func TestCreateSource_MissingName(t *testing.T) {
@@ -27,3 +32,145 @@ func TestCreateSource_MissingName(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 }
+
+func TestCreateSource_ReportsAllValidationErrors(t *testing.T) {
+	handler := source.NewHandler(nil, t.TempDir(), 50)
+
+	body := []byte(`{"type":"ftp","url":"","name":"Docs","max_depth":-1,"crawl_delay_ms":3600000,"exclusions":["/blog/.*","(unclosed"]}`)
+	req := httptest.NewRequest("POST", "/sources", bytes.NewBuffer(body))
+	w := httptest.NewRecorder()
+
+	handler.Create(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var resp struct {
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+			Details []struct {
+				Field   string `json:"field"`
+				Message string `json:"message"`
+			} `json:"details"`
+		} `json:"error"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, "VALIDATION_ERROR", resp.Error.Code)
+	assert.Equal(t, "URL is required", resp.Error.Message)
+
+	var fields []string
+	for _, d := range resp.Error.Details {
+		fields = append(fields, d.Field)
+	}
+	assert.Equal(t, []string{"url", "type", "max_depth", "crawl_delay_ms", "exclusions[1]"}, fields)
+}
+
+func TestCreateSource_RejectsUnknownEmbeddingModel(t *testing.T) {
+	svc := source.NewService(nil, nil, nil, nil)
+	svc.SetEmbeddingModels([]string{"code-model"})
+	handler := source.NewHandler(svc, t.TempDir(), 50)
+
+	body := []byte(`{"url":"https://example.com","name":"Docs","embedding_model":"text-embedding-004"}`)
+	req := httptest.NewRequest("POST", "/sources", bytes.NewBuffer(body))
+	w := httptest.NewRecorder()
+
+	handler.Create(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"field":"embedding_model"`)
+}
+
+func TestTestConfig_ReportsDecisions(t *testing.T) {
+	handler := source.NewHandler(nil, t.TempDir(), 50)
+
+	body := []byte(`{
+		"seed_url": "https://example.com/docs/intro",
+		"candidate_links": [
+			"https://example.com/docs/setup",
+			"https://example.com/docs/blog/post",
+			"https://other.com/docs/setup",
+			"https://example.com/pricing"
+		],
+		"max_depth": 1,
+		"exclusions": ["/blog/"],
+		"scope": "seed_path"
+	}`)
+	req := httptest.NewRequest("POST", "/sources/config/test", bytes.NewBuffer(body))
+	w := httptest.NewRecorder()
+
+	handler.TestConfig(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Data []struct {
+			URL    string `json:"url"`
+			Reason string `json:"reason"`
+		} `json:"data"`
+		Meta map[string]int `json:"meta"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Len(t, resp.Data, 4)
+
+	reasons := make(map[string]string)
+	for _, d := range resp.Data {
+		reasons[d.URL] = d.Reason
+	}
+	assert.Equal(t, "ok", reasons["https://example.com/docs/setup"])
+	assert.Equal(t, "excluded", reasons["https://example.com/docs/blog/post"])
+	assert.Equal(t, "external", reasons["https://other.com/docs/setup"])
+	assert.Equal(t, "out_of_scope", reasons["https://example.com/pricing"])
+	assert.Equal(t, 1, resp.Meta["enqueued"])
+}
+
+func TestTestConfig_DepthExhausted(t *testing.T) {
+	handler := source.NewHandler(nil, t.TempDir(), 50)
+
+	body := []byte(`{"seed_url": "https://example.com", "candidate_links": ["https://example.com/a"], "max_depth": 0}`)
+	req := httptest.NewRequest("POST", "/sources/config/test", bytes.NewBuffer(body))
+	w := httptest.NewRecorder()
+
+	handler.TestConfig(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"reason":"depth"`)
+}
+
+func TestCreateSource_RejectsUnknownType(t *testing.T) {
+	handler := source.NewHandler(nil, t.TempDir(), 50)
+
+	body := []byte(`{"type":"wb","url":"https://example.com","name":"Docs"}`)
+	req := httptest.NewRequest("POST", "/sources", bytes.NewBuffer(body))
+	w := httptest.NewRecorder()
+
+	handler.Create(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"VALIDATION_ERROR"`)
+	assert.Contains(t, w.Body.String(), `"field":"type"`)
+}
+
+func TestCreateSource_EmptyTypeDefaultsToWeb(t *testing.T) {
+	mockRepo := new(MockRepo)
+	mockPub := new(MockPublisher)
+	mockSettings := new(MockSettingsService)
+	svc := source.NewService(mockRepo, mockPub, nil, mockSettings)
+	handler := source.NewHandler(svc, t.TempDir(), 50)
+
+	mockRepo.On("ExistsByHash", mock.Anything, mock.Anything).Return(false, nil)
+	mockRepo.On("Save", mock.Anything, mock.MatchedBy(func(s *source.Source) bool {
+		return s.Type == source.TypeWeb
+	})).Return(nil)
+	mockRepo.On("BulkCreatePages", mock.Anything, mock.Anything).Return([]string{}, nil)
+	mockSettings.On("Get", mock.Anything).Return(&settings.Settings{}, nil)
+	mockPub.On("Publish", config.TopicIngestWeb, mock.Anything).Return(nil)
+
+	body := []byte(`{"url":"https://example.com","name":"Docs"}`)
+	req := httptest.NewRequest("POST", "/sources", bytes.NewBuffer(body))
+	w := httptest.NewRecorder()
+
+	handler.Create(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	mockRepo.AssertExpectations(t)
+}
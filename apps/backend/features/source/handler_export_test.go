@@ -0,0 +1,98 @@
+package source_test
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"qurio/apps/backend/features/source"
+	"qurio/apps/backend/internal/worker"
+)
+
+func newExportRequest(id string) *http.Request {
+	req := httptest.NewRequest("GET", "/sources/"+id+"/export", nil)
+	req.SetPathValue("id", id)
+	return req
+}
+
+func TestExport_StreamsOneLinePerChunk(t *testing.T) {
+	mockRepo := new(MockRepo)
+	mockChunks := new(MockChunkStore)
+	mockRepo.On("Get", mock.Anything, "src1").Return(&source.Source{ID: "src1", Type: "web"}, nil)
+
+	// A full first batch of pages and a partial second one
+	var first []source.SourcePage
+	for i := 0; i < 100; i++ {
+		first = append(first, source.SourcePage{ID: fmt.Sprintf("p%03d", i), URL: fmt.Sprintf("https://example.com/%d", i)})
+	}
+	second := []source.SourcePage{
+		{ID: "p100", URL: "https://example.com/b"},
+		{ID: "p101", URL: "https://example.com/alias"},
+	}
+	mockRepo.On("ListPagesAfter", mock.Anything, "src1", "00000000-0000-0000-0000-000000000000", 100).Return(first, nil)
+	mockRepo.On("ListPagesAfter", mock.Anything, "src1", "p099", 100).Return(second, nil)
+
+	var chunks []worker.Chunk
+	for i := 0; i < 3; i++ {
+		chunks = append(chunks, worker.Chunk{Content: fmt.Sprintf("chunk %d", i), SourceURL: "https://example.com/0", ChunkIndex: i, Type: "prose"})
+	}
+	canonical := []worker.Chunk{{Content: "fn main() {}", SourceURL: "https://example.com/b", Type: "code", Language: "rust"}}
+	mockChunks.On("GetPageChunks", mock.Anything, "src1", "https://example.com/0").Return(chunks, nil)
+	mockChunks.On("GetPageChunks", mock.Anything, "src1", "https://example.com/b").Return(canonical, nil)
+	// The alias page finds the canonical page's chunks again
+	mockChunks.On("GetPageChunks", mock.Anything, "src1", "https://example.com/alias").Return(canonical, nil)
+	mockChunks.On("GetPageChunks", mock.Anything, "src1", mock.Anything).Return([]worker.Chunk{}, nil)
+
+	handler := source.NewHandler(source.NewService(mockRepo, nil, mockChunks, nil), t.TempDir(), 50)
+	w := httptest.NewRecorder()
+	handler.Export(w, newExportRequest("src1"))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+
+	var lines []source.ImportLine
+	scanner := bufio.NewScanner(w.Body)
+	for scanner.Scan() {
+		var line source.ImportLine
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+		lines = append(lines, line)
+	}
+	require.Len(t, lines, 4)
+	assert.Equal(t, source.ImportLine{Content: "chunk 2", URL: "https://example.com/0", ChunkIndex: 2, Type: "prose"}, lines[2])
+	assert.Equal(t, source.ImportLine{Content: "fn main() {}", URL: "https://example.com/b", Type: "code", Language: "rust"}, lines[3])
+	mockRepo.AssertExpectations(t)
+}
+
+func TestExport_FileSourceReadsItsPath(t *testing.T) {
+	mockRepo := new(MockRepo)
+	mockChunks := new(MockChunkStore)
+	mockRepo.On("Get", mock.Anything, "src1").Return(&source.Source{ID: "src1", Type: "file", URL: "/uploads/guide.pdf"}, nil)
+	mockRepo.On("ListPagesAfter", mock.Anything, "src1", mock.Anything, 100).Return([]source.SourcePage{}, nil)
+	mockChunks.On("GetPageChunks", mock.Anything, "src1", "/uploads/guide.pdf").Return([]worker.Chunk{{Content: "Intro", SourceURL: "/uploads/guide.pdf"}}, nil)
+
+	handler := source.NewHandler(source.NewService(mockRepo, nil, mockChunks, nil), t.TempDir(), 50)
+	w := httptest.NewRecorder()
+	handler.Export(w, newExportRequest("src1"))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"content":"Intro","type":"","language":"","url":"/uploads/guide.pdf","chunk_index":0}`, w.Body.String())
+}
+
+func TestExport_NotFound(t *testing.T) {
+	mockRepo := new(MockRepo)
+	mockRepo.On("Get", mock.Anything, "missing").Return(nil, sql.ErrNoRows)
+
+	handler := source.NewHandler(source.NewService(mockRepo, nil, new(MockChunkStore), nil), t.TempDir(), 50)
+	w := httptest.NewRecorder()
+	handler.Export(w, newExportRequest("missing"))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"NOT_FOUND"`)
+}
@@ -0,0 +1,105 @@
+package source_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"qurio/apps/backend/features/source"
+	"qurio/apps/backend/internal/worker"
+)
+
+type recordingChunkWriter struct {
+	chunks []worker.Chunk
+}
+
+func (w *recordingChunkWriter) StoreChunk(ctx context.Context, chunk worker.Chunk) error {
+	w.chunks = append(w.chunks, chunk)
+	return nil
+}
+
+type MockImportEmbedder struct{ mock.Mock }
+
+func (m *MockImportEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	args := m.Called(ctx, text)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]float32), args.Error(1)
+}
+
+func newImportRequest(id, body string) *http.Request {
+	req := httptest.NewRequest("POST", "/sources/"+id+"/import", strings.NewReader(body))
+	req.SetPathValue("id", id)
+	return req
+}
+
+func TestImport_StoresLinesAndEmbedsMissingVectors(t *testing.T) {
+	mockRepo := new(MockRepo)
+	mockRepo.On("Get", mock.Anything, "src1").Return(&source.Source{ID: "src1", Name: "Docs", Metadata: map[string]string{"team": "core"}}, nil)
+	mockRepo.On("BulkCreatePages", mock.Anything, mock.Anything).Return([]string{}, nil)
+
+	writer := &recordingChunkWriter{}
+	emb := new(MockImportEmbedder)
+	emb.On("Embed", mock.Anything, "Second chunk").Return([]float32{0.5, 0.6}, nil).Once()
+
+	svc := source.NewService(mockRepo, nil, nil, nil)
+	svc.SetChunkImport(writer, emb)
+	handler := source.NewHandler(svc, t.TempDir(), 50)
+
+	body := `{"content":"First chunk","type":"prose","url":"https://example.com/a","chunk_index":0,"vector":[0.1,0.2]}
+{"content":"Second chunk","type":"code","language":"go","url":"https://example.com/a","chunk_index":1}
+
+{"content":"Third chunk","url":"https://example.com/b","chunk_index":0,"vector":[0.3,0.4]}
+`
+	w := httptest.NewRecorder()
+	handler.Import(w, newImportRequest("src1", body))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Data source.ImportResult `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, 3, resp.Data.Stored)
+	assert.Equal(t, 1, resp.Data.Embedded)
+
+	require.Len(t, writer.chunks, 3)
+	assert.Equal(t, []float32{0.1, 0.2}, writer.chunks[0].Vector)
+	assert.Equal(t, []float32{0.5, 0.6}, writer.chunks[1].Vector)
+	assert.Equal(t, "go", writer.chunks[1].Language)
+	assert.Equal(t, "src1", writer.chunks[2].SourceID)
+	assert.Equal(t, "Docs", writer.chunks[2].SourceName)
+	assert.Equal(t, map[string]string{"team": "core"}, writer.chunks[2].Metadata)
+	emb.AssertExpectations(t)
+
+	// Each URL is recorded once as a page, so an export finds it
+	mockRepo.AssertNumberOfCalls(t, "BulkCreatePages", 2)
+	mockRepo.AssertCalled(t, "BulkCreatePages", mock.Anything, []source.SourcePage{{SourceID: "src1", URL: "https://example.com/b", Status: "completed"}})
+}
+
+func TestImport_InvalidLine(t *testing.T) {
+	mockRepo := new(MockRepo)
+	mockRepo.On("Get", mock.Anything, "src1").Return(&source.Source{ID: "src1"}, nil)
+	mockRepo.On("BulkCreatePages", mock.Anything, mock.Anything).Return([]string{}, nil)
+
+	writer := &recordingChunkWriter{}
+	svc := source.NewService(mockRepo, nil, nil, nil)
+	svc.SetChunkImport(writer, new(MockImportEmbedder))
+	handler := source.NewHandler(svc, t.TempDir(), 50)
+
+	body := `{"content":"ok","url":"https://example.com/a","vector":[0.1]}
+{"content":"","url":"https://example.com/b","vector":[0.1]}
+`
+	w := httptest.NewRecorder()
+	handler.Import(w, newImportRequest("src1", body))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "record 2")
+	assert.Len(t, writer.chunks, 1)
+}
@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"regexp"
 	"strings"
 	"testing"
 	"time"
@@ -18,8 +19,11 @@ import (
 	"qurio/apps/backend/internal/settings"
 	"qurio/apps/backend/internal/worker"
 
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // MockRepo implements source.Repository
@@ -32,8 +36,8 @@ func (m *MockRepo) Save(ctx context.Context, src *source.Source) error {
 	return args.Error(0)
 }
 
-func (m *MockRepo) List(ctx context.Context) ([]source.Source, error) {
-	args := m.Called(ctx)
+func (m *MockRepo) List(ctx context.Context, f source.ListFilter) ([]source.Source, error) {
+	args := m.Called(ctx, f)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -68,11 +72,21 @@ func (m *MockRepo) UpdateBodyHash(ctx context.Context, id, hash string) error {
 	return args.Error(0)
 }
 
+func (m *MockRepo) UpdateConfig(ctx context.Context, id string, maxDepth int, exclusions []string) error {
+	args := m.Called(ctx, id, maxDepth, exclusions)
+	return args.Error(0)
+}
+
 func (m *MockRepo) Count(ctx context.Context) (int, error) {
 	args := m.Called(ctx)
 	return args.Int(0), args.Error(1)
 }
 
+func (m *MockRepo) CountMatching(ctx context.Context, f source.ListFilter) (int, error) {
+	args := m.Called(ctx, f)
+	return args.Int(0), args.Error(1)
+}
+
 func (m *MockRepo) BulkCreatePages(ctx context.Context, pages []source.SourcePage) ([]string, error) {
 	args := m.Called(ctx, pages)
 	return args.Get(0).([]string), args.Error(1)
@@ -83,6 +97,31 @@ func (m *MockRepo) UpdatePageStatus(ctx context.Context, sourceID, url, status,
 	return args.Error(0)
 }
 
+func (m *MockRepo) RecordPageFetch(ctx context.Context, sourceID, url string, statusCode, fetchMs int) error {
+	args := m.Called(ctx, sourceID, url, statusCode, fetchMs)
+	return args.Error(0)
+}
+
+func (m *MockRepo) GetPageHash(ctx context.Context, sourceID, url string) (string, error) {
+	args := m.Called(ctx, sourceID, url)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockRepo) UpdatePageHash(ctx context.Context, sourceID, url, hash string) error {
+	args := m.Called(ctx, sourceID, url, hash)
+	return args.Error(0)
+}
+
+func (m *MockRepo) BeginPageHash(ctx context.Context, sourceID, url, hash string, chunks int) error {
+	args := m.Called(ctx, sourceID, url, hash, chunks)
+	return args.Error(0)
+}
+
+func (m *MockRepo) RecordStoredChunk(ctx context.Context, sourceID, url, hash string) error {
+	args := m.Called(ctx, sourceID, url, hash)
+	return args.Error(0)
+}
+
 func (m *MockRepo) GetPages(ctx context.Context, sourceID string) ([]source.SourcePage, error) {
 	args := m.Called(ctx, sourceID)
 	if args.Get(0) == nil {
@@ -101,6 +140,43 @@ func (m *MockRepo) CountPendingPages(ctx context.Context, sourceID string) (int,
 	return args.Int(0), args.Error(1)
 }
 
+func (m *MockRepo) CountFailedPages(ctx context.Context, sourceID string) (int, error) {
+	args := m.Called(ctx, sourceID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockRepo) CountPagesByStatus(ctx context.Context, sourceID string) (map[string]int, error) {
+	args := m.Called(ctx, sourceID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]int), args.Error(1)
+}
+
+func (m *MockRepo) ListPagesAfter(ctx context.Context, sourceID, afterID string, limit int) ([]source.SourcePage, error) {
+	args := m.Called(ctx, sourceID, afterID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]source.SourcePage), args.Error(1)
+}
+
+func (m *MockRepo) ListHeldPages(ctx context.Context, sourceID string, limit int) ([]source.SourcePage, error) {
+	args := m.Called(ctx, sourceID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]source.SourcePage), args.Error(1)
+}
+
+func (m *MockRepo) GroupPageErrors(ctx context.Context, sourceID string, limit, offset int) ([]source.PageErrorGroup, error) {
+	args := m.Called(ctx, sourceID, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]source.PageErrorGroup), args.Error(1)
+}
+
 func (m *MockRepo) ResetStuckPages(ctx context.Context, timeout time.Duration) (int64, error) {
 	args := m.Called(ctx, timeout)
 	return args.Get(0).(int64), args.Error(1)
@@ -119,6 +195,14 @@ func (m *MockChunkStore) GetChunks(ctx context.Context, sourceID string, limit,
 	return args.Get(0).([]worker.Chunk), args.Error(1)
 }
 
+func (m *MockChunkStore) GetPageChunks(ctx context.Context, sourceID, url string) ([]worker.Chunk, error) {
+	args := m.Called(ctx, sourceID, url)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]worker.Chunk), args.Error(1)
+}
+
 func (m *MockChunkStore) DeleteChunksBySourceID(ctx context.Context, sourceID string) error {
 	args := m.Called(ctx, sourceID)
 	return args.Error(0)
@@ -265,12 +349,161 @@ func TestHandler_ReSync(t *testing.T) {
 	})
 }
 
+func TestHandler_List_Filters(t *testing.T) {
+	mockRepo := new(MockRepo)
+	handler := source.NewHandler(source.NewService(mockRepo, nil, nil, nil), t.TempDir(), 50)
+
+	want := source.ListFilter{Status: "completed", Type: "web", Query: "docs", Sort: "created_at", Desc: true, Limit: 20}
+	mockRepo.On("List", mock.Anything, want).Return([]source.Source{{ID: "1"}}, nil)
+	mockRepo.On("CountMatching", mock.Anything, want).Return(1, nil)
+
+	req := httptest.NewRequest("GET", "/sources?status=completed&type=web&q=%20docs%20&sort=created_at&order=desc", nil)
+	w := httptest.NewRecorder()
+	handler.List(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+	mockRepo.AssertExpectations(t)
+
+	for _, q := range []string{"sort=url", "order=sideways", "type=ftp"} {
+		w := httptest.NewRecorder()
+		handler.List(w, httptest.NewRequest("GET", "/sources?"+q, nil))
+		assert.Equal(t, http.StatusBadRequest, w.Result().StatusCode, q)
+	}
+}
+
+func TestHandler_UpdateConfig(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		handler := source.NewHandler(source.NewService(mockRepo, nil, nil, nil), t.TempDir(), 50)
+		mockRepo.On("UpdateConfig", mock.Anything, "1", 3, []string{`/blog/`}).Return(nil)
+
+		req := httptest.NewRequest("PUT", "/sources/1", strings.NewReader(`{"max_depth":3,"exclusions":["/blog/"]}`))
+		req.SetPathValue("id", "1")
+		w := httptest.NewRecorder()
+		handler.UpdateConfig(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		handler := source.NewHandler(source.NewService(mockRepo, nil, nil, nil), t.TempDir(), 50)
+		mockRepo.On("UpdateConfig", mock.Anything, "1", 1, []string(nil)).Return(sql.ErrNoRows)
+
+		req := httptest.NewRequest("PUT", "/sources/1", strings.NewReader(`{"max_depth":1}`))
+		req.SetPathValue("id", "1")
+		w := httptest.NewRecorder()
+		handler.UpdateConfig(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Result().StatusCode)
+	})
+
+	for name, body := range map[string]string{
+		"DepthTooLarge": `{"max_depth":11}`,
+		"NegativeDepth": `{"max_depth":-1}`,
+		"InvalidRegexp": `{"max_depth":2,"exclusions":["/docs/", "(unclosed"]}`,
+		"MalformedJSON": `{"max_depth":`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			mockRepo := new(MockRepo)
+			handler := source.NewHandler(source.NewService(mockRepo, nil, nil, nil), t.TempDir(), 50)
+
+			req := httptest.NewRequest("PUT", "/sources/1", strings.NewReader(body))
+			req.SetPathValue("id", "1")
+			w := httptest.NewRecorder()
+			handler.UpdateConfig(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+			assert.Contains(t, w.Body.String(), `"code":"VALIDATION_ERROR"`)
+			mockRepo.AssertNotCalled(t, "UpdateConfig", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestHandler_Pause(t *testing.T) {
+	tests := []struct {
+		name   string
+		source *source.Source
+		err    error
+		want   int
+	}{
+		{"in progress", &source.Source{ID: "1", Status: "in_progress"}, nil, http.StatusOK},
+		{"completed", &source.Source{ID: "1", Status: "completed"}, nil, http.StatusConflict},
+		{"not found", nil, sql.ErrNoRows, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockRepo)
+			handler := source.NewHandler(source.NewService(mockRepo, nil, nil, nil), t.TempDir(), 50)
+
+			mockRepo.On("Get", mock.Anything, "1").Return(tt.source, tt.err)
+			mockRepo.On("UpdateStatus", mock.Anything, "1", "paused").Return(nil)
+
+			req := httptest.NewRequest("POST", "/sources/1/pause", nil)
+			req.SetPathValue("id", "1")
+			w := httptest.NewRecorder()
+			handler.Pause(w, req)
+
+			assert.Equal(t, tt.want, w.Result().StatusCode)
+			if tt.want != http.StatusOK {
+				mockRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestHandler_Resume(t *testing.T) {
+	t.Run("paused", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockPub := new(MockPublisher)
+		mockSettings := new(MockSettingsService)
+		handler := source.NewHandler(source.NewService(mockRepo, mockPub, nil, mockSettings), t.TempDir(), 50)
+
+		mockRepo.On("Get", mock.Anything, "1").Return(&source.Source{ID: "1", Type: "web", Status: "paused", MaxDepth: 2}, nil)
+		mockRepo.On("UpdateStatus", mock.Anything, "1", "in_progress").Return(nil)
+		mockSettings.On("Get", mock.Anything).Return(&settings.Settings{}, nil)
+		mockRepo.On("ListHeldPages", mock.Anything, "1", mock.Anything).Return([]source.SourcePage{
+			{ID: "p2", URL: "http://example.com/b", Status: "held", Depth: 2},
+		}, nil)
+		mockRepo.On("UpdatePageStatus", mock.Anything, "1", "http://example.com/b", "pending", "").Return(nil)
+		mockPub.On("Publish", config.TopicIngestWeb, mock.Anything).Return(nil)
+
+		req := httptest.NewRequest("POST", "/sources/1/resume", nil)
+		req.SetPathValue("id", "1")
+		w := httptest.NewRecorder()
+		handler.Resume(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+		assert.JSONEq(t, `{"data":{"queued":1}}`, w.Body.String())
+		mockPub.AssertNumberOfCalls(t, "Publish", 1)
+		body := string(mockPub.Calls[0].Arguments.Get(1).([]byte))
+		assert.Contains(t, body, `"url":"http://example.com/b"`)
+		assert.Contains(t, body, `"depth":2`)
+	})
+
+	t.Run("not paused", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		handler := source.NewHandler(source.NewService(mockRepo, nil, nil, nil), t.TempDir(), 50)
+
+		mockRepo.On("Get", mock.Anything, "1").Return(&source.Source{ID: "1", Status: "in_progress"}, nil)
+
+		req := httptest.NewRequest("POST", "/sources/1/resume", nil)
+		req.SetPathValue("id", "1")
+		w := httptest.NewRecorder()
+		handler.Resume(w, req)
+
+		assert.Equal(t, http.StatusConflict, w.Result().StatusCode)
+	})
+}
+
 func TestHandler_List(t *testing.T) {
 	mockRepo := new(MockRepo)
 	svc := source.NewService(mockRepo, nil, nil, nil) // nil nsq, nil vector, nil settings
 	handler := source.NewHandler(svc, t.TempDir(), 50)
 
-	mockRepo.On("List", mock.Anything).Return([]source.Source{{ID: "1"}}, nil)
+	mockRepo.On("List", mock.Anything, source.ListFilter{Limit: 20}).Return([]source.Source{{ID: "1"}}, nil)
+	mockRepo.On("Count", mock.Anything).Return(1, nil)
 
 	req := httptest.NewRequest("GET", "/sources", nil)
 	w := httptest.NewRecorder()
@@ -281,6 +514,54 @@ func TestHandler_List(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestHandler_List_Pagination(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	handler := source.NewHandler(source.NewService(source.NewPostgresRepo(db), nil, nil, nil), t.TempDir(), 50)
+
+	columns := []string{"id", "type", "url", "status", "max_depth", "exclusions", "name", "metadata", "embed_title_prefix", "crawl_delay_ms", "keyword_only_types", "restrict_to_seed_path", "embedding_model", "crawl_subdomains", "seed_path_prefix", "updated_at"}
+	// Of sources a..e, offset 2 with limit 2 returns c and d
+	sqlMock.ExpectQuery(regexp.QuoteMeta("FROM sources WHERE deleted_at IS NULL ORDER BY name ASC, id ASC LIMIT $1 OFFSET $2")).
+		WithArgs(2, 2).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("c", "web", "http://c.example.com", "completed", 0, pq.Array([]string{}), "c", []byte(`{}`), false, 0, pq.Array([]string{}), false, "", false, "", "").
+			AddRow("d", "web", "http://d.example.com", "completed", 0, pq.Array([]string{}), "d", []byte(`{}`), false, 0, pq.Array([]string{}), false, "", false, "", ""))
+	sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM sources WHERE deleted_at IS NULL")).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(5))
+
+	w := httptest.NewRecorder()
+	handler.List(w, httptest.NewRequest("GET", "/sources?limit=2&offset=2", nil))
+	require.Equal(t, http.StatusOK, w.Result().StatusCode)
+
+	var resp struct {
+		Data []source.Source `json:"data"`
+		Meta map[string]int  `json:"meta"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Len(t, resp.Data, 2)
+	assert.Equal(t, "c", resp.Data[0].ID)
+	assert.Equal(t, "d", resp.Data[1].ID)
+	assert.Equal(t, map[string]int{"count": 2, "total": 5, "limit": 2, "offset": 2}, resp.Meta)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestHandler_List_LimitCapped(t *testing.T) {
+	mockRepo := new(MockRepo)
+	handler := source.NewHandler(source.NewService(mockRepo, nil, nil, nil), t.TempDir(), 50)
+
+	mockRepo.On("List", mock.Anything, source.ListFilter{Limit: 100}).Return([]source.Source{}, nil)
+	mockRepo.On("Count", mock.Anything).Return(0, nil)
+
+	w := httptest.NewRecorder()
+	handler.List(w, httptest.NewRequest("GET", "/sources?limit=1000", nil))
+
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+	assert.Contains(t, w.Body.String(), `"limit":100`)
+	mockRepo.AssertExpectations(t)
+}
+
 func TestHandler_Delete(t *testing.T) {
 	mockRepo := new(MockRepo)
 	mockChunkStore := new(MockChunkStore)
@@ -326,6 +607,7 @@ func TestHandler_Get(t *testing.T) {
 
 	mockRepo.On("Get", mock.Anything, "1").Return(&source.Source{ID: "1"}, nil)
 	mockChunkStore.On("CountChunksBySource", mock.Anything, "1").Return(10, nil)
+	mockRepo.On("CountPagesByStatus", mock.Anything, "1").Return(map[string]int{}, nil)
 	mockChunkStore.On("GetChunks", mock.Anything, "1", 100, 0).Return([]worker.Chunk{}, nil)
 
 	req := httptest.NewRequest("GET", "/sources/1", nil)
@@ -345,6 +627,7 @@ func TestHandler_Get_Pagination(t *testing.T) {
 
 	mockRepo.On("Get", mock.Anything, "1").Return(&source.Source{ID: "1"}, nil)
 	mockChunkStore.On("CountChunksBySource", mock.Anything, "1").Return(200, nil)
+	mockRepo.On("CountPagesByStatus", mock.Anything, "1").Return(map[string]int{}, nil)
 	mockChunkStore.On("GetChunks", mock.Anything, "1", 20, 10).Return([]worker.Chunk{}, nil)
 
 	req := httptest.NewRequest("GET", "/sources/1?limit=20&offset=10", nil)
@@ -364,6 +647,7 @@ func TestHandler_Get_ExcludeChunks(t *testing.T) {
 
 	mockRepo.On("Get", mock.Anything, "1").Return(&source.Source{ID: "1"}, nil)
 	mockChunkStore.On("CountChunksBySource", mock.Anything, "1").Return(200, nil)
+	mockRepo.On("CountPagesByStatus", mock.Anything, "1").Return(map[string]int{}, nil)
 	// GetChunks shouldn't be called
 
 	req := httptest.NewRequest("GET", "/sources/1?exclude_chunks=true", nil)
@@ -409,6 +693,30 @@ func TestHandler_GetPages(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
 }
 
+func TestHandler_PageErrors(t *testing.T) {
+	mockRepo := new(MockRepo)
+	svc := source.NewService(mockRepo, nil, nil, nil)
+	handler := source.NewHandler(svc, t.TempDir(), 50)
+
+	mockRepo.On("GroupPageErrors", mock.Anything, "src1", 10, 0).Return([]source.PageErrorGroup{
+		{Error: "connection refused", Count: 3, SampleURLs: []string{"http://example.com/a"}},
+	}, nil)
+
+	req := httptest.NewRequest("GET", "/admin/pages/errors?source_id=src1&limit=10", nil)
+	w := httptest.NewRecorder()
+
+	handler.PageErrors(w, req)
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+
+	var body struct {
+		Data []source.PageErrorGroup `json:"data"`
+	}
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+	assert.Equal(t, "connection refused", body.Data[0].Error)
+	assert.Equal(t, 3, body.Data[0].Count)
+	mockRepo.AssertExpectations(t)
+}
+
 func TestHandler_Upload_DefaultDirectory(t *testing.T) {
 	uploadDir := t.TempDir()
 
@@ -3,6 +3,9 @@ package source
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/lib/pq"
@@ -27,8 +30,12 @@ func (r *PostgresRepo) ExistsByHash(ctx context.Context, hash string) (bool, err
 }
 
 func (r *PostgresRepo) Save(ctx context.Context, src *Source) error {
-	query := `INSERT INTO sources (type, url, content_hash, max_depth, exclusions, name) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
-	return r.db.QueryRowContext(ctx, query, src.Type, src.URL, src.ContentHash, src.MaxDepth, pq.Array(src.Exclusions), src.Name).Scan(&src.ID)
+	metadata, err := encodeMetadata(src.Metadata)
+	if err != nil {
+		return err
+	}
+	query := `INSERT INTO sources (type, url, content_hash, max_depth, exclusions, name, metadata, embed_title_prefix, crawl_delay_ms, keyword_only_types, restrict_to_seed_path, embedding_model, crawl_subdomains, seed_path_prefix) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) RETURNING id`
+	return r.db.QueryRowContext(ctx, query, src.Type, src.URL, src.ContentHash, src.MaxDepth, pq.Array(src.Exclusions), src.Name, metadata, src.EmbedTitlePrefix, src.CrawlDelayMs, pq.Array(src.KeywordOnlyTypes), src.RestrictToSeedPath, src.EmbeddingModel, src.CrawlSubdomains, src.SeedPathPrefix).Scan(&src.ID)
 }
 
 func (r *PostgresRepo) UpdateStatus(ctx context.Context, id, status string) error {
@@ -37,9 +44,59 @@ func (r *PostgresRepo) UpdateStatus(ctx context.Context, id, status string) erro
 	return err
 }
 
-func (r *PostgresRepo) List(ctx context.Context) ([]Source, error) {
-	query := `SELECT id, type, url, status, max_depth, exclusions, name, updated_at FROM sources WHERE deleted_at IS NULL ORDER BY created_at DESC`
-	rows, err := r.db.QueryContext(ctx, query)
+// listSortColumns maps ListFilter.Sort values to the columns they order by.
+var listSortColumns = map[string]string{
+	"":           "name",
+	"name":       "name",
+	"created_at": "created_at",
+}
+
+// likeEscaper escapes LIKE wildcards so a search term matches literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// sourceConditions returns the WHERE conditions for f's filters, to append
+// after "deleted_at IS NULL", and their arguments.
+func sourceConditions(f ListFilter) (string, []interface{}) {
+	var where string
+	var args []interface{}
+	if f.Status != "" {
+		args = append(args, f.Status)
+		where += fmt.Sprintf(" AND status = $%d", len(args))
+	}
+	if f.Type != "" {
+		args = append(args, f.Type)
+		where += fmt.Sprintf(" AND type = $%d", len(args))
+	}
+	if f.Query != "" {
+		args = append(args, "%"+likeEscaper.Replace(f.Query)+"%")
+		where += fmt.Sprintf(" AND (url ILIKE $%d OR name ILIKE $%d)", len(args), len(args))
+	}
+	if len(f.IDs) > 0 {
+		args = append(args, pq.Array(f.IDs))
+		where += fmt.Sprintf(" AND id = ANY($%d)", len(args))
+	}
+	return where, args
+}
+
+func (r *PostgresRepo) List(ctx context.Context, f ListFilter) ([]Source, error) {
+	where, args := sourceConditions(f)
+	query := `SELECT id, type, url, status, max_depth, exclusions, name, metadata, embed_title_prefix, crawl_delay_ms, keyword_only_types, restrict_to_seed_path, embedding_model, crawl_subdomains, seed_path_prefix, updated_at FROM sources WHERE deleted_at IS NULL` + where
+
+	column, ok := listSortColumns[f.Sort]
+	if !ok {
+		return nil, fmt.Errorf("unsupported sort %q", f.Sort)
+	}
+	dir := "ASC"
+	if f.Desc {
+		dir = "DESC"
+	}
+	query += fmt.Sprintf(" ORDER BY %s %s, id %s", column, dir, dir)
+	if f.Limit > 0 {
+		args = append(args, f.Limit, f.Offset)
+		query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -48,7 +105,11 @@ func (r *PostgresRepo) List(ctx context.Context) ([]Source, error) {
 	var sources []Source
 	for rows.Next() {
 		var s Source
-		if err := rows.Scan(&s.ID, &s.Type, &s.URL, &s.Status, &s.MaxDepth, pq.Array(&s.Exclusions), &s.Name, &s.UpdatedAt); err != nil {
+		var metadata []byte
+		if err := rows.Scan(&s.ID, &s.Type, &s.URL, &s.Status, &s.MaxDepth, pq.Array(&s.Exclusions), &s.Name, &metadata, &s.EmbedTitlePrefix, &s.CrawlDelayMs, pq.Array(&s.KeywordOnlyTypes), &s.RestrictToSeedPath, &s.EmbeddingModel, &s.CrawlSubdomains, &s.SeedPathPrefix, &s.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if s.Metadata, err = decodeMetadata(metadata); err != nil {
 			return nil, err
 		}
 		sources = append(sources, s)
@@ -58,11 +119,15 @@ func (r *PostgresRepo) List(ctx context.Context) ([]Source, error) {
 
 func (r *PostgresRepo) Get(ctx context.Context, id string) (*Source, error) {
 	s := &Source{}
-	query := `SELECT id, type, url, status, max_depth, exclusions, name, updated_at FROM sources WHERE id = $1 AND deleted_at IS NULL`
-	err := r.db.QueryRowContext(ctx, query, id).Scan(&s.ID, &s.Type, &s.URL, &s.Status, &s.MaxDepth, pq.Array(&s.Exclusions), &s.Name, &s.UpdatedAt)
+	var metadata []byte
+	query := `SELECT id, type, url, status, max_depth, exclusions, name, metadata, embed_title_prefix, crawl_delay_ms, keyword_only_types, restrict_to_seed_path, embedding_model, crawl_subdomains, seed_path_prefix, updated_at FROM sources WHERE id = $1 AND deleted_at IS NULL`
+	err := r.db.QueryRowContext(ctx, query, id).Scan(&s.ID, &s.Type, &s.URL, &s.Status, &s.MaxDepth, pq.Array(&s.Exclusions), &s.Name, &metadata, &s.EmbedTitlePrefix, &s.CrawlDelayMs, pq.Array(&s.KeywordOnlyTypes), &s.RestrictToSeedPath, &s.EmbeddingModel, &s.CrawlSubdomains, &s.SeedPathPrefix, &s.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
+	if s.Metadata, err = decodeMetadata(metadata); err != nil {
+		return nil, err
+	}
 	return s, nil
 }
 
@@ -82,6 +147,22 @@ func (r *PostgresRepo) SoftDelete(ctx context.Context, id string) error {
 	return nil
 }
 
+func (r *PostgresRepo) UpdateConfig(ctx context.Context, id string, maxDepth int, exclusions []string) error {
+	query := `UPDATE sources SET max_depth = $1, exclusions = $2, updated_at = NOW() WHERE id = $3 AND deleted_at IS NULL`
+	res, err := r.db.ExecContext(ctx, query, maxDepth, pq.Array(exclusions), id)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
 func (r *PostgresRepo) UpdateBodyHash(ctx context.Context, id, hash string) error {
 	query := `UPDATE sources SET body_hash = $1, updated_at = NOW() WHERE id = $2`
 	_, err := r.db.ExecContext(ctx, query, hash, id)
@@ -95,6 +176,16 @@ func (r *PostgresRepo) Count(ctx context.Context) (int, error) {
 	return count, err
 }
 
+// CountMatching counts the sources that match f's filters, ignoring its
+// order and page window.
+func (r *PostgresRepo) CountMatching(ctx context.Context, f ListFilter) (int, error) {
+	var count int
+	where, args := sourceConditions(f)
+	query := `SELECT COUNT(*) FROM sources WHERE deleted_at IS NULL` + where
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&count)
+	return count, err
+}
+
 func (r *PostgresRepo) BulkCreatePages(ctx context.Context, pages []SourcePage) ([]string, error) {
 	if len(pages) == 0 {
 		return nil, nil
@@ -144,11 +235,65 @@ func (r *PostgresRepo) UpdatePageStatus(ctx context.Context, sourceID, url, stat
 	return err
 }
 
+func (r *PostgresRepo) RecordPageFetch(ctx context.Context, sourceID, url string, statusCode, fetchMs int) error {
+	query := `UPDATE source_pages 
+              SET status_code = $1, fetch_ms = $2, updated_at = NOW() 
+              WHERE source_id = $3 AND url = $4`
+	_, err := r.db.ExecContext(ctx, query, statusCode, fetchMs, sourceID, url)
+	return err
+}
+
+// GetPageHash returns the content hash last indexed for a page, or "" when
+// none is stored. Hashes are kept apart from source_pages so they survive the
+// page reset of a re-sync.
+func (r *PostgresRepo) GetPageHash(ctx context.Context, sourceID, url string) (string, error) {
+	var hash string
+	query := `SELECT content_hash FROM source_page_hashes WHERE source_id = $1 AND url = $2`
+	err := r.db.QueryRowContext(ctx, query, sourceID, url).Scan(&hash)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return hash, err
+}
+
+func (r *PostgresRepo) UpdatePageHash(ctx context.Context, sourceID, url, hash string) error {
+	query := `INSERT INTO source_page_hashes (source_id, url, content_hash) 
+              VALUES ($1, $2, $3) 
+              ON CONFLICT (source_id, url) DO UPDATE SET content_hash = EXCLUDED.content_hash, updated_at = NOW()`
+	_, err := r.db.ExecContext(ctx, query, sourceID, url, hash)
+	return err
+}
+
+// BeginPageHash clears a page's hash while chunks of its new content are
+// embedded. hash becomes the page's hash once RecordStoredChunk has been
+// called for each of them.
+func (r *PostgresRepo) BeginPageHash(ctx context.Context, sourceID, url, hash string, chunks int) error {
+	query := `INSERT INTO source_page_hashes (source_id, url, content_hash, pending_hash, pending_chunks)
+              VALUES ($1, $2, '', $3, $4)
+              ON CONFLICT (source_id, url) DO UPDATE SET content_hash = '', pending_hash = EXCLUDED.pending_hash,
+                  pending_chunks = EXCLUDED.pending_chunks, updated_at = NOW()`
+	_, err := r.db.ExecContext(ctx, query, sourceID, url, hash, chunks)
+	return err
+}
+
+// RecordStoredChunk counts one stored chunk towards the pending hash of a
+// page and makes it the page's hash when it was the last one. Chunks of
+// content that has since been replaced are ignored.
+func (r *PostgresRepo) RecordStoredChunk(ctx context.Context, sourceID, url, hash string) error {
+	query := `UPDATE source_page_hashes
+              SET pending_chunks = pending_chunks - 1,
+                  content_hash = CASE WHEN pending_chunks = 1 THEN pending_hash ELSE content_hash END,
+                  updated_at = NOW()
+              WHERE source_id = $1 AND url = $2 AND pending_hash = $3 AND pending_chunks > 0`
+	_, err := r.db.ExecContext(ctx, query, sourceID, url, hash)
+	return err
+}
+
 func (r *PostgresRepo) GetPages(ctx context.Context, sourceID string) ([]SourcePage, error) {
-	query := `SELECT id, source_id, url, status, depth, COALESCE(error, ''), created_at, updated_at 
+	query := `SELECT id, source_id, url, status, depth, COALESCE(error, ''), COALESCE(status_code, 0), COALESCE(fetch_ms, 0), created_at, updated_at 
               FROM source_pages 
               WHERE source_id = $1 
-              ORDER BY created_at ASC`
+              ORDER BY depth ASC, url ASC`
 	rows, err := r.db.QueryContext(ctx, query, sourceID)
 	if err != nil {
 		return nil, err
@@ -158,7 +303,7 @@ func (r *PostgresRepo) GetPages(ctx context.Context, sourceID string) ([]SourceP
 	var pages []SourcePage
 	for rows.Next() {
 		var p SourcePage
-		if err := rows.Scan(&p.ID, &p.SourceID, &p.URL, &p.Status, &p.Depth, &p.Error, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		if err := rows.Scan(&p.ID, &p.SourceID, &p.URL, &p.Status, &p.Depth, &p.Error, &p.StatusCode, &p.FetchMs, &p.CreatedAt, &p.UpdatedAt); err != nil {
 			return nil, err
 		}
 		pages = append(pages, p)
@@ -166,6 +311,55 @@ func (r *PostgresRepo) GetPages(ctx context.Context, sourceID string) ([]SourceP
 	return pages, nil
 }
 
+// ListPagesAfter returns up to limit pages of a source with IDs greater than
+// afterID, in ID order.
+func (r *PostgresRepo) ListPagesAfter(ctx context.Context, sourceID, afterID string, limit int) ([]SourcePage, error) {
+	query := `SELECT id, source_id, url, status, depth, COALESCE(error, ''), COALESCE(status_code, 0), COALESCE(fetch_ms, 0), created_at, updated_at
+              FROM source_pages
+              WHERE source_id = $1 AND id > $2
+              ORDER BY id ASC
+              LIMIT $3`
+	rows, err := r.db.QueryContext(ctx, query, sourceID, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pages := []SourcePage{}
+	for rows.Next() {
+		var p SourcePage
+		if err := rows.Scan(&p.ID, &p.SourceID, &p.URL, &p.Status, &p.Depth, &p.Error, &p.StatusCode, &p.FetchMs, &p.CreatedAt, &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		pages = append(pages, p)
+	}
+	return pages, rows.Err()
+}
+
+// ListHeldPages returns up to limit of a source's held pages in ID order.
+func (r *PostgresRepo) ListHeldPages(ctx context.Context, sourceID string, limit int) ([]SourcePage, error) {
+	query := `SELECT id, source_id, url, status, depth, COALESCE(error, ''), COALESCE(status_code, 0), COALESCE(fetch_ms, 0), created_at, updated_at
+              FROM source_pages
+              WHERE source_id = $1 AND status = 'held'
+              ORDER BY id ASC
+              LIMIT $2`
+	rows, err := r.db.QueryContext(ctx, query, sourceID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pages := []SourcePage{}
+	for rows.Next() {
+		var p SourcePage
+		if err := rows.Scan(&p.ID, &p.SourceID, &p.URL, &p.Status, &p.Depth, &p.Error, &p.StatusCode, &p.FetchMs, &p.CreatedAt, &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		pages = append(pages, p)
+	}
+	return pages, rows.Err()
+}
+
 func (r *PostgresRepo) DeletePages(ctx context.Context, sourceID string) error {
 	query := `DELETE FROM source_pages WHERE source_id = $1`
 	_, err := r.db.ExecContext(ctx, query, sourceID)
@@ -175,11 +369,67 @@ func (r *PostgresRepo) DeletePages(ctx context.Context, sourceID string) error {
 func (r *PostgresRepo) CountPendingPages(ctx context.Context, sourceID string) (int, error) {
 	var count int
 	query := `SELECT COUNT(*) FROM source_pages 
-              WHERE source_id = $1 AND (status = 'pending' OR status = 'processing')`
+              WHERE source_id = $1 AND status IN ('pending', 'processing', 'held')`
+	err := r.db.QueryRowContext(ctx, query, sourceID).Scan(&count)
+	return count, err
+}
+
+func (r *PostgresRepo) CountPagesByStatus(ctx context.Context, sourceID string) (map[string]int, error) {
+	query := `SELECT status, COUNT(*) FROM source_pages WHERE source_id = $1 GROUP BY status`
+	rows, err := r.db.QueryContext(ctx, query, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[string]int{}
+	for rows.Next() {
+		var status string
+		var n int
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, err
+		}
+		counts[status] = n
+	}
+	return counts, rows.Err()
+}
+
+func (r *PostgresRepo) CountFailedPages(ctx context.Context, sourceID string) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM source_pages WHERE source_id = $1 AND status = 'failed'`
 	err := r.db.QueryRowContext(ctx, query, sourceID).Scan(&count)
 	return count, err
 }
 
+// pageErrorSamples is how many URLs GroupPageErrors returns per error.
+const pageErrorSamples = 3
+
+func (r *PostgresRepo) GroupPageErrors(ctx context.Context, sourceID string, limit, offset int) ([]PageErrorGroup, error) {
+	query := `SELECT p.error, COUNT(*), (ARRAY_AGG(p.url ORDER BY p.updated_at DESC))[1:$1]
+              FROM source_pages p
+              JOIN sources s ON s.id = p.source_id AND s.deleted_at IS NULL
+              WHERE p.status = 'failed' AND COALESCE(p.error, '') <> ''
+                AND ($2 = '' OR p.source_id::text = $2)
+              GROUP BY p.error
+              ORDER BY COUNT(*) DESC, p.error ASC
+              LIMIT $3 OFFSET $4`
+	rows, err := r.db.QueryContext(ctx, query, pageErrorSamples, sourceID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := []PageErrorGroup{}
+	for rows.Next() {
+		var g PageErrorGroup
+		if err := rows.Scan(&g.Error, &g.Count, pq.Array(&g.SampleURLs)); err != nil {
+			return nil, err
+		}
+		groups = append(groups, g)
+	}
+	return groups, nil
+}
+
 func (r *PostgresRepo) ResetStuckPages(ctx context.Context, timeout time.Duration) (int64, error) {
 	query := `UPDATE source_pages 
               SET status = 'pending', updated_at = NOW(), error = 'timeout_reset' 
@@ -193,3 +443,24 @@ func (r *PostgresRepo) ResetStuckPages(ctx context.Context, timeout time.Duratio
 	}
 	return result.RowsAffected()
 }
+
+func encodeMetadata(m map[string]string) ([]byte, error) {
+	if m == nil {
+		m = map[string]string{}
+	}
+	return json.Marshal(m)
+}
+
+func decodeMetadata(raw []byte) (map[string]string, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var m map[string]string
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, err
+	}
+	if len(m) == 0 {
+		return nil, nil
+	}
+	return m, nil
+}
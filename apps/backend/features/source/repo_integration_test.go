@@ -46,7 +46,7 @@ func TestSourceRepo_Integration(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, src.URL, retrieved.URL)
 
-	list, err := repo.List(ctx)
+	list, err := repo.List(ctx, source.ListFilter{})
 	require.NoError(t, err)
 	assert.Len(t, list, 1)
 
@@ -65,7 +65,7 @@ func TestSourceRepo_Integration(t *testing.T) {
 	_, err = repo.Get(ctx, src.ID)
 	assert.Error(t, err)
 
-	listAfterDelete, err := repo.List(ctx)
+	listAfterDelete, err := repo.List(ctx, source.ListFilter{})
 	require.NoError(t, err)
 	assert.Len(t, listAfterDelete, 0)
 
@@ -2,6 +2,8 @@ package source_test
 
 import (
 	"context"
+	"database/sql"
+	"database/sql/driver"
 	"regexp"
 	"testing"
 	"time"
@@ -39,16 +41,24 @@ func TestPostgresRepo_Save(t *testing.T) {
 
 	t.Run("Success", func(t *testing.T) {
 		src := &source.Source{
-			Type:        "web",
-			URL:         "http://example.com",
-			ContentHash: "hash",
-			MaxDepth:    2,
-			Exclusions:  []string{},
-			Name:        "Example",
+			Type:               "web",
+			URL:                "http://example.com",
+			ContentHash:        "hash",
+			MaxDepth:           2,
+			Exclusions:         []string{},
+			Name:               "Example",
+			Metadata:           map[string]string{"team": "core"},
+			EmbedTitlePrefix:   true,
+			CrawlDelayMs:       500,
+			KeywordOnlyTypes:   []string{"cmd"},
+			RestrictToSeedPath: true,
+			EmbeddingModel:     "text-embedding-004",
+			CrawlSubdomains:    true,
+			SeedPathPrefix:     "/docs/",
 		}
 
-		mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO sources (type, url, content_hash, max_depth, exclusions, name) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id")).
-			WithArgs(src.Type, src.URL, src.ContentHash, src.MaxDepth, pq.Array(src.Exclusions), src.Name).
+		mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO sources (type, url, content_hash, max_depth, exclusions, name, metadata, embed_title_prefix, crawl_delay_ms, keyword_only_types, restrict_to_seed_path, embedding_model, crawl_subdomains, seed_path_prefix) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) RETURNING id")).
+			WithArgs(src.Type, src.URL, src.ContentHash, src.MaxDepth, pq.Array(src.Exclusions), src.Name, []byte(`{"team":"core"}`), true, 500, pq.Array(src.KeywordOnlyTypes), true, "text-embedding-004", true, "/docs/").
 			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1"))
 
 		err := repo.Save(context.Background(), src)
@@ -65,16 +75,24 @@ func TestPostgresRepo_Get(t *testing.T) {
 	repo := source.NewPostgresRepo(db)
 
 	t.Run("Success", func(t *testing.T) {
-		rows := sqlmock.NewRows([]string{"id", "type", "url", "status", "max_depth", "exclusions", "name", "updated_at"}).
-			AddRow("1", "web", "http://example.com", "pending", 2, pq.Array([]string{}), "Example", time.Now())
+		rows := sqlmock.NewRows([]string{"id", "type", "url", "status", "max_depth", "exclusions", "name", "metadata", "embed_title_prefix", "crawl_delay_ms", "keyword_only_types", "restrict_to_seed_path", "embedding_model", "crawl_subdomains", "seed_path_prefix", "updated_at"}).
+			AddRow("1", "web", "http://example.com", "pending", 2, pq.Array([]string{}), "Example", []byte(`{"version":"v2"}`), true, 250, pq.Array([]string{"cmd", "config"}), true, "text-embedding-004", true, "/docs/", time.Now())
 
-		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, type, url, status, max_depth, exclusions, name, updated_at FROM sources WHERE id = $1 AND deleted_at IS NULL")).
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, type, url, status, max_depth, exclusions, name, metadata, embed_title_prefix, crawl_delay_ms, keyword_only_types, restrict_to_seed_path, embedding_model, crawl_subdomains, seed_path_prefix, updated_at FROM sources WHERE id = $1 AND deleted_at IS NULL")).
 			WithArgs("1").
 			WillReturnRows(rows)
 
 		s, err := repo.Get(context.Background(), "1")
 		assert.NoError(t, err)
 		assert.Equal(t, "1", s.ID)
+		assert.Equal(t, map[string]string{"version": "v2"}, s.Metadata)
+		assert.True(t, s.EmbedTitlePrefix)
+		assert.Equal(t, 250, s.CrawlDelayMs)
+		assert.Equal(t, []string{"cmd", "config"}, s.KeywordOnlyTypes)
+		assert.True(t, s.RestrictToSeedPath)
+		assert.Equal(t, "text-embedding-004", s.EmbeddingModel)
+		assert.True(t, s.CrawlSubdomains)
+		assert.Equal(t, "/docs/", s.SeedPathPrefix)
 	})
 }
 
@@ -86,16 +104,51 @@ func TestPostgresRepo_List(t *testing.T) {
 	repo := source.NewPostgresRepo(db)
 
 	t.Run("Success", func(t *testing.T) {
-		rows := sqlmock.NewRows([]string{"id", "type", "url", "status", "max_depth", "exclusions", "name", "updated_at"}).
-			AddRow("1", "website", "http://example.com", "pending", 2, pq.Array([]string{}), "Example", time.Now())
+		rows := sqlmock.NewRows([]string{"id", "type", "url", "status", "max_depth", "exclusions", "name", "metadata", "embed_title_prefix", "crawl_delay_ms", "keyword_only_types", "restrict_to_seed_path", "embedding_model", "crawl_subdomains", "seed_path_prefix", "updated_at"}).
+			AddRow("1", "website", "http://example.com", "pending", 2, pq.Array([]string{}), "Example", []byte(`{}`), false, 0, pq.Array([]string{}), false, "", false, "", time.Now())
 
-		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, type, url, status, max_depth, exclusions, name, updated_at FROM sources WHERE deleted_at IS NULL ORDER BY created_at DESC")).
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, type, url, status, max_depth, exclusions, name, metadata, embed_title_prefix, crawl_delay_ms, keyword_only_types, restrict_to_seed_path, embedding_model, crawl_subdomains, seed_path_prefix, updated_at FROM sources WHERE deleted_at IS NULL ORDER BY name ASC, id ASC")).
 			WillReturnRows(rows)
 
-		sources, err := repo.List(context.Background())
+		sources, err := repo.List(context.Background(), source.ListFilter{})
 		assert.NoError(t, err)
 		assert.Len(t, sources, 1)
 	})
+
+	const base = "SELECT id, type, url, status, max_depth, exclusions, name, metadata, embed_title_prefix, crawl_delay_ms, keyword_only_types, restrict_to_seed_path, embedding_model, crawl_subdomains, seed_path_prefix, updated_at FROM sources WHERE deleted_at IS NULL"
+	columns := []string{"id", "type", "url", "status", "max_depth", "exclusions", "name", "metadata", "embed_title_prefix", "crawl_delay_ms", "keyword_only_types", "restrict_to_seed_path", "embedding_model", "crawl_subdomains", "seed_path_prefix", "updated_at"}
+
+	tests := []struct {
+		name   string
+		filter source.ListFilter
+		where  string
+		args   []driver.Value
+	}{
+		{"status", source.ListFilter{Status: "failed"}, " AND status = $1 ORDER BY name ASC, id ASC", []driver.Value{"failed"}},
+		{"type", source.ListFilter{Type: "file"}, " AND type = $1 ORDER BY name ASC, id ASC", []driver.Value{"file"}},
+		{"query", source.ListFilter{Query: "docs"}, " AND (url ILIKE $1 OR name ILIKE $1) ORDER BY name ASC, id ASC", []driver.Value{"%docs%"}},
+		{"query escapes wildcards", source.ListFilter{Query: "100%_done"}, " AND (url ILIKE $1 OR name ILIKE $1) ORDER BY name ASC, id ASC", []driver.Value{`%100\%\_done%`}},
+		{"ids", source.ListFilter{IDs: []string{"1", "2"}}, " AND id = ANY($1) ORDER BY name ASC, id ASC", []driver.Value{"{\"1\",\"2\"}"}},
+		{"sort created_at desc", source.ListFilter{Sort: "created_at", Desc: true}, " ORDER BY created_at DESC, id DESC", nil},
+		{"combined", source.ListFilter{Status: "completed", Type: "web", Query: "api", Sort: "name", Desc: true}, " AND status = $1 AND type = $2 AND (url ILIKE $3 OR name ILIKE $3) ORDER BY name DESC, id DESC", []driver.Value{"completed", "web", "%api%"}},
+		{"page window", source.ListFilter{Status: "failed", Limit: 20, Offset: 40}, " AND status = $1 ORDER BY name ASC, id ASC LIMIT $2 OFFSET $3", []driver.Value{"failed", 20, 40}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock.ExpectQuery(regexp.QuoteMeta(base + tt.where)).
+				WithArgs(tt.args...).
+				WillReturnRows(sqlmock.NewRows(columns))
+
+			_, err := repo.List(context.Background(), tt.filter)
+			assert.NoError(t, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+
+	t.Run("unsupported sort", func(t *testing.T) {
+		_, err := repo.List(context.Background(), source.ListFilter{Sort: "url; DROP TABLE sources"})
+		assert.Error(t, err)
+	})
 }
 
 func TestPostgresRepo_BulkCreatePages(t *testing.T) {
@@ -138,6 +191,28 @@ func TestPostgresRepo_UpdateStatus(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestPostgresRepo_UpdateConfig(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := source.NewPostgresRepo(db)
+	query := regexp.QuoteMeta("UPDATE sources SET max_depth = $1, exclusions = $2, updated_at = NOW() WHERE id = $3 AND deleted_at IS NULL")
+
+	mock.ExpectExec(query).
+		WithArgs(3, pq.Array([]string{"/blog/"}), "src1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, repo.UpdateConfig(context.Background(), "src1", 3, []string{"/blog/"}))
+
+	// Missing or deleted sources update nothing
+	mock.ExpectExec(query).
+		WithArgs(1, pq.Array([]string(nil)), "gone").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.ErrorIs(t, repo.UpdateConfig(context.Background(), "gone", 1, nil), sql.ErrNoRows)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestPostgresRepo_SoftDelete(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	assert.NoError(t, err)
@@ -205,16 +280,91 @@ func TestPostgresRepo_GetPages(t *testing.T) {
 
 	repo := source.NewPostgresRepo(db)
 
-	rows := sqlmock.NewRows([]string{"id", "source_id", "url", "status", "depth", "error", "created_at", "updated_at"}).
-		AddRow("p1", "src1", "http://u.rl", "pending", 0, "", time.Now(), time.Now())
+	rows := sqlmock.NewRows([]string{"id", "source_id", "url", "status", "depth", "error", "status_code", "fetch_ms", "created_at", "updated_at"}).
+		AddRow("p1", "src1", "http://u.rl", "completed", 0, "", 200, 340, time.Now(), time.Now())
 
-	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, source_id, url, status, depth, COALESCE(error, ''), created_at, updated_at FROM source_pages")).
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, source_id, url, status, depth, COALESCE(error, ''), COALESCE(status_code, 0), COALESCE(fetch_ms, 0), created_at, updated_at FROM source_pages WHERE source_id = $1 ORDER BY depth ASC, url ASC")).
 		WithArgs("src1").
 		WillReturnRows(rows)
 
 	pages, err := repo.GetPages(context.Background(), "src1")
 	assert.NoError(t, err)
 	assert.Len(t, pages, 1)
+	assert.Equal(t, 200, pages[0].StatusCode)
+	assert.Equal(t, 340, pages[0].FetchMs)
+}
+
+func TestPostgresRepo_RecordPageFetch(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := source.NewPostgresRepo(db)
+
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE source_pages SET status_code = $1, fetch_ms = $2, updated_at = NOW() WHERE source_id = $3 AND url = $4")).
+		WithArgs(200, 340, "src1", "http://u.rl").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, repo.RecordPageFetch(context.Background(), "src1", "http://u.rl", 200, 340))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepo_GetPageHash(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := source.NewPostgresRepo(db)
+	query := regexp.QuoteMeta("SELECT content_hash FROM source_page_hashes WHERE source_id = $1 AND url = $2")
+
+	mock.ExpectQuery(query).WithArgs("src1", "http://u.rl").
+		WillReturnRows(sqlmock.NewRows([]string{"content_hash"}).AddRow("abc"))
+	hash, err := repo.GetPageHash(context.Background(), "src1", "http://u.rl")
+	assert.NoError(t, err)
+	assert.Equal(t, "abc", hash)
+
+	// A page never indexed has no hash
+	mock.ExpectQuery(query).WithArgs("src1", "http://new.rl").WillReturnError(sql.ErrNoRows)
+	hash, err = repo.GetPageHash(context.Background(), "src1", "http://new.rl")
+	assert.NoError(t, err)
+	assert.Empty(t, hash)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepo_UpdatePageHash(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := source.NewPostgresRepo(db)
+
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO source_page_hashes (source_id, url, content_hash) VALUES ($1, $2, $3) ON CONFLICT (source_id, url) DO UPDATE SET content_hash = EXCLUDED.content_hash, updated_at = NOW()")).
+		WithArgs("src1", "http://u.rl", "abc").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, repo.UpdatePageHash(context.Background(), "src1", "http://u.rl", "abc"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepo_PendingPageHash(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := source.NewPostgresRepo(db)
+
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO source_page_hashes (source_id, url, content_hash, pending_hash, pending_chunks) VALUES ($1, $2, '', $3, $4)")).
+		WithArgs("src1", "http://u.rl", "abc", 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// The last stored chunk promotes the pending hash
+	mock.ExpectExec(regexp.QuoteMeta("SET pending_chunks = pending_chunks - 1, content_hash = CASE WHEN pending_chunks = 1 THEN pending_hash ELSE content_hash END")).
+		WithArgs("src1", "http://u.rl", "abc").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, repo.BeginPageHash(context.Background(), "src1", "http://u.rl", "abc", 3))
+	assert.NoError(t, repo.RecordStoredChunk(context.Background(), "src1", "http://u.rl", "abc"))
+	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
 func TestPostgresRepo_DeletePages(t *testing.T) {
@@ -232,6 +382,24 @@ func TestPostgresRepo_DeletePages(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestPostgresRepo_CountMatching(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := source.NewPostgresRepo(db)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM sources WHERE deleted_at IS NULL AND type = $1 AND (url ILIKE $2 OR name ILIKE $2)")).
+		WithArgs("web", "%docs%").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+	// Order and page window don't affect the count
+	count, err := repo.CountMatching(context.Background(), source.ListFilter{Type: "web", Query: "docs", Sort: "created_at", Limit: 10, Offset: 30})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestPostgresRepo_CountPendingPages(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	assert.NoError(t, err)
@@ -239,7 +407,7 @@ func TestPostgresRepo_CountPendingPages(t *testing.T) {
 
 	repo := source.NewPostgresRepo(db)
 
-	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM source_pages WHERE source_id = $1 AND (status = 'pending' OR status = 'processing')")).
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM source_pages WHERE source_id = $1 AND status IN ('pending', 'processing', 'held')")).
 		WithArgs("src1").
 		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
 
@@ -248,6 +416,92 @@ func TestPostgresRepo_CountPendingPages(t *testing.T) {
 	assert.Equal(t, 3, count)
 }
 
+func TestPostgresRepo_CountFailedPages(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := source.NewPostgresRepo(db)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM source_pages WHERE source_id = $1 AND status = 'failed'")).
+		WithArgs("src1").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	count, err := repo.CountFailedPages(context.Background(), "src1")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestPostgresRepo_GroupPageErrors(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := source.NewPostgresRepo(db)
+
+	rows := sqlmock.NewRows([]string{"error", "count", "urls"}).
+		AddRow("connection refused", 500, `{"http://a.com/1","http://a.com/2","http://b.com/x"}`).
+		AddRow("404 not found", 2, `{"http://a.com/missing"}`)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT p.error, COUNT(*), (ARRAY_AGG(p.url ORDER BY p.updated_at DESC))[1:$1] FROM source_pages p JOIN sources s ON s.id = p.source_id AND s.deleted_at IS NULL WHERE p.status = 'failed' AND COALESCE(p.error, '') <> '' AND ($2 = '' OR p.source_id::text = $2) GROUP BY p.error ORDER BY COUNT(*) DESC, p.error ASC LIMIT $3 OFFSET $4`)).
+		WithArgs(3, "src1", 20, 40).
+		WillReturnRows(rows)
+
+	groups, err := repo.GroupPageErrors(context.Background(), "src1", 20, 40)
+	assert.NoError(t, err)
+	assert.Equal(t, []source.PageErrorGroup{
+		{Error: "connection refused", Count: 500, SampleURLs: []string{"http://a.com/1", "http://a.com/2", "http://b.com/x"}},
+		{Error: "404 not found", Count: 2, SampleURLs: []string{"http://a.com/missing"}},
+	}, groups)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepo_ListPagesAfter(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := source.NewPostgresRepo(db)
+
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "source_id", "url", "status", "depth", "error", "status_code", "fetch_ms", "created_at", "updated_at"}).
+		AddRow("p2", "src1", "http://a.com/2", "completed", 1, "", 200, 40, now, now)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, source_id, url, status, depth, COALESCE(error, ''), COALESCE(status_code, 0), COALESCE(fetch_ms, 0), created_at, updated_at FROM source_pages WHERE source_id = $1 AND id > $2 ORDER BY id ASC LIMIT $3`)).
+		WithArgs("src1", "p1", 51).
+		WillReturnRows(rows)
+
+	pages, err := repo.ListPagesAfter(context.Background(), "src1", "p1", 51)
+	assert.NoError(t, err)
+	if assert.Len(t, pages, 1) {
+		assert.Equal(t, "http://a.com/2", pages[0].URL)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepo_ListHeldPages(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := source.NewPostgresRepo(db)
+
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "source_id", "url", "status", "depth", "error", "status_code", "fetch_ms", "created_at", "updated_at"}).
+		AddRow("p3", "src1", "http://a.com/3", "held", 2, "", 0, 0, now, now)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, source_id, url, status, depth, COALESCE(error, ''), COALESCE(status_code, 0), COALESCE(fetch_ms, 0), created_at, updated_at FROM source_pages WHERE source_id = $1 AND status = 'held' ORDER BY id ASC LIMIT $2`)).
+		WithArgs("src1", 10).
+		WillReturnRows(rows)
+
+	pages, err := repo.ListHeldPages(context.Background(), "src1", 10)
+	assert.NoError(t, err)
+	if assert.Len(t, pages, 1) {
+		assert.Equal(t, "held", pages[0].Status)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestPostgresRepo_ResetStuckPages(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	assert.NoError(t, err)
@@ -31,6 +31,31 @@ func (m *MockRepository) UpdatePageStatus(ctx context.Context, sourceID, url, st
 	return args.Error(0)
 }
 
+func (m *MockRepository) RecordPageFetch(ctx context.Context, sourceID, url string, statusCode, fetchMs int) error {
+	args := m.Called(ctx, sourceID, url, statusCode, fetchMs)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetPageHash(ctx context.Context, sourceID, url string) (string, error) {
+	args := m.Called(ctx, sourceID, url)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockRepository) UpdatePageHash(ctx context.Context, sourceID, url, hash string) error {
+	args := m.Called(ctx, sourceID, url, hash)
+	return args.Error(0)
+}
+
+func (m *MockRepository) BeginPageHash(ctx context.Context, sourceID, url, hash string, chunks int) error {
+	args := m.Called(ctx, sourceID, url, hash, chunks)
+	return args.Error(0)
+}
+
+func (m *MockRepository) RecordStoredChunk(ctx context.Context, sourceID, url, hash string) error {
+	args := m.Called(ctx, sourceID, url, hash)
+	return args.Error(0)
+}
+
 func (m *MockRepository) GetPages(ctx context.Context, sourceID string) ([]SourcePage, error) {
 	args := m.Called(ctx, sourceID)
 	return args.Get(0).([]SourcePage), args.Error(1)
@@ -46,6 +71,43 @@ func (m *MockRepository) CountPendingPages(ctx context.Context, sourceID string)
 	return args.Int(0), args.Error(1)
 }
 
+func (m *MockRepository) CountFailedPages(ctx context.Context, sourceID string) (int, error) {
+	args := m.Called(ctx, sourceID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockRepository) CountPagesByStatus(ctx context.Context, sourceID string) (map[string]int, error) {
+	args := m.Called(ctx, sourceID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]int), args.Error(1)
+}
+
+func (m *MockRepository) ListPagesAfter(ctx context.Context, sourceID, afterID string, limit int) ([]SourcePage, error) {
+	args := m.Called(ctx, sourceID, afterID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]SourcePage), args.Error(1)
+}
+
+func (m *MockRepository) ListHeldPages(ctx context.Context, sourceID string, limit int) ([]SourcePage, error) {
+	args := m.Called(ctx, sourceID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]SourcePage), args.Error(1)
+}
+
+func (m *MockRepository) GroupPageErrors(ctx context.Context, sourceID string, limit, offset int) ([]PageErrorGroup, error) {
+	args := m.Called(ctx, sourceID, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]PageErrorGroup), args.Error(1)
+}
+
 func (m *MockRepository) ResetStuckPages(ctx context.Context, timeout time.Duration) (int64, error) {
 	args := m.Called(ctx, timeout)
 	return args.Get(0).(int64), args.Error(1)
@@ -69,8 +131,8 @@ func (m *MockRepository) Get(ctx context.Context, id string) (*Source, error) {
 	return args.Get(0).(*Source), args.Error(1)
 }
 
-func (m *MockRepository) List(ctx context.Context) ([]Source, error) {
-	args := m.Called(ctx)
+func (m *MockRepository) List(ctx context.Context, f ListFilter) ([]Source, error) {
+	args := m.Called(ctx, f)
 	return args.Get(0).([]Source), args.Error(1)
 }
 
@@ -84,6 +146,11 @@ func (m *MockRepository) UpdateBodyHash(ctx context.Context, id, hash string) er
 	return args.Error(0)
 }
 
+func (m *MockRepository) UpdateConfig(ctx context.Context, id string, maxDepth int, exclusions []string) error {
+	args := m.Called(ctx, id, maxDepth, exclusions)
+	return args.Error(0)
+}
+
 func (m *MockRepository) SoftDelete(ctx context.Context, id string) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
@@ -94,6 +161,11 @@ func (m *MockRepository) Count(ctx context.Context) (int, error) {
 	return args.Int(0), args.Error(1)
 }
 
+func (m *MockRepository) CountMatching(ctx context.Context, f ListFilter) (int, error) {
+	args := m.Called(ctx, f)
+	return args.Int(0), args.Error(1)
+}
+
 type MockPublisher struct {
 	mock.Mock
 }
@@ -103,6 +175,15 @@ func (m *MockPublisher) Publish(topic string, body []byte) error {
 	return args.Error(0)
 }
 
+type MockDeferredPublisher struct {
+	MockPublisher
+}
+
+func (m *MockDeferredPublisher) DeferredPublish(topic string, delay time.Duration, body []byte) error {
+	args := m.Called(topic, delay, body)
+	return args.Error(0)
+}
+
 type MockChunkStore struct {
 	mock.Mock
 }
@@ -112,6 +193,14 @@ func (m *MockChunkStore) GetChunks(ctx context.Context, sourceID string, limit,
 	return args.Get(0).([]worker.Chunk), args.Error(1)
 }
 
+func (m *MockChunkStore) GetPageChunks(ctx context.Context, sourceID, url string) ([]worker.Chunk, error) {
+	args := m.Called(ctx, sourceID, url)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]worker.Chunk), args.Error(1)
+}
+
 func (m *MockChunkStore) DeleteChunksBySourceID(ctx context.Context, sourceID string) error {
 	args := m.Called(ctx, sourceID)
 	return args.Error(0)
@@ -174,6 +263,58 @@ func TestService_Create_Success(t *testing.T) {
 	mockPub.AssertExpectations(t)
 }
 
+func TestService_Create_NormalizesSeedURL(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockPub := new(MockPublisher)
+	mockSettings := new(MockSettingsService)
+
+	svc := NewService(mockRepo, mockPub, nil, mockSettings)
+	src := &Source{URL: "https://Docs.Example.com:443/guide/"}
+
+	mockRepo.On("ExistsByHash", mock.Anything, mock.Anything).Return(false, nil)
+	mockRepo.On("Save", mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("BulkCreatePages", mock.Anything, mock.MatchedBy(func(pages []SourcePage) bool {
+		return len(pages) == 1 && pages[0].URL == "https://docs.example.com/guide"
+	})).Return([]string{"https://docs.example.com/guide"}, nil)
+	mockSettings.On("Get", mock.Anything).Return(&settings.Settings{}, nil)
+	mockPub.On("Publish", config.TopicIngestWeb, mock.Anything).Return(nil)
+
+	assert.NoError(t, svc.Create(context.Background(), src))
+	assert.Equal(t, "https://docs.example.com/guide", src.URL)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_Create_RestrictToSeedPathKeepsDirectoryAfterNormalizing(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockPub := new(MockPublisher)
+	mockSettings := new(MockSettingsService)
+
+	svc := NewService(mockRepo, mockPub, nil, mockSettings)
+	svc.SetSitemapFetcher(stubSitemap{
+		"https://example.com/docs/install",
+		"https://example.com/blog/post",
+	})
+	src := &Source{URL: "https://example.com/docs/", RestrictToSeedPath: true, UseSitemap: true}
+
+	mockRepo.On("ExistsByHash", mock.Anything, mock.Anything).Return(false, nil)
+	mockRepo.On("Save", mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("BulkCreatePages", mock.Anything, mock.MatchedBy(func(pages []SourcePage) bool {
+		return len(pages) == 1 && pages[0].URL == "https://example.com/docs"
+	})).Return([]string{"https://example.com/docs"}, nil)
+	// The trailing slash is gone from the seed, but /blog is still out of scope
+	mockRepo.On("BulkCreatePages", mock.Anything, mock.MatchedBy(func(pages []SourcePage) bool {
+		return len(pages) == 1 && pages[0].URL == "https://example.com/docs/install"
+	})).Return([]string{}, nil)
+	mockSettings.On("Get", mock.Anything).Return(&settings.Settings{}, nil)
+	mockPub.On("Publish", config.TopicIngestWeb, mock.Anything).Return(nil)
+
+	assert.NoError(t, svc.Create(context.Background(), src))
+	svc.background.Wait()
+	assert.Equal(t, "https://example.com/docs", src.URL)
+	assert.Equal(t, "/docs/", src.SeedPathPrefix)
+	mockRepo.AssertExpectations(t)
+}
+
 func TestService_Create_Duplicate(t *testing.T) {
 	mockRepo := new(MockRepository)
 	svc := NewService(mockRepo, nil, nil, nil)
@@ -253,6 +394,7 @@ func TestService_Get_Pagination(t *testing.T) {
 
 	mockRepo.On("Get", mock.Anything, id).Return(src, nil)
 	mockChunk.On("CountChunksBySource", mock.Anything, id).Return(150, nil)
+	mockRepo.On("CountPagesByStatus", mock.Anything, id).Return(map[string]int{}, nil)
 	mockChunk.On("GetChunks", mock.Anything, id, 10, 5).Return([]worker.Chunk{{Content: "c1"}}, nil)
 
 	detail, err := svc.Get(context.Background(), id, 10, 5, true)
@@ -261,6 +403,23 @@ func TestService_Get_Pagination(t *testing.T) {
 	assert.Len(t, detail.Chunks, 1)
 }
 
+func TestService_Get_Progress(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockChunk := new(MockChunkStore)
+	svc := NewService(mockRepo, nil, mockChunk, nil)
+
+	id := "src-1"
+	mockRepo.On("Get", mock.Anything, id).Return(&Source{ID: id, URL: "http://example.com"}, nil)
+	mockChunk.On("CountChunksBySource", mock.Anything, id).Return(0, nil)
+	mockRepo.On("CountPagesByStatus", mock.Anything, id).Return(map[string]int{"completed": 3, "pending": 5, "failed": 2}, nil)
+
+	detail, err := svc.Get(context.Background(), id, 10, 0, false)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, detail.CompletedPages)
+	assert.Equal(t, 10, detail.TotalPages)
+	assert.Equal(t, 30, detail.ProgressPercent)
+}
+
 func TestService_Get_ExcludeChunks(t *testing.T) {
 	mockRepo := new(MockRepository)
 	mockChunk := new(MockChunkStore)
@@ -271,6 +430,7 @@ func TestService_Get_ExcludeChunks(t *testing.T) {
 
 	mockRepo.On("Get", mock.Anything, id).Return(src, nil)
 	mockChunk.On("CountChunksBySource", mock.Anything, id).Return(150, nil)
+	mockRepo.On("CountPagesByStatus", mock.Anything, id).Return(map[string]int{}, nil)
 	// GetChunks should NOT be called
 
 	detail, err := svc.Get(context.Background(), id, 10, 5, false)
@@ -324,13 +484,137 @@ func TestService_List(t *testing.T) {
 	svc := NewService(mockRepo, nil, nil, nil)
 
 	expected := []Source{{ID: "1"}, {ID: "2"}}
-	mockRepo.On("List", mock.Anything).Return(expected, nil)
+	mockRepo.On("List", mock.Anything, ListFilter{}).Return(expected, nil)
 
 	result, err := svc.List(context.Background())
 	assert.NoError(t, err)
 	assert.Equal(t, expected, result)
 }
 
+func TestService_ListByIDs(t *testing.T) {
+	mockRepo := new(MockRepository)
+	svc := NewService(mockRepo, nil, nil, nil)
+
+	expected := []Source{{ID: "1"}}
+	mockRepo.On("List", mock.Anything, ListFilter{IDs: []string{"1", "gone"}}).Return(expected, nil)
+
+	result, err := svc.ListByIDs(context.Background(), []string{"1", "gone"})
+	assert.NoError(t, err)
+	assert.Equal(t, expected, result)
+
+	result, err = svc.ListByIDs(context.Background(), nil)
+	assert.NoError(t, err)
+	assert.Empty(t, result)
+	mockRepo.AssertNumberOfCalls(t, "List", 1)
+}
+
+type stubEmbedPauses map[string]time.Time
+
+func (p stubEmbedPauses) PausedUntil(sourceID string) (time.Time, bool) {
+	until, ok := p[sourceID]
+	return until, ok
+}
+
+func TestService_List_EmbeddingPaused(t *testing.T) {
+	mockRepo := new(MockRepository)
+	svc := NewService(mockRepo, nil, nil, nil)
+	until := time.Now().Add(time.Minute)
+	svc.SetEmbedPauses(stubEmbedPauses{"2": until})
+
+	mockRepo.On("List", mock.Anything, ListFilter{}).Return([]Source{{ID: "1"}, {ID: "2"}}, nil)
+
+	result, err := svc.List(context.Background())
+	assert.NoError(t, err)
+	assert.Nil(t, result[0].EmbeddingPausedUntil)
+	if assert.NotNil(t, result[1].EmbeddingPausedUntil) {
+		assert.Equal(t, until, *result[1].EmbeddingPausedUntil)
+	}
+}
+
+type stubChunkScanner []worker.Chunk
+
+func (s stubChunkScanner) ScanChunks(ctx context.Context, sourceID string, limit, offset int) ([]worker.Chunk, error) {
+	if offset >= len(s) {
+		return nil, nil
+	}
+	return s[offset:min(offset+limit, len(s))], nil
+}
+
+func TestService_ReembedMissing_OnlyChunksWithoutVectors(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockPub := new(MockPublisher)
+	svc := NewService(mockRepo, mockPub, nil, nil)
+	svc.SetChunkScanner(stubChunkScanner{
+		{ID: "c1", Content: "embedded", Type: "prose", Vector: []float32{0.1}},
+		{ID: "c2", Content: "missing", Type: "prose", SourceURL: "https://example.com/a", ChunkIndex: 3},
+		{ID: "c3", Content: "keyword only", Type: "cmd"},
+	})
+
+	src := &Source{ID: "src-1", Name: "Docs", KeywordOnlyTypes: []string{"cmd"}, EmbeddingModel: "custom-model"}
+	mockRepo.On("Get", mock.Anything, "src-1").Return(src, nil)
+
+	var published []worker.IngestEmbedPayload
+	mockPub.On("Publish", config.TopicIngestEmbed, mock.Anything).Run(func(args mock.Arguments) {
+		var p worker.IngestEmbedPayload
+		_ = json.Unmarshal(args.Get(1).([]byte), &p)
+		published = append(published, p)
+	}).Return(nil)
+
+	queued, err := svc.ReembedMissing(context.Background(), "src-1", false)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, queued)
+	if assert.Len(t, published, 1) {
+		assert.Equal(t, "c2", published[0].ChunkID)
+		assert.Equal(t, "missing", published[0].Content)
+		assert.Equal(t, "https://example.com/a", published[0].SourceURL)
+		assert.Equal(t, 3, published[0].ChunkIndex)
+		assert.Equal(t, "Docs", published[0].SourceName)
+		assert.Equal(t, "custom-model", published[0].EmbeddingModel)
+	}
+
+	published = nil
+	queued, err = svc.ReembedMissing(context.Background(), "src-1", true)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, queued)
+	assert.Len(t, published, 2)
+}
+
+func TestService_ReembedMissing_Disabled(t *testing.T) {
+	svc := NewService(new(MockRepository), nil, nil, nil)
+	_, err := svc.ReembedMissing(context.Background(), "src-1", false)
+	assert.ErrorIs(t, err, ErrReembedDisabled)
+}
+
+func TestService_ListPages_Cursor(t *testing.T) {
+	mockRepo := new(MockRepository)
+	svc := NewService(mockRepo, nil, nil, nil)
+
+	first := "00000000-0000-0000-0000-000000000000"
+	p1 := "0b6f2d4e-1c1a-4b8e-9f3a-2d5c7e9a1b01"
+	p2 := "5d8e1f2a-3b4c-4d5e-8f6a-7b8c9d0e1f02"
+	p3 := "9a1b2c3d-4e5f-4a6b-8c7d-8e9f0a1b2c03"
+
+	mockRepo.On("ListPagesAfter", mock.Anything, "src1", first, 3).
+		Return([]SourcePage{{ID: p1}, {ID: p2}, {ID: p3}}, nil)
+
+	pages, next, err := svc.ListPages(context.Background(), "src1", "", 2)
+	assert.NoError(t, err)
+	assert.Len(t, pages, 2)
+	assert.NotEmpty(t, next)
+
+	mockRepo.On("ListPagesAfter", mock.Anything, "src1", p2, 3).
+		Return([]SourcePage{{ID: p3}}, nil)
+
+	pages, next, err = svc.ListPages(context.Background(), "src1", next, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []SourcePage{{ID: p3}}, pages)
+	assert.Empty(t, next)
+
+	_, _, err = svc.ListPages(context.Background(), "src1", "not-a-cursor!", 2)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+	mockRepo.AssertExpectations(t)
+}
+
 func TestService_ResetStuckPages(t *testing.T) {
 	mockRepo := new(MockRepository)
 	svc := NewService(mockRepo, nil, nil, nil)
@@ -388,6 +672,7 @@ func TestService_Get_ChunkCountError(t *testing.T) {
 
 	mockRepo.On("Get", mock.Anything, "src-1").Return(&Source{ID: "src-1"}, nil)
 	mockChunk.On("CountChunksBySource", mock.Anything, "src-1").Return(0, errors.New("count error"))
+	mockRepo.On("CountPagesByStatus", mock.Anything, "src-1").Return(map[string]int{}, nil)
 	mockChunk.On("GetChunks", mock.Anything, "src-1", 10, 0).Return([]worker.Chunk{{Content: "c1"}}, nil)
 
 	// Should succeed even if count fails (logs warning only)
@@ -405,6 +690,7 @@ func TestService_Get_ChunksError(t *testing.T) {
 
 	mockRepo.On("Get", mock.Anything, "src-1").Return(&Source{ID: "src-1"}, nil)
 	mockChunk.On("CountChunksBySource", mock.Anything, "src-1").Return(10, nil)
+	mockRepo.On("CountPagesByStatus", mock.Anything, "src-1").Return(map[string]int{}, nil)
 	mockChunk.On("GetChunks", mock.Anything, "src-1", 10, 0).Return([]worker.Chunk{}, errors.New("weaviate error"))
 
 	// Should succeed even if chunks fetch fails (returns empty chunks)
@@ -444,6 +730,7 @@ func TestService_Get_DefaultLimit(t *testing.T) {
 
 	mockRepo.On("Get", mock.Anything, "src-1").Return(&Source{ID: "src-1"}, nil)
 	mockChunk.On("CountChunksBySource", mock.Anything, "src-1").Return(5, nil)
+	mockRepo.On("CountPagesByStatus", mock.Anything, "src-1").Return(map[string]int{}, nil)
 	// limit <= 0 should default to 100
 	mockChunk.On("GetChunks", mock.Anything, "src-1", 100, 0).Return([]worker.Chunk{}, nil)
 
@@ -451,3 +738,77 @@ func TestService_Get_DefaultLimit(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, detail)
 }
+
+func TestService_Resume_CapsDeferralAndLeavesRestHeld(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockPub := new(MockDeferredPublisher)
+	mockSettings := new(MockSettingsService)
+	svc := NewService(mockRepo, mockPub, nil, mockSettings)
+
+	// A 20 minute delay fits slots at 20m and 40m after the page already
+	// queued; the rest stay held for a later pass.
+	mockRepo.On("Get", mock.Anything, "1").Return(&Source{ID: "1", Type: TypeWeb, Status: "paused", CrawlDelayMs: 20 * 60 * 1000}, nil)
+	mockRepo.On("CountPagesByStatus", mock.Anything, "1").Return(map[string]int{"pending": 1, "held": 5}, nil)
+	mockSettings.On("Get", mock.Anything).Return(nil, errors.New("no settings"))
+	mockRepo.On("ListHeldPages", mock.Anything, "1", 2).Return([]SourcePage{
+		{ID: "p1", URL: "http://example.com/a", Status: "held"},
+		{ID: "p2", URL: "http://example.com/b", Status: "held"},
+	}, nil)
+	mockRepo.On("UpdatePageStatus", mock.Anything, "1", mock.Anything, "pending", "").Return(nil)
+	mockPub.On("DeferredPublish", config.TopicIngestWeb, mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("UpdateStatus", mock.Anything, "1", "in_progress").Return(nil)
+
+	queued, err := svc.Resume(context.Background(), "1")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, queued)
+	mockPub.AssertCalled(t, "DeferredPublish", config.TopicIngestWeb, 20*time.Minute, mock.Anything)
+	mockPub.AssertCalled(t, "DeferredPublish", config.TopicIngestWeb, 40*time.Minute, mock.Anything)
+	for _, c := range mockPub.Calls {
+		if c.Method == "DeferredPublish" {
+			assert.LessOrEqual(t, c.Arguments.Get(1).(time.Duration), worker.MaxDeferral)
+		}
+	}
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_Resume_PublishFailureKeepsSourcePaused(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockPub := new(MockPublisher)
+	mockSettings := new(MockSettingsService)
+	svc := NewService(mockRepo, mockPub, nil, mockSettings)
+
+	mockRepo.On("Get", mock.Anything, "1").Return(&Source{ID: "1", Type: TypeWeb, Status: "paused"}, nil)
+	mockSettings.On("Get", mock.Anything).Return(&settings.Settings{}, nil)
+	mockRepo.On("ListHeldPages", mock.Anything, "1", releaseBatchSize).Return([]SourcePage{
+		{ID: "p1", URL: "http://example.com/a", Status: "held"},
+	}, nil)
+	mockPub.On("Publish", config.TopicIngestWeb, mock.Anything).Return(errors.New("nsq down"))
+
+	_, err := svc.Resume(context.Background(), "1")
+	assert.Error(t, err)
+	mockRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "UpdatePageStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestService_ReleaseHeldPages_PacedByHostRate(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockPub := new(MockDeferredPublisher)
+	mockSettings := new(MockSettingsService)
+	svc := NewService(mockRepo, mockPub, nil, mockSettings)
+	svc.SetHostRPS(2)
+
+	mockRepo.On("List", mock.Anything, ListFilter{Status: "in_progress"}).Return([]Source{{ID: "1", Type: TypeWeb, Status: "in_progress"}}, nil)
+	mockRepo.On("CountPagesByStatus", mock.Anything, "1").Return(map[string]int{"held": 2}, nil)
+	mockSettings.On("Get", mock.Anything).Return(&settings.Settings{}, nil)
+	mockRepo.On("ListHeldPages", mock.Anything, "1", mock.Anything).Return([]SourcePage{
+		{ID: "p1", URL: "http://example.com/a", Status: "held"},
+		{ID: "p2", URL: "http://example.com/b", Status: "held"},
+	}, nil).Once()
+	mockRepo.On("UpdatePageStatus", mock.Anything, "1", mock.Anything, "pending", "").Return(nil)
+	mockPub.On("Publish", config.TopicIngestWeb, mock.Anything).Return(nil)
+	mockPub.On("DeferredPublish", config.TopicIngestWeb, 500*time.Millisecond, mock.Anything).Return(nil)
+
+	assert.NoError(t, svc.ReleaseHeldPages(context.Background()))
+	mockPub.AssertNumberOfCalls(t, "Publish", 1)
+	mockPub.AssertNumberOfCalls(t, "DeferredPublish", 1)
+}
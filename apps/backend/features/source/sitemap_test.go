@@ -0,0 +1,223 @@
+package source
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"qurio/apps/backend/internal/config"
+	"qurio/apps/backend/internal/settings"
+	"qurio/apps/backend/internal/worker"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var fixtureURLs = []string{
+	"https://example.com/docs/intro",
+	"https://example.com/docs/install",
+	"https://example.com/docs/orphan-page",
+}
+
+func gzipBytes(t *testing.T, b []byte) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err := gz.Write(b)
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func TestParseSitemap(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/sitemap.xml")
+	require.NoError(t, err)
+
+	t.Run("urlset", func(t *testing.T) {
+		pages, nested, err := parseSitemap(bytes.NewReader(fixture), maxSitemapBytes)
+		require.NoError(t, err)
+		assert.Equal(t, fixtureURLs, pages)
+		assert.Empty(t, nested)
+	})
+
+	t.Run("gzipped", func(t *testing.T) {
+		pages, _, err := parseSitemap(bytes.NewReader(gzipBytes(t, fixture)), maxSitemapBytes)
+		require.NoError(t, err)
+		assert.Equal(t, fixtureURLs, pages)
+	})
+
+	t.Run("index", func(t *testing.T) {
+		index, err := os.ReadFile("testdata/sitemap_index.xml")
+		require.NoError(t, err)
+
+		pages, nested, err := parseSitemap(bytes.NewReader(index), maxSitemapBytes)
+		require.NoError(t, err)
+		assert.Empty(t, pages)
+		assert.Equal(t, []string{"{{BASE}}/sitemap-docs.xml.gz", "{{BASE}}/sitemap-missing.xml"}, nested)
+	})
+
+	t.Run("not xml", func(t *testing.T) {
+		_, _, err := parseSitemap(strings.NewReader("<html><body>Not Found"), maxSitemapBytes)
+		assert.Error(t, err)
+	})
+}
+
+func TestHTTPSitemapFetcher_FollowsIndex(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/sitemap.xml")
+	require.NoError(t, err)
+	index, err := os.ReadFile("testdata/sitemap_index.xml")
+	require.NoError(t, err)
+
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sitemap.xml":
+			w.Write(bytes.ReplaceAll(index, []byte("{{BASE}}"), []byte(ts.URL)))
+		case "/sitemap-docs.xml.gz":
+			w.Header().Set("Content-Type", "application/x-gzip")
+			w.Write(gzipBytes(t, fixture))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	// The missing nested sitemap is skipped
+	locs, err := NewHTTPSitemapFetcher().FetchSitemap(context.Background(), ts.URL+"/docs/intro")
+	require.NoError(t, err)
+	assert.Equal(t, fixtureURLs, locs)
+}
+
+func TestHTTPSitemapFetcher_NoSitemap(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	defer ts.Close()
+
+	_, err := NewHTTPSitemapFetcher().FetchSitemap(context.Background(), ts.URL)
+	assert.Error(t, err)
+}
+
+func TestHTTPSitemapFetcher_RejectsOversizedSitemap(t *testing.T) {
+	// Small on the wire, but past the cap once decompressed
+	doc := `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">` +
+		strings.Repeat("<url><loc>https://example.com/docs/page</loc></url>\n", 1000) +
+		`</urlset>`
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(gzipBytes(t, []byte(doc)))
+	}))
+	defer ts.Close()
+
+	f := NewHTTPSitemapFetcher()
+	f.maxBytes = 4 << 10
+	locs, err := f.FetchSitemap(context.Background(), ts.URL)
+	assert.ErrorContains(t, err, "sitemap larger than 4096 bytes")
+	assert.Empty(t, locs)
+}
+
+func TestHTTPSitemapFetcher_CapsNestedSitemaps(t *testing.T) {
+	var fetched atomic.Int32
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetched.Add(1)
+		if r.URL.Path != "/sitemap.xml" {
+			fmt.Fprintf(w, `<urlset><url><loc>%s/page%s</loc></url></urlset>`, ts.URL, r.URL.Path)
+			return
+		}
+		var b strings.Builder
+		b.WriteString("<sitemapindex>")
+		for i := 0; i < maxNestedSitemaps+20; i++ {
+			fmt.Fprintf(&b, "<sitemap><loc>%s/s%d.xml</loc></sitemap>", ts.URL, i)
+		}
+		b.WriteString("</sitemapindex>")
+		w.Write([]byte(b.String()))
+	}))
+	defer ts.Close()
+
+	locs, err := NewHTTPSitemapFetcher().FetchSitemap(context.Background(), ts.URL)
+	require.NoError(t, err)
+	assert.Len(t, locs, maxNestedSitemaps)
+	assert.EqualValues(t, maxNestedSitemaps+1, fetched.Load())
+}
+
+type stubSitemap []string
+
+func (s stubSitemap) FetchSitemap(ctx context.Context, siteURL string) ([]string, error) {
+	return s, nil
+}
+
+func TestService_Create_UseSitemap(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockPub := new(MockPublisher)
+	mockSettings := new(MockSettingsService)
+
+	svc := NewService(mockRepo, mockPub, nil, mockSettings)
+	svc.SetSitemapFetcher(stubSitemap{
+		"https://example.com/docs/intro",
+		"https://example.com/docs/install",
+		"https://example.com/docs/changelog",
+		"https://other.example.org/docs/elsewhere",
+		"https://example.com/blog/post",
+	})
+
+	src := &Source{
+		ID:                 "src-1",
+		URL:                "https://example.com/docs/intro",
+		Exclusions:         []string{"changelog"},
+		RestrictToSeedPath: true,
+		UseSitemap:         true,
+	}
+
+	mockRepo.On("ExistsByHash", mock.Anything, mock.Anything).Return(false, nil)
+	mockRepo.On("Save", mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("BulkCreatePages", mock.Anything, []SourcePage{{SourceID: "src-1", URL: "https://example.com/docs/intro", Status: "pending"}}).
+		Return([]string{"https://example.com/docs/intro"}, nil)
+	// Sitemap pages are held and then released
+	mockRepo.On("BulkCreatePages", mock.Anything, []SourcePage{{SourceID: "src-1", URL: "https://example.com/docs/install", Status: worker.PageHeld}}).
+		Return([]string{"https://example.com/docs/install"}, nil)
+	mockRepo.On("Get", mock.Anything, "src-1").Return(&Source{ID: "src-1", Type: TypeWeb, Status: "in_progress"}, nil)
+	mockRepo.On("ListHeldPages", mock.Anything, "src-1", releaseBatchSize).Return([]SourcePage{
+		{ID: "p2", URL: "https://example.com/docs/install", Status: worker.PageHeld},
+	}, nil)
+	mockRepo.On("UpdatePageStatus", mock.Anything, "src-1", "https://example.com/docs/install", "pending", "").Return(nil)
+	mockSettings.On("Get", mock.Anything).Return(&settings.Settings{}, nil)
+	mockPub.On("Publish", config.TopicIngestWeb, mock.Anything).Return(nil)
+
+	require.NoError(t, svc.Create(context.Background(), src))
+	svc.background.Wait()
+
+	mockRepo.AssertExpectations(t)
+	mockPub.AssertNumberOfCalls(t, "Publish", 2)
+	body := mockPub.Calls[1].Arguments.Get(1).([]byte)
+	assert.Contains(t, string(body), `"url":"https://example.com/docs/install"`)
+	assert.Contains(t, string(body), `"depth":0`)
+}
+
+func TestService_Create_SitemapReopensFinishedCrawl(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockPub := new(MockPublisher)
+	mockSettings := new(MockSettingsService)
+
+	svc := NewService(mockRepo, mockPub, nil, mockSettings)
+	svc.SetSitemapFetcher(stubSitemap{"https://example.com/docs/install"})
+
+	mockRepo.On("ExistsByHash", mock.Anything, mock.Anything).Return(false, nil)
+	mockRepo.On("Save", mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("BulkCreatePages", mock.Anything, mock.Anything).Return([]string{"https://example.com/docs/install"}, nil)
+	// The seed page finished before the sitemap was read
+	mockRepo.On("Get", mock.Anything, "src-1").Return(&Source{ID: "src-1", Type: TypeWeb, Status: "completed"}, nil)
+	mockRepo.On("UpdateStatus", mock.Anything, "src-1", "in_progress").Return(nil)
+	mockRepo.On("ListHeldPages", mock.Anything, "src-1", releaseBatchSize).Return([]SourcePage{}, nil)
+	mockSettings.On("Get", mock.Anything).Return(&settings.Settings{}, nil)
+	mockPub.On("Publish", config.TopicIngestWeb, mock.Anything).Return(nil)
+
+	require.NoError(t, svc.Create(context.Background(), &Source{ID: "src-1", URL: "https://example.com/docs", UseSitemap: true}))
+	svc.background.Wait()
+
+	mockRepo.AssertExpectations(t)
+}
@@ -3,18 +3,44 @@ package source
 import (
 	"context"
 	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"net/url"
 	"regexp"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
 	"qurio/apps/backend/internal/config"
 	"qurio/apps/backend/internal/middleware"
 	"qurio/apps/backend/internal/settings"
 	"qurio/apps/backend/internal/worker"
+
+	"github.com/google/uuid"
+)
+
+// Source types understood by the ingestion workers.
+const (
+	TypeWeb  = "web"
+	TypeFile = "file"
+
+	// DefaultType is used when a source is created without a type.
+	DefaultType = TypeWeb
 )
 
+// IsValidType reports whether t is a source type a worker can process.
+func IsValidType(t string) bool {
+	return t == TypeWeb || t == TypeFile
+}
+
+// MaxCrawlDelayMs is the longest crawl delay a source may ask for.
+const MaxCrawlDelayMs = 60000
+
 type Source struct {
 	ID          string   `json:"id"`
 	Type        string   `json:"type"`
@@ -26,6 +52,49 @@ type Source struct {
 	Exclusions  []string `json:"exclusions"`
 	Name        string   `json:"name"`
 	UpdatedAt   string   `json:"updated_at"`
+
+	// Metadata holds user-defined key/value pairs (team, product, version)
+	// that are copied onto every chunk of the source for filtering.
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// EmbedTitlePrefix prepends the page title to chunk text at embed time.
+	EmbedTitlePrefix bool `json:"embed_title_prefix"`
+
+	// CrawlDelayMs spaces out page task enqueues for this source.
+	CrawlDelayMs int `json:"crawl_delay_ms"`
+
+	// KeywordOnlyTypes lists chunk types (e.g. "cmd", "config") stored
+	// without vectors, searchable through BM25 only.
+	KeywordOnlyTypes []string `json:"keyword_only_types,omitempty"`
+
+	// RestrictToSeedPath limits link discovery to URLs under the seed URL's
+	// directory (e.g. /docs/product/ for /docs/product/intro).
+	RestrictToSeedPath bool `json:"restrict_to_seed_path"`
+
+	// SeedPathPrefix is the directory RestrictToSeedPath keeps discovery
+	// under, taken from the seed URL as entered. The stored URL is
+	// normalized without a trailing slash, so "/docs/" could no longer be
+	// told apart from a "/docs" page.
+	SeedPathPrefix string `json:"seed_path_prefix,omitempty"`
+
+	// CrawlSubdomains lets link discovery follow links to other subdomains
+	// of the seed's registered domain, e.g. api.example.com from
+	// docs.example.com.
+	CrawlSubdomains bool `json:"crawl_subdomains"`
+
+	// EmbeddingModel overrides the default embedding model for this source's
+	// chunks and for queries scoped to it. Empty uses the default. It must be
+	// one of the models set with SetEmbeddingModels.
+	EmbeddingModel string `json:"embedding_model,omitempty"`
+
+	// UseSitemap seeds a new web source with every same-host page listed in
+	// the site's /sitemap.xml, in addition to its URL. The sitemap is read in
+	// the background after creation, and the flag is not stored.
+	UseSitemap bool `json:"use_sitemap,omitempty"`
+
+	// EmbeddingPausedUntil is set while the source's embedding is paused
+	// after repeated provider rate limits. It is runtime state, not stored.
+	EmbeddingPausedUntil *time.Time `json:"embedding_paused_until,omitempty"`
 }
 
 type SourcePage struct {
@@ -37,35 +106,104 @@ type SourcePage struct {
 	Error     string `json:"error,omitempty"`
 	CreatedAt string `json:"created_at"`
 	UpdatedAt string `json:"updated_at"`
+
+	// StatusCode and FetchMs describe the last fetch; zero when unknown.
+	StatusCode int `json:"status_code,omitempty"`
+	FetchMs    int `json:"fetch_ms,omitempty"`
+}
+
+// PageErrorGroup is one distinct page error message across sources, with how
+// many failed pages report it and a few of their URLs.
+type PageErrorGroup struct {
+	Error      string   `json:"error"`
+	Count      int      `json:"count"`
+	SampleURLs []string `json:"sample_urls"`
+}
+
+// ListFilter narrows and orders a source listing. The zero value lists
+// every source by name.
+type ListFilter struct {
+	Status string
+	Type   string
+	// Query matches a substring of the URL or name, ignoring case.
+	Query string
+	// IDs keeps only the sources with these IDs.
+	IDs []string
+	// Sort is "name" (the default) or "created_at".
+	Sort string
+	Desc bool
+	// Limit caps the number of sources returned, starting at Offset. Zero
+	// returns them all.
+	Limit  int
+	Offset int
+}
+
+// filtered reports whether f narrows the listing, as opposed to only
+// ordering or paging it.
+func (f ListFilter) filtered() bool {
+	return f.Status != "" || f.Type != "" || f.Query != "" || len(f.IDs) > 0
 }
 
 type Repository interface {
 	// Pages
 	BulkCreatePages(ctx context.Context, pages []SourcePage) ([]string, error)
 	UpdatePageStatus(ctx context.Context, sourceID, url, status, err string) error
+	RecordPageFetch(ctx context.Context, sourceID, url string, statusCode, fetchMs int) error
+	GetPageHash(ctx context.Context, sourceID, url string) (string, error)
+	UpdatePageHash(ctx context.Context, sourceID, url, hash string) error
+	BeginPageHash(ctx context.Context, sourceID, url, hash string, chunks int) error
+	RecordStoredChunk(ctx context.Context, sourceID, url, hash string) error
 	GetPages(ctx context.Context, sourceID string) ([]SourcePage, error)
+	ListPagesAfter(ctx context.Context, sourceID, afterID string, limit int) ([]SourcePage, error)
+	ListHeldPages(ctx context.Context, sourceID string, limit int) ([]SourcePage, error)
 	DeletePages(ctx context.Context, sourceID string) error
 	CountPendingPages(ctx context.Context, sourceID string) (int, error)
+	CountFailedPages(ctx context.Context, sourceID string) (int, error)
+	CountPagesByStatus(ctx context.Context, sourceID string) (map[string]int, error)
 	ResetStuckPages(ctx context.Context, timeout time.Duration) (int64, error)
+	GroupPageErrors(ctx context.Context, sourceID string, limit, offset int) ([]PageErrorGroup, error)
 
 	// Sources
 
 	Save(ctx context.Context, src *Source) error
 	ExistsByHash(ctx context.Context, hash string) (bool, error)
 	Get(ctx context.Context, id string) (*Source, error)
-	List(ctx context.Context) ([]Source, error)
+	List(ctx context.Context, f ListFilter) ([]Source, error)
 	UpdateStatus(ctx context.Context, id, status string) error
 	UpdateBodyHash(ctx context.Context, id, hash string) error
+	UpdateConfig(ctx context.Context, id string, maxDepth int, exclusions []string) error
 	SoftDelete(ctx context.Context, id string) error
 	Count(ctx context.Context) (int, error)
+	CountMatching(ctx context.Context, f ListFilter) (int, error)
 }
 
 type ChunkStore interface {
 	GetChunks(ctx context.Context, sourceID string, limit, offset int) ([]worker.Chunk, error)
+	GetPageChunks(ctx context.Context, sourceID, url string) ([]worker.Chunk, error)
 	DeleteChunksBySourceID(ctx context.Context, sourceID string) error
 	CountChunksBySource(ctx context.Context, sourceID string) (int, error)
 }
 
+// ChunkWriter stores chunks that bypass the ingestion pipeline.
+type ChunkWriter interface {
+	StoreChunk(ctx context.Context, chunk worker.Chunk) error
+}
+
+type Embedder interface {
+	Embed(ctx context.Context, text string) ([]float32, error)
+}
+
+// ChunkScanner pages through a source's stored chunks including their object
+// IDs and vectors.
+type ChunkScanner interface {
+	ScanChunks(ctx context.Context, sourceID string, limit, offset int) ([]worker.Chunk, error)
+}
+
+// EmbedPauses reports sources whose embedding is paused by rate-limit backoff.
+type EmbedPauses interface {
+	PausedUntil(sourceID string) (time.Time, bool)
+}
+
 type EventPublisher interface {
 	Publish(topic string, body []byte) error
 }
@@ -79,6 +217,19 @@ type Service struct {
 	pub        EventPublisher
 	chunkStore ChunkStore
 	settings   SettingsService
+
+	chunkWriter ChunkWriter
+	embedder    Embedder
+	pauses      EmbedPauses
+	scanner     ChunkScanner
+	sitemaps    SitemapFetcher
+	// hostInterval is the minimum spacing of released held pages.
+	hostInterval time.Duration
+	// models are the embedding models a source may override the default with.
+	models []string
+
+	// background tracks sitemap expansions started by Create.
+	background sync.WaitGroup
 }
 
 func NewService(repo Repository, pub EventPublisher, chunkStore ChunkStore, settings SettingsService) *Service {
@@ -93,15 +244,22 @@ func (s *Service) Create(ctx context.Context, src *Source) error {
 		}
 	}
 
+	if src.Type == "" {
+		src.Type = DefaultType
+	}
+	// Store the seed the way discovered links are stored, so a link back to
+	// it is recognized as the same page
+	if src.Type == TypeWeb {
+		if src.RestrictToSeedPath && src.SeedPathPrefix == "" {
+			src.SeedPathPrefix = worker.SeedPathPrefix(src.URL)
+		}
+		src.URL = worker.NormalizeURL(src.URL)
+	}
+
 	// 0. Compute Hash
 	hash := sha256.Sum256([]byte(src.URL))
 	src.ContentHash = fmt.Sprintf("%x", hash)
 
-	// Default to web if empty
-	if src.Type == "" {
-		src.Type = "web"
-	}
-
 	// 1. Check Duplicate
 	exists, err := s.repo.ExistsByHash(ctx, src.ContentHash)
 	if err != nil {
@@ -118,14 +276,14 @@ func (s *Service) Create(ctx context.Context, src *Source) error {
 	}
 
 	// 2.1 Create Seed Page (Crawl Frontier)
-	if src.Type == "web" {
-		_, err = s.repo.BulkCreatePages(ctx, []SourcePage{{
+	if src.Type == TypeWeb {
+		pages := []SourcePage{{
 			SourceID: src.ID,
 			URL:      src.URL,
 			Status:   "pending",
 			Depth:    0,
-		}})
-		if err != nil {
+		}}
+		if _, err := s.repo.BulkCreatePages(ctx, pages); err != nil {
 			// Log error but proceed? No, fail.
 			return fmt.Errorf("failed to create seed page: %w", err)
 		}
@@ -151,7 +309,7 @@ func (s *Service) Create(ctx context.Context, src *Source) error {
 	})
 
 	topic := config.TopicIngestWeb
-	if src.Type == "file" {
+	if src.Type == TypeFile {
 		topic = config.TopicIngestFile
 	}
 
@@ -161,9 +319,118 @@ func (s *Service) Create(ctx context.Context, src *Source) error {
 		slog.Info("published ingest task", "url", src.URL, "id", src.ID, "topic", topic)
 	}
 
+	// 5. Expand the sitemap in the background; its pages join the crawl as
+	// they are found
+	if src.Type == TypeWeb && src.UseSitemap {
+		seed := *src
+		s.background.Add(1)
+		go func() {
+			defer s.background.Done()
+			bg, cancel := context.WithTimeout(context.WithoutCancel(ctx), sitemapTimeout)
+			defer cancel()
+			s.seedFromSitemap(bg, &seed)
+		}()
+	}
+
 	return nil
 }
 
+// SetEmbeddingModels lists the embedding models sources may be created with
+// in place of the default.
+func (s *Service) SetEmbeddingModels(models []string) {
+	s.models = models
+}
+
+// IsKnownEmbeddingModel reports whether a source may use model. The empty
+// model, meaning the default, always may.
+func (s *Service) IsKnownEmbeddingModel(model string) bool {
+	return model == "" || slices.Contains(s.models, model)
+}
+
+// SetSitemapFetcher enables sitemap seeding for sources created with
+// UseSitemap.
+func (s *Service) SetSitemapFetcher(f SitemapFetcher) {
+	s.sitemaps = f
+}
+
+// seedFromSitemap records src's sitemap pages as held seed pages and releases
+// the first of them. The rest are paced out by ReleaseHeldPages. A crawl that
+// already finished with its seed page is reopened for them.
+func (s *Service) seedFromSitemap(ctx context.Context, src *Source) {
+	var pages []SourcePage
+	for _, u := range s.sitemapSeeds(ctx, src) {
+		pages = append(pages, SourcePage{SourceID: src.ID, URL: u, Status: worker.PageHeld, Depth: 0})
+	}
+	if len(pages) == 0 {
+		return
+	}
+	created, err := s.repo.BulkCreatePages(ctx, pages)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to create sitemap pages", "id", src.ID, "error", err)
+		return
+	}
+	if len(created) == 0 {
+		return
+	}
+	slog.InfoContext(ctx, "seeded pages from sitemap", "id", src.ID, "count", len(created))
+
+	cur, err := s.repo.Get(ctx, src.ID)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to load source after sitemap seeding", "id", src.ID, "error", err)
+		return
+	}
+	switch cur.Status {
+	case "paused":
+		return
+	case "completed", "completed_with_errors":
+		if err := s.repo.UpdateStatus(ctx, src.ID, "in_progress"); err != nil {
+			slog.ErrorContext(ctx, "failed to reopen source for sitemap pages", "id", src.ID, "error", err)
+			return
+		}
+	}
+	if _, err := s.releaseHeld(ctx, cur); err != nil {
+		slog.ErrorContext(ctx, "failed to release sitemap pages", "id", src.ID, "error", err)
+	}
+}
+
+// sitemapSeeds returns the sitemap URLs of src's site that its crawl rules
+// allow: same host (or domain, with CrawlSubdomains), not excluded and, with
+// RestrictToSeedPath, under the seed directory. A sitemap that cannot be read
+// yields none.
+func (s *Service) sitemapSeeds(ctx context.Context, src *Source) []string {
+	if s.sitemaps == nil {
+		slog.WarnContext(ctx, "sitemap seeding requested but not enabled", "id", src.ID)
+		return nil
+	}
+	u, err := url.Parse(src.URL)
+	if err != nil {
+		return nil
+	}
+	locs, err := s.sitemaps.FetchSitemap(ctx, src.URL)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to fetch sitemap, seeding only the source url", "id", src.ID, "error", err)
+		return nil
+	}
+
+	prefix := ""
+	if src.RestrictToSeedPath {
+		prefix = src.SeedPathPrefix
+	}
+	var seeds []string
+	for _, d := range worker.ExplainLinks(u.Host, locs, 0, 1, src.Exclusions, src.CrawlSubdomains) {
+		if d.Reason != worker.LinkOK || d.URL == src.URL {
+			continue
+		}
+		if prefix != "" {
+			if p, err := url.Parse(d.URL); err != nil || !strings.HasPrefix(p.Path, prefix) {
+				continue
+			}
+		}
+		seeds = append(seeds, d.URL)
+	}
+	return seeds
+}
+
 func (s *Service) Upload(ctx context.Context, path string, hash string, name string) (*Source, error) {
 	// Check Duplicate
 	exists, err := s.repo.ExistsByHash(ctx, hash)
@@ -175,7 +442,7 @@ func (s *Service) Upload(ctx context.Context, path string, hash string, name str
 	}
 
 	src := &Source{
-		Type:        "file",
+		Type:        TypeFile,
 		URL:         path, // Use URL field to store file path
 		ContentHash: hash,
 		Status:      "in_progress",
@@ -188,7 +455,7 @@ func (s *Service) Upload(ctx context.Context, path string, hash string, name str
 
 	// Publish to NSQ
 	payload, _ := json.Marshal(map[string]interface{}{
-		"type":           "file",
+		"type":           TypeFile,
 		"path":           path,
 		"id":             src.ID,
 		"correlation_id": middleware.GetCorrelationID(ctx),
@@ -206,6 +473,15 @@ type SourceDetail struct {
 	Source
 	Chunks      []worker.Chunk `json:"chunks"`
 	TotalChunks int            `json:"total_chunks"`
+
+	// PageCounts is the number of crawled pages per page status.
+	PageCounts map[string]int `json:"page_counts"`
+
+	// Crawl progress derived from PageCounts. ProgressPercent is 0 until
+	// any page is known.
+	CompletedPages  int `json:"completed_pages"`
+	TotalPages      int `json:"total_pages"`
+	ProgressPercent int `json:"progress_percent"`
 }
 
 func (s *Service) Get(ctx context.Context, id string, limit, offset int, includeChunks bool) (*SourceDetail, error) {
@@ -213,6 +489,7 @@ func (s *Service) Get(ctx context.Context, id string, limit, offset int, include
 	if err != nil {
 		return nil, err
 	}
+	s.markPaused(src)
 
 	if limit <= 0 {
 		limit = 100
@@ -223,6 +500,12 @@ func (s *Service) Get(ctx context.Context, id string, limit, offset int, include
 		slog.Warn("failed to count chunks", "error", err, "source_id", id) // #nosec G706 -- id is from URL path param, not exploitable
 	}
 
+	pageCounts, err := s.repo.CountPagesByStatus(ctx, id)
+	if err != nil {
+		slog.Warn("failed to count pages", "error", err, "source_id", id) // #nosec G706
+		pageCounts = map[string]int{}
+	}
+
 	var chunks []worker.Chunk
 	if includeChunks {
 		chunks, err = s.chunkStore.GetChunks(ctx, id, limit, offset)
@@ -234,15 +517,67 @@ func (s *Service) Get(ctx context.Context, id string, limit, offset int, include
 		chunks = []worker.Chunk{}
 	}
 
+	totalPages := 0
+	for _, n := range pageCounts {
+		totalPages += n
+	}
+	progress := 0
+	if totalPages > 0 {
+		progress = pageCounts["completed"] * 100 / totalPages
+	}
+
 	return &SourceDetail{
-		Source:      *src,
-		Chunks:      chunks,
-		TotalChunks: totalChunks,
+		Source:          *src,
+		Chunks:          chunks,
+		TotalChunks:     totalChunks,
+		PageCounts:      pageCounts,
+		CompletedPages:  pageCounts["completed"],
+		TotalPages:      totalPages,
+		ProgressPercent: progress,
 	}, nil
 }
 
 func (s *Service) List(ctx context.Context) ([]Source, error) {
-	return s.repo.List(ctx)
+	return s.list(ctx, ListFilter{})
+}
+
+// ListByIDs returns the sources with the given IDs, skipping any that do not
+// exist or are deleted.
+func (s *Service) ListByIDs(ctx context.Context, ids []string) ([]Source, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	return s.list(ctx, ListFilter{IDs: ids})
+}
+
+// ListPage returns the window of sources f selects, in f's order, and how
+// many sources match f in total.
+func (s *Service) ListPage(ctx context.Context, f ListFilter) ([]Source, int, error) {
+	sources, err := s.list(ctx, f)
+	if err != nil {
+		return nil, 0, err
+	}
+	var total int
+	if f.filtered() {
+		total, err = s.repo.CountMatching(ctx, f)
+	} else {
+		total, err = s.repo.Count(ctx)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	return sources, total, nil
+}
+
+func (s *Service) list(ctx context.Context, f ListFilter) ([]Source, error) {
+	sources, err := s.repo.List(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	for i := range sources {
+		s.markPaused(&sources[i])
+	}
+	return sources, nil
 }
 
 func (s *Service) Delete(ctx context.Context, id string) error {
@@ -266,7 +601,7 @@ func (s *Service) ReSync(ctx context.Context, id string) error {
 	}
 
 	// Clean up pages for fresh start
-	if src.Type == "web" {
+	if src.Type == TypeWeb {
 		if err := s.repo.DeletePages(ctx, id); err != nil {
 			return fmt.Errorf("failed to clean up pages: %w", err)
 		}
@@ -295,7 +630,7 @@ func (s *Service) ReSync(ctx context.Context, id string) error {
 		"correlation_id": middleware.GetCorrelationID(ctx),
 	}
 
-	if src.Type == "file" {
+	if src.Type == TypeFile {
 		payloadMap["path"] = src.URL
 	} else {
 		payloadMap["url"] = src.URL
@@ -308,7 +643,7 @@ func (s *Service) ReSync(ctx context.Context, id string) error {
 	payload, _ := json.Marshal(payloadMap)
 
 	topic := config.TopicIngestWeb
-	if src.Type == "file" {
+	if src.Type == TypeFile {
 		topic = config.TopicIngestFile
 	}
 
@@ -319,10 +654,206 @@ func (s *Service) ReSync(ctx context.Context, id string) error {
 	return nil
 }
 
+// UpdateConfig changes the crawl depth and exclusions of a source. They apply
+// to pages discovered from then on; a re-sync recrawls with them.
+func (s *Service) UpdateConfig(ctx context.Context, id string, maxDepth int, exclusions []string) error {
+	return s.repo.UpdateConfig(ctx, id, maxDepth, exclusions)
+}
+
+// ErrInvalidState is returned when a source's status doesn't allow the
+// requested transition, such as pausing a completed crawl.
+var ErrInvalidState = errors.New("source status does not allow this action")
+
+// releaseBatchSize is how many held pages are read per query.
+const releaseBatchSize = 500
+
+// Pause stops an in-progress crawl from enqueueing further pages. Pages found
+// while paused are recorded as held, and tasks already queued still complete.
+func (s *Service) Pause(ctx context.Context, id string) error {
+	src, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if src.Status != "in_progress" {
+		return ErrInvalidState
+	}
+	return s.repo.UpdateStatus(ctx, id, "paused")
+}
+
+// Resume continues a paused crawl by publishing tasks for its held pages and
+// returns the number published. The source is marked in progress only once
+// they are out, so a failed Resume can be retried. Pages that don't fit in
+// one deferral window are left for ReleaseHeldPages.
+func (s *Service) Resume(ctx context.Context, id string) (int, error) {
+	src, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	if src.Status != "paused" {
+		return 0, ErrInvalidState
+	}
+
+	queued, err := s.releaseHeld(ctx, src)
+	if err != nil {
+		return queued, err
+	}
+	if err := s.repo.UpdateStatus(ctx, id, "in_progress"); err != nil {
+		return queued, err
+	}
+
+	slog.InfoContext(ctx, "resumed crawl", "id", id, "queued", queued)
+	return queued, nil
+}
+
+// ReleaseHeldPages publishes held pages of in-progress sources, as many per
+// source as its crawl pacing fits into one deferral window.
+func (s *Service) ReleaseHeldPages(ctx context.Context) error {
+	sources, err := s.repo.List(ctx, ListFilter{Status: "in_progress"})
+	if err != nil {
+		return err
+	}
+	for i := range sources {
+		queued, err := s.releaseHeld(ctx, &sources[i])
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to release held pages", "source_id", sources[i].ID, "error", err)
+			continue
+		}
+		if queued > 0 {
+			slog.InfoContext(ctx, "released held pages", "source_id", sources[i].ID, "queued", queued)
+		}
+	}
+	return nil
+}
+
+// SetHostRPS spaces out released held pages to at most rps tasks a second,
+// the worker's per-host crawl rate.
+func (s *Service) SetHostRPS(rps float64) {
+	s.hostInterval = time.Duration(float64(time.Second) / rps)
+}
+
+// releaseHeld publishes tasks for src's held pages and marks them pending.
+// With a crawl delay or host rate, tasks are spaced after the pages already
+// queued and release stops at the first one that would be deferred past
+// worker.MaxDeferral.
+func (s *Service) releaseHeld(ctx context.Context, src *Source) (int, error) {
+	interval := max(time.Duration(src.CrawlDelayMs)*time.Millisecond, s.hostInterval)
+	dp, canDefer := s.pub.(worker.DeferredPublisher)
+	if !canDefer {
+		interval = 0
+	}
+
+	limit := -1
+	slot := 0
+	if interval > 0 {
+		counts, err := s.repo.CountPagesByStatus(ctx, src.ID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to count pages: %w", err)
+		}
+		slot = counts["pending"] + counts["processing"]
+		limit = int(worker.MaxDeferral/interval) + 1 - slot
+		if limit <= 0 {
+			return 0, nil
+		}
+	}
+
+	apiKey := ""
+	if set, err := s.settings.Get(ctx); err == nil && set != nil {
+		apiKey = set.GeminiAPIKey
+	}
+
+	queued := 0
+	for limit < 0 || queued < limit {
+		batch := releaseBatchSize
+		if limit > 0 {
+			batch = min(batch, limit-queued)
+		}
+		pages, err := s.repo.ListHeldPages(ctx, src.ID, batch)
+		if err != nil {
+			return queued, fmt.Errorf("failed to list held pages: %w", err)
+		}
+		for _, p := range pages {
+			payload, _ := json.Marshal(map[string]interface{}{
+				"type":           TypeWeb,
+				"url":            p.URL,
+				"id":             src.ID,
+				"depth":          p.Depth,
+				"max_depth":      src.MaxDepth,
+				"exclusions":     src.Exclusions,
+				"gemini_api_key": apiKey,
+				"correlation_id": middleware.GetCorrelationID(ctx),
+			})
+			var err error
+			if delay := time.Duration(slot+queued) * interval; delay > 0 {
+				err = dp.DeferredPublish(config.TopicIngestWeb, delay, payload)
+			} else {
+				err = s.pub.Publish(config.TopicIngestWeb, payload)
+			}
+			if err != nil {
+				return queued, fmt.Errorf("failed to publish page task: %w", err)
+			}
+			if err := s.repo.UpdatePageStatus(ctx, src.ID, p.URL, "pending", ""); err != nil {
+				return queued, fmt.Errorf("failed to mark page pending: %w", err)
+			}
+			queued++
+		}
+		if len(pages) < batch {
+			break
+		}
+	}
+	return queued, nil
+}
+
 func (s *Service) GetPages(ctx context.Context, id string) ([]SourcePage, error) {
 	return s.repo.GetPages(ctx, id)
 }
 
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ListPages returns up to limit pages of a source in ID order, starting after
+// cursor. The returned cursor fetches the next batch and is empty once the
+// last page has been returned.
+func (s *Service) ListPages(ctx context.Context, id, cursor string, limit int) ([]SourcePage, string, error) {
+	after := uuid.Nil.String()
+	if cursor != "" {
+		raw, err := base64.RawURLEncoding.DecodeString(cursor)
+		if err != nil {
+			return nil, "", ErrInvalidCursor
+		}
+		pageID, err := uuid.Parse(string(raw))
+		if err != nil {
+			return nil, "", ErrInvalidCursor
+		}
+		after = pageID.String()
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+
+	// Fetch one extra row to learn whether another batch follows
+	pages, err := s.repo.ListPagesAfter(ctx, id, after, limit+1)
+	if err != nil {
+		return nil, "", err
+	}
+	next := ""
+	if len(pages) > limit {
+		pages = pages[:limit]
+		next = base64.RawURLEncoding.EncodeToString([]byte(pages[limit-1].ID))
+	}
+	return pages, next, nil
+}
+
+// GroupPageErrors groups failed pages by error message, most frequent first.
+// An empty sourceID covers all sources.
+func (s *Service) GroupPageErrors(ctx context.Context, sourceID string, limit, offset int) ([]PageErrorGroup, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return s.repo.GroupPageErrors(ctx, sourceID, limit, offset)
+}
+
 func (s *Service) ResetStuckPages(ctx context.Context) error {
 	count, err := s.repo.ResetStuckPages(ctx, 5*time.Minute)
 	if err != nil {
@@ -334,3 +865,272 @@ func (s *Service) ResetStuckPages(ctx context.Context) error {
 	}
 	return nil
 }
+
+// ImportLine is one NDJSON record accepted by Import.
+type ImportLine struct {
+	Content    string    `json:"content"`
+	Type       string    `json:"type"`
+	Language   string    `json:"language"`
+	URL        string    `json:"url"`
+	ChunkIndex int       `json:"chunk_index"`
+	Vector     []float32 `json:"vector,omitempty"`
+}
+
+// ImportResult summarizes an Import call.
+type ImportResult struct {
+	Stored   int `json:"stored"`
+	Embedded int `json:"embedded"`
+}
+
+// ImportLineError reports a malformed or invalid NDJSON record. Line counts
+// records, not physical lines; blank lines are skipped.
+type ImportLineError struct {
+	Line int
+	Err  error
+}
+
+func (e *ImportLineError) Error() string {
+	return fmt.Sprintf("record %d: %v", e.Line, e.Err)
+}
+
+func (e *ImportLineError) Unwrap() error {
+	return e.Err
+}
+
+var ErrImportDisabled = errors.New("chunk import is not configured")
+
+// SetEmbedPauses surfaces embedding backoff state on listed and fetched
+// sources.
+func (s *Service) SetEmbedPauses(p EmbedPauses) {
+	s.pauses = p
+}
+
+func (s *Service) markPaused(src *Source) {
+	if s.pauses == nil {
+		return
+	}
+	if until, ok := s.pauses.PausedUntil(src.ID); ok {
+		src.EmbeddingPausedUntil = &until
+	}
+}
+
+// SetChunkImport enables Import, storing chunks through w and embedding
+// records that arrive without a vector through e.
+func (s *Service) SetChunkImport(w ChunkWriter, e Embedder) {
+	s.chunkWriter = w
+	s.embedder = e
+}
+
+// Import stores pre-chunked NDJSON records for an existing source. Records are
+// decoded and stored one at a time so large files are never held in memory.
+// Each record's URL is recorded as a completed page, so Export finds it. It
+// stops at the first invalid record; records before it stay stored.
+func (s *Service) Import(ctx context.Context, id string, r io.Reader) (ImportResult, error) {
+	var res ImportResult
+	if s.chunkWriter == nil || s.embedder == nil {
+		return res, ErrImportDisabled
+	}
+
+	src, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return res, err
+	}
+
+	dec := json.NewDecoder(r)
+	recorded := make(map[string]bool)
+	for line := 1; ; line++ {
+		var rec ImportLine
+		if err := dec.Decode(&rec); err == io.EOF {
+			break
+		} else if err != nil {
+			return res, &ImportLineError{Line: line, Err: err}
+		}
+		if strings.TrimSpace(rec.Content) == "" {
+			return res, &ImportLineError{Line: line, Err: errors.New("content is required")}
+		}
+		if rec.URL == "" {
+			return res, &ImportLineError{Line: line, Err: errors.New("url is required")}
+		}
+
+		vector := rec.Vector
+		if len(vector) == 0 {
+			vector, err = s.embedder.Embed(ctx, rec.Content)
+			if err != nil {
+				return res, fmt.Errorf("record %d: embed: %w", line, err)
+			}
+			res.Embedded++
+		}
+
+		chunk := worker.Chunk{
+			Content:    rec.Content,
+			Vector:     vector,
+			SourceURL:  rec.URL,
+			SourceID:   src.ID,
+			SourceName: src.Name,
+			ChunkIndex: rec.ChunkIndex,
+			Type:       rec.Type,
+			Language:   rec.Language,
+			Metadata:   src.Metadata,
+		}
+		if err := s.chunkWriter.StoreChunk(ctx, chunk); err != nil {
+			return res, fmt.Errorf("record %d: store: %w", line, err)
+		}
+		res.Stored++
+		if !recorded[rec.URL] {
+			page := SourcePage{SourceID: src.ID, URL: rec.URL, Status: "completed"}
+			if _, err := s.repo.BulkCreatePages(ctx, []SourcePage{page}); err != nil {
+				return res, fmt.Errorf("record %d: record page: %w", line, err)
+			}
+			recorded[rec.URL] = true
+		}
+	}
+	return res, nil
+}
+
+// exportPage is how many pages Export reads per query.
+const exportPage = 100
+
+// Export writes every chunk of a source to w as NDJSON, one ImportLine per
+// line, so the output can be imported into another source. It walks the
+// source's pages and reads the chunks of one page at a time, so no query
+// pages past the vector store's result window and nothing is held in memory
+// but the URLs written. A file source's chunks are read under its path.
+// Vectors are not exported; an import re-embeds the content.
+func (s *Service) Export(ctx context.Context, id string, w io.Writer) error {
+	src, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	written := make(map[string]bool)
+	writePage := func(pageURL string) error {
+		chunks, err := s.chunkStore.GetPageChunks(ctx, src.ID, pageURL)
+		if err != nil {
+			return fmt.Errorf("failed to fetch chunks: %w", err)
+		}
+		var urls []string
+		for _, c := range chunks {
+			// Chunks indexed under a canonical URL are found from both pages
+			if written[c.SourceURL] {
+				continue
+			}
+			line := ImportLine{
+				Content:    c.Content,
+				Type:       c.Type,
+				Language:   c.Language,
+				URL:        c.SourceURL,
+				ChunkIndex: c.ChunkIndex,
+			}
+			if err := enc.Encode(line); err != nil {
+				return err
+			}
+			urls = append(urls, c.SourceURL)
+		}
+		for _, u := range urls {
+			written[u] = true
+		}
+		return nil
+	}
+
+	if src.Type == TypeFile {
+		if err := writePage(src.URL); err != nil {
+			return err
+		}
+	}
+	after := uuid.Nil.String()
+	for {
+		pages, err := s.repo.ListPagesAfter(ctx, src.ID, after, exportPage)
+		if err != nil {
+			return fmt.Errorf("failed to list pages: %w", err)
+		}
+		for _, p := range pages {
+			if err := writePage(p.URL); err != nil {
+				return err
+			}
+		}
+		if len(pages) < exportPage {
+			return nil
+		}
+		after = pages[len(pages)-1].ID
+	}
+}
+
+var ErrReembedDisabled = errors.New("chunk re-embedding is not configured")
+
+const reembedScanPage = 100
+
+// SetChunkScanner enables ReembedMissing.
+func (s *Service) SetChunkScanner(sc ChunkScanner) {
+	s.scanner = sc
+}
+
+// ReembedMissing republishes embed tasks built from the stored text of a
+// source's chunks that have no vector, or of every chunk when all is set.
+// Chunks of the source's keyword-only types are skipped. The embedder replaces
+// each chunk in place, so pages are not re-fetched or re-chunked. It returns
+// the number of tasks published.
+func (s *Service) ReembedMissing(ctx context.Context, id string, all bool) (int, error) {
+	if s.scanner == nil {
+		return 0, ErrReembedDisabled
+	}
+
+	src, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+
+	queued := 0
+	for offset := 0; ; offset += reembedScanPage {
+		chunks, err := s.scanner.ScanChunks(ctx, src.ID, reembedScanPage, offset)
+		if err != nil {
+			return queued, fmt.Errorf("failed to scan chunks: %w", err)
+		}
+		for _, c := range chunks {
+			if !all && len(c.Vector) > 0 {
+				continue
+			}
+			if slices.Contains(src.KeywordOnlyTypes, c.Type) {
+				continue
+			}
+			payload := worker.IngestEmbedPayload{
+				SourceID:         src.ID,
+				SourceURL:        c.SourceURL,
+				SourceName:       src.Name,
+				Title:            c.Title,
+				Content:          c.Content,
+				ChunkIndex:       c.ChunkIndex,
+				ChunkType:        c.Type,
+				Language:         c.Language,
+				Author:           c.Author,
+				CreatedAt:        c.CreatedAt,
+				PageCount:        c.PageCount,
+				Metadata:         src.Metadata,
+				EmbedTitlePrefix: src.EmbedTitlePrefix,
+				Selector:         c.Selector,
+				AliasURL:         c.AliasURL,
+				EmbeddingModel:   src.EmbeddingModel,
+				AnchorKeywords:   c.AnchorKeywords,
+				TitlePath:        c.TitlePath,
+				Heading:          c.Heading,
+				Overlap:          c.Overlap,
+				ChunkID:          c.ID,
+				CorrelationID:    middleware.GetCorrelationID(ctx),
+			}
+			body, err := json.Marshal(payload)
+			if err != nil {
+				return queued, err
+			}
+			if err := s.pub.Publish(config.TopicIngestEmbed, body); err != nil {
+				return queued, fmt.Errorf("failed to publish embed task: %w", err)
+			}
+			queued++
+		}
+		if len(chunks) < reembedScanPage {
+			break
+		}
+	}
+
+	slog.InfoContext(ctx, "re-embed tasks published", "source_id", src.ID, "queued", queued, "all", all)
+	return queued, nil
+}
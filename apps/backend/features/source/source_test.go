@@ -33,6 +33,9 @@ type TestRepo struct{ Repository }
 func (m *TestRepo) ExistsByHash(ctx context.Context, hash string) (bool, error) { return false, nil }
 func (m *TestRepo) Save(ctx context.Context, src *Source) error                 { return nil }
 func (m *TestRepo) Count(ctx context.Context) (int, error)                      { return 0, nil }
+func (m *TestRepo) CountMatching(ctx context.Context, f ListFilter) (int, error) {
+	return 0, nil
+}
 func (m *TestRepo) ResetStuckPages(ctx context.Context, timeout time.Duration) (int64, error) {
 	return 1, nil
 }
@@ -45,6 +48,26 @@ func (m *TestRepo) UpdatePageStatus(ctx context.Context, sourceID, url, status,
 	return nil
 }
 
+func (m *TestRepo) RecordPageFetch(ctx context.Context, sourceID, url string, statusCode, fetchMs int) error {
+	return nil
+}
+
+func (m *TestRepo) GetPageHash(ctx context.Context, sourceID, url string) (string, error) {
+	return "", nil
+}
+
+func (m *TestRepo) UpdatePageHash(ctx context.Context, sourceID, url, hash string) error {
+	return nil
+}
+
+func (m *TestRepo) BeginPageHash(ctx context.Context, sourceID, url, hash string, chunks int) error {
+	return nil
+}
+
+func (m *TestRepo) RecordStoredChunk(ctx context.Context, sourceID, url, hash string) error {
+	return nil
+}
+
 func (m *TestRepo) GetPages(ctx context.Context, sourceID string) ([]SourcePage, error) {
 	return nil, nil
 }
@@ -52,11 +75,20 @@ func (m *TestRepo) DeletePages(ctx context.Context, sourceID string) error { ret
 func (m *TestRepo) CountPendingPages(ctx context.Context, sourceID string) (int, error) {
 	return 0, nil
 }
+func (m *TestRepo) CountFailedPages(ctx context.Context, sourceID string) (int, error) {
+	return 0, nil
+}
+func (m *TestRepo) GroupPageErrors(ctx context.Context, sourceID string, limit, offset int) ([]PageErrorGroup, error) {
+	return nil, nil
+}
 func (m *TestRepo) Get(ctx context.Context, id string) (*Source, error)       { return nil, nil }
-func (m *TestRepo) List(ctx context.Context) ([]Source, error)                { return nil, nil }
+func (m *TestRepo) List(ctx context.Context, f ListFilter) ([]Source, error)  { return nil, nil }
 func (m *TestRepo) UpdateStatus(ctx context.Context, id, status string) error { return nil }
 func (m *TestRepo) UpdateBodyHash(ctx context.Context, id, hash string) error { return nil }
 func (m *TestRepo) SoftDelete(ctx context.Context, id string) error           { return nil }
+func (m *TestRepo) UpdateConfig(ctx context.Context, id string, maxDepth int, exclusions []string) error {
+	return nil
+}
 
 type TestSettings struct{ SettingsService }
 
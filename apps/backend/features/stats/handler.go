@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"log/slog"
 	"net/http"
+	"strconv"
 
 	"qurio/apps/backend/internal/middleware"
 )
@@ -19,6 +20,7 @@ type JobRepo interface {
 
 type VectorStore interface {
 	CountChunks(ctx context.Context) (int, error)
+	Facets(ctx context.Context) (map[string]map[string]int, error)
 }
 
 type Handler struct {
@@ -35,43 +37,69 @@ type StatsResponse struct {
 	Sources    int `json:"sources"`
 	Documents  int `json:"documents"`
 	FailedJobs int `json:"failed_jobs"`
+
+	// Warnings names the counts that could not be computed; they are
+	// reported as 0.
+	Warnings []string `json:"warnings,omitempty"`
 }
 
+// GetStats reports the dashboard counts. A count that fails is reported as 0
+// with a warning so one unavailable backend does not blank the dashboard;
+// ?strict=true fails the whole request instead.
 func (h *Handler) GetStats(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	correlationID := middleware.GetCorrelationID(ctx)
-
-	slog.InfoContext(ctx, "getting stats", "correlationId", correlationID)
-
-	sCount, err := h.sourceRepo.Count(ctx)
-	if err != nil {
-		slog.ErrorContext(ctx, "failed to count sources", "error", err, "correlationId", correlationID)
-		h.writeError(ctx, w, "INTERNAL_ERROR", "failed to count sources", http.StatusInternalServerError)
-		return
+	strict, _ := strconv.ParseBool(r.URL.Query().Get("strict"))
+
+	slog.InfoContext(ctx, "getting stats", "correlationId", correlationID, "strict", strict)
+
+	var resp StatsResponse
+	counts := []struct {
+		name  string
+		count func(context.Context) (int, error)
+		dst   *int
+	}{
+		{"sources", h.sourceRepo.Count, &resp.Sources},
+		{"jobs", h.jobRepo.Count, &resp.FailedJobs},
+		{"documents", h.vectorStore.CountChunks, &resp.Documents},
+	}
+	for _, c := range counts {
+		n, err := c.count(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to count "+c.name, "error", err, "correlationId", correlationID)
+			if strict {
+				h.writeError(ctx, w, "INTERNAL_ERROR", "failed to count "+c.name, http.StatusInternalServerError)
+				return
+			}
+			resp.Warnings = append(resp.Warnings, "failed to count "+c.name)
+			continue
+		}
+		*c.dst = n
 	}
 
-	jCount, err := h.jobRepo.Count(ctx)
-	if err != nil {
-		slog.ErrorContext(ctx, "failed to count jobs", "error", err, "correlationId", correlationID)
-		h.writeError(ctx, w, "INTERNAL_ERROR", "failed to count jobs", http.StatusInternalServerError)
-		return
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{"data": resp}); err != nil {
+		slog.ErrorContext(ctx, "failed to encode response", "error", err)
 	}
+}
+
+// GetFacets returns the distinct values and chunk counts for each facet
+// property (type, language, sourceId).
+func (h *Handler) GetFacets(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	correlationID := middleware.GetCorrelationID(ctx)
 
-	dCount, err := h.vectorStore.CountChunks(ctx)
+	slog.InfoContext(ctx, "getting facets", "correlationId", correlationID)
+
+	facets, err := h.vectorStore.Facets(ctx)
 	if err != nil {
-		slog.ErrorContext(ctx, "failed to count documents", "error", err, "correlationId", correlationID)
-		h.writeError(ctx, w, "INTERNAL_ERROR", "failed to count documents", http.StatusInternalServerError)
+		slog.ErrorContext(ctx, "failed to aggregate facets", "error", err, "correlationId", correlationID)
+		h.writeError(ctx, w, "INTERNAL_ERROR", "failed to aggregate facets", http.StatusInternalServerError)
 		return
 	}
 
-	resp := StatsResponse{
-		Sources:    sCount,
-		Documents:  dCount,
-		FailedJobs: jCount,
-	}
-
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(map[string]interface{}{"data": resp}); err != nil {
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{"data": facets}); err != nil {
 		slog.ErrorContext(ctx, "failed to encode response", "error", err)
 	}
 }
@@ -33,9 +33,18 @@ func (m *MockVectorStore) CountChunks(ctx context.Context) (int, error) {
 	return args.Int(0), args.Error(1)
 }
 
+func (m *MockVectorStore) Facets(ctx context.Context) (map[string]map[string]int, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]map[string]int), args.Error(1)
+}
+
 func TestHandler_GetStats_Table(t *testing.T) {
 	tests := []struct {
 		name       string
+		query      string
 		setupMocks func(*MockSourceRepo, *MockJobRepo, *MockVectorStore)
 		wantStatus int
 		wantError  bool
@@ -58,7 +67,8 @@ func TestHandler_GetStats_Table(t *testing.T) {
 			},
 		},
 		{
-			name: "SourceRepo Error",
+			name:  "SourceRepo Error Strict",
+			query: "?strict=true",
 			setupMocks: func(s *MockSourceRepo, j *MockJobRepo, v *MockVectorStore) {
 				s.On("Count", mock.Anything).Return(0, errors.New("db error"))
 			},
@@ -66,7 +76,8 @@ func TestHandler_GetStats_Table(t *testing.T) {
 			wantError:  true,
 		},
 		{
-			name: "JobRepo Error",
+			name:  "JobRepo Error Strict",
+			query: "?strict=true",
 			setupMocks: func(s *MockSourceRepo, j *MockJobRepo, v *MockVectorStore) {
 				s.On("Count", mock.Anything).Return(10, nil)
 				j.On("Count", mock.Anything).Return(0, errors.New("db error"))
@@ -75,7 +86,8 @@ func TestHandler_GetStats_Table(t *testing.T) {
 			wantError:  true,
 		},
 		{
-			name: "VectorStore Error",
+			name:  "VectorStore Error Strict",
+			query: "?strict=true",
 			setupMocks: func(s *MockSourceRepo, j *MockJobRepo, v *MockVectorStore) {
 				s.On("Count", mock.Anything).Return(10, nil)
 				j.On("Count", mock.Anything).Return(5, nil)
@@ -84,6 +96,36 @@ func TestHandler_GetStats_Table(t *testing.T) {
 			wantStatus: http.StatusInternalServerError,
 			wantError:  true,
 		},
+		{
+			name: "VectorStore Error Partial",
+			setupMocks: func(s *MockSourceRepo, j *MockJobRepo, v *MockVectorStore) {
+				s.On("Count", mock.Anything).Return(10, nil)
+				j.On("Count", mock.Anything).Return(5, nil)
+				v.On("CountChunks", mock.Anything).Return(0, errors.New("weaviate error"))
+			},
+			wantStatus: http.StatusOK,
+			checkBody: func(t *testing.T, body map[string]interface{}) {
+				data := body["data"].(map[string]interface{})
+				assert.EqualValues(t, 10, data["sources"])
+				assert.EqualValues(t, 5, data["failed_jobs"])
+				assert.EqualValues(t, 0, data["documents"])
+				assert.Equal(t, []interface{}{"failed to count documents"}, data["warnings"])
+			},
+		},
+		{
+			name: "SourceRepo Error Partial",
+			setupMocks: func(s *MockSourceRepo, j *MockJobRepo, v *MockVectorStore) {
+				s.On("Count", mock.Anything).Return(0, errors.New("db error"))
+				j.On("Count", mock.Anything).Return(5, nil)
+				v.On("CountChunks", mock.Anything).Return(100, nil)
+			},
+			wantStatus: http.StatusOK,
+			checkBody: func(t *testing.T, body map[string]interface{}) {
+				data := body["data"].(map[string]interface{})
+				assert.EqualValues(t, 100, data["documents"])
+				assert.Equal(t, []interface{}{"failed to count sources"}, data["warnings"])
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -95,7 +137,7 @@ func TestHandler_GetStats_Table(t *testing.T) {
 			tt.setupMocks(mSource, mJob, mVector)
 
 			h := NewHandler(mSource, mJob, mVector)
-			req := httptest.NewRequest("GET", "/stats", nil)
+			req := httptest.NewRequest("GET", "/stats"+tt.query, nil)
 			w := httptest.NewRecorder()
 
 			h.GetStats(w, req)
@@ -117,3 +159,33 @@ func TestHandler_GetStats_Table(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_GetFacets(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		v := new(MockVectorStore)
+		v.On("Facets", mock.Anything).Return(map[string]map[string]int{
+			"language": {"go": 7, "python": 3},
+		}, nil)
+		h := NewHandler(new(MockSourceRepo), new(MockJobRepo), v)
+
+		w := httptest.NewRecorder()
+		h.GetFacets(w, httptest.NewRequest("GET", "/facets", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var body map[string]map[string]map[string]int
+		assert.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+		assert.Equal(t, 7, body["data"]["language"]["go"])
+		assert.Equal(t, 3, body["data"]["language"]["python"])
+	})
+
+	t.Run("Store Error", func(t *testing.T) {
+		v := new(MockVectorStore)
+		v.On("Facets", mock.Anything).Return(nil, errors.New("weaviate down"))
+		h := NewHandler(new(MockSourceRepo), new(MockJobRepo), v)
+
+		w := httptest.NewRecorder()
+		h.GetFacets(w, httptest.NewRequest("GET", "/facets", nil))
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
@@ -1,51 +1,113 @@
 package gemini
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"math/rand/v2"
+	"net/http"
 	"sync"
+	"time"
 
 	"github.com/google/generative-ai-go/genai"
 	"google.golang.org/api/option"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
+	"qurio/apps/backend/internal/adapter/openai"
 	"qurio/apps/backend/internal/settings"
+	"qurio/apps/backend/internal/worker"
 )
 
+// DefaultEmbeddingModel is used when neither the request nor the settings
+// name a model.
+const DefaultEmbeddingModel = "gemini-embedding-001"
+
+// ProviderOpenAI selects an OpenAI-compatible embeddings API in settings in
+// place of Gemini.
+const ProviderOpenAI = "openai"
+
+// maxBatchEmbed is the most texts Gemini accepts in one batchEmbedContents
+// request.
+const maxBatchEmbed = 100
+
+// Defaults for retrying rate-limited and failed embedding calls.
+const (
+	DefaultRetryAttempts  = 3
+	DefaultRetryBaseDelay = time.Second
+)
+
+// DynamicEmbedder embeds through the provider, model and credentials in the
+// current settings, so changes apply without a restart.
 type DynamicEmbedder struct {
 	settingsSvc *settings.Service
 	client      *genai.Client
 	currentKey  string
 	mu          sync.RWMutex
 	clientOpts  []option.ClientOption
+
+	retryAttempts  int
+	retryBaseDelay time.Duration
 }
 
 func NewDynamicEmbedder(svc *settings.Service, opts ...option.ClientOption) *DynamicEmbedder {
 	return &DynamicEmbedder{
-		settingsSvc: svc,
-		clientOpts:  opts,
+		settingsSvc:    svc,
+		clientOpts:     opts,
+		retryAttempts:  DefaultRetryAttempts,
+		retryBaseDelay: DefaultRetryBaseDelay,
 	}
 }
 
+// SetRetry makes each embedding call up to attempts times when it is rate
+// limited (429) or fails server-side (5xx), doubling the delay from baseDelay
+// after each failure, plus jitter. One attempt disables retries.
+func (e *DynamicEmbedder) SetRetry(attempts int, baseDelay time.Duration) {
+	e.retryAttempts = max(attempts, 1)
+	e.retryBaseDelay = baseDelay
+}
+
+// Embed embeds a document chunk with the default model.
 func (e *DynamicEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	return e.EmbedWithModel(ctx, "", text)
+}
+
+// EmbedWithModel embeds a document chunk with the named Gemini embedding
+// model, or the default model when modelName is empty.
+func (e *DynamicEmbedder) EmbedWithModel(ctx context.Context, modelName, text string) ([]float32, error) {
+	return e.embed(ctx, modelName, genai.TaskTypeRetrievalDocument, text)
+}
+
+// EmbedQuery embeds a search query, for matching against document chunks, with
+// the named model or the default model when modelName is empty.
+func (e *DynamicEmbedder) EmbedQuery(ctx context.Context, modelName, text string) ([]float32, error) {
+	return e.embed(ctx, modelName, genai.TaskTypeRetrievalQuery, text)
+}
+
+func (e *DynamicEmbedder) embed(ctx context.Context, modelName string, task genai.TaskType, text string) ([]float32, error) {
 	s, err := e.settingsSvc.Get(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get settings: %w", err)
 	}
-
-	if s.GeminiAPIKey == "" {
-		return nil, fmt.Errorf("gemini api key not configured")
+	if s.EmbeddingProvider == ProviderOpenAI {
+		return e.embedOpenAI(ctx, s, modelName, text)
 	}
 
-	client, err := e.getClient(ctx, s.GeminiAPIKey)
+	model, err := e.model(ctx, s, modelName)
 	if err != nil {
 		return nil, err
 	}
+	model.TaskType = task
 
-	model := client.EmbeddingModel("gemini-embedding-001")
-	res, err := model.EmbedContent(ctx, genai.Text(text))
+	var res *genai.EmbedContentResponse
+	err = e.retry(ctx, func() (err error) {
+		res, err = model.EmbedContent(ctx, genai.Text(text))
+		return err
+	})
 	if err != nil {
-		return nil, err
+		return nil, wrapEmbedError(err)
 	}
 
 	if len(res.Embedding.Values) == 0 {
@@ -55,6 +117,165 @@ func (e *DynamicEmbedder) Embed(ctx context.Context, text string) ([]float32, er
 	return res.Embedding.Values, nil
 }
 
+// EmbedBatch embeds document chunks with the default model through
+// batchEmbedContents, one request per maxBatchEmbed texts. Vectors are
+// returned in input order.
+func (e *DynamicEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	s, err := e.settingsSvc.Get(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get settings: %w", err)
+	}
+	if s.EmbeddingProvider == ProviderOpenAI {
+		return e.embedBatchOpenAI(ctx, s, texts)
+	}
+
+	model, err := e.model(ctx, s, "")
+	if err != nil {
+		return nil, err
+	}
+	model.TaskType = genai.TaskTypeRetrievalDocument
+
+	vectors := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += maxBatchEmbed {
+		batch := model.NewBatch()
+		for _, text := range texts[start:min(start+maxBatchEmbed, len(texts))] {
+			batch.AddContent(genai.Text(text))
+		}
+
+		var res *genai.BatchEmbedContentsResponse
+		err := e.retry(ctx, func() (err error) {
+			res, err = model.BatchEmbedContents(ctx, batch)
+			return err
+		})
+		if err != nil {
+			return nil, wrapEmbedError(err)
+		}
+		for _, emb := range res.Embeddings {
+			if emb == nil || len(emb.Values) == 0 {
+				return nil, fmt.Errorf("empty embedding received")
+			}
+			vectors = append(vectors, emb.Values)
+		}
+	}
+
+	if len(vectors) != len(texts) {
+		return nil, fmt.Errorf("batch embedding returned %d vectors for %d texts", len(vectors), len(texts))
+	}
+	return vectors, nil
+}
+
+// embedOpenAI embeds text through the OpenAI-compatible API in settings. The
+// protocol has no task types, so queries and documents are embedded alike.
+func (e *DynamicEmbedder) embedOpenAI(ctx context.Context, s *settings.Settings, modelName, text string) ([]float32, error) {
+	embedder := openai.NewEmbedder(s.EmbeddingBaseURL, s.EmbeddingAPIKey, s.EmbeddingModel)
+
+	var vec []float32
+	err := e.retry(ctx, func() (err error) {
+		if modelName == "" {
+			vec, err = embedder.Embed(ctx, text)
+		} else {
+			vec, err = embedder.EmbedWithModel(ctx, modelName, text)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, wrapEmbedError(err)
+	}
+	return vec, nil
+}
+
+// embedBatchOpenAI embeds texts through the OpenAI-compatible API in settings,
+// one request per maxBatchEmbed texts.
+func (e *DynamicEmbedder) embedBatchOpenAI(ctx context.Context, s *settings.Settings, texts []string) ([][]float32, error) {
+	embedder := openai.NewEmbedder(s.EmbeddingBaseURL, s.EmbeddingAPIKey, s.EmbeddingModel)
+
+	vectors := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += maxBatchEmbed {
+		var batch [][]float32
+		err := e.retry(ctx, func() (err error) {
+			batch, err = embedder.EmbedBatch(ctx, texts[start:min(start+maxBatchEmbed, len(texts))])
+			return err
+		})
+		if err != nil {
+			return nil, wrapEmbedError(err)
+		}
+		vectors = append(vectors, batch...)
+	}
+	return vectors, nil
+}
+
+// model returns the named embedding model on a client for the configured key.
+// An empty name selects the settings' model, or DefaultEmbeddingModel.
+func (e *DynamicEmbedder) model(ctx context.Context, s *settings.Settings, name string) (*genai.EmbeddingModel, error) {
+	if s.GeminiAPIKey == "" {
+		return nil, fmt.Errorf("gemini api key not configured")
+	}
+
+	client, err := e.getClient(ctx, s.GeminiAPIKey)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		name = cmp.Or(s.EmbeddingModel, DefaultEmbeddingModel)
+	}
+	return client.EmbeddingModel(name), nil
+}
+
+// wrapEmbedError marks quota rejections with worker.ErrRateLimited.
+func wrapEmbedError(err error) error {
+	if isRateLimited(err) {
+		return fmt.Errorf("%w: %v", worker.ErrRateLimited, err)
+	}
+	return err
+}
+
+// retry runs call until it succeeds, fails with an error that is not worth
+// retrying, or runs out of attempts, and returns its last error.
+func (e *DynamicEmbedder) retry(ctx context.Context, call func() error) error {
+	delay := e.retryBaseDelay
+	for attempt := 1; ; attempt++ {
+		err := call()
+		if err == nil || attempt >= e.retryAttempts || !isRetryable(err) {
+			return err
+		}
+
+		wait := delay
+		if delay > 0 {
+			wait += rand.N(delay/4 + 1) // #nosec G404 -- jitter, not security sensitive
+		}
+		slog.WarnContext(ctx, "embedding failed, retrying", "attempt", attempt, "wait", wait, "error", err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+		delay *= 2
+	}
+}
+
+// isRetryable reports whether err is a rate limit or a server-side failure.
+func isRetryable(err error) bool {
+	if isRateLimited(err) {
+		return true
+	}
+	switch status.Code(err) {
+	case codes.Unavailable, codes.Internal:
+		return true
+	}
+	var httpErr interface{ HTTPCode() int }
+	return errors.As(err, &httpErr) && httpErr.HTTPCode() >= http.StatusInternalServerError
+}
+
+// isRateLimited reports whether err is a quota rejection from the embedding
+// API, over either gRPC (RESOURCE_EXHAUSTED) or HTTP (429).
+func isRateLimited(err error) bool {
+	if status.Code(err) == codes.ResourceExhausted {
+		return true
+	}
+	var httpErr interface{ HTTPCode() int }
+	return errors.As(err, &httpErr) && httpErr.HTTPCode() == http.StatusTooManyRequests
+}
+
 func (e *DynamicEmbedder) getClient(ctx context.Context, key string) (*genai.Client, error) {
 	e.mu.RLock()
 	if e.client != nil && e.currentKey == key {
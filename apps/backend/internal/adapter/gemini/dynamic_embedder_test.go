@@ -7,11 +7,15 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/google/generative-ai-go/genai"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"google.golang.org/api/option"
 	"qurio/apps/backend/internal/settings"
+	"qurio/apps/backend/internal/worker"
 )
 
 // --- Mocks ---
@@ -224,7 +228,236 @@ func TestDynamicEmbedder_Embed_TooManyRequests(t *testing.T) {
 	}, nil)
 
 	embedder := NewDynamicEmbedder(settingsSvc, option.WithEndpoint(ts.URL))
+	embedder.SetRetry(1, 0) // retries are covered by TestDynamicEmbedder_Embed_RetriesRateLimits
 
 	_, err := embedder.Embed(context.Background(), "test")
-	assert.Error(t, err)
+	assert.ErrorIs(t, err, worker.ErrRateLimited)
+}
+
+func TestDynamicEmbedder_EmbedBatch(t *testing.T) {
+	var requests []int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1beta/models/gemini-embedding-001:batchEmbedContents" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		var body struct {
+			Requests []struct {
+				Content struct {
+					Parts []struct {
+						Text string `json:"text"`
+					} `json:"parts"`
+				} `json:"content"`
+			} `json:"requests"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		requests = append(requests, len(body.Requests))
+
+		// Each vector carries the length of its text so order can be checked
+		embeddings := make([]map[string]interface{}, len(body.Requests))
+		for i, req := range body.Requests {
+			embeddings[i] = map[string]interface{}{
+				"values": []float32{float32(len(req.Content.Parts[0].Text))},
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"embeddings": embeddings})
+	}))
+	defer ts.Close()
+
+	mockRepo := new(MockSettingsRepo)
+	mockRepo.On("Get", mock.Anything).Return(&settings.Settings{GeminiAPIKey: "test-key"}, nil)
+	embedder := NewDynamicEmbedder(settings.NewService(mockRepo), option.WithEndpoint(ts.URL))
+
+	t.Run("multi-text batch", func(t *testing.T) {
+		requests = nil
+		vectors, err := embedder.EmbedBatch(context.Background(), []string{"a", "bb", "ccc"})
+
+		require.NoError(t, err)
+		assert.Equal(t, [][]float32{{1}, {2}, {3}}, vectors)
+		assert.Equal(t, []int{3}, requests)
+	})
+
+	t.Run("split at the request limit", func(t *testing.T) {
+		requests = nil
+		texts := make([]string, maxBatchEmbed+20)
+		for i := range texts {
+			texts[i] = fmt.Sprintf("text-%03d", i)
+		}
+		vectors, err := embedder.EmbedBatch(context.Background(), texts)
+
+		require.NoError(t, err)
+		assert.Len(t, vectors, len(texts))
+		assert.Equal(t, []int{maxBatchEmbed, 20}, requests)
+	})
+}
+
+func TestDynamicEmbedder_Embed_RetriesRateLimits(t *testing.T) {
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls <= 2 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"embedding": map[string]interface{}{"values": []float32{0.4}},
+		})
+	}))
+	defer ts.Close()
+
+	mockRepo := new(MockSettingsRepo)
+	mockRepo.On("Get", mock.Anything).Return(&settings.Settings{GeminiAPIKey: "test-key"}, nil)
+	embedder := NewDynamicEmbedder(settings.NewService(mockRepo), option.WithEndpoint(ts.URL))
+	embedder.SetRetry(3, time.Millisecond)
+
+	vals, err := embedder.Embed(context.Background(), "test")
+
+	require.NoError(t, err)
+	assert.Equal(t, []float32{0.4}, vals)
+	assert.Equal(t, 3, calls)
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		calls = -10 // every call is rate limited
+		embedder.SetRetry(2, time.Millisecond)
+
+		_, err := embedder.Embed(context.Background(), "test")
+		assert.ErrorIs(t, err, worker.ErrRateLimited)
+		assert.Equal(t, -8, calls)
+	})
+
+	t.Run("client errors are not retried", func(t *testing.T) {
+		bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.WriteHeader(http.StatusBadRequest)
+		}))
+		defer bad.Close()
+		embedder := NewDynamicEmbedder(settings.NewService(mockRepo), option.WithEndpoint(bad.URL))
+		embedder.SetRetry(3, time.Millisecond)
+
+		calls = 0
+		_, err := embedder.Embed(context.Background(), "test")
+		assert.Error(t, err)
+		assert.Equal(t, 1, calls)
+	})
+}
+
+func TestDynamicEmbedder_TaskTypes(t *testing.T) {
+	// The REST client sends taskType as its enum number
+	var path string
+	var taskType genai.TaskType
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			TaskType genai.TaskType `json:"taskType"`
+			Requests []struct {
+				TaskType genai.TaskType `json:"taskType"`
+			} `json:"requests"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		path, taskType = r.URL.Path, body.TaskType
+		if len(body.Requests) > 0 {
+			taskType = body.Requests[0].TaskType
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"embeddings": []map[string]interface{}{{"values": []float32{0.1}}},
+			})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"embedding": map[string]interface{}{"values": []float32{0.1}},
+		})
+	}))
+	defer ts.Close()
+
+	mockRepo := new(MockSettingsRepo)
+	mockRepo.On("Get", mock.Anything).Return(&settings.Settings{
+		GeminiAPIKey:   "test-key",
+		EmbeddingModel: "text-embedding-004",
+	}, nil)
+	embedder := NewDynamicEmbedder(settings.NewService(mockRepo), option.WithEndpoint(ts.URL))
+	ctx := context.Background()
+
+	t.Run("documents", func(t *testing.T) {
+		_, err := embedder.Embed(ctx, "chunk")
+		require.NoError(t, err)
+		assert.Equal(t, "/v1beta/models/text-embedding-004:embedContent", path)
+		assert.Equal(t, genai.TaskTypeRetrievalDocument, taskType)
+	})
+
+	t.Run("document batch", func(t *testing.T) {
+		_, err := embedder.EmbedBatch(ctx, []string{"chunk"})
+		require.NoError(t, err)
+		assert.Equal(t, "/v1beta/models/text-embedding-004:batchEmbedContents", path)
+		assert.Equal(t, genai.TaskTypeRetrievalDocument, taskType)
+	})
+
+	t.Run("queries", func(t *testing.T) {
+		_, err := embedder.EmbedQuery(ctx, "", "how do I deploy")
+		require.NoError(t, err)
+		assert.Equal(t, "/v1beta/models/text-embedding-004:embedContent", path)
+		assert.Equal(t, genai.TaskTypeRetrievalQuery, taskType)
+	})
+
+	t.Run("query with source model", func(t *testing.T) {
+		_, err := embedder.EmbedQuery(ctx, "gemini-embedding-001", "how do I deploy")
+		require.NoError(t, err)
+		assert.Equal(t, "/v1beta/models/gemini-embedding-001:embedContent", path)
+		assert.Equal(t, genai.TaskTypeRetrievalQuery, taskType)
+	})
+}
+
+func TestDynamicEmbedder_OpenAIProvider(t *testing.T) {
+	var models []string
+	var inputs []int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/embeddings" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		var body struct {
+			Model string   `json:"model"`
+			Input []string `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		models = append(models, body.Model)
+		inputs = append(inputs, len(body.Input))
+
+		data := make([]map[string]interface{}, len(body.Input))
+		for i, text := range body.Input {
+			data[i] = map[string]interface{}{"index": i, "embedding": []float32{float32(len(text))}}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	}))
+	defer ts.Close()
+
+	mockRepo := new(MockSettingsRepo)
+	mockRepo.On("Get", mock.Anything).Return(&settings.Settings{
+		EmbeddingProvider: ProviderOpenAI,
+		EmbeddingBaseURL:  ts.URL + "/v1",
+		EmbeddingModel:    "nomic-embed-text",
+	}, nil)
+	embedder := NewDynamicEmbedder(settings.NewService(mockRepo))
+	ctx := context.Background()
+
+	vec, err := embedder.Embed(ctx, "chunk")
+	require.NoError(t, err)
+	assert.Equal(t, []float32{5}, vec)
+
+	vec, err = embedder.EmbedQuery(ctx, "source-model", "query")
+	require.NoError(t, err)
+	assert.Equal(t, []float32{5}, vec)
+
+	vectors, err := embedder.EmbedBatch(ctx, []string{"a", "bb"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{1}, {2}}, vectors)
+
+	assert.Equal(t, []string{"nomic-embed-text", "source-model", "nomic-embed-text"}, models)
+	assert.Equal(t, []int{1, 1, 2}, inputs)
 }
@@ -0,0 +1,93 @@
+package gemini
+
+import (
+	"context"
+
+	"golang.org/x/time/rate"
+)
+
+type embedder interface {
+	Embed(ctx context.Context, text string) ([]float32, error)
+}
+
+type modelEmbedder interface {
+	EmbedWithModel(ctx context.Context, model, text string) ([]float32, error)
+}
+
+type queryEmbedder interface {
+	EmbedQuery(ctx context.Context, model, text string) ([]float32, error)
+}
+
+type batchEmbedder interface {
+	EmbedBatch(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// RateLimitedEmbedder shares one requests-per-minute budget across every
+// caller of the wrapped embedder. Calls block until a token is available or
+// the context is done.
+type RateLimitedEmbedder struct {
+	next    embedder
+	limiter *rate.Limiter
+}
+
+// NewRateLimitedEmbedder allows rpm embed requests per minute, evenly spaced.
+func NewRateLimitedEmbedder(next embedder, rpm int) *RateLimitedEmbedder {
+	return &RateLimitedEmbedder{
+		next:    next,
+		limiter: rate.NewLimiter(rate.Limit(float64(rpm)/60), 1),
+	}
+}
+
+func (e *RateLimitedEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	if err := e.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	return e.next.Embed(ctx, text)
+}
+
+// EmbedWithModel draws from the same budget as Embed. If the wrapped embedder
+// cannot select a model, the default model is used.
+func (e *RateLimitedEmbedder) EmbedWithModel(ctx context.Context, model, text string) ([]float32, error) {
+	if err := e.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	if me, ok := e.next.(modelEmbedder); ok {
+		return me.EmbedWithModel(ctx, model, text)
+	}
+	return e.next.Embed(ctx, text)
+}
+
+// EmbedQuery draws from the same budget as Embed. If the wrapped embedder
+// does not embed queries differently, the query is embedded like a document.
+func (e *RateLimitedEmbedder) EmbedQuery(ctx context.Context, model, text string) ([]float32, error) {
+	if qe, ok := e.next.(queryEmbedder); ok {
+		if err := e.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+		return qe.EmbedQuery(ctx, model, text)
+	}
+	if model == "" {
+		return e.Embed(ctx, text)
+	}
+	return e.EmbedWithModel(ctx, model, text)
+}
+
+// EmbedBatch draws one token per batch when the wrapped embedder can batch,
+// and one per text otherwise.
+func (e *RateLimitedEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	if be, ok := e.next.(batchEmbedder); ok {
+		if err := e.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+		return be.EmbedBatch(ctx, texts)
+	}
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vector, err := e.Embed(ctx, text)
+		if err != nil {
+			return nil, err
+		}
+		vectors[i] = vector
+	}
+	return vectors, nil
+}
@@ -0,0 +1,48 @@
+package gemini
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubEmbedder struct {
+	calls []time.Time
+}
+
+func (s *stubEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	s.calls = append(s.calls, time.Now())
+	return []float32{0.1}, nil
+}
+
+func TestRateLimitedEmbedder_SpacesCalls(t *testing.T) {
+	stub := &stubEmbedder{}
+	e := NewRateLimitedEmbedder(stub, 120) // 2 per second
+
+	for i := 0; i < 3; i++ {
+		_, err := e.Embed(context.Background(), "text")
+		require.NoError(t, err)
+	}
+
+	require.Len(t, stub.calls, 3)
+	for i := 1; i < 3; i++ {
+		assert.GreaterOrEqual(t, stub.calls[i].Sub(stub.calls[i-1]), 450*time.Millisecond)
+	}
+}
+
+func TestRateLimitedEmbedder_ContextDeadline(t *testing.T) {
+	stub := &stubEmbedder{}
+	e := NewRateLimitedEmbedder(stub, 60) // 1 per second
+
+	_, err := e.Embed(context.Background(), "first")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = e.Embed(ctx, "second")
+	assert.Error(t, err)
+	assert.Len(t, stub.calls, 1)
+}
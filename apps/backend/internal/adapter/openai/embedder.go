@@ -0,0 +1,134 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultBaseURL is the OpenAI API root; self-hosted servers that speak the
+// same protocol are reached by overriding it.
+const DefaultBaseURL = "https://api.openai.com/v1"
+
+// DefaultModel is used when no model is named.
+const DefaultModel = "text-embedding-3-small"
+
+// APIError is a non-200 response from the embeddings endpoint.
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("openai embeddings api error: %d, body: %s", e.StatusCode, e.Body)
+}
+
+// HTTPCode returns the response status, matching googleapi.Error so callers
+// can classify both providers' errors the same way.
+func (e *APIError) HTTPCode() int {
+	return e.StatusCode
+}
+
+// Embedder calls an OpenAI-compatible /embeddings endpoint.
+type Embedder struct {
+	baseURL string
+	apiKey  string
+	model   string
+	client  *http.Client
+}
+
+// NewEmbedder returns an embedder for the API at baseURL, or DefaultBaseURL
+// when it is empty. apiKey may be empty for servers that do not check it.
+func NewEmbedder(baseURL, apiKey, model string) *Embedder {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	if model == "" {
+		model = DefaultModel
+	}
+	return &Embedder{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		apiKey:  apiKey,
+		model:   model,
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Embed embeds text with the embedder's model.
+func (e *Embedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	return e.EmbedWithModel(ctx, e.model, text)
+}
+
+// EmbedWithModel embeds text with the named model.
+func (e *Embedder) EmbedWithModel(ctx context.Context, model, text string) ([]float32, error) {
+	vectors, err := e.embed(ctx, model, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return vectors[0], nil
+}
+
+// EmbedBatch embeds texts in one request. Vectors are returned in input order.
+func (e *Embedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	return e.embed(ctx, e.model, texts)
+}
+
+func (e *Embedder) embed(ctx context.Context, model string, inputs []string) ([][]float32, error) {
+	jsonBody, err := json.Marshal(map[string]interface{}{
+		"model": model,
+		"input": inputs,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", e.baseURL+"/embeddings", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if e.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.apiKey)
+	}
+
+	resp, err := e.client.Do(req) // #nosec G704 -- URL is the operator-configured embedding endpoint
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var bodyBytes bytes.Buffer
+		_, _ = bodyBytes.ReadFrom(resp.Body)
+		return nil, &APIError{StatusCode: resp.StatusCode, Body: bodyBytes.String()}
+	}
+
+	var result struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	if len(result.Data) != len(inputs) {
+		return nil, fmt.Errorf("embedding returned %d vectors for %d texts", len(result.Data), len(inputs))
+	}
+	sort.SliceStable(result.Data, func(i, j int) bool { return result.Data[i].Index < result.Data[j].Index })
+
+	vectors := make([][]float32, len(result.Data))
+	for i, d := range result.Data {
+		if len(d.Embedding) == 0 {
+			return nil, fmt.Errorf("empty embedding received")
+		}
+		vectors[i] = d.Embedding
+	}
+	return vectors, nil
+}
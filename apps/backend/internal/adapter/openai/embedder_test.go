@@ -0,0 +1,83 @@
+package openai_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"qurio/apps/backend/internal/adapter/openai"
+)
+
+func TestEmbedder_EmbedBatch(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/embeddings", r.URL.Path)
+		assert.Equal(t, "Bearer sk-test", r.Header.Get("Authorization"))
+
+		var body struct {
+			Model string   `json:"model"`
+			Input []string `json:"input"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "nomic-embed-text", body.Model)
+		assert.Equal(t, []string{"a", "bb"}, body.Input)
+
+		// Servers may return data out of order; index is authoritative
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"object": "list",
+			"data": []map[string]interface{}{
+				{"object": "embedding", "index": 1, "embedding": []float32{2}},
+				{"object": "embedding", "index": 0, "embedding": []float32{1}},
+			},
+			"model": "nomic-embed-text",
+		})
+	}))
+	defer ts.Close()
+
+	e := openai.NewEmbedder(ts.URL+"/v1/", "sk-test", "nomic-embed-text")
+	vectors, err := e.EmbedBatch(context.Background(), []string{"a", "bb"})
+
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{1}, {2}}, vectors)
+}
+
+func TestEmbedder_EmbedWithModel(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("Authorization"))
+
+		var body struct {
+			Model string `json:"model"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "other-model", body.Model)
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": []map[string]interface{}{{"index": 0, "embedding": []float32{0.1, 0.2}}},
+		})
+	}))
+	defer ts.Close()
+
+	e := openai.NewEmbedder(ts.URL, "", "")
+	vec, err := e.EmbedWithModel(context.Background(), "other-model", "hello")
+
+	require.NoError(t, err)
+	assert.Equal(t, []float32{0.1, 0.2}, vec)
+}
+
+func TestEmbedder_Embed_APIError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":{"message":"slow down"}}`, http.StatusTooManyRequests)
+	}))
+	defer ts.Close()
+
+	_, err := openai.NewEmbedder(ts.URL, "", "").Embed(context.Background(), "hello")
+
+	var apiErr *openai.APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusTooManyRequests, apiErr.HTTPCode())
+	assert.Contains(t, err.Error(), "slow down")
+}
@@ -2,13 +2,24 @@ package weaviate
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"math"
+	"net/http"
+	"slices"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/go-openapi/strfmt"
+	"github.com/google/uuid"
 	"github.com/weaviate/weaviate-go-client/v5/weaviate"
+	"github.com/weaviate/weaviate-go-client/v5/weaviate/fault"
 	"github.com/weaviate/weaviate-go-client/v5/weaviate/filters"
 	"github.com/weaviate/weaviate-go-client/v5/weaviate/graphql"
+	"github.com/weaviate/weaviate/entities/models"
 
 	"qurio/apps/backend/internal/retrieval"
 	"qurio/apps/backend/internal/vector"
@@ -16,13 +27,42 @@ import (
 )
 
 type Store struct {
-	client *weaviate.Client
+	client          *weaviate.Client
+	titlePathBoost  int
+	insertBatchSize int
 }
 
+// defaultInsertBatchSize is how many objects StoreChunks sends per batch
+// request when no size is set.
+const defaultInsertBatchSize = 100
+
 func NewStore(client *weaviate.Client) *Store {
 	return &Store{client: client}
 }
 
+// keywordProperties are the text properties BM25 searches when the keyword
+// side of a hybrid query is weighted explicitly.
+var keywordProperties = []string{"content", "title", "sourceName", "author", "anchorKeywords"}
+
+// SetTitlePathBoost weights title path matches n times in the keyword side of
+// hybrid search. Values of 1 or less leave all text properties equally
+// weighted.
+func (s *Store) SetTitlePathBoost(n int) {
+	s.titlePathBoost = n
+}
+
+// SetInsertBatchSize caps how many objects StoreChunks sends per batch
+// request. Values below 1 restore the default.
+func (s *Store) SetInsertBatchSize(n int) {
+	s.insertBatchSize = n
+}
+
+// Ping checks that Weaviate is reachable by fetching its meta information.
+func (s *Store) Ping(ctx context.Context) error {
+	_, err := s.client.Misc().MetaGetter().Do(ctx)
+	return err
+}
+
 func (s *Store) EnsureSchema(ctx context.Context) error {
 	wAdapter := vector.NewWeaviateClientAdapter(s.client)
 	return vector.EnsureSchema(ctx, wAdapter)
@@ -30,6 +70,141 @@ func (s *Store) EnsureSchema(ctx context.Context) error {
 
 func (s *Store) StoreChunk(ctx context.Context, chunk worker.Chunk) error {
 	slog.DebugContext(ctx, "storing chunk", "source_id", chunk.SourceID, "chunk_index", chunk.ChunkIndex, "url", chunk.SourceURL)
+	properties := chunkProperties(chunk)
+
+	// Re-embedded chunks replace their existing object in place
+	if chunk.ID != "" {
+		updater := s.client.Data().Updater().
+			WithID(chunk.ID).
+			WithClassName("DocumentChunk").
+			WithProperties(properties)
+		if len(chunk.Vector) > 0 {
+			updater = updater.WithVector(chunk.Vector)
+		}
+		if err := updater.Do(ctx); err != nil {
+			slog.ErrorContext(ctx, "failed to replace chunk", "error", err, "source_id", chunk.SourceID, "id", chunk.ID)
+			return err
+		}
+		return nil
+	}
+
+	// New chunks get the same derived ID as on the batch path, so storing one
+	// again after a retry replaces the first copy instead of duplicating it
+	id := chunkObjectID(chunk)
+	creator := s.client.Data().Creator().
+		WithClassName("DocumentChunk").
+		WithID(id).
+		WithProperties(properties)
+	// Keyword-only chunks have no vector and are found through BM25 alone
+	if len(chunk.Vector) > 0 {
+		creator = creator.WithVector(chunk.Vector)
+	}
+
+	_, err := creator.Do(ctx)
+	if alreadyExists(err) {
+		chunk.ID = id
+		return s.StoreChunk(ctx, chunk)
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to store chunk", "error", err, "source_id", chunk.SourceID, "chunk_index", chunk.ChunkIndex)
+	}
+	return err
+}
+
+// alreadyExists reports whether err is Weaviate refusing to create an object
+// whose ID is taken.
+func alreadyExists(err error) bool {
+	var wErr *fault.WeaviateClientError
+	return errors.As(err, &wErr) && wErr.StatusCode == http.StatusUnprocessableEntity && strings.Contains(wErr.Msg, "already exists")
+}
+
+// StoreChunks creates chunks through the batch endpoint, sending at most the
+// insert batch size per request. Chunks that replace an existing object go
+// through StoreChunk instead. Each new object gets an ID derived from its
+// source, URL and chunk index, so storing a chunk again after a retry
+// overwrites it rather than adding a duplicate. Weaviate reports failures per
+// object, so failed chunks are returned as worker.ChunkErrors.
+func (s *Store) StoreChunks(ctx context.Context, chunks []worker.Chunk) error {
+	size := s.insertBatchSize
+	if size < 1 {
+		size = defaultInsertBatchSize
+	}
+
+	errs := worker.ChunkErrors{}
+	var objects []*models.Object
+	var indexes []int
+	for i, chunk := range chunks {
+		if chunk.ID != "" {
+			if err := s.StoreChunk(ctx, chunk); err != nil {
+				errs[i] = err
+			}
+			continue
+		}
+		obj := &models.Object{
+			Class:      "DocumentChunk",
+			ID:         strfmt.UUID(chunkObjectID(chunk)),
+			Properties: chunkProperties(chunk),
+		}
+		// Keyword-only chunks have no vector and are found through BM25 alone
+		if len(chunk.Vector) > 0 {
+			obj.Vector = chunk.Vector
+		}
+		objects = append(objects, obj)
+		indexes = append(indexes, i)
+	}
+
+	for start := 0; start < len(objects); start += size {
+		end := min(start+size, len(objects))
+		batch := objects[start:end]
+		slog.DebugContext(ctx, "storing chunk batch", "count", len(batch))
+		results, err := s.client.Batch().ObjectsBatcher().WithObjects(batch...).Do(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to store chunk batch", "error", err, "count", len(batch))
+			for _, i := range indexes[start:end] {
+				errs[i] = err
+			}
+			continue
+		}
+		for pos, err := range batchErrors(results) {
+			slog.ErrorContext(ctx, "failed to store chunk", "error", err, "id", batch[pos].ID)
+			errs[indexes[start+pos]] = err
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// chunkObjectID derives a stable object ID for a chunk from its source, URL
+// and position on the page.
+func chunkObjectID(chunk worker.Chunk) string {
+	name := fmt.Sprintf("%s\x00%s\x00%d", chunk.SourceID, chunk.SourceURL, chunk.ChunkIndex)
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte(name)).String()
+}
+
+// batchErrors maps the position of each object that failed in a batch
+// response to its error. Results come back in the order objects were sent.
+func batchErrors(results []models.ObjectsGetResponse) map[int]error {
+	errs := map[int]error{}
+	for i, r := range results {
+		if r.Result == nil || r.Result.Errors == nil {
+			continue
+		}
+		for _, e := range r.Result.Errors.Error {
+			if e != nil {
+				errs[i] = fmt.Errorf("batch object %d: %s", i, e.Message)
+				break
+			}
+		}
+	}
+	return errs
+}
+
+// chunkProperties maps a chunk onto DocumentChunk properties, leaving unset
+// optional fields out.
+func chunkProperties(chunk worker.Chunk) map[string]interface{} {
 	properties := map[string]interface{}{
 		"content":    chunk.Content,
 		"url":        chunk.SourceURL,
@@ -58,38 +233,72 @@ func (s *Store) StoreChunk(ctx context.Context, chunk worker.Chunk) error {
 	if chunk.PageCount > 0 {
 		properties["pageCount"] = chunk.PageCount
 	}
-
-	_, err := s.client.Data().Creator().
-		WithClassName("DocumentChunk").
-		WithProperties(properties).
-		WithVector(chunk.Vector).
-		Do(ctx)
-	if err != nil {
-		slog.ErrorContext(ctx, "failed to store chunk", "error", err, "source_id", chunk.SourceID, "chunk_index", chunk.ChunkIndex)
+	if len(chunk.Metadata) > 0 {
+		properties["metadata"] = metadataTags(chunk.Metadata)
 	}
-	return err
+	if chunk.Truncated {
+		properties["truncated"] = true
+	}
+	if chunk.Selector != "" {
+		properties["selector"] = chunk.Selector
+	}
+	if chunk.AliasURL != "" {
+		properties["aliasUrl"] = chunk.AliasURL
+	}
+	if chunk.EmbeddingModel != "" {
+		properties["embeddingModel"] = chunk.EmbeddingModel
+	}
+	if len(chunk.AnchorKeywords) > 0 {
+		properties["anchorKeywords"] = chunk.AnchorKeywords
+	}
+	if chunk.TitlePath != "" {
+		properties["titlePath"] = chunk.TitlePath
+	}
+	if chunk.Heading != "" {
+		properties["heading"] = chunk.Heading
+	}
+	if chunk.Overlap > 0 {
+		properties["overlap"] = chunk.Overlap
+	}
+	return properties
 }
 
+// DeleteChunksByURL deletes a source's chunks for one page: those indexed
+// under url, and those indexed under a canonical URL that were fetched from
+// url.
 func (s *Store) DeleteChunksByURL(ctx context.Context, sourceID, url string) error {
 	_, err := s.client.Batch().ObjectsBatchDeleter().
 		WithClassName("DocumentChunk").
 		WithOutput("minimal").
-		WithWhere(filters.Where().
-			WithOperator(filters.And).
-			WithOperands([]*filters.WhereBuilder{
-				filters.Where().
-					WithPath([]string{"sourceId"}).
-					WithOperator(filters.Equal).
-					WithValueString(sourceID),
-				filters.Where().
-					WithPath([]string{"url"}).
-					WithOperator(filters.Equal).
-					WithValueString(url),
-			})).
+		WithWhere(pageWhere(sourceID, url)).
 		Do(ctx)
 	return err
 }
 
+// pageWhere matches a source's chunks indexed under url or fetched from it.
+func pageWhere(sourceID, url string) *filters.WhereBuilder {
+	return filters.Where().
+		WithOperator(filters.And).
+		WithOperands([]*filters.WhereBuilder{
+			filters.Where().
+				WithPath([]string{"sourceId"}).
+				WithOperator(filters.Equal).
+				WithValueString(sourceID),
+			filters.Where().
+				WithOperator(filters.Or).
+				WithOperands([]*filters.WhereBuilder{
+					filters.Where().
+						WithPath([]string{"url"}).
+						WithOperator(filters.Equal).
+						WithValueString(url),
+					filters.Where().
+						WithPath([]string{"aliasUrl"}).
+						WithOperator(filters.Equal).
+						WithValueString(url),
+				}),
+		})
+}
+
 func (s *Store) DeleteChunksBySourceID(ctx context.Context, sourceID string) error {
 	_, err := s.client.Batch().ObjectsBatchDeleter().
 		WithClassName("DocumentChunk").
@@ -102,12 +311,15 @@ func (s *Store) DeleteChunksBySourceID(ctx context.Context, sourceID string) err
 	return err
 }
 
-func (s *Store) Search(ctx context.Context, query string, vector []float32, alpha float32, limit int, searchFilters map[string]interface{}) ([]retrieval.SearchResult, error) {
-	slog.DebugContext(ctx, "searching vector store", "query", query, "alpha", alpha, "limit", limit)
+func (s *Store) Search(ctx context.Context, query string, vector []float32, alpha float32, limit, offset int, searchFilters map[string]interface{}) ([]retrieval.SearchResult, error) {
+	slog.DebugContext(ctx, "searching vector store", "query", query, "alpha", alpha, "limit", limit, "offset", offset)
 	hybrid := s.client.GraphQL().HybridArgumentBuilder().
 		WithQuery(query).
 		WithVector(vector).
 		WithAlpha(alpha)
+	if s.titlePathBoost > 1 {
+		hybrid = hybrid.WithProperties(append(slices.Clone(keywordProperties), fmt.Sprintf("titlePath^%d", s.titlePathBoost)))
+	}
 
 	fields := []graphql.Field{
 		{Name: "content"},
@@ -121,32 +333,27 @@ func (s *Store) Search(ctx context.Context, query string, vector []float32, alph
 		{Name: "author"},
 		{Name: "createdAt"},
 		{Name: "pageCount"},
-		{Name: "_additional", Fields: []graphql.Field{{Name: "score"}}},
+		{Name: "selector"},
+		{Name: "titlePath"},
+	}
+	additional := []graphql.Field{{Name: "score"}, {Name: "distance"}, {Name: "certainty"}}
+	// Vectors are large; read them back only when the caller reranks by them
+	if retrieval.WantsVectors(ctx) {
+		additional = append(additional, graphql.Field{Name: "vector"})
 	}
+	fields = append(fields, graphql.Field{Name: "_additional", Fields: additional})
 
 	queryBuilder := s.client.GraphQL().Get().
 		WithClassName("DocumentChunk").
 		WithHybrid(hybrid).
 		WithLimit(limit).
 		WithFields(fields...)
+	if offset > 0 {
+		queryBuilder = queryBuilder.WithOffset(offset)
+	}
 
-	if len(searchFilters) > 0 {
-		operands := []*filters.WhereBuilder{}
-		for k, v := range searchFilters {
-			if sVal, ok := v.(string); ok {
-				operands = append(operands, filters.Where().
-					WithPath([]string{k}).
-					WithOperator(filters.Equal).
-					WithValueString(sVal))
-			}
-		}
-
-		if len(operands) > 0 {
-			where := filters.Where().
-				WithOperator(filters.And).
-				WithOperands(operands)
-			queryBuilder = queryBuilder.WithWhere(where)
-		}
+	if where := buildSearchFilter(searchFilters); where != nil {
+		queryBuilder = queryBuilder.WithWhere(where)
 	}
 
 	res, err := queryBuilder.Do(ctx)
@@ -214,16 +421,26 @@ func (s *Store) Search(ctx context.Context, query string, vector []float32, alph
 						result.PageCount = int(pageCount)
 						result.Metadata["pageCount"] = int(pageCount)
 					}
+					if selector, ok := props["selector"].(string); ok && selector != "" {
+						result.Metadata["selector"] = selector
+					}
+					if titlePath, ok := props["titlePath"].(string); ok && titlePath != "" {
+						result.Metadata["titlePath"] = titlePath
+					}
 
-					// Extract score
+					// Extract score, plus distance and certainty when the query
+					// has a vector side that produced them
 					if additional, ok := props["_additional"].(map[string]interface{}); ok {
-						if score, ok := additional["score"].(string); ok {
-							if fScore, err := strconv.ParseFloat(score, 64); err == nil {
-								result.Score = float32(fScore)
-							}
-						} else if score, ok := additional["score"].(float64); ok {
-							result.Score = float32(score)
+						if score, ok := parseAdditionalFloat(additional["score"]); ok {
+							result.Score = score
+						}
+						if distance, ok := parseAdditionalFloat(additional["distance"]); ok {
+							result.Distance = &distance
 						}
+						if certainty, ok := parseAdditionalFloat(additional["certainty"]); ok {
+							result.Certainty = &certainty
+						}
+						result.Vector = parseVector(additional["vector"])
 					}
 
 					results = append(results, result)
@@ -235,18 +452,167 @@ func (s *Store) Search(ctx context.Context, query string, vector []float32, alph
 	return results, nil
 }
 
-func (s *Store) GetChunks(ctx context.Context, sourceID string, limit, offset int) ([]worker.Chunk, error) {
-	fields := []graphql.Field{
-		{Name: "content"},
-		{Name: "url"},
-		{Name: "sourceId"},
-		{Name: "chunkIndex"},
-		{Name: "type"},
-		{Name: "language"},
-		{Name: "title"},
-		{Name: "sourceName"},
+// parseAdditionalFloat reads a numeric _additional value. Weaviate returns
+// some of these as strings and others as numbers depending on the query type.
+func parseAdditionalFloat(raw interface{}) (float32, bool) {
+	switch v := raw.(type) {
+	case float64:
+		return float32(v), true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, false
+		}
+		return float32(f), true
+	}
+	return 0, false
+}
+
+// parseVector converts a GraphQL _additional.vector value. It returns nil for
+// objects stored without a vector.
+func parseVector(raw interface{}) []float32 {
+	vec, ok := raw.([]interface{})
+	if !ok {
+		return nil
+	}
+	out := make([]float32, 0, len(vec))
+	for _, v := range vec {
+		if f, ok := v.(float64); ok {
+			out = append(out, float32(f))
+		}
+	}
+	return out
+}
+
+// buildSearchFilter translates search filters into a Weaviate AND clause.
+// String values are exact matches on the named property; the "metadata" key
+// takes a map of custom source metadata pairs, each of which must be present.
+// Any other map value is read as a range such as {"gte": 0, "lte": 2}.
+func buildSearchFilter(searchFilters map[string]interface{}) *filters.WhereBuilder {
+	operands := []*filters.WhereBuilder{}
+	for k, v := range searchFilters {
+		switch val := v.(type) {
+		case string:
+			operands = append(operands, filters.Where().
+				WithPath([]string{k}).
+				WithOperator(filters.Equal).
+				WithValueString(val))
+		case retrieval.NotIn:
+			for _, excluded := range val {
+				operands = append(operands, filters.Where().
+					WithPath([]string{k}).
+					WithOperator(filters.NotEqual).
+					WithValueString(excluded))
+			}
+		case retrieval.Range:
+			operands = append(operands, rangeOperands(k, val)...)
+		case map[string]interface{}:
+			if k != "metadata" {
+				if r, ok := retrieval.ParseRange(val); ok {
+					operands = append(operands, rangeOperands(k, r)...)
+				}
+				continue
+			}
+			for mk, mv := range val {
+				if sVal, ok := mv.(string); ok {
+					operands = append(operands, filters.Where().
+						WithPath([]string{"metadata"}).
+						WithOperator(filters.ContainsAny).
+						WithValueString(metadataTag(mk, sVal)))
+				}
+			}
+		}
+	}
+
+	if len(operands) == 0 {
+		return nil
+	}
+	return filters.Where().
+		WithOperator(filters.And).
+		WithOperands(operands)
+}
+
+// rangeOperands turns each set bound of r into a comparison on path. Bounds
+// of an unsupported type are skipped; retrieval.ValidateFilters rejects them
+// before a caller's filters reach the store.
+func rangeOperands(path string, r retrieval.Range) []*filters.WhereBuilder {
+	bounds := []struct {
+		op    filters.WhereOperator
+		value interface{}
+	}{
+		{filters.GreaterThan, r.GT},
+		{filters.GreaterThanEqual, r.GTE},
+		{filters.LessThan, r.LT},
+		{filters.LessThanEqual, r.LTE},
 	}
 
+	var operands []*filters.WhereBuilder
+	for _, b := range bounds {
+		if b.value == nil {
+			continue
+		}
+		where := filters.Where().WithPath([]string{path}).WithOperator(b.op)
+		switch v := b.value.(type) {
+		case int:
+			where = where.WithValueInt(int64(v))
+		case int64:
+			where = where.WithValueInt(v)
+		case float64:
+			// JSON numbers arrive as float64; whole ones compare against
+			// int properties such as chunkIndex
+			if v == math.Trunc(v) {
+				where = where.WithValueInt(int64(v))
+			} else {
+				where = where.WithValueNumber(v)
+			}
+		case time.Time:
+			where = where.WithValueDate(v)
+		case string:
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				continue
+			}
+			where = where.WithValueDate(t)
+		default:
+			continue
+		}
+		operands = append(operands, where)
+	}
+	return operands
+}
+
+// metadataTags flattens custom metadata into sorted "key=value" tokens.
+// Weaviate cannot filter on nested object properties, so metadata is stored
+// as a string array and matched token-wise.
+func metadataTags(m map[string]string) []string {
+	tags := make([]string, 0, len(m))
+	for k, v := range m {
+		tags = append(tags, metadataTag(k, v))
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+func metadataTag(key, value string) string {
+	return key + "=" + value
+}
+
+// chunkFields are the properties GetChunks and GetPageChunks read.
+var chunkFields = []graphql.Field{
+	{Name: "content"},
+	{Name: "url"},
+	{Name: "sourceId"},
+	{Name: "chunkIndex"},
+	{Name: "type"},
+	{Name: "language"},
+	{Name: "title"},
+	{Name: "sourceName"},
+	{Name: "selector"},
+	{Name: "heading"},
+	{Name: "overlap"},
+}
+
+func (s *Store) GetChunks(ctx context.Context, sourceID string, limit, offset int) ([]worker.Chunk, error) {
 	where := filters.Where().
 		WithOperator(filters.Equal).
 		WithPath([]string{"sourceId"}).
@@ -257,11 +623,42 @@ func (s *Store) GetChunks(ctx context.Context, sourceID string, limit, offset in
 		WithWhere(where).
 		WithLimit(limit).
 		WithOffset(offset).
-		WithFields(fields...).
+		WithFields(chunkFields...).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return decodeChunks(res)
+}
+
+// maxPageChunks bounds how many chunks GetPageChunks reads for one page. It
+// matches Weaviate's default QUERY_MAXIMUM_RESULTS.
+const maxPageChunks = 10000
+
+// GetPageChunks returns a source's chunks for one page in chunk order: those
+// indexed under url, and those indexed under a canonical URL that were fetched
+// from url.
+func (s *Store) GetPageChunks(ctx context.Context, sourceID, url string) ([]worker.Chunk, error) {
+	res, err := s.client.GraphQL().Get().
+		WithClassName("DocumentChunk").
+		WithWhere(pageWhere(sourceID, url)).
+		WithLimit(maxPageChunks).
+		WithSort(graphql.Sort{Path: []string{"chunkIndex"}, Order: graphql.Asc}).
+		WithFields(chunkFields...).
 		Do(ctx)
 	if err != nil {
 		return nil, err
 	}
+	chunks, err := decodeChunks(res)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(chunks, func(i, j int) bool { return chunks[i].ChunkIndex < chunks[j].ChunkIndex })
+	return chunks, nil
+}
+
+// decodeChunks reads the chunkFields of a DocumentChunk Get response.
+func decodeChunks(res *models.GraphQLResponse) ([]worker.Chunk, error) {
 	if len(res.Errors) > 0 {
 		msg := ""
 		for _, e := range res.Errors {
@@ -300,6 +697,15 @@ func (s *Store) GetChunks(ctx context.Context, sourceID string, limit, offset in
 					if sourceName, ok := props["sourceName"].(string); ok {
 						chunk.SourceName = sourceName
 					}
+					if selector, ok := props["selector"].(string); ok {
+						chunk.Selector = selector
+					}
+					if heading, ok := props["heading"].(string); ok {
+						chunk.Heading = heading
+					}
+					if overlap, ok := props["overlap"].(float64); ok {
+						chunk.Overlap = int(overlap)
+					}
 					chunks = append(chunks, chunk)
 				}
 			}
@@ -308,6 +714,100 @@ func (s *Store) GetChunks(ctx context.Context, sourceID string, limit, offset in
 	return chunks, nil
 }
 
+// ScanChunks returns a page of a source's chunks with their object IDs,
+// vectors and the properties needed to re-embed them.
+func (s *Store) ScanChunks(ctx context.Context, sourceID string, limit, offset int) ([]worker.Chunk, error) {
+	fields := []graphql.Field{
+		{Name: "content"},
+		{Name: "url"},
+		{Name: "sourceId"},
+		{Name: "chunkIndex"},
+		{Name: "type"},
+		{Name: "language"},
+		{Name: "title"},
+		{Name: "sourceName"},
+		{Name: "selector"},
+		{Name: "author"},
+		{Name: "createdAt"},
+		{Name: "pageCount"},
+		{Name: "aliasUrl"},
+		{Name: "anchorKeywords"},
+		{Name: "titlePath"},
+		{Name: "heading"},
+		{Name: "overlap"},
+		{Name: "_additional", Fields: []graphql.Field{{Name: "id"}, {Name: "vector"}}},
+	}
+
+	where := filters.Where().
+		WithOperator(filters.Equal).
+		WithPath([]string{"sourceId"}).
+		WithValueString(sourceID)
+
+	res, err := s.client.GraphQL().Get().
+		WithClassName("DocumentChunk").
+		WithWhere(where).
+		WithLimit(limit).
+		WithOffset(offset).
+		WithFields(fields...).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Errors) > 0 {
+		msg := ""
+		for _, e := range res.Errors {
+			msg += fmt.Sprintf("%s; ", e.Message)
+		}
+		return nil, fmt.Errorf("graphql error: %s", msg)
+	}
+
+	var chunks []worker.Chunk
+	data, _ := res.Data["Get"].(map[string]interface{})
+	rawChunks, _ := data["DocumentChunk"].([]interface{})
+	for _, c := range rawChunks {
+		props, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		chunk := worker.Chunk{}
+		chunk.Content, _ = props["content"].(string)
+		chunk.SourceURL, _ = props["url"].(string)
+		chunk.SourceID, _ = props["sourceId"].(string)
+		if idx, ok := props["chunkIndex"].(float64); ok {
+			chunk.ChunkIndex = int(idx)
+		}
+		chunk.Type, _ = props["type"].(string)
+		chunk.Language, _ = props["language"].(string)
+		chunk.Title, _ = props["title"].(string)
+		chunk.SourceName, _ = props["sourceName"].(string)
+		chunk.Selector, _ = props["selector"].(string)
+		chunk.Author, _ = props["author"].(string)
+		chunk.CreatedAt, _ = props["createdAt"].(string)
+		if pc, ok := props["pageCount"].(float64); ok {
+			chunk.PageCount = int(pc)
+		}
+		chunk.AliasURL, _ = props["aliasUrl"].(string)
+		chunk.TitlePath, _ = props["titlePath"].(string)
+		chunk.Heading, _ = props["heading"].(string)
+		if overlap, ok := props["overlap"].(float64); ok {
+			chunk.Overlap = int(overlap)
+		}
+		if anchors, ok := props["anchorKeywords"].([]interface{}); ok {
+			for _, a := range anchors {
+				if kw, ok := a.(string); ok {
+					chunk.AnchorKeywords = append(chunk.AnchorKeywords, kw)
+				}
+			}
+		}
+		if additional, ok := props["_additional"].(map[string]interface{}); ok {
+			chunk.ID, _ = additional["id"].(string)
+			chunk.Vector = parseVector(additional["vector"])
+		}
+		chunks = append(chunks, chunk)
+	}
+	return chunks, nil
+}
+
 func (s *Store) GetChunksByURL(ctx context.Context, url string) ([]retrieval.SearchResult, error) {
 	fields := []graphql.Field{
 		{Name: "content"},
@@ -321,6 +821,7 @@ func (s *Store) GetChunksByURL(ctx context.Context, url string) ([]retrieval.Sea
 		{Name: "author"},
 		{Name: "createdAt"},
 		{Name: "pageCount"},
+		{Name: "overlap"},
 	}
 
 	where := filters.Where().
@@ -396,11 +897,22 @@ func (s *Store) GetChunksByURL(ctx context.Context, url string) ([]retrieval.Sea
 						result.PageCount = int(pageCount)
 						result.Metadata["pageCount"] = int(pageCount)
 					}
+					if overlap, ok := props["overlap"].(float64); ok {
+						result.Overlap = int(overlap)
+					}
 					results = append(results, result)
 				}
 			}
 		}
 	}
+
+	// The query already sorts, but page reconstruction depends on chunk order,
+	// so don't trust the server with it.
+	sort.SliceStable(results, func(i, j int) bool {
+		a, _ := results[i].Metadata["chunkIndex"].(int)
+		b, _ := results[j].Metadata["chunkIndex"].(int)
+		return a < b
+	})
 	return results, nil
 }
 
@@ -475,3 +987,65 @@ func (s *Store) CountChunksBySource(ctx context.Context, sourceID string) (int,
 	}
 	return 0, nil
 }
+
+// facetProperties are the chunk properties Facets groups by.
+var facetProperties = []string{"type", "language", "sourceId"}
+
+// Facets returns the distinct values of each facet property with the number
+// of chunks holding that value, keyed by property name.
+func (s *Store) Facets(ctx context.Context) (map[string]map[string]int, error) {
+	facets := make(map[string]map[string]int, len(facetProperties))
+	for _, prop := range facetProperties {
+		counts, err := s.facetCounts(ctx, prop)
+		if err != nil {
+			return nil, fmt.Errorf("facet %s: %w", prop, err)
+		}
+		facets[prop] = counts
+	}
+	return facets, nil
+}
+
+func (s *Store) facetCounts(ctx context.Context, prop string) (map[string]int, error) {
+	meta, err := s.client.GraphQL().Aggregate().
+		WithClassName("DocumentChunk").
+		WithGroupBy(prop).
+		WithFields(
+			graphql.Field{
+				Name:   "groupedBy",
+				Fields: []graphql.Field{{Name: "value"}},
+			},
+			graphql.Field{
+				Name:   "meta",
+				Fields: []graphql.Field{{Name: "count"}},
+			},
+		).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(meta.Errors) > 0 {
+		return nil, fmt.Errorf("graphql error: %v", meta.Errors)
+	}
+
+	counts := make(map[string]int)
+	if data, ok := meta.Data["Aggregate"].(map[string]interface{}); ok {
+		if groups, ok := data["DocumentChunk"].([]interface{}); ok {
+			for _, g := range groups {
+				group, ok := g.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				groupedBy, _ := group["groupedBy"].(map[string]interface{})
+				value, ok := groupedBy["value"].(string)
+				if !ok || value == "" {
+					continue
+				}
+				metaStats, _ := group["meta"].(map[string]interface{})
+				if count, ok := metaStats["count"].(float64); ok {
+					counts[value] = int(count)
+				}
+			}
+		}
+	}
+	return counts, nil
+}
@@ -45,7 +45,7 @@ func TestWeaviateStore_Integration(t *testing.T) {
 	require.NoError(t, err)
 
 	// Verify existence via Search
-	res, err := store.Search(ctx, "Postgres", nil, 0.0, 10, nil)
+	res, err := store.Search(ctx, "Postgres", nil, 0.0, 10, 0, nil)
 	require.NoError(t, err)
 	assert.NotEmpty(t, res)
 	assert.Equal(t, "Postgres is a database", res[0].Content)
@@ -55,7 +55,7 @@ func TestWeaviateStore_Integration(t *testing.T) {
 	require.NoError(t, err)
 
 	// Verify deletion
-	res, err = store.Search(ctx, "Postgres", nil, 0.0, 10, nil)
+	res, err = store.Search(ctx, "Postgres", nil, 0.0, 10, 0, nil)
 	require.NoError(t, err)
 	assert.Empty(t, res)
 
@@ -68,7 +68,7 @@ func TestWeaviateStore_Integration(t *testing.T) {
 	require.NoError(t, err)
 
 	// Search for "Postgres" with keyword preference (alpha 0.0)
-	res, err = store.Search(ctx, "Postgres", []float32{0.1, 0.1, 0.1}, 0.0, 10, nil)
+	res, err = store.Search(ctx, "Postgres", []float32{0.1, 0.1, 0.1}, 0.0, 10, 0, nil)
 	require.NoError(t, err)
 	require.NotEmpty(t, res)
 	assert.Equal(t, "Postgres", res[0].Content)
@@ -76,7 +76,7 @@ func TestWeaviateStore_Integration(t *testing.T) {
 
 	// Search with filter (Type=pdf)
 	filters := map[string]interface{}{"type": "pdf"}
-	res, err = store.Search(ctx, "Databases", []float32{0.2, 0.2, 0.2}, 0.5, 10, filters)
+	res, err = store.Search(ctx, "Databases", []float32{0.2, 0.2, 0.2}, 0.5, 10, 0, filters)
 	require.NoError(t, err)
 	require.NotEmpty(t, res)
 	assert.Equal(t, "Databases", res[0].Content)
@@ -3,12 +3,16 @@ package weaviate
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/weaviate/weaviate-go-client/v5/weaviate"
+	"qurio/apps/backend/internal/retrieval"
 	"qurio/apps/backend/internal/worker"
 )
 
@@ -47,7 +51,10 @@ func newMockWeaviateServer(t *testing.T, checkFunc func(r *http.Request, body ma
 								"content":  "hello world",
 								"sourceId": "src-1",
 								"_additional": map[string]interface{}{
-									"score": "0.95",
+									"score":     "0.95",
+									"distance":  "0.12",
+									"certainty": 0.94,
+									"vector":    []interface{}{0.5, 0.25},
 								},
 							},
 						},
@@ -69,6 +76,10 @@ func newMockWeaviateServer(t *testing.T, checkFunc func(r *http.Request, body ma
 
 		if r.URL.Path == "/v1/batch/objects" {
 			w.WriteHeader(http.StatusOK)
+			if r.Method == http.MethodPost {
+				json.NewEncoder(w).Encode([]interface{}{}) // Batch create returns one result per object
+				return
+			}
 			json.NewEncoder(w).Encode(map[string]interface{}{}) // Batch delete returns object
 			return
 		}
@@ -90,9 +101,15 @@ func newTestStore(t *testing.T, server *httptest.Server) *Store {
 // --- Tests ---
 
 func TestStore_StoreChunk(t *testing.T) {
+	chunk := worker.Chunk{
+		Content:   "hello",
+		SourceID:  "src-1",
+		SourceURL: "http://a",
+	}
 	server := newMockWeaviateServer(t, func(r *http.Request, body map[string]interface{}) {
 		assert.Equal(t, "/v1/objects", r.URL.Path)
 		assert.Equal(t, "DocumentChunk", body["class"])
+		assert.Equal(t, chunkObjectID(chunk), body["id"])
 		props := body["properties"].(map[string]interface{})
 		assert.Equal(t, "hello", props["content"])
 		assert.Equal(t, "src-1", props["sourceId"])
@@ -101,17 +118,45 @@ func TestStore_StoreChunk(t *testing.T) {
 
 	store := newTestStore(t, server)
 
-	err := store.StoreChunk(context.Background(), worker.Chunk{
-		Content:  "hello",
-		SourceID: "src-1",
-	})
+	err := store.StoreChunk(context.Background(), chunk)
+	assert.NoError(t, err)
+}
+
+func TestStore_StoreChunk_RetryReplacesFirstCopy(t *testing.T) {
+	chunk := worker.Chunk{Content: "hello", SourceID: "src-1", SourceURL: "http://a", ChunkIndex: 2}
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/meta" {
+			json.NewEncoder(w).Encode(map[string]interface{}{"version": "1.19.0"})
+			return
+		}
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"error": []interface{}{map[string]interface{}{"message": "id '" + chunkObjectID(chunk) + "' already exists"}},
+			})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"class": "DocumentChunk", "id": chunkObjectID(chunk)})
+	}))
+	defer server.Close()
+
+	store := newTestStore(t, server)
+
+	err := store.StoreChunk(context.Background(), chunk)
 	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"POST /v1/objects",
+		"PUT /v1/objects/DocumentChunk/" + chunkObjectID(chunk),
+	}, requests)
 }
 
 func TestStore_Search(t *testing.T) {
+	var query string
 	server := newMockWeaviateServer(t, func(r *http.Request, body map[string]interface{}) {
 		assert.Equal(t, "/v1/graphql", r.URL.Path)
-		query := body["query"].(string)
+		query = body["query"].(string)
 		// Relaxed checks
 		assert.Contains(t, query, "Get")
 		assert.Contains(t, query, "DocumentChunk")
@@ -121,10 +166,71 @@ func TestStore_Search(t *testing.T) {
 
 	store := newTestStore(t, server)
 
-	results, err := store.Search(context.Background(), "test", nil, 0.5, 10, nil)
+	results, err := store.Search(context.Background(), "test", nil, 0.5, 10, 0, nil)
 	assert.NoError(t, err)
 	assert.Len(t, results, 1)
 	assert.Equal(t, "hello world", results[0].Content)
+	assert.Contains(t, query, "_additional{score distance certainty}")
+
+	t.Run("WithVectors reads stored vectors", func(t *testing.T) {
+		results, err := store.Search(retrieval.WithVectors(context.Background()), "test", nil, 0.5, 10, 0, nil)
+		assert.NoError(t, err)
+		assert.Contains(t, query, "_additional{score distance certainty vector}")
+		assert.Equal(t, []float32{0.5, 0.25}, results[0].Vector)
+	})
+}
+
+func TestStore_Search_DistanceAndCertainty(t *testing.T) {
+	server := newMockWeaviateServer(t, func(r *http.Request, body map[string]interface{}) {
+		query := body["query"].(string)
+		assert.Contains(t, query, "distance")
+		assert.Contains(t, query, "certainty")
+	})
+	defer server.Close()
+
+	store := newTestStore(t, server)
+
+	results, err := store.Search(context.Background(), "test", []float32{0.1}, 1, 10, 0, nil)
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+	assert.InDelta(t, 0.95, results[0].Score, 1e-6)
+	// Certainty arrives as a number, distance as a string
+	if assert.NotNil(t, results[0].Certainty) {
+		assert.InDelta(t, 0.94, *results[0].Certainty, 1e-6)
+	}
+	if assert.NotNil(t, results[0].Distance) {
+		assert.InDelta(t, 0.12, *results[0].Distance, 1e-6)
+	}
+}
+
+func TestStore_Search_Offset(t *testing.T) {
+	tests := []struct {
+		name   string
+		offset int
+		want   string
+	}{
+		{"first page", 0, ""},
+		{"later page", 20, "offset: 20"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newMockWeaviateServer(t, func(r *http.Request, body map[string]interface{}) {
+				query := body["query"].(string)
+				if tt.want == "" {
+					assert.NotContains(t, query, "offset")
+				} else {
+					assert.Contains(t, query, tt.want)
+				}
+			})
+			defer server.Close()
+
+			store := newTestStore(t, server)
+
+			_, err := store.Search(context.Background(), "test", nil, 0.5, 10, tt.offset, nil)
+			assert.NoError(t, err)
+		})
+	}
 }
 
 func TestStore_DeleteChunksBySourceID(t *testing.T) {
@@ -144,6 +250,36 @@ func TestStore_DeleteChunksBySourceID(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestStore_DeleteChunksByURL_MatchesAlias(t *testing.T) {
+	// A page indexed under its canonical URL keeps the fetched URL as its
+	// alias, so a later gone result for the fetched URL must still match it
+	var where map[string]interface{}
+	server := newMockWeaviateServer(t, func(r *http.Request, body map[string]interface{}) {
+		assert.Equal(t, "/v1/batch/objects", r.URL.Path)
+		assert.Equal(t, "DELETE", r.Method)
+		where = body["match"].(map[string]interface{})["where"].(map[string]interface{})
+	})
+	defer server.Close()
+
+	store := newTestStore(t, server)
+
+	err := store.DeleteChunksByURL(context.Background(), "src-1", "http://example.com/old")
+	assert.NoError(t, err)
+
+	operands := where["operands"].([]interface{})
+	if assert.Len(t, operands, 2) {
+		page := operands[1].(map[string]interface{})
+		assert.Equal(t, "Or", page["operator"])
+		var paths []interface{}
+		for _, o := range page["operands"].([]interface{}) {
+			operand := o.(map[string]interface{})
+			paths = append(paths, operand["path"].([]interface{})[0])
+			assert.Equal(t, "http://example.com/old", operand["valueString"])
+		}
+		assert.Equal(t, []interface{}{"url", "aliasUrl"}, paths)
+	}
+}
+
 func TestStore_Search_NetworkError(t *testing.T) {
 	// 1. Start a server that always fails
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -160,7 +296,7 @@ func TestStore_Search_NetworkError(t *testing.T) {
 	store := NewStore(client)
 
 	// 3. Call Search
-	_, err := store.Search(context.Background(), "test", []float32{0.1}, 0.5, 10, nil)
+	_, err := store.Search(context.Background(), "test", []float32{0.1}, 0.5, 10, 0, nil)
 
 	// 4. Expect Error
 	assert.Error(t, err)
@@ -188,7 +324,7 @@ func TestStore_Search_GraphQLError(t *testing.T) {
 	defer server.Close()
 
 	store := newTestStore(t, server)
-	_, err := store.Search(context.Background(), "test", nil, 0.5, 10, nil)
+	_, err := store.Search(context.Background(), "test", nil, 0.5, 10, 0, nil)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "syntax error")
 }
@@ -208,6 +344,24 @@ func TestStore_GetChunks(t *testing.T) {
 	assert.Equal(t, "src-1", chunks[0].SourceID)
 }
 
+func TestStore_GetPageChunks(t *testing.T) {
+	var query string
+	server := newMockWeaviateServer(t, func(r *http.Request, body map[string]interface{}) {
+		query, _ = body["query"].(string)
+	})
+	defer server.Close()
+
+	store := newTestStore(t, server)
+
+	chunks, err := store.GetPageChunks(context.Background(), "src-1", "http://example.com/a")
+	assert.NoError(t, err)
+	assert.Len(t, chunks, 1)
+	// Chunks indexed under a canonical URL are found by the URL they came from
+	assert.Contains(t, query, `path: ["aliasUrl"]`)
+	assert.Contains(t, query, `path: ["sourceId"]`)
+	assert.NotContains(t, query, "offset")
+}
+
 func TestStore_GetChunksByURL(t *testing.T) {
 	server := newMockWeaviateServer(t, func(r *http.Request, body map[string]interface{}) {
 		assert.Equal(t, "/v1/graphql", r.URL.Path)
@@ -221,3 +375,450 @@ func TestStore_GetChunksByURL(t *testing.T) {
 	assert.Len(t, results, 1)
 	assert.Equal(t, "hello world", results[0].Content)
 }
+
+func TestStore_GetChunksByURL_SortsByChunkIndex(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/meta" {
+			json.NewEncoder(w).Encode(map[string]interface{}{"version": "1.19.0"})
+			return
+		}
+		chunks := []interface{}{}
+		for _, idx := range []int{2, 0, 1} {
+			chunks = append(chunks, map[string]interface{}{
+				"content":    fmt.Sprintf("part %d", idx),
+				"url":        "http://example.com",
+				"chunkIndex": idx,
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"Get": map[string]interface{}{"DocumentChunk": chunks},
+			},
+		})
+	}))
+	defer server.Close()
+
+	store := newTestStore(t, server)
+
+	results, err := store.GetChunksByURL(context.Background(), "http://example.com")
+	assert.NoError(t, err)
+	assert.Len(t, results, 3)
+	for i, res := range results {
+		assert.Equal(t, fmt.Sprintf("part %d", i), res.Content)
+		assert.Equal(t, i, res.Metadata["chunkIndex"])
+	}
+}
+
+func TestStore_StoreChunk_Metadata(t *testing.T) {
+	server := newMockWeaviateServer(t, func(r *http.Request, body map[string]interface{}) {
+		props := body["properties"].(map[string]interface{})
+		assert.Equal(t, []interface{}{"product=billing", "team=payments"}, props["metadata"])
+	})
+	defer server.Close()
+
+	store := newTestStore(t, server)
+
+	err := store.StoreChunk(context.Background(), worker.Chunk{
+		Content:  "hello",
+		SourceID: "src-1",
+		Metadata: map[string]string{"team": "payments", "product": "billing"},
+	})
+	assert.NoError(t, err)
+}
+
+func TestStore_StoreChunk_Selector(t *testing.T) {
+	server := newMockWeaviateServer(t, func(r *http.Request, body map[string]interface{}) {
+		props := body["properties"].(map[string]interface{})
+		assert.Equal(t, "main > article", props["selector"])
+	})
+	defer server.Close()
+
+	store := newTestStore(t, server)
+
+	err := store.StoreChunk(context.Background(), worker.Chunk{
+		Content:  "hello",
+		SourceID: "src-1",
+		Selector: "main > article",
+	})
+	assert.NoError(t, err)
+}
+
+func TestStore_StoreChunk_EmbeddingModel(t *testing.T) {
+	server := newMockWeaviateServer(t, func(r *http.Request, body map[string]interface{}) {
+		props := body["properties"].(map[string]interface{})
+		assert.Equal(t, "text-embedding-004", props["embeddingModel"])
+	})
+	defer server.Close()
+
+	store := newTestStore(t, server)
+
+	err := store.StoreChunk(context.Background(), worker.Chunk{
+		Content:        "hello",
+		SourceID:       "src-1",
+		EmbeddingModel: "text-embedding-004",
+	})
+	assert.NoError(t, err)
+}
+
+func TestStore_StoreChunk_AnchorKeywords(t *testing.T) {
+	server := newMockWeaviateServer(t, func(r *http.Request, body map[string]interface{}) {
+		props := body["properties"].(map[string]interface{})
+		assert.Equal(t, []interface{}{"Configure webhooks"}, props["anchorKeywords"])
+	})
+	defer server.Close()
+
+	store := newTestStore(t, server)
+
+	err := store.StoreChunk(context.Background(), worker.Chunk{
+		Content:        "hello",
+		SourceID:       "src-1",
+		AnchorKeywords: []string{"Configure webhooks"},
+	})
+	assert.NoError(t, err)
+}
+
+func TestStore_StoreChunk_TitlePath(t *testing.T) {
+	server := newMockWeaviateServer(t, func(r *http.Request, body map[string]interface{}) {
+		props := body["properties"].(map[string]interface{})
+		assert.Equal(t, "Guides > Auth > Webhooks", props["titlePath"])
+	})
+	defer server.Close()
+
+	store := newTestStore(t, server)
+
+	err := store.StoreChunk(context.Background(), worker.Chunk{
+		Content:   "hello",
+		SourceID:  "src-1",
+		TitlePath: "Guides > Auth > Webhooks",
+	})
+	assert.NoError(t, err)
+}
+
+func TestStore_Search_TitlePathBoost(t *testing.T) {
+	server := newMockWeaviateServer(t, func(r *http.Request, body map[string]interface{}) {
+		query := body["query"].(string)
+		assert.Contains(t, query, `"titlePath^3"`)
+		assert.Contains(t, query, `"content"`)
+	})
+	defer server.Close()
+
+	store := newTestStore(t, server)
+	store.SetTitlePathBoost(3)
+
+	_, err := store.Search(context.Background(), "webhooks", nil, 0.5, 10, 0, nil)
+	assert.NoError(t, err)
+}
+
+func TestStore_Search_MetadataFilter(t *testing.T) {
+	server := newMockWeaviateServer(t, func(r *http.Request, body map[string]interface{}) {
+		query := body["query"].(string)
+		assert.Contains(t, query, "ContainsAny")
+		assert.Contains(t, query, `path: ["metadata"]`)
+		assert.Contains(t, query, "team=payments")
+	})
+	defer server.Close()
+
+	store := newTestStore(t, server)
+
+	_, err := store.Search(context.Background(), "test", nil, 0.5, 10, 0, map[string]interface{}{
+		"metadata": map[string]interface{}{"team": "payments"},
+	})
+	assert.NoError(t, err)
+}
+
+func TestStore_Search_TypeAndLanguageFilter(t *testing.T) {
+	server := newMockWeaviateServer(t, func(r *http.Request, body map[string]interface{}) {
+		query := body["query"].(string)
+		assert.Contains(t, query, "hybrid")
+		assert.Contains(t, query, "operator: And")
+		assert.Contains(t, query, `path: ["type"]`)
+		assert.Contains(t, query, `valueString: "code"`)
+		assert.Contains(t, query, `path: ["language"]`)
+		assert.Contains(t, query, `valueString: "go"`)
+	})
+	defer server.Close()
+
+	store := newTestStore(t, server)
+
+	_, err := store.Search(context.Background(), "test", nil, 0.5, 10, 0, map[string]interface{}{
+		"type":     "code",
+		"language": "go",
+	})
+	assert.NoError(t, err)
+}
+
+func TestBuildSearchFilter_Range(t *testing.T) {
+	// Filters decoded from JSON carry float64 bounds
+	where := buildSearchFilter(map[string]interface{}{
+		"chunkIndex": map[string]interface{}{"gte": 0.0, "lte": 2.0},
+	}).Build()
+
+	assert.Equal(t, "And", where.Operator)
+	if assert.Len(t, where.Operands, 2) {
+		ops := map[string]int64{}
+		for _, op := range where.Operands {
+			assert.Equal(t, []string{"chunkIndex"}, op.Path)
+			if assert.NotNil(t, op.ValueInt) {
+				ops[op.Operator] = *op.ValueInt
+			}
+		}
+		assert.Equal(t, map[string]int64{"GreaterThanEqual": 0, "LessThanEqual": 2}, ops)
+	}
+
+	t.Run("date bounds", func(t *testing.T) {
+		where := buildSearchFilter(map[string]interface{}{
+			"createdAt": retrieval.Range{GT: "2024-01-01T00:00:00Z"},
+		}).Build()
+		if assert.Len(t, where.Operands, 1) {
+			assert.Equal(t, "GreaterThan", where.Operands[0].Operator)
+			assert.NotNil(t, where.Operands[0].ValueDate)
+		}
+	})
+
+	t.Run("unknown operator is ignored", func(t *testing.T) {
+		assert.Nil(t, buildSearchFilter(map[string]interface{}{
+			"chunkIndex": map[string]interface{}{"between": 1.0},
+		}))
+	})
+}
+
+func TestStore_Search_RangeFilter(t *testing.T) {
+	server := newMockWeaviateServer(t, func(r *http.Request, body map[string]interface{}) {
+		query := body["query"].(string)
+		assert.Contains(t, query, "GreaterThanEqual")
+		assert.Contains(t, query, "LessThanEqual")
+		assert.Contains(t, query, `path: ["chunkIndex"]`)
+		assert.Contains(t, query, "valueInt: 2")
+	})
+	defer server.Close()
+
+	store := newTestStore(t, server)
+
+	_, err := store.Search(context.Background(), "test", nil, 0.5, 10, 0, map[string]interface{}{
+		"chunkIndex": map[string]interface{}{"gte": 0.0, "lte": 2.0},
+	})
+	assert.NoError(t, err)
+}
+
+func TestStore_Search_NotInFilter(t *testing.T) {
+	server := newMockWeaviateServer(t, func(r *http.Request, body map[string]interface{}) {
+		query := body["query"].(string)
+		assert.Contains(t, query, "NotEqual")
+		assert.Contains(t, query, `path: ["type"]`)
+		assert.Contains(t, query, `valueString: "config"`)
+	})
+	defer server.Close()
+
+	store := newTestStore(t, server)
+
+	_, err := store.Search(context.Background(), "test", nil, 0.5, 10, 0, map[string]interface{}{
+		"type": retrieval.NotIn{"config"},
+	})
+	assert.NoError(t, err)
+}
+
+func TestStore_StoreChunk_WithoutVector(t *testing.T) {
+	server := newMockWeaviateServer(t, func(r *http.Request, body map[string]interface{}) {
+		_, hasVector := body["vector"]
+		assert.False(t, hasVector, "keyword-only chunk should not send a vector")
+	})
+	defer server.Close()
+
+	store := newTestStore(t, server)
+
+	err := store.StoreChunk(context.Background(), worker.Chunk{
+		Content:  "make build",
+		SourceID: "src-1",
+		Type:     "cmd",
+	})
+	assert.NoError(t, err)
+}
+
+func TestStore_StoreChunk_WithIDReplacesObject(t *testing.T) {
+	var method, path string
+	server := newMockWeaviateServer(t, func(r *http.Request, body map[string]interface{}) {
+		method, path = r.Method, r.URL.Path
+		assert.NotEmpty(t, body["vector"])
+	})
+	defer server.Close()
+
+	store := newTestStore(t, server)
+
+	err := store.StoreChunk(context.Background(), worker.Chunk{
+		ID:       "7b3c1a52-8a43-4c43-9d4f-2f0a1b8e6c11",
+		Content:  "hello",
+		SourceID: "src-1",
+		Vector:   []float32{0.1, 0.2},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.MethodPut, method)
+	assert.Equal(t, "/v1/objects/DocumentChunk/7b3c1a52-8a43-4c43-9d4f-2f0a1b8e6c11", path)
+}
+
+func TestStore_Facets_ParsesGroupedAggregates(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/meta" {
+			json.NewEncoder(w).Encode(map[string]interface{}{"version": "1.19.0"})
+			return
+		}
+		var body struct {
+			Query string `json:"query"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+
+		groups := []interface{}{}
+		if strings.Contains(body.Query, `groupBy: "language"`) {
+			groups = []interface{}{
+				map[string]interface{}{
+					"groupedBy": map[string]interface{}{"value": "go"},
+					"meta":      map[string]interface{}{"count": 7},
+				},
+				map[string]interface{}{
+					"groupedBy": map[string]interface{}{"value": "python"},
+					"meta":      map[string]interface{}{"count": 3},
+				},
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"Aggregate": map[string]interface{}{"DocumentChunk": groups},
+			},
+		})
+	}))
+	defer server.Close()
+
+	store := newTestStore(t, server)
+
+	facets, err := store.Facets(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"go": 7, "python": 3}, facets["language"])
+	assert.Empty(t, facets["type"])
+	assert.Contains(t, facets, "sourceId")
+}
+
+func TestStore_CountChunksBySource_ParsesFilteredCount(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/meta" {
+			json.NewEncoder(w).Encode(map[string]interface{}{"version": "1.19.0"})
+			return
+		}
+		var body struct {
+			Query string `json:"query"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		assert.Contains(t, body.Query, "Aggregate")
+		assert.Contains(t, body.Query, `path: ["sourceId"]`)
+		assert.Contains(t, body.Query, `valueString: "src-1"`)
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"Aggregate": map[string]interface{}{"DocumentChunk": []interface{}{
+					map[string]interface{}{"meta": map[string]interface{}{"count": 42}},
+				}},
+			},
+		})
+	}))
+	defer server.Close()
+
+	store := newTestStore(t, server)
+
+	count, err := store.CountChunksBySource(context.Background(), "src-1")
+	assert.NoError(t, err)
+	assert.Equal(t, 42, count)
+}
+
+func TestStore_StoreChunks_SingleBatchRequest(t *testing.T) {
+	var requests int
+	var objects []interface{}
+	server := newMockWeaviateServer(t, func(r *http.Request, body map[string]interface{}) {
+		requests++
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/v1/batch/objects", r.URL.Path)
+		objects, _ = body["objects"].([]interface{})
+	})
+	defer server.Close()
+
+	store := newTestStore(t, server)
+	err := store.StoreChunks(context.Background(), []worker.Chunk{
+		{Content: "one", SourceID: "src-1", SourceURL: "http://a", ChunkIndex: 0, Vector: []float32{0.1}},
+		{Content: "two", SourceID: "src-1", SourceURL: "http://a", ChunkIndex: 1, Vector: []float32{0.2}},
+		{Content: "three", SourceID: "src-1", SourceURL: "http://a", ChunkIndex: 2},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, requests)
+	if assert.Len(t, objects, 3) {
+		first := objects[0].(map[string]interface{})
+		assert.Equal(t, "DocumentChunk", first["class"])
+		assert.Equal(t, "one", first["properties"].(map[string]interface{})["content"])
+		assert.NotContains(t, objects[2].(map[string]interface{}), "vector")
+		assert.NotEqual(t, first["id"], objects[1].(map[string]interface{})["id"])
+	}
+}
+
+func TestStore_StoreChunks_StableObjectIDs(t *testing.T) {
+	var ids []interface{}
+	server := newMockWeaviateServer(t, func(r *http.Request, body map[string]interface{}) {
+		objects, _ := body["objects"].([]interface{})
+		for _, o := range objects {
+			ids = append(ids, o.(map[string]interface{})["id"])
+		}
+	})
+	defer server.Close()
+
+	store := newTestStore(t, server)
+	chunk := worker.Chunk{Content: "one", SourceID: "src-1", SourceURL: "http://a", ChunkIndex: 4}
+
+	// Storing the same chunk again, as a redelivery would, reuses its ID
+	assert.NoError(t, store.StoreChunks(context.Background(), []worker.Chunk{chunk}))
+	chunk.Content = "one, again"
+	assert.NoError(t, store.StoreChunks(context.Background(), []worker.Chunk{chunk}))
+
+	require.Len(t, ids, 2)
+	assert.NotEmpty(t, ids[0])
+	assert.Equal(t, ids[0], ids[1])
+}
+
+func TestStore_StoreChunks_SplitsAndReportsObjectErrors(t *testing.T) {
+	var sizes []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/batch/objects" {
+			return
+		}
+		var body struct {
+			Objects []map[string]interface{} `json:"objects"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		sizes = append(sizes, len(body.Objects))
+
+		// The second batch fails on one object
+		results := make([]map[string]interface{}, len(body.Objects))
+		for i := range results {
+			results[i] = map[string]interface{}{"class": "DocumentChunk"}
+		}
+		if len(sizes) == 2 {
+			results[0]["result"] = map[string]interface{}{
+				"errors": map[string]interface{}{"error": []map[string]string{{"message": "vector length mismatch"}}},
+			}
+		}
+		json.NewEncoder(w).Encode(results)
+	}))
+	defer server.Close()
+
+	store := newTestStore(t, server)
+	store.SetInsertBatchSize(2)
+	chunks := make([]worker.Chunk, 3)
+	for i := range chunks {
+		chunks[i] = worker.Chunk{Content: fmt.Sprintf("c%d", i), SourceID: "src-1", ChunkIndex: i}
+	}
+
+	err := store.StoreChunks(context.Background(), chunks)
+	assert.ErrorContains(t, err, "vector length mismatch")
+	assert.Equal(t, []int{2, 1}, sizes)
+
+	// Only the chunk that failed is reported
+	var chunkErrs worker.ChunkErrors
+	require.ErrorAs(t, err, &chunkErrs)
+	assert.Len(t, chunkErrs, 1)
+	assert.Contains(t, chunkErrs, 2)
+}
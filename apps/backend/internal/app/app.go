@@ -7,6 +7,9 @@ import (
 	"log/slog"
 	"net/http"
 	"os"
+	"regexp"
+	"slices"
+	"sync/atomic"
 	"time"
 
 	"qurio/apps/backend/features/job"
@@ -19,17 +22,44 @@ import (
 	"qurio/apps/backend/internal/middleware"
 	"qurio/apps/backend/internal/retrieval"
 	"qurio/apps/backend/internal/settings"
+	"qurio/apps/backend/internal/text"
 	"qurio/apps/backend/internal/worker"
+
+	"github.com/nsqio/go-nsq"
 )
 
 type App struct {
 	cfg              *config.Config
 	Handler          http.Handler
 	SourceService    *source.Service
+	JobService       *job.Service
 	ResultConsumer   *worker.ResultConsumer
 	EmbedderConsumer *worker.EmbedderConsumer
+
+	vecStore  VectorStore
+	ready     *atomic.Bool
+	consumers []Consumer
 }
 
+// Consumer is a message consumer the App stops on shutdown. Done is closed
+// once its in-flight messages have been handled.
+type Consumer interface {
+	Stop()
+	Done() <-chan int
+}
+
+// nsqConsumer adapts *nsq.Consumer, whose stop signal is a field.
+type nsqConsumer struct {
+	*nsq.Consumer
+}
+
+func (c nsqConsumer) Done() <-chan int {
+	return c.StopChan
+}
+
+// defaultShutdownTimeout bounds consumer draining when no timeout is set.
+const defaultShutdownTimeout = 30 * time.Second
+
 type Options struct {
 	Embedder retrieval.Embedder
 	Reranker retrieval.Reranker
@@ -105,9 +135,15 @@ func New(
 	}
 	sourceHandler := source.NewHandler(sourceService, uploadDir, cfg.MaxUploadSizeMB)
 
+	// Ingestion events shared between the result consumer and the SSE stream
+	eventBus := worker.NewEventBus()
+	eventBus.SetMaxSubscribers(cfg.MaxSSESessions)
+	sourceHandler.SetEventBus(eventBus)
+
 	// Feature: Job
 	jobRepo := job.NewPostgresRepo(sqlDB)
 	jobService := job.NewService(jobRepo, taskPub, logger)
+	jobService.SetAutoRetry(cfg.JobRetryMaxAttempts, time.Duration(cfg.JobRetryBaseSeconds)*time.Second)
 	jobHandler := job.NewHandler(jobService)
 
 	// Feature: Stats
@@ -118,7 +154,19 @@ func New(
 	if opts != nil && opts.Embedder != nil {
 		geminiEmbedder = opts.Embedder
 	} else {
-		geminiEmbedder = gemini.NewDynamicEmbedder(settingsService)
+		dynamic := gemini.NewDynamicEmbedder(settingsService)
+		dynamic.SetRetry(cfg.EmbedRetryAttempts, time.Duration(cfg.EmbedRetryBaseMs)*time.Millisecond)
+		geminiEmbedder = dynamic
+	}
+	if cfg.EmbedRPM > 0 {
+		geminiEmbedder = gemini.NewRateLimitedEmbedder(geminiEmbedder, cfg.EmbedRPM)
+	}
+	sourceService.SetChunkImport(vecStore, geminiEmbedder)
+	sourceService.SetChunkScanner(vecStore)
+	sourceService.SetSitemapFetcher(source.NewHTTPSitemapFetcher())
+	sourceService.SetEmbeddingModels(cfg.SourceEmbeddingModels)
+	if cfg.CrawlHostRPS > 0 {
+		sourceService.SetHostRPS(cfg.CrawlHostRPS)
 	}
 
 	var rerankerClient retrieval.Reranker
@@ -143,24 +191,48 @@ func New(
 		}
 	}
 
+	// Middleware: Readiness gate for routes that query the vector store
+	ready := &atomic.Bool{}
+	requireReady := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !ready.Load() {
+				w.Header().Set("Retry-After", "1")
+				http.Error(w, "service not ready", http.StatusServiceUnavailable)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+
 	// Routes
 	mux := http.NewServeMux()
 
 	mux.Handle("POST /sources", middleware.CorrelationID(enableCORS(sourceHandler.Create)))
+	mux.Handle("POST /sources/config/test", middleware.CorrelationID(enableCORS(sourceHandler.TestConfig)))
 	mux.Handle("POST /sources/upload", middleware.CorrelationID(enableCORS(sourceHandler.Upload)))
 	mux.Handle("GET /sources", middleware.CorrelationID(enableCORS(sourceHandler.List)))
 	mux.Handle("GET /sources/{id}", middleware.CorrelationID(enableCORS(sourceHandler.Get)))
+	mux.Handle("PUT /sources/{id}", middleware.CorrelationID(enableCORS(sourceHandler.UpdateConfig)))
 	mux.Handle("DELETE /sources/{id}", middleware.CorrelationID(enableCORS(sourceHandler.Delete)))
 	mux.Handle("POST /sources/{id}/resync", middleware.CorrelationID(enableCORS(sourceHandler.ReSync)))
+	mux.Handle("POST /sources/{id}/pause", middleware.CorrelationID(enableCORS(sourceHandler.Pause)))
+	mux.Handle("POST /sources/{id}/resume", middleware.CorrelationID(enableCORS(sourceHandler.Resume)))
+	mux.Handle("POST /sources/{id}/import", middleware.CorrelationID(enableCORS(sourceHandler.Import)))
+	mux.Handle("GET /sources/{id}/export", middleware.CorrelationID(enableCORS(sourceHandler.Export)))
+	mux.Handle("POST /sources/{id}/reembed-missing", middleware.CorrelationID(enableCORS(sourceHandler.ReembedMissing)))
 	mux.Handle("GET /sources/{id}/pages", middleware.CorrelationID(enableCORS(sourceHandler.GetPages)))
+	mux.Handle("GET /sources/{id}/events", middleware.CorrelationID(enableCORS(sourceHandler.Events)))
+	mux.Handle("GET /admin/pages/errors", middleware.CorrelationID(enableCORS(sourceHandler.PageErrors)))
 
 	mux.Handle("GET /settings", middleware.CorrelationID(enableCORS(settingsHandler.GetSettings)))
 	mux.Handle("PUT /settings", middleware.CorrelationID(enableCORS(settingsHandler.UpdateSettings)))
 
 	mux.Handle("GET /jobs/failed", middleware.CorrelationID(enableCORS(jobHandler.List)))
 	mux.Handle("POST /jobs/{id}/retry", middleware.CorrelationID(enableCORS(jobHandler.Retry)))
+	mux.Handle("POST /jobs/retry-all", middleware.CorrelationID(enableCORS(jobHandler.RetryAll)))
 
 	mux.Handle("GET /stats", middleware.CorrelationID(enableCORS(statsHandler.GetStats)))
+	mux.Handle("GET /facets", middleware.CorrelationID(enableCORS(statsHandler.GetFacets)))
 
 	// Feature: Retrieval & MCP
 	queryLogger, err := retrieval.NewFileQueryLogger(cfg.QueryLogPath)
@@ -170,18 +242,24 @@ func New(
 	}
 
 	retrievalService := retrieval.NewService(geminiEmbedder, vecStore, rerankerClient, settingsService, queryLogger)
+	retrievalService.SetRerankCandidates(cfg.RerankCandidates)
+	retrievalService.SetMinAlpha(cfg.MinAlpha)
+	retrievalService.SetDefaultExcludedTypes(cfg.SearchExcludeTypes)
+	retrievalService.SetSourceModels(&sourceModelAdapter{repo: sourceRepo})
+	retrievalService.SetOverrideModels(cfg.SourceEmbeddingModels)
+	retrievalService.SetQueryCache(cfg.QueryCacheSize, time.Duration(cfg.QueryCacheTTL)*time.Second)
 	mcpHandler := mcp.NewHandler(retrievalService, sourceService)
+	mcpHandler.SetProfile(cfg.MCPProfile)
+	mcpHandler.SetMaxSearchChars(cfg.SearchMaxChars)
 
 	// Unified Endpoint (Streaming)
-	mux.Handle("/mcp", middleware.CorrelationID(enableCORS(mcpHandler.ServeHTTP)))
+	mux.Handle("/mcp", requireReady(middleware.CorrelationID(enableCORS(mcpHandler.ServeHTTP))))
+	// Same endpoint with qurio_search scoped to one source
+	mux.Handle("/mcp/sources/{id}", requireReady(middleware.CorrelationID(enableCORS(mcpHandler.ServeHTTP))))
 
-	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		if _, err := w.Write([]byte(`{"status":"ok"}`)); err != nil {
-			slog.Error("failed to write health response", "error", err)
-		}
-	})
+	// Readiness checks dependencies; liveness only the process
+	mux.HandleFunc("/health", healthHandler(db, vecStore, taskPub))
+	mux.HandleFunc("/health/live", liveHandler)
 
 	// Worker (Result Consumer) Setup
 	sfAdapter := &sourceFetcherAdapter{repo: sourceRepo, settings: settingsService}
@@ -189,20 +267,126 @@ func New(
 
 	resultConsumer := worker.NewResultConsumer(vecStore, sourceRepo, jobRepo, sfAdapter, pmAdapter, taskPub)
 
+	resultConsumer.SetPruneGonePages(cfg.PruneGonePages)
+	resultConsumer.SetPreferCanonical(cfg.PreferCanonical)
+	resultConsumer.SetMergeAdjacentCode(cfg.MergeAdjacentCode)
+	resultConsumer.SetMarkPartialFailures(cfg.MarkPartialFailures)
+	resultConsumer.SetAnchorKeywords(cfg.IndexAnchorKeywords)
+	resultConsumer.SetTitlePath(cfg.StoreTitlePath)
+	resultConsumer.SetFallbackTitle(cfg.FallbackTitle)
+	resultConsumer.SetMaxResultAttempts(cfg.ResultMaxAttempts)
+	resultConsumer.SetMaxMessageAttempts(cfg.MaxMessageAttempts)
+	resultConsumer.SetNormalizeHash(cfg.NormalizeHash)
+	resultConsumer.SetSkipUnchangedPages(cfg.SkipUnchangedPages)
+	if cfg.HashIgnorePattern != "" {
+		re, err := regexp.Compile(cfg.HashIgnorePattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid HASH_IGNORE_PATTERN: %w", err)
+		}
+		resultConsumer.SetHashVolatilePatterns(append(slices.Clone(text.DefaultVolatilePatterns), re))
+	}
+	resultConsumer.SetEnqueueDedupWindow(time.Duration(cfg.EnqueueDedupSeconds) * time.Second)
+	if cfg.CrawlHostRPS > 0 {
+		resultConsumer.SetHostLimiter(worker.NewHostRateLimiter(cfg.CrawlHostRPS))
+	}
+	if cfg.RespectRobotsTxt {
+		robots := worker.NewHTTPRobotsChecker(cfg.RobotsUserAgent)
+		robots.SetTimeout(time.Duration(cfg.RobotsTimeoutMs) * time.Millisecond)
+		resultConsumer.SetRobotsChecker(robots)
+	}
+	resultConsumer.SetEventBus(eventBus)
+	resultConsumer.SetMinPageTokensToSplit(cfg.MinPageTokensToSplit)
+	resultConsumer.SetKeepLists(cfg.ChunkKeepLists)
+
+	// One limiter shared by both consumers so the cap applies across the whole pipeline
+	var sourceLimiter *worker.SourceLimiter
+	if cfg.MaxConcurrentSources > 0 {
+		sourceLimiter = worker.NewSourceLimiter(cfg.MaxConcurrentSources)
+		resultConsumer.SetSourceLimiter(sourceLimiter)
+	}
+
 	var embedderConsumer *worker.EmbedderConsumer
 	if cfg.EnableEmbedderWorker {
 		embedderConsumer = worker.NewEmbedderConsumer(geminiEmbedder, vecStore)
+		embedderConsumer.SetMaxInputTokens(cfg.EmbedMaxInputTokens)
+		embedderConsumer.SetEmbedBatch(cfg.EmbedBatchSize, time.Duration(cfg.EmbedBatchWaitMs)*time.Millisecond)
+		embedderConsumer.SetStoreBatch(cfg.StoreBatchSize, time.Duration(cfg.StoreBatchWaitMs)*time.Millisecond)
+		embedderConsumer.SetDeadLetter(taskPub, cfg.MaxMessageAttempts)
+		embedderConsumer.SetPageHashRecorder(pmAdapter)
+		if dp, ok := taskPub.(worker.DeferredPublisher); ok {
+			embedderConsumer.SetRequeuePublisher(dp)
+		}
+		if sourceLimiter != nil {
+			embedderConsumer.SetSourceLimiter(sourceLimiter)
+		}
+		if cfg.EmbedPauseThreshold > 0 {
+			embedBackoff := worker.NewEmbedBackoff(cfg.EmbedPauseThreshold, time.Duration(cfg.EmbedPauseSeconds)*time.Second)
+			embedderConsumer.SetEmbedBackoff(embedBackoff)
+			sourceService.SetEmbedPauses(embedBackoff)
+		}
 	}
 
 	return &App{
 		cfg:              cfg,
 		Handler:          mux,
 		SourceService:    sourceService,
+		JobService:       jobService,
 		ResultConsumer:   resultConsumer,
 		EmbedderConsumer: embedderConsumer,
+		vecStore:         vecStore,
+		ready:            ready,
 	}, nil
 }
 
+// AwaitSchema ensures the vector schema exists and then opens the readiness
+// gate. Until it succeeds, search routes respond with 503.
+func (a *App) AwaitSchema(ctx context.Context) error {
+	attempts := a.cfg.BootstrapRetryAttempts
+	if attempts <= 0 {
+		attempts = 1
+	}
+	delay := time.Duration(a.cfg.BootstrapRetryDelaySeconds) * time.Second
+	if err := EnsureSchemaWithRetry(ctx, a.vecStore, attempts, delay); err != nil {
+		return fmt.Errorf("schema not ready: %w", err)
+	}
+	a.ready.Store(true)
+	slog.Info("schema ready, accepting search traffic")
+	return nil
+}
+
+// Ready reports whether the readiness gate is open.
+func (a *App) Ready() bool {
+	return a.ready.Load()
+}
+
+// AddConsumer registers an NSQ consumer to be stopped on shutdown.
+func (a *App) AddConsumer(c *nsq.Consumer) {
+	a.consumers = append(a.consumers, nsqConsumer{c})
+}
+
+// StopConsumers stops every registered consumer and waits for their
+// in-flight messages, giving up once the shutdown timeout has passed.
+// Messages still unfinished then are redelivered by NSQ after restart.
+func (a *App) StopConsumers() {
+	timeout := time.Duration(a.cfg.ShutdownTimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+	for _, c := range a.consumers {
+		c.Stop()
+	}
+	deadline := time.After(timeout)
+	for _, c := range a.consumers {
+		select {
+		case <-c.Done():
+		case <-deadline:
+			slog.Warn("timed out waiting for consumers to stop", "timeout", timeout)
+			return
+		}
+	}
+	slog.Info("consumers stopped")
+}
+
 func (a *App) Run(ctx context.Context) error {
 	addr := fmt.Sprintf(":%d", a.cfg.ServerPort)
 	srv := &http.Server{
@@ -213,6 +397,9 @@ func (a *App) Run(ctx context.Context) error {
 
 	go func() {
 		<-ctx.Done()
+		// Drain consumers first so in-flight messages finish while the
+		// server and its dependencies are still up
+		a.StopConsumers()
 		slog.Info("shutting down server...")
 		if err := srv.Shutdown(context.Background()); err != nil {
 			slog.Error("server shutdown failed", "error", err)
@@ -255,6 +442,43 @@ func (a *sourceFetcherAdapter) GetSourceConfig(ctx context.Context, id string) (
 	return s.MaxDepth, s.Exclusions, apiKey, s.Name, nil
 }
 
+func (a *sourceFetcherAdapter) GetSourceOptions(ctx context.Context, id string) (*worker.SourceOptions, error) {
+	s, err := a.repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	opts := &worker.SourceOptions{
+		Metadata:         s.Metadata,
+		EmbedTitlePrefix: s.EmbedTitlePrefix,
+		CrawlDelay:       time.Duration(s.CrawlDelayMs) * time.Millisecond,
+		KeywordOnlyTypes: s.KeywordOnlyTypes,
+		EmbeddingModel:   s.EmbeddingModel,
+		CrawlSubdomains:  s.CrawlSubdomains,
+		Paused:           s.Status == "paused",
+	}
+	if s.RestrictToSeedPath {
+		opts.SeedPathPrefix = s.SeedPathPrefix
+		// Sources saved before the prefix was stored derive it from their URL
+		if opts.SeedPathPrefix == "" {
+			opts.SeedPathPrefix = worker.SeedPathPrefix(s.URL)
+		}
+	}
+	return opts, nil
+}
+
+// Adapter for retrieval.SourceModelResolver
+type sourceModelAdapter struct {
+	repo source.Repository
+}
+
+func (a *sourceModelAdapter) EmbeddingModel(ctx context.Context, id string) (string, error) {
+	s, err := a.repo.Get(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	return s.EmbeddingModel, nil
+}
+
 // Adapter for PageManager
 type pageManagerAdapter struct {
 	repo source.Repository
@@ -278,6 +502,30 @@ func (a *pageManagerAdapter) UpdatePageStatus(ctx context.Context, sourceID, url
 	return a.repo.UpdatePageStatus(ctx, sourceID, url, status, err)
 }
 
+func (a *pageManagerAdapter) RecordPageFetch(ctx context.Context, sourceID, url string, statusCode, fetchMs int) error {
+	return a.repo.RecordPageFetch(ctx, sourceID, url, statusCode, fetchMs)
+}
+
+func (a *pageManagerAdapter) GetPageHash(ctx context.Context, sourceID, url string) (string, error) {
+	return a.repo.GetPageHash(ctx, sourceID, url)
+}
+
+func (a *pageManagerAdapter) UpdatePageHash(ctx context.Context, sourceID, url, hash string) error {
+	return a.repo.UpdatePageHash(ctx, sourceID, url, hash)
+}
+
+func (a *pageManagerAdapter) BeginPageHash(ctx context.Context, sourceID, url, hash string, chunks int) error {
+	return a.repo.BeginPageHash(ctx, sourceID, url, hash, chunks)
+}
+
+func (a *pageManagerAdapter) RecordStoredChunk(ctx context.Context, sourceID, url, hash string) error {
+	return a.repo.RecordStoredChunk(ctx, sourceID, url, hash)
+}
+
 func (a *pageManagerAdapter) CountPendingPages(ctx context.Context, sourceID string) (int, error) {
 	return a.repo.CountPendingPages(ctx, sourceID)
 }
+
+func (a *pageManagerAdapter) CountFailedPages(ctx context.Context, sourceID string) (int, error) {
+	return a.repo.CountFailedPages(ctx, sourceID)
+}
@@ -7,7 +7,10 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
@@ -63,6 +66,55 @@ func TestNew_Success(t *testing.T) {
 	}
 }
 
+func TestNew_ReadinessGate(t *testing.T) {
+	db, _, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	application, err := New(&config.Config{}, db, &MockVectorStore{}, &MockTaskPublisher{}, logger, nil)
+	require.NoError(t, err)
+
+	ts := httptest.NewServer(application.Handler)
+	defer ts.Close()
+
+	postMCP := func() int {
+		body := `{"jsonrpc":"2.0","method":"initialize","id":1}`
+		resp, err := http.Post(ts.URL+"/mcp", "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// Before readiness: search routes are gated, health is not
+	assert.False(t, application.Ready())
+	assert.Equal(t, http.StatusServiceUnavailable, postMCP())
+
+	resp, err := http.Get(ts.URL + "/health")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// After readiness
+	require.NoError(t, application.AwaitSchema(context.Background()))
+	assert.True(t, application.Ready())
+	assert.Equal(t, http.StatusOK, postMCP())
+}
+
+func TestAwaitSchema_Failure(t *testing.T) {
+	db, _, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	mockVec := &MockVectorStore{EnsureSchemaErr: assert.AnError}
+	application, err := New(&config.Config{}, db, mockVec, &MockTaskPublisher{}, logger, nil)
+	require.NoError(t, err)
+
+	assert.Error(t, application.AwaitSchema(context.Background()))
+	assert.False(t, application.Ready())
+}
+
 type FakeDB struct{}
 
 func (f *FakeDB) PingContext(ctx context.Context) error { return nil }
@@ -93,3 +145,45 @@ func TestNew_PanicsOnInvalidDB(t *testing.T) {
 		_, _ = New(cfg, fakeDB, mockVec, mockPub, logger, nil)
 	})
 }
+
+// fakeConsumer records Stop and finishes draining immediately.
+type fakeConsumer struct {
+	stopped atomic.Bool
+	done    chan int
+}
+
+func (c *fakeConsumer) Stop() {
+	if c.stopped.CompareAndSwap(false, true) {
+		close(c.done)
+	}
+}
+
+func (c *fakeConsumer) Done() <-chan int {
+	return c.done
+}
+
+func TestRun_StopsConsumersOnShutdown(t *testing.T) {
+	db, _, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	application, err := New(&config.Config{ServerPort: 0}, db, &MockVectorStore{}, &MockTaskPublisher{}, logger, nil)
+	require.NoError(t, err)
+
+	consumer := &fakeConsumer{done: make(chan int)}
+	application.consumers = append(application.consumers, consumer)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- application.Run(ctx) }()
+
+	cancel()
+	select {
+	case err := <-errCh:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after cancel")
+	}
+	assert.True(t, consumer.stopped.Load())
+}
@@ -72,6 +72,8 @@ func Bootstrap(ctx context.Context, cfg *config.Config) (*Dependencies, error) {
 		return nil, fmt.Errorf("weaviate client error: %w", err)
 	}
 	vecStore := wstore.NewStore(wClient)
+	vecStore.SetTitlePathBoost(cfg.TitlePathBoost)
+	vecStore.SetInsertBatchSize(cfg.StoreBatchSize)
 
 	// Ensure Schema Retry
 	if err := EnsureSchemaWithRetry(ctx, vecStore, cfg.BootstrapRetryAttempts, retryDelay); err != nil {
@@ -115,6 +117,7 @@ func createTopics(nsqdHTTP string) {
 		create(config.TopicIngestFile)
 		create(config.TopicIngestResult)
 		create(config.TopicIngestEmbed)
+		create(config.TopicIngestDeadLetter)
 	}()
 }
 
@@ -0,0 +1,77 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// healthCheckTimeout bounds each dependency check of the readiness probe.
+const healthCheckTimeout = 2 * time.Second
+
+// vectorPinger is implemented by vector stores that can report whether the
+// database behind them is reachable.
+type vectorPinger interface {
+	Ping(ctx context.Context) error
+}
+
+// queuePinger is implemented by publishers that can check their connection,
+// such as *nsq.Producer.
+type queuePinger interface {
+	Ping() error
+}
+
+type healthResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+// healthHandler checks each dependency and answers 503 with a per-dependency
+// status map when any of them is down. Dependencies that cannot be pinged are
+// left out of the report.
+func healthHandler(db Database, vecStore VectorStore, taskPub TaskPublisher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		checks := map[string]func(ctx context.Context) error{
+			"database": db.PingContext,
+		}
+		if p, ok := vecStore.(vectorPinger); ok {
+			checks["weaviate"] = p.Ping
+		}
+		if p, ok := taskPub.(queuePinger); ok {
+			checks["nsq"] = func(context.Context) error { return p.Ping() }
+		}
+
+		resp := healthResponse{Status: "ok", Checks: make(map[string]string, len(checks))}
+		status := http.StatusOK
+		for name, check := range checks {
+			ctx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
+			err := check(ctx)
+			cancel()
+			if err != nil {
+				slog.WarnContext(r.Context(), "health check failed", "dependency", name, "error", err)
+				resp.Checks[name] = "error: " + err.Error()
+				resp.Status = "degraded"
+				status = http.StatusServiceUnavailable
+				continue
+			}
+			resp.Checks[name] = "ok"
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			slog.Error("failed to write health response", "error", err)
+		}
+	}
+}
+
+// liveHandler reports only that the process is serving requests.
+func liveHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write([]byte(`{"status":"ok"}`)); err != nil {
+		slog.Error("failed to write health response", "error", err)
+	}
+}
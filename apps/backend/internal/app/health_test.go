@@ -0,0 +1,98 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pingableVectorStore adds a configurable Ping to MockVectorStore.
+type pingableVectorStore struct {
+	MockVectorStore
+	PingErr error
+}
+
+func (m *pingableVectorStore) Ping(ctx context.Context) error {
+	return m.PingErr
+}
+
+// pingablePublisher adds a configurable Ping to MockTaskPublisher.
+type pingablePublisher struct {
+	MockTaskPublisher
+	PingErr error
+}
+
+func (m *pingablePublisher) Ping() error {
+	return m.PingErr
+}
+
+func serveHealth(t *testing.T, h http.HandlerFunc) (int, healthResponse) {
+	w := httptest.NewRecorder()
+	h(w, httptest.NewRequest("GET", "/health", nil))
+	var resp healthResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	return w.Code, resp
+}
+
+func TestHealthHandler_AllHealthy(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	require.NoError(t, err)
+	defer db.Close()
+	mock.ExpectPing()
+
+	code, resp := serveHealth(t, healthHandler(db, &pingableVectorStore{}, &pingablePublisher{}))
+
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok", resp.Status)
+	assert.Equal(t, map[string]string{"database": "ok", "weaviate": "ok", "nsq": "ok"}, resp.Checks)
+}
+
+func TestHealthHandler_FailingDependency(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	require.NoError(t, err)
+	defer db.Close()
+	mock.ExpectPing()
+
+	vec := &pingableVectorStore{PingErr: errors.New("connection refused")}
+	code, resp := serveHealth(t, healthHandler(db, vec, &pingablePublisher{}))
+
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "degraded", resp.Status)
+	assert.Equal(t, "ok", resp.Checks["database"])
+	assert.Equal(t, "ok", resp.Checks["nsq"])
+	assert.Contains(t, resp.Checks["weaviate"], "connection refused")
+}
+
+func TestHealthHandler_SkipsDependenciesWithoutPing(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	require.NoError(t, err)
+	defer db.Close()
+	mock.ExpectPing().WillReturnError(errors.New("db down"))
+
+	code, resp := serveHealth(t, healthHandler(db, &MockVectorStore{}, &MockTaskPublisher{}))
+
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, []string{"database"}, keys(resp.Checks))
+}
+
+func TestLiveHandler(t *testing.T) {
+	w := httptest.NewRecorder()
+	liveHandler(w, httptest.NewRequest("GET", "/health/live", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"status":"ok"}`, w.Body.String())
+}
+
+func keys(m map[string]string) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	return out
+}
@@ -22,11 +22,14 @@ type VectorStore interface {
 	StoreChunk(ctx context.Context, chunk worker.Chunk) error
 	DeleteChunksByURL(ctx context.Context, sourceID, url string) error
 	DeleteChunksBySourceID(ctx context.Context, sourceID string) error
-	Search(ctx context.Context, query string, vector []float32, alpha float32, limit int, searchFilters map[string]interface{}) ([]retrieval.SearchResult, error)
+	Search(ctx context.Context, query string, vector []float32, alpha float32, limit, offset int, searchFilters map[string]interface{}) ([]retrieval.SearchResult, error)
 	GetChunks(ctx context.Context, sourceID string, limit, offset int) ([]worker.Chunk, error)
+	GetPageChunks(ctx context.Context, sourceID, url string) ([]worker.Chunk, error)
+	ScanChunks(ctx context.Context, sourceID string, limit, offset int) ([]worker.Chunk, error)
 	GetChunksByURL(ctx context.Context, url string) ([]retrieval.SearchResult, error)
 	CountChunks(ctx context.Context) (int, error)
 	CountChunksBySource(ctx context.Context, sourceID string) (int, error)
+	Facets(ctx context.Context) (map[string]map[string]int, error)
 	EnsureSchema(ctx context.Context) error
 }
 
@@ -20,6 +20,8 @@ type MockVectorStore struct {
 	GetChunksByURLErr    error
 	CountChunksRes       int
 	CountChunksErr       error
+	FacetsRes            map[string]map[string]int
+	FacetsErr            error
 	EnsureSchemaErr      error
 }
 
@@ -35,7 +37,7 @@ func (m *MockVectorStore) DeleteChunksBySourceID(ctx context.Context, sourceID s
 	return m.DeleteChunksErr
 }
 
-func (m *MockVectorStore) Search(ctx context.Context, query string, vector []float32, alpha float32, limit int, searchFilters map[string]interface{}) ([]retrieval.SearchResult, error) {
+func (m *MockVectorStore) Search(ctx context.Context, query string, vector []float32, alpha float32, limit, offset int, searchFilters map[string]interface{}) ([]retrieval.SearchResult, error) {
 	return m.SearchRes, m.SearchErr
 }
 
@@ -43,6 +45,14 @@ func (m *MockVectorStore) GetChunks(ctx context.Context, sourceID string, limit,
 	return m.GetChunksRes, m.GetChunksErr
 }
 
+func (m *MockVectorStore) GetPageChunks(ctx context.Context, sourceID, url string) ([]worker.Chunk, error) {
+	return m.GetChunksRes, m.GetChunksErr
+}
+
+func (m *MockVectorStore) ScanChunks(ctx context.Context, sourceID string, limit, offset int) ([]worker.Chunk, error) {
+	return m.GetChunksRes, m.GetChunksErr
+}
+
 func (m *MockVectorStore) CountChunksBySource(ctx context.Context, sourceID string) (int, error) {
 	return 0, nil
 }
@@ -55,6 +65,10 @@ func (m *MockVectorStore) CountChunks(ctx context.Context) (int, error) {
 	return m.CountChunksRes, m.CountChunksErr
 }
 
+func (m *MockVectorStore) Facets(ctx context.Context) (map[string]map[string]int, error) {
+	return m.FacetsRes, m.FacetsErr
+}
+
 func (m *MockVectorStore) EnsureSchema(ctx context.Context) error {
 	return m.EnsureSchemaErr
 }
@@ -30,11 +30,60 @@ type Config struct {
 	EnableAPI            bool   `envconfig:"ENABLE_API" default:"true"`
 	EnableEmbedderWorker bool   `envconfig:"ENABLE_EMBEDDER_WORKER" default:"false"`
 	IngestionConcurrency int    `envconfig:"INGESTION_CONCURRENCY" default:"50"`
+	MaxConcurrentSources int    `envconfig:"MAX_CONCURRENT_SOURCES" default:"0"` // 0 = unlimited
+	PruneGonePages       bool   `envconfig:"PRUNE_GONE_PAGES" default:"true"`
+	PreferCanonical      bool   `envconfig:"PREFER_CANONICAL" default:"true"`
+	MergeAdjacentCode    bool   `envconfig:"MERGE_ADJACENT_CODE" default:"false"`
+	MarkPartialFailures  bool   `envconfig:"MARK_PARTIAL_FAILURES" default:"true"`
+	IndexAnchorKeywords  bool   `envconfig:"INDEX_ANCHOR_KEYWORDS" default:"false"`
+	StoreTitlePath       bool   `envconfig:"STORE_TITLE_PATH" default:"false"`
+	FallbackTitle        bool   `envconfig:"FALLBACK_TITLE" default:"true"`
+	TitlePathBoost       int    `envconfig:"TITLE_PATH_BOOST" default:"0"` // BM25 weight for titlePath; <= 1 = unweighted
+	NormalizeHash        bool   `envconfig:"NORMALIZE_HASH" default:"true"`
+	SkipUnchangedPages   bool   `envconfig:"SKIP_UNCHANGED_PAGES" default:"true"`
+	HashIgnorePattern    string `envconfig:"HASH_IGNORE_PATTERN"`                  // extra volatile regex, added to the defaults
+	EnqueueDedupSeconds  int    `envconfig:"ENQUEUE_DEDUP_SECONDS" default:"10"`   // 0 = disabled
+	ResultMaxAttempts    int    `envconfig:"RESULT_MAX_ATTEMPTS" default:"5"`      // 0 = retry transient store errors forever
+	MaxMessageAttempts   int    `envconfig:"MAX_MESSAGE_ATTEMPTS" default:"10"`    // 0 = requeue failing messages forever
+	JobRetryMaxAttempts  int    `envconfig:"JOB_RETRY_MAX_ATTEMPTS" default:"3"`   // 0 = failed jobs wait for a manual retry
+	JobRetryBaseSeconds  int    `envconfig:"JOB_RETRY_BASE_SECONDS" default:"60"`  // doubled after each attempt
+	MaxSSESessions       int    `envconfig:"MAX_SSE_SESSIONS" default:"100"`       // 0 = unlimited
+	MinPageTokensToSplit int    `envconfig:"MIN_PAGE_TOKENS_TO_SPLIT" default:"0"` // 0 = always split
+	ChunkKeepLists       bool   `envconfig:"CHUNK_KEEP_LISTS" default:"true"`
+	EmbedMaxInputTokens  int    `envconfig:"EMBED_MAX_INPUT_TOKENS" default:"2048"` // 0 = no truncation
+	EmbedRPM             int    `envconfig:"EMBED_RPM" default:"0"`                 // 0 = unlimited
+	EmbedBatchSize       int    `envconfig:"EMBED_BATCH_SIZE" default:"100"`        // chunks per embedding call; < 2 = unbatched
+	EmbedBatchWaitMs     int    `envconfig:"EMBED_BATCH_WAIT_MS" default:"200"`     // max wait for a batch to fill
+	StoreBatchSize       int    `envconfig:"STORE_BATCH_SIZE" default:"100"`        // chunks per vector store write; < 2 = unbatched
+	StoreBatchWaitMs     int    `envconfig:"STORE_BATCH_WAIT_MS" default:"200"`     // max wait for a batch to fill
+	EmbedRetryAttempts   int    `envconfig:"EMBED_RETRY_ATTEMPTS" default:"3"`      // per Gemini call, on 429/5xx; 1 = no retry
+	EmbedRetryBaseMs     int    `envconfig:"EMBED_RETRY_BASE_MS" default:"1000"`    // doubled after each failed attempt
+	EmbedPauseThreshold  int    `envconfig:"EMBED_PAUSE_THRESHOLD" default:"5"`     // 0 = never pause
+	EmbedPauseSeconds    int    `envconfig:"EMBED_PAUSE_SECONDS" default:"60"`
 	MigrationPath        string `envconfig:"MIGRATION_PATH" default:"file://migrations"`
 	GeminiAPIKey         string `envconfig:"GEMINI_API_KEY"`
 	RerankAPIKey         string `envconfig:"RERANK_API_KEY"`
+	RerankCandidates     int    `envconfig:"RERANK_CANDIDATES" default:"0"`       // 0 = rerank all
 	NSQMaxMsgSize        int64  `envconfig:"NSQ_MAX_MSG_SIZE" default:"10485760"` // 10MB
 
+	// Crawl politeness
+	RespectRobotsTxt bool    `envconfig:"RESPECT_ROBOTS_TXT" default:"true"`
+	RobotsUserAgent  string  `envconfig:"ROBOTS_USER_AGENT" default:"Qurio"` // matched against robots.txt User-agent lines
+	RobotsTimeoutMs  int     `envconfig:"ROBOTS_TIMEOUT_MS" default:"5000"`  // per robots.txt fetch; on timeout all paths are allowed
+	CrawlHostRPS     float64 `envconfig:"CRAWL_HOST_RPS" default:"2"`        // crawl tasks per second per host; 0 = unlimited
+
+	// Search
+	MinAlpha           float32  `envconfig:"MIN_ALPHA" default:"0"`               // 0 = no floor
+	QueryCacheSize     int      `envconfig:"QUERY_CACHE_SIZE" default:"512"`      // cached query embeddings; 0 = disabled
+	QueryCacheTTL      int      `envconfig:"QUERY_CACHE_TTL_SECONDS" default:"0"` // 0 = no expiry
+	MCPProfile         string   `envconfig:"MCP_PROFILE" default:"general"`       // general, code or api
+	SearchMaxChars     int      `envconfig:"SEARCH_MAX_CHARS" default:"40000"`    // total qurio_search output; 0 = unlimited
+	SearchExcludeTypes []string `envconfig:"SEARCH_EXCLUDE_TYPES"`                // e.g. "config,cmd"; a type filter overrides
+
+	// Per-source embedding models. Each must produce vectors of the default
+	// model's dimension; empty disables overrides.
+	SourceEmbeddingModels []string `envconfig:"SOURCE_EMBEDDING_MODELS"`
+
 	// Server
 	ServerPort      int    `envconfig:"SERVER_PORT" default:"8081"`
 	QueryLogPath    string `envconfig:"QUERY_LOG_PATH" default:"data/logs/query.log"`
@@ -44,6 +93,7 @@ type Config struct {
 	// Resilience
 	BootstrapRetryAttempts     int `envconfig:"BOOTSTRAP_RETRY_ATTEMPTS" default:"10"`
 	BootstrapRetryDelaySeconds int `envconfig:"BOOTSTRAP_RETRY_DELAY_SECONDS" default:"2"`
+	ShutdownTimeoutSeconds     int `envconfig:"SHUTDOWN_TIMEOUT_SECONDS" default:"30"` // max wait for consumers to drain
 }
 
 func Load() (*Config, error) {
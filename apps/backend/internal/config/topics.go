@@ -12,4 +12,8 @@ const (
 
 	// TopicIngestEmbed is the NSQ topic for embedding generation tasks.
 	TopicIngestEmbed = "ingest.embed"
+
+	// TopicIngestDeadLetter is the NSQ topic for messages that exhausted their
+	// attempts or could not be decoded.
+	TopicIngestDeadLetter = "ingest.deadletter"
 )
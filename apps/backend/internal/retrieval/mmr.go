@@ -0,0 +1,75 @@
+package retrieval
+
+import "math"
+
+// mmrCandidateFactor is how many hybrid results per requested result MMR
+// chooses from.
+const mmrCandidateFactor = 3
+
+// applyMMR orders docs by Maximal Marginal Relevance: each pick
+// maximises lambda*relevance - (1-lambda)*similarity, where relevance is the
+// search score scaled to [0, 1] and similarity is the highest cosine
+// similarity to a doc already picked. Docs without a vector count as
+// dissimilar to everything.
+func applyMMR(docs []SearchResult, lambda float32) []SearchResult {
+	if len(docs) < 2 {
+		return docs
+	}
+	lambda = min(max(lambda, 0), 1)
+
+	lo, hi := docs[0].Score, docs[0].Score
+	for _, d := range docs {
+		lo, hi = min(lo, d.Score), max(hi, d.Score)
+	}
+	relevance := make([]float64, len(docs))
+	for i, d := range docs {
+		relevance[i] = 1
+		if hi > lo {
+			relevance[i] = float64((d.Score - lo) / (hi - lo))
+		}
+	}
+
+	picked := make([]bool, len(docs))
+	// maxSim[i] is doc i's highest similarity to the picked set so far
+	maxSim := make([]float64, len(docs))
+	selected := make([]SearchResult, 0, len(docs))
+
+	for len(selected) < len(docs) {
+		best, bestScore := -1, math.Inf(-1)
+		for i := range docs {
+			if picked[i] {
+				continue
+			}
+			score := float64(lambda)*relevance[i] - float64(1-lambda)*maxSim[i]
+			if score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+		picked[best] = true
+		selected = append(selected, docs[best])
+
+		for i := range docs {
+			if !picked[i] {
+				maxSim[i] = math.Max(maxSim[i], cosineSimilarity(docs[i].Vector, docs[best].Vector))
+			}
+		}
+	}
+	return selected
+}
+
+// cosineSimilarity returns 0 for missing or mismatched vectors.
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
@@ -0,0 +1,84 @@
+package retrieval
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// queryCache is an LRU of query embeddings keyed by model and exact query
+// text, so repeated searches skip the embedding round-trip.
+type queryCache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration // 0 = entries never expire
+	order   *list.List    // front = most recently used
+	entries map[queryKey]*list.Element
+	now     func() time.Time
+}
+
+type queryKey struct {
+	model string // "" = default model
+	query string
+}
+
+type cachedQuery struct {
+	key    queryKey
+	vector []float32
+	stored time.Time
+}
+
+func newQueryCache(size int, ttl time.Duration) *queryCache {
+	return &queryCache{
+		size:    size,
+		ttl:     ttl,
+		order:   list.New(),
+		entries: make(map[queryKey]*list.Element, size),
+		now:     time.Now,
+	}
+}
+
+// get returns the cached vector for query under model, if present and fresh.
+func (c *queryCache) get(model, query string) ([]float32, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[queryKey{model, query}]
+	if !ok {
+		return nil, false
+	}
+	entry := el.Value.(*cachedQuery)
+	if c.ttl > 0 && c.now().Sub(entry.stored) >= c.ttl {
+		c.order.Remove(el)
+		delete(c.entries, entry.key)
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return entry.vector, true
+}
+
+// put stores vector for query under model, evicting the least recently used
+// entry when the cache is full.
+func (c *queryCache) put(model, query string, vector []float32) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := queryKey{model, query}
+	if el, ok := c.entries[key]; ok {
+		el.Value = &cachedQuery{key: key, vector: vector, stored: c.now()}
+		c.order.MoveToFront(el)
+		return
+	}
+	if c.order.Len() >= c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cachedQuery).key)
+	}
+	c.entries[key] = c.order.PushFront(&cachedQuery{key: key, vector: vector, stored: c.now()})
+}
@@ -0,0 +1,42 @@
+package retrieval
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	c := newQueryCache(2, 0)
+	c.put("", "a", []float32{1})
+	c.put("", "b", []float32{2})
+
+	// Touching a makes b the eviction candidate
+	_, ok := c.get("", "a")
+	assert.True(t, ok)
+	c.put("", "c", []float32{3})
+
+	_, ok = c.get("", "b")
+	assert.False(t, ok)
+	vec, ok := c.get("", "a")
+	assert.True(t, ok)
+	assert.Equal(t, []float32{1}, vec)
+	_, ok = c.get("", "c")
+	assert.True(t, ok)
+}
+
+func TestQueryCache_TTL(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := newQueryCache(4, time.Minute)
+	c.now = func() time.Time { return now }
+
+	c.put("", "a", []float32{1})
+	now = now.Add(59 * time.Second)
+	_, ok := c.get("", "a")
+	assert.True(t, ok)
+
+	now = now.Add(time.Second)
+	_, ok = c.get("", "a")
+	assert.False(t, ok)
+}
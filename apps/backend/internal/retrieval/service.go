@@ -2,6 +2,8 @@ package retrieval
 
 import (
 	"context"
+	"fmt"
+	"log/slog"
 	"time"
 
 	"qurio/apps/backend/internal/settings"
@@ -20,23 +22,182 @@ type SearchResult struct {
 	Language   string                 `json:"language,omitempty"`   // New
 	Type       string                 `json:"type,omitempty"`       // New
 	Metadata   map[string]interface{} `json:"metadata"`
+	Vector     []float32              `json:"-"` // Stored embedding, set only for searches WithVectors
+
+	// Distance and Certainty come from the vector side of the query. Unlike
+	// Score they are stable across queries; they are nil when Weaviate did
+	// not return them, e.g. for keyword-only results.
+	Distance  *float32 `json:"distance,omitempty"`
+	Certainty *float32 `json:"certainty,omitempty"`
+
+	// DuplicateCount is how many other results with identical content were
+	// collapsed into this one when SearchOptions.Dedupe is set.
+	DuplicateCount int `json:"duplicateCount,omitempty"`
+
+	// Overlap is the length of the text at the start of Content repeated
+	// from the previous chunk of the page. Only GetChunksByURL sets it.
+	Overlap int `json:"-"`
+}
+
+// NotIn is a search filter value matching chunks whose property is none of
+// the listed values.
+type NotIn []string
+
+// Range is a search filter value matching chunks whose property lies within
+// the set bounds; nil bounds are open. Bounds are numbers, or RFC 3339
+// timestamps for date properties such as createdAt.
+type Range struct {
+	GT, GTE, LT, LTE interface{}
+}
+
+// ParseRange reads a filter value such as {"gte": 0, "lte": 2}. It reports
+// false unless m has at least one bound and only the keys gt, gte, lt and lte.
+func ParseRange(m map[string]interface{}) (Range, bool) {
+	var r Range
+	if len(m) == 0 {
+		return r, false
+	}
+	for k, v := range m {
+		switch k {
+		case "gt":
+			r.GT = v
+		case "gte":
+			r.GTE = v
+		case "lt":
+			r.LT = v
+		case "lte":
+			r.LTE = v
+		default:
+			return Range{}, false
+		}
+	}
+	return r, true
+}
+
+// ValidateFilters checks search filters supplied by a caller, so a filter the
+// store cannot express is rejected instead of silently matching everything.
+// Values are strings, {"key": "value"} string maps under metadata, or ranges
+// whose bounds are numbers or RFC 3339 timestamps.
+func ValidateFilters(filters map[string]interface{}) error {
+	for k, v := range filters {
+		switch val := v.(type) {
+		case string, NotIn:
+		case Range:
+			if err := validateRange(val); err != nil {
+				return fmt.Errorf("filter %q: %w", k, err)
+			}
+		case map[string]interface{}:
+			if k == "metadata" {
+				for mk, mv := range val {
+					if _, ok := mv.(string); !ok {
+						return fmt.Errorf("filter %q: value of %q must be a string", k, mk)
+					}
+				}
+				continue
+			}
+			r, ok := ParseRange(val)
+			if !ok {
+				return fmt.Errorf("filter %q: a range takes only the bounds gt, gte, lt and lte", k)
+			}
+			if err := validateRange(r); err != nil {
+				return fmt.Errorf("filter %q: %w", k, err)
+			}
+		default:
+			return fmt.Errorf("filter %q: unsupported value %v", k, v)
+		}
+	}
+	return nil
+}
+
+func validateRange(r Range) error {
+	for _, bound := range []interface{}{r.GT, r.GTE, r.LT, r.LTE} {
+		switch b := bound.(type) {
+		case nil, int, int64, float64, time.Time:
+		case string:
+			if _, err := time.Parse(time.RFC3339, b); err != nil {
+				return fmt.Errorf("bound %q is not a number or RFC 3339 timestamp", b)
+			}
+		default:
+			return fmt.Errorf("bound %v is not a number or RFC 3339 timestamp", bound)
+		}
+	}
+	return nil
 }
 
 type SearchOptions struct {
 	Alpha   *float32
 	Limit   *int
 	Filters map[string]interface{}
+
+	// SnippetsPerPage groups results by URL, keeping at most this many
+	// snippets per page. Nil or zero leaves results ungrouped.
+	SnippetsPerPage *int
+
+	// Exact opts out of the minimum alpha floor so alpha=0 runs a pure
+	// keyword search.
+	Exact bool
+
+	// Diversity reorders results with Maximal Marginal Relevance instead of
+	// the reranker. It is the MMR lambda: 1 ranks by relevance alone, lower
+	// values increasingly penalise results similar to ones already picked.
+	Diversity *float32
+
+	// MinScore drops results whose hybrid score is below it.
+	MinScore *float32
+
+	// Offset skips that many results, for paging past the first Limit results.
+	Offset *int
+
+	// Dedupe collapses results with byte-identical content, such as
+	// boilerplate repeated across pages, into the highest-scoring one.
+	Dedupe bool
 }
 
 type Embedder interface {
 	Embed(ctx context.Context, text string) ([]float32, error)
 }
 
+// ModelEmbedder is implemented by embedders that can embed with a model other
+// than their default.
+type ModelEmbedder interface {
+	EmbedWithModel(ctx context.Context, model, text string) ([]float32, error)
+}
+
+// QueryEmbedder is implemented by embedders that embed search queries
+// differently from the documents they are matched against. An empty model
+// means the default.
+type QueryEmbedder interface {
+	EmbedQuery(ctx context.Context, model, text string) ([]float32, error)
+}
+
+// SourceModelResolver looks up a source's embedding model override. An empty
+// model means the source uses the default.
+type SourceModelResolver interface {
+	EmbeddingModel(ctx context.Context, sourceID string) (string, error)
+}
+
+// MaxSearchWindow caps offset+limit for a search. Hybrid search ranks every
+// hit up to the window, so deep pages get expensive.
+const MaxSearchWindow = 1000
+
 type VectorStore interface {
-	Search(ctx context.Context, query string, vector []float32, alpha float32, limit int, filters map[string]interface{}) ([]SearchResult, error)
+	Search(ctx context.Context, query string, vector []float32, alpha float32, limit, offset int, filters map[string]interface{}) ([]SearchResult, error)
 	GetChunksByURL(ctx context.Context, url string) ([]SearchResult, error)
 }
 
+type vectorsKey struct{}
+
+// WithVectors asks VectorStore.Search in ctx to fill SearchResult.Vector.
+func WithVectors(ctx context.Context) context.Context {
+	return context.WithValue(ctx, vectorsKey{}, true)
+}
+
+// WantsVectors reports whether a search in ctx must return stored vectors.
+func WantsVectors(ctx context.Context) bool {
+	want, _ := ctx.Value(vectorsKey{}).(bool)
+	return want
+}
+
 type Reranker interface {
 	Rerank(ctx context.Context, query string, docs []string) ([]int, error)
 }
@@ -47,12 +208,62 @@ type Service struct {
 	reranker Reranker
 	settings *settings.Service
 	logger   *QueryLogger
+	models   SourceModelResolver
+	queries  *queryCache
+
+	rerankCandidates int
+	minAlpha         float32
+	excludedTypes    []string
+	overrideModels   []string
 }
 
 func NewService(e Embedder, s VectorStore, r Reranker, set *settings.Service, l *QueryLogger) *Service {
 	return &Service{embedder: e, store: s, reranker: r, settings: set, logger: l}
 }
 
+// SetRerankCandidates caps how many of the top hybrid results are sent to the
+// reranker. The remainder follow the reranked set in their original order.
+// Zero (the default) reranks every candidate.
+func (s *Service) SetRerankCandidates(n int) {
+	s.rerankCandidates = n
+}
+
+// SetMinAlpha sets the lowest alpha a search runs with, so some vector signal
+// always contributes. Searches with SearchOptions.Exact are not clamped.
+func (s *Service) SetMinAlpha(a float32) {
+	s.minAlpha = a
+}
+
+// SetDefaultExcludedTypes keeps chunks of these types out of searches that
+// don't filter on type themselves. An explicit type filter overrides it.
+func (s *Service) SetDefaultExcludedTypes(types []string) {
+	s.excludedTypes = types
+}
+
+// SetQueryCache keeps the embeddings of the size most recently searched
+// queries, so repeating a query skips the embedder. Entries expire after ttl;
+// zero keeps them until evicted. A size of zero disables the cache.
+func (s *Service) SetQueryCache(size int, ttl time.Duration) {
+	if size <= 0 {
+		s.queries = nil
+		return
+	}
+	s.queries = newQueryCache(size, ttl)
+}
+
+// SetSourceModels lets searches scoped to one source embed the query with that
+// source's embedding model, so query and chunk vectors are comparable.
+func (s *Service) SetSourceModels(r SourceModelResolver) {
+	s.models = r
+}
+
+// SetOverrideModels lists the embedding models sources may use in place of the
+// default. Searches in the default model skip chunks embedded with any of
+// them, since their vectors are not comparable.
+func (s *Service) SetOverrideModels(models []string) {
+	s.overrideModels = models
+}
+
 func (s *Service) Search(ctx context.Context, query string, opts *SearchOptions) ([]SearchResult, error) {
 	start := time.Now()
 	var finalDocs []SearchResult
@@ -78,7 +289,12 @@ func (s *Service) Search(ctx context.Context, query string, opts *SearchOptions)
 	// Resolve params
 	alpha := cfg.SearchAlpha
 	limit := cfg.SearchTopK
+	offset := 0
 	var filters map[string]interface{}
+	snippetsPerPage := 0
+	exact := false
+	dedupe := false
+	var diversity, minScore *float32
 
 	if opts != nil {
 		if opts.Alpha != nil {
@@ -87,17 +303,68 @@ func (s *Service) Search(ctx context.Context, query string, opts *SearchOptions)
 		if opts.Limit != nil {
 			limit = *opts.Limit
 		}
+		if opts.Offset != nil && *opts.Offset > 0 {
+			offset = *opts.Offset
+		}
 		filters = opts.Filters
+		if opts.SnippetsPerPage != nil {
+			snippetsPerPage = *opts.SnippetsPerPage
+		}
+		exact = opts.Exact
+		diversity = opts.Diversity
+		minScore = opts.MinScore
+		dedupe = opts.Dedupe
+	}
+
+	if !exact && alpha < s.minAlpha {
+		alpha = s.minAlpha
+	}
+
+	if _, typed := filters["type"]; len(s.excludedTypes) > 0 && !typed {
+		scoped := make(map[string]interface{}, len(filters)+1)
+		for k, v := range filters {
+			scoped[k] = v
+		}
+		scoped["type"] = NotIn(s.excludedTypes)
+		filters = scoped
+	}
+
+	// MMR picks from a wider pool so it has alternatives to near-duplicates,
+	// and page grouping so the snippets it drops can be replaced. Both reorder
+	// and drop hits, so an offset into the raw hits would not line up with the
+	// pages before it: they rank the first offset+limit results from the top
+	// and the offset is skipped afterwards. Nothing past the search window is
+	// fetched.
+	if offset >= MaxSearchWindow {
+		return []SearchResult{}, nil
+	}
+	want, storeOffset := limit, offset
+	if diversity != nil || snippetsPerPage > 0 {
+		want, storeOffset = offset+limit, 0
 	}
+	fetch := want
+	if diversity != nil {
+		fetch = want * mmrCandidateFactor
+	} else if snippetsPerPage > 0 {
+		fetch = want * pageGroupCandidateFactor
+	}
+	fetch = min(fetch, MaxSearchWindow-storeOffset)
 
-	// 1. Embed Query
-	vec, err := s.embedder.Embed(ctx, query)
+	// 1. Embed Query, and match only chunks embedded with the same model
+	model := s.queryModel(ctx, filters)
+	filters = s.scopeToModel(filters, model, cfg.EmbeddingModel)
+	vec, err := s.embedQuery(ctx, query, model, cfg.EmbeddingModel)
 	if err != nil {
 		return nil, err
 	}
 
-	// 2. Hybrid Search (BM25 + Vector)
-	docs, err := s.store.Search(ctx, query, vec, alpha, limit, filters)
+	// 2. Hybrid Search (BM25 + Vector). MMR compares results by their
+	// stored vectors, so only then does the store need to return them.
+	searchCtx := ctx
+	if diversity != nil {
+		searchCtx = WithVectors(ctx)
+	}
+	docs, err := s.store.Search(searchCtx, query, vec, alpha, fetch, storeOffset, filters)
 	if err != nil {
 		return nil, err
 	}
@@ -109,11 +376,32 @@ func (s *Service) Search(ctx context.Context, query string, opts *SearchOptions)
 		}
 	}
 
-	// 3. Rerank (if configured)
-	if s.reranker != nil && len(docs) > 0 {
+	// The reranker reorders without rescoring, so thresholding the hybrid
+	// score here is the same as after reranking and spares it the work.
+	if minScore != nil {
+		docs = filterMinScore(docs, *minScore)
+	}
+	if dedupe {
+		docs = dedupeContent(docs)
+	}
+
+	// 3. Diversify, or rerank (if configured). Zero or one result has nothing
+	// to reorder.
+	if diversity != nil {
+		// Order the whole pool so page grouping can still fill the limit
+		docs = applyMMR(docs, *diversity)
+		if snippetsPerPage == 0 && len(docs) > want {
+			docs = docs[:want]
+		}
+	} else if s.reranker != nil && len(docs) > 1 {
+		candidates, rest := docs, []SearchResult(nil)
+		if s.rerankCandidates > 0 && len(docs) > s.rerankCandidates {
+			candidates, rest = docs[:s.rerankCandidates], docs[s.rerankCandidates:]
+		}
+
 		// Extract content for reranker
-		contents := make([]string, len(docs))
-		for i, d := range docs {
+		contents := make([]string, len(candidates))
+		for i, d := range candidates {
 			contents[i] = d.Content
 		}
 
@@ -122,20 +410,184 @@ func (s *Service) Search(ctx context.Context, query string, opts *SearchOptions)
 			return nil, err
 		}
 
-		reranked := make([]SearchResult, len(indices))
-		for i, idx := range indices {
-			if idx < len(docs) {
-				reranked[i] = docs[idx]
-			}
-		}
-		finalDocs = reranked
-		return reranked, nil
+		docs = append(applyRerankOrder(candidates, indices), rest...)
+	}
+
+	if snippetsPerPage > 0 {
+		docs = groupByPage(docs, snippetsPerPage, want)
+	}
+	if skip := offset - storeOffset; skip > 0 {
+		docs = docs[min(skip, len(docs)):]
 	}
 
 	finalDocs = docs
 	return docs, nil
 }
 
+// scopeToModel returns filters narrowed to chunks embedded with model, or,
+// for the default model, to chunks not embedded with any override other than
+// the current default. filters itself is not modified.
+func (s *Service) scopeToModel(filters map[string]interface{}, model, defaultModel string) map[string]interface{} {
+	var value interface{}
+	if model != "" {
+		value = model
+	} else {
+		var others NotIn
+		for _, m := range s.overrideModels {
+			if m != defaultModel {
+				others = append(others, m)
+			}
+		}
+		if len(others) == 0 {
+			return filters
+		}
+		value = others
+	}
+	scoped := make(map[string]interface{}, len(filters)+1)
+	for k, v := range filters {
+		scoped[k] = v
+	}
+	scoped["embeddingModel"] = value
+	return scoped
+}
+
+// embedQuery embeds the query with model, the scoped source's override, or
+// with the default when it is empty. Embeddings are served from the query
+// cache when it holds them, keyed by the model that produced them so a
+// settings change is not masked.
+func (s *Service) embedQuery(ctx context.Context, query, model, defaultModel string) ([]float32, error) {
+	key := model
+	if key == "" {
+		key = defaultModel
+	}
+	if vec, ok := s.queries.get(key, query); ok {
+		return vec, nil
+	}
+
+	var vec []float32
+	var err error
+	if qe, ok := s.embedder.(QueryEmbedder); ok {
+		vec, err = qe.EmbedQuery(ctx, model, query)
+	} else if model != "" {
+		vec, err = s.embedder.(ModelEmbedder).EmbedWithModel(ctx, model, query)
+	} else {
+		vec, err = s.embedder.Embed(ctx, query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.queries.put(key, query, vec)
+	return vec, nil
+}
+
+// queryModel returns the embedding model override for the search's scoped
+// source, or "" for the default. Overrides need a ModelEmbedder.
+func (s *Service) queryModel(ctx context.Context, filters map[string]interface{}) string {
+	if s.models == nil {
+		return ""
+	}
+	if _, ok := s.embedder.(ModelEmbedder); !ok {
+		return ""
+	}
+	sourceID, ok := filters["sourceId"].(string)
+	if !ok || sourceID == "" {
+		return ""
+	}
+	model, err := s.models.EmbeddingModel(ctx, sourceID)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to resolve source embedding model, using default", "source_id", sourceID, "error", err)
+		return ""
+	}
+	return model
+}
+
+// filterMinScore keeps the docs scoring at least threshold, in order.
+func filterMinScore(docs []SearchResult, threshold float32) []SearchResult {
+	kept := make([]SearchResult, 0, len(docs))
+	for _, d := range docs {
+		if d.Score >= threshold {
+			kept = append(kept, d)
+		}
+	}
+	return kept
+}
+
+// dedupeContent collapses docs with identical content into the
+// highest-scoring instance, which takes the place of the first one seen and
+// counts the rest in DuplicateCount.
+func dedupeContent(docs []SearchResult) []SearchResult {
+	kept := make([]SearchResult, 0, len(docs))
+	index := make(map[string]int, len(docs))
+	for _, d := range docs {
+		i, seen := index[d.Content]
+		if !seen {
+			index[d.Content] = len(kept)
+			kept = append(kept, d)
+			continue
+		}
+		count := kept[i].DuplicateCount + 1
+		if d.Score > kept[i].Score {
+			kept[i] = d
+		}
+		kept[i].DuplicateCount = count
+	}
+	return kept
+}
+
+// applyRerankOrder puts the reranked documents first. Documents the reranker
+// did not reference (or referenced twice) keep their original relative order
+// after the ranked ones, so no result is lost to a short provider response.
+func applyRerankOrder(docs []SearchResult, indices []int) []SearchResult {
+	used := make([]bool, len(docs))
+	reranked := make([]SearchResult, 0, len(docs))
+	for _, idx := range indices {
+		if idx < 0 || idx >= len(docs) || used[idx] {
+			continue
+		}
+		used[idx] = true
+		reranked = append(reranked, docs[idx])
+	}
+	for i, d := range docs {
+		if !used[i] {
+			reranked = append(reranked, d)
+		}
+	}
+	return reranked
+}
+
+// pageGroupCandidateFactor is how many hybrid results per requested result
+// groupByPage chooses from.
+const pageGroupCandidateFactor = 3
+
+// groupByPage keeps the best perPage snippets of each URL, placing a page's
+// snippets together in the order its best snippet ranked. The output never
+// exceeds limit. Results without a URL are kept as their own group.
+func groupByPage(docs []SearchResult, perPage, limit int) []SearchResult {
+	var order []string
+	groups := make(map[string][]SearchResult)
+	for i, d := range docs {
+		key := d.URL
+		if key == "" {
+			key = fmt.Sprintf("#%d", i)
+		}
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		if len(groups[key]) < perPage {
+			groups[key] = append(groups[key], d)
+		}
+	}
+
+	grouped := make([]SearchResult, 0, len(docs))
+	for _, key := range order {
+		grouped = append(grouped, groups[key]...)
+	}
+	if limit > 0 && len(grouped) > limit {
+		grouped = grouped[:limit]
+	}
+	return grouped
+}
+
 func (s *Service) GetChunksByURL(ctx context.Context, url string) ([]SearchResult, error) {
 	results, err := s.store.GetChunksByURL(ctx, url)
 	if err != nil {
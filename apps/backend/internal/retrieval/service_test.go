@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"testing"
 
 	"qurio/apps/backend/internal/retrieval"
@@ -24,10 +25,18 @@ func (m *MockEmbedder) Embed(ctx context.Context, text string) ([]float32, error
 	return args.Get(0).([]float32), args.Error(1)
 }
 
+func (m *MockEmbedder) EmbedWithModel(ctx context.Context, model, text string) ([]float32, error) {
+	args := m.Called(ctx, model, text)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]float32), args.Error(1)
+}
+
 type MockStore struct{ mock.Mock }
 
-func (m *MockStore) Search(ctx context.Context, query string, vector []float32, alpha float32, limit int, filters map[string]interface{}) ([]retrieval.SearchResult, error) {
-	args := m.Called(ctx, query, vector, alpha, limit, filters)
+func (m *MockStore) Search(ctx context.Context, query string, vector []float32, alpha float32, limit, offset int, filters map[string]interface{}) ([]retrieval.SearchResult, error) {
+	args := m.Called(ctx, query, vector, alpha, limit, offset, filters)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -83,7 +92,7 @@ func TestService_Search(t *testing.T) {
 			setup: func(e *MockEmbedder, s *MockStore, r *MockReranker, set *MockSettingsRepo) {
 				set.On("Get", mock.Anything).Return(&settings.Settings{SearchAlpha: 0.5, SearchTopK: 10}, nil)
 				e.On("Embed", mock.Anything, "test").Return([]float32{0.1}, nil)
-				s.On("Search", mock.Anything, "test", []float32{0.1}, float32(0.5), 10, map[string]interface{}(nil)).
+				s.On("Search", mock.Anything, "test", []float32{0.1}, float32(0.5), 10, 0, map[string]interface{}(nil)).
 					Return([]retrieval.SearchResult{{Content: "A", Score: 0.9}}, nil)
 			},
 			wantLen: 1,
@@ -95,7 +104,7 @@ func TestService_Search(t *testing.T) {
 			setup: func(e *MockEmbedder, s *MockStore, r *MockReranker, set *MockSettingsRepo) {
 				set.On("Get", mock.Anything).Return(&settings.Settings{SearchAlpha: 0.5, SearchTopK: 10}, nil)
 				e.On("Embed", mock.Anything, "test").Return([]float32{0.1}, nil)
-				s.On("Search", mock.Anything, "test", []float32{0.1}, float32(0.5), 10, map[string]interface{}(nil)).
+				s.On("Search", mock.Anything, "test", []float32{0.1}, float32(0.5), 10, 0, map[string]interface{}(nil)).
 					Return([]retrieval.SearchResult{{Content: "A", Score: 0.8}, {Content: "B", Score: 0.9}}, nil)
 				r.On("Rerank", mock.Anything, "test", []string{"A", "B"}).Return([]int{1, 0}, nil)
 			},
@@ -116,11 +125,127 @@ func TestService_Search(t *testing.T) {
 			setup: func(e *MockEmbedder, s *MockStore, r *MockReranker, set *MockSettingsRepo) {
 				set.On("Get", mock.Anything).Return(&settings.Settings{SearchAlpha: 0.5, SearchTopK: 10}, nil)
 				e.On("Embed", mock.Anything, "test").Return([]float32{0.1}, nil)
-				s.On("Search", mock.Anything, "test", []float32{0.1}, float32(0.8), 5, map[string]interface{}{"type": "code"}).
+				s.On("Search", mock.Anything, "test", []float32{0.1}, float32(0.8), 5, 0, map[string]interface{}{"type": "code"}).
 					Return([]retrieval.SearchResult{}, nil)
 			},
 			wantLen: 0,
 		},
+		{
+			name:  "Success with Custom Metadata Filter",
+			query: "test",
+			opts: &retrieval.SearchOptions{
+				Filters: map[string]interface{}{"metadata": map[string]interface{}{"team": "payments"}},
+			},
+			nilReranker: true,
+			setup: func(e *MockEmbedder, s *MockStore, r *MockReranker, set *MockSettingsRepo) {
+				set.On("Get", mock.Anything).Return(&settings.Settings{SearchAlpha: 0.5, SearchTopK: 10}, nil)
+				e.On("Embed", mock.Anything, "test").Return([]float32{0.1}, nil)
+				s.On("Search", mock.Anything, "test", []float32{0.1}, float32(0.5), 10, 0,
+					map[string]interface{}{"metadata": map[string]interface{}{"team": "payments"}}).
+					Return([]retrieval.SearchResult{{Content: "A", Score: 0.9, SourceID: "src-payments"}}, nil)
+			},
+			wantLen: 1,
+			check: func(t *testing.T, res []retrieval.SearchResult) {
+				assert.Equal(t, "src-payments", res[0].SourceID)
+			},
+		},
+		{
+			name:  "Success with Snippets Per Page",
+			query: "test",
+			opts: &retrieval.SearchOptions{
+				SnippetsPerPage: &[]int{2}[0],
+			},
+			nilReranker: true,
+			setup: func(e *MockEmbedder, s *MockStore, r *MockReranker, set *MockSettingsRepo) {
+				set.On("Get", mock.Anything).Return(&settings.Settings{SearchAlpha: 0.5, SearchTopK: 10}, nil)
+				e.On("Embed", mock.Anything, "test").Return([]float32{0.1}, nil)
+				// Grouping draws from three candidates per requested result
+				s.On("Search", mock.Anything, "test", []float32{0.1}, float32(0.5), 30, 0, map[string]interface{}(nil)).
+					Return([]retrieval.SearchResult{
+						{Content: "A1", Score: 0.9, URL: "http://a"},
+						{Content: "B1", Score: 0.85, URL: "http://b"},
+						{Content: "A2", Score: 0.8, URL: "http://a"},
+						{Content: "A3", Score: 0.7, URL: "http://a"},
+					}, nil)
+			},
+			wantLen: 3,
+			check: func(t *testing.T, res []retrieval.SearchResult) {
+				assert.Equal(t, "A1", res[0].Content)
+				assert.Equal(t, "A2", res[1].Content)
+				assert.Equal(t, "B1", res[2].Content)
+			},
+		},
+		{
+			name:  "Snippets Per Page Respects Limit",
+			query: "test",
+			opts: &retrieval.SearchOptions{
+				Limit:           &[]int{2}[0],
+				SnippetsPerPage: &[]int{2}[0],
+			},
+			nilReranker: true,
+			setup: func(e *MockEmbedder, s *MockStore, r *MockReranker, set *MockSettingsRepo) {
+				set.On("Get", mock.Anything).Return(&settings.Settings{SearchAlpha: 0.5, SearchTopK: 10}, nil)
+				e.On("Embed", mock.Anything, "test").Return([]float32{0.1}, nil)
+				s.On("Search", mock.Anything, "test", []float32{0.1}, float32(0.5), 6, 0, map[string]interface{}(nil)).
+					Return([]retrieval.SearchResult{
+						{Content: "A1", Score: 0.9, URL: "http://a"},
+						{Content: "B1", Score: 0.85, URL: "http://b"},
+						{Content: "A2", Score: 0.8, URL: "http://a"},
+					}, nil)
+			},
+			wantLen: 2,
+			check: func(t *testing.T, res []retrieval.SearchResult) {
+				assert.Equal(t, "A1", res[0].Content)
+				assert.Equal(t, "A2", res[1].Content)
+			},
+		},
+		{
+			name:  "Snippets Per Page Fills Limit From Wider Pool",
+			query: "test",
+			opts: &retrieval.SearchOptions{
+				Limit:           &[]int{2}[0],
+				SnippetsPerPage: &[]int{1}[0],
+			},
+			nilReranker: true,
+			setup: func(e *MockEmbedder, s *MockStore, r *MockReranker, set *MockSettingsRepo) {
+				set.On("Get", mock.Anything).Return(&settings.Settings{SearchAlpha: 0.5, SearchTopK: 10}, nil)
+				e.On("Embed", mock.Anything, "test").Return([]float32{0.1}, nil)
+				s.On("Search", mock.Anything, "test", []float32{0.1}, float32(0.5), 6, 0, map[string]interface{}(nil)).
+					Return([]retrieval.SearchResult{
+						{Content: "A1", Score: 0.9, URL: "http://a"},
+						{Content: "A2", Score: 0.85, URL: "http://a"},
+						{Content: "A3", Score: 0.8, URL: "http://a"},
+						{Content: "B1", Score: 0.7, URL: "http://b"},
+					}, nil)
+			},
+			wantLen: 2,
+			check: func(t *testing.T, res []retrieval.SearchResult) {
+				assert.Equal(t, "A1", res[0].Content)
+				assert.Equal(t, "B1", res[1].Content)
+			},
+		},
+		{
+			name:  "Reranker Returns Fewer Indices Than Docs",
+			query: "test",
+			opts:  nil,
+			setup: func(e *MockEmbedder, s *MockStore, r *MockReranker, set *MockSettingsRepo) {
+				set.On("Get", mock.Anything).Return(&settings.Settings{SearchAlpha: 0.5, SearchTopK: 10}, nil)
+				e.On("Embed", mock.Anything, "test").Return([]float32{0.1}, nil)
+				s.On("Search", mock.Anything, "test", []float32{0.1}, float32(0.5), 10, 0, map[string]interface{}(nil)).
+					Return([]retrieval.SearchResult{
+						{Content: "A"}, {Content: "B"}, {Content: "C"}, {Content: "D"}, {Content: "E"},
+					}, nil)
+				r.On("Rerank", mock.Anything, "test", []string{"A", "B", "C", "D", "E"}).Return([]int{1, 0}, nil)
+			},
+			wantLen: 5,
+			check: func(t *testing.T, res []retrieval.SearchResult) {
+				got := make([]string, len(res))
+				for i, d := range res {
+					got[i] = d.Content
+				}
+				assert.Equal(t, []string{"B", "A", "C", "D", "E"}, got)
+			},
+		},
 		{
 			name:  "Embedder Error",
 			query: "test",
@@ -136,7 +261,7 @@ func TestService_Search(t *testing.T) {
 			setup: func(e *MockEmbedder, s *MockStore, r *MockReranker, set *MockSettingsRepo) {
 				set.On("Get", mock.Anything).Return(&settings.Settings{SearchAlpha: 0.5, SearchTopK: 10}, nil)
 				e.On("Embed", mock.Anything, "test").Return([]float32{0.1}, nil)
-				s.On("Search", mock.Anything, "test", []float32{0.1}, float32(0.5), 10, map[string]interface{}(nil)).
+				s.On("Search", mock.Anything, "test", []float32{0.1}, float32(0.5), 10, 0, map[string]interface{}(nil)).
 					Return(nil, errors.New("store error"))
 			},
 			wantErr: true,
@@ -147,9 +272,9 @@ func TestService_Search(t *testing.T) {
 			setup: func(e *MockEmbedder, s *MockStore, r *MockReranker, set *MockSettingsRepo) {
 				set.On("Get", mock.Anything).Return(&settings.Settings{SearchAlpha: 0.5, SearchTopK: 10}, nil)
 				e.On("Embed", mock.Anything, "test").Return([]float32{0.1}, nil)
-				s.On("Search", mock.Anything, "test", []float32{0.1}, float32(0.5), 10, map[string]interface{}(nil)).
-					Return([]retrieval.SearchResult{{Content: "A"}}, nil)
-				r.On("Rerank", mock.Anything, "test", []string{"A"}).Return(nil, errors.New("rerank error"))
+				s.On("Search", mock.Anything, "test", []float32{0.1}, float32(0.5), 10, 0, map[string]interface{}(nil)).
+					Return([]retrieval.SearchResult{{Content: "A"}, {Content: "B"}}, nil)
+				r.On("Rerank", mock.Anything, "test", []string{"A", "B"}).Return(nil, errors.New("rerank error"))
 			},
 			wantErr: true,
 		},
@@ -160,7 +285,7 @@ func TestService_Search(t *testing.T) {
 				set.On("Get", mock.Anything).Return((*settings.Settings)(nil), errors.New("settings error"))
 				e.On("Embed", mock.Anything, "test").Return([]float32{0.1}, nil)
 				// Expect defaults: Alpha 0.5, Limit 10
-				s.On("Search", mock.Anything, "test", []float32{0.1}, float32(0.5), 10, map[string]interface{}(nil)).
+				s.On("Search", mock.Anything, "test", []float32{0.1}, float32(0.5), 10, 0, map[string]interface{}(nil)).
 					Return([]retrieval.SearchResult{}, nil)
 			},
 			wantLen: 0,
@@ -172,7 +297,7 @@ func TestService_Search(t *testing.T) {
 			setup: func(e *MockEmbedder, s *MockStore, r *MockReranker, set *MockSettingsRepo) {
 				set.On("Get", mock.Anything).Return(&settings.Settings{SearchAlpha: 0.5, SearchTopK: 10}, nil)
 				e.On("Embed", mock.Anything, "test").Return([]float32{0.1}, nil)
-				s.On("Search", mock.Anything, "test", []float32{0.1}, float32(0.5), 10, map[string]interface{}(nil)).
+				s.On("Search", mock.Anything, "test", []float32{0.1}, float32(0.5), 10, 0, map[string]interface{}(nil)).
 					Return([]retrieval.SearchResult{
 						{Content: "A", Metadata: map[string]interface{}{"title": "My Title"}},
 					}, nil)
@@ -225,7 +350,7 @@ func TestService_Search_Logging(t *testing.T) {
 
 	setRepo.On("Get", mock.Anything).Return(&settings.Settings{SearchAlpha: 0.5, SearchTopK: 10}, nil)
 	e.On("Embed", mock.Anything, "test").Return([]float32{0.1}, nil)
-	s.On("Search", mock.Anything, "test", []float32{0.1}, float32(0.5), 10, map[string]interface{}(nil)).
+	s.On("Search", mock.Anything, "test", []float32{0.1}, float32(0.5), 10, 0, map[string]interface{}(nil)).
 		Return([]retrieval.SearchResult{{Content: "A"}}, nil)
 
 	var buf bytes.Buffer
@@ -252,7 +377,7 @@ func TestService_Search_RerankerEdgeCases(t *testing.T) {
 
 		setRepo.On("Get", mock.Anything).Return(&settings.Settings{}, nil)
 		e.On("Embed", mock.Anything, "test").Return([]float32{0.1}, nil)
-		s.On("Search", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		s.On("Search", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 			Return([]retrieval.SearchResult{{Content: "A"}, {Content: "B"}}, nil)
 
 		// Reranker returns index 5 which is out of bounds (len 2)
@@ -262,19 +387,10 @@ func TestService_Search_RerankerEdgeCases(t *testing.T) {
 		res, err := svc.Search(context.Background(), "test", nil)
 
 		assert.NoError(t, err)
-		assert.Len(t, res, 2) // Should return 2?
-		// Logic:
-		// reranked := make([]SearchResult, len(indices))
-		// for i, idx := range indices {
-		//    if idx < len(docs) { reranked[i] = docs[idx] }
-		// }
-		// It will have empty SearchResult at index 0 (because idx 5 skipped) and docs[0] at index 1.
-		// Wait, make creates zero-valued structs. So index 0 will be empty SearchResult.
-		// Is this desired behavior? Probably not, but it's safe from panic.
-		// Let's verify that's what happens.
-
-		assert.Equal(t, "", res[0].Content)  // Empty struct
-		assert.Equal(t, "A", res[1].Content) // Index 0 of docs maps to index 1 of indices
+		assert.Len(t, res, 2)
+		// Index 5 is skipped; the unreferenced doc B is appended after the ranked A.
+		assert.Equal(t, "A", res[0].Content)
+		assert.Equal(t, "B", res[1].Content)
 	})
 
 	t.Run("Empty Docs - Reranker Skipped", func(t *testing.T) {
@@ -285,7 +401,7 @@ func TestService_Search_RerankerEdgeCases(t *testing.T) {
 
 		setRepo.On("Get", mock.Anything).Return(&settings.Settings{}, nil)
 		e.On("Embed", mock.Anything, "test").Return([]float32{0.1}, nil)
-		s.On("Search", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		s.On("Search", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 			Return([]retrieval.SearchResult{}, nil)
 
 		svc := retrieval.NewService(e, s, r, settings.NewService(setRepo), nil)
@@ -295,6 +411,128 @@ func TestService_Search_RerankerEdgeCases(t *testing.T) {
 		assert.Empty(t, res)
 		r.AssertNotCalled(t, "Rerank")
 	})
+
+	t.Run("Single Doc - Reranker Skipped", func(t *testing.T) {
+		e := new(MockEmbedder)
+		s := new(MockStore)
+		r := new(MockReranker) // Should NOT be called
+		setRepo := new(MockSettingsRepo)
+
+		setRepo.On("Get", mock.Anything).Return(&settings.Settings{}, nil)
+		e.On("Embed", mock.Anything, "test").Return([]float32{0.1}, nil)
+		s.On("Search", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return([]retrieval.SearchResult{{Content: "A"}}, nil)
+
+		svc := retrieval.NewService(e, s, r, settings.NewService(setRepo), nil)
+		res, err := svc.Search(context.Background(), "test", nil)
+
+		assert.NoError(t, err)
+		assert.Len(t, res, 1)
+		assert.Equal(t, "A", res[0].Content)
+		r.AssertNotCalled(t, "Rerank")
+	})
+}
+
+func TestService_Search_RerankCandidatesCap(t *testing.T) {
+	e := new(MockEmbedder)
+	s := new(MockStore)
+	r := new(MockReranker)
+	setRepo := new(MockSettingsRepo)
+
+	docs := make([]retrieval.SearchResult, 50)
+	for i := range docs {
+		docs[i] = retrieval.SearchResult{Content: fmt.Sprintf("doc-%d", i)}
+	}
+
+	setRepo.On("Get", mock.Anything).Return(&settings.Settings{SearchTopK: 50}, nil)
+	e.On("Embed", mock.Anything, "test").Return([]float32{0.1}, nil)
+	s.On("Search", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(docs, nil)
+
+	var sent []string
+	r.On("Rerank", mock.Anything, "test", mock.Anything).Run(func(args mock.Arguments) {
+		sent = args.Get(2).([]string)
+	}).Return([]int{9, 8, 7, 6, 5, 4, 3, 2, 1, 0}, nil)
+
+	svc := retrieval.NewService(e, s, r, settings.NewService(setRepo), nil)
+	svc.SetRerankCandidates(10)
+	res, err := svc.Search(context.Background(), "test", nil)
+
+	assert.NoError(t, err)
+	assert.Len(t, sent, 10)
+	assert.Equal(t, "doc-0", sent[0])
+	assert.Equal(t, "doc-9", sent[9])
+
+	assert.Len(t, res, 50)
+	assert.Equal(t, "doc-9", res[0].Content)
+	assert.Equal(t, "doc-0", res[9].Content)
+	// The tail keeps its original order after the reranked set
+	for i := 10; i < 50; i++ {
+		assert.Equal(t, fmt.Sprintf("doc-%d", i), res[i].Content)
+	}
+}
+
+func TestService_Search_MinAlphaFloor(t *testing.T) {
+	zero := float32(0)
+	tests := []struct {
+		name      string
+		opts      *retrieval.SearchOptions
+		wantAlpha float32
+	}{
+		{"Clamped To Floor", &retrieval.SearchOptions{Alpha: &zero}, 0.1},
+		{"Exact Keeps Zero", &retrieval.SearchOptions{Alpha: &zero, Exact: true}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := new(MockEmbedder)
+			s := new(MockStore)
+			setRepo := new(MockSettingsRepo)
+
+			setRepo.On("Get", mock.Anything).Return(&settings.Settings{SearchAlpha: 0.5, SearchTopK: 10}, nil)
+			e.On("Embed", mock.Anything, "test").Return([]float32{0.1}, nil)
+			s.On("Search", mock.Anything, "test", []float32{0.1}, tt.wantAlpha, 10, 0, map[string]interface{}(nil)).
+				Return([]retrieval.SearchResult{}, nil)
+
+			svc := retrieval.NewService(e, s, nil, settings.NewService(setRepo), nil)
+			svc.SetMinAlpha(0.1)
+			_, err := svc.Search(context.Background(), "test", tt.opts)
+
+			assert.NoError(t, err)
+			s.AssertExpectations(t)
+		})
+	}
+}
+
+type stubSourceModels map[string]string
+
+func (m stubSourceModels) EmbeddingModel(ctx context.Context, sourceID string) (string, error) {
+	return m[sourceID], nil
+}
+
+func TestService_Search_ScopedSourceModel(t *testing.T) {
+	e := new(MockEmbedder)
+	s := new(MockStore)
+	setRepo := new(MockSettingsRepo)
+
+	setRepo.On("Get", mock.Anything).Return(&settings.Settings{SearchAlpha: 0.5, SearchTopK: 10}, nil)
+	e.On("EmbedWithModel", mock.Anything, "code-model", "test").Return([]float32{0.7}, nil)
+	e.On("Embed", mock.Anything, "test").Return([]float32{0.1}, nil)
+	s.On("Search", mock.Anything, "test", mock.Anything, float32(0.5), 10, 0, mock.Anything).
+		Return([]retrieval.SearchResult{}, nil)
+
+	svc := retrieval.NewService(e, s, nil, settings.NewService(setRepo), nil)
+	svc.SetSourceModels(stubSourceModels{"src-code": "code-model"})
+
+	_, err := svc.Search(context.Background(), "test", &retrieval.SearchOptions{Filters: map[string]interface{}{"sourceId": "src-code"}})
+	assert.NoError(t, err)
+	_, err = svc.Search(context.Background(), "test", &retrieval.SearchOptions{Filters: map[string]interface{}{"sourceId": "src-prose"}})
+	assert.NoError(t, err)
+	_, err = svc.Search(context.Background(), "test", nil)
+	assert.NoError(t, err)
+
+	e.AssertNumberOfCalls(t, "EmbedWithModel", 1)
+	e.AssertNumberOfCalls(t, "Embed", 2)
+	s.AssertCalled(t, "Search", mock.Anything, "test", []float32{0.7}, float32(0.5), 10, 0, map[string]interface{}{"sourceId": "src-code", "embeddingModel": "code-model"})
 }
 
 func TestGetChunksByURL(t *testing.T) {
@@ -339,3 +577,373 @@ func TestGetChunksByURL_Error(t *testing.T) {
 	assert.Contains(t, err.Error(), "store error")
 	s.AssertExpectations(t)
 }
+
+func TestService_Search_DefaultExcludedTypes(t *testing.T) {
+	e := new(MockEmbedder)
+	s := new(MockStore)
+	setRepo := new(MockSettingsRepo)
+
+	setRepo.On("Get", mock.Anything).Return(&settings.Settings{SearchAlpha: 0.5, SearchTopK: 10}, nil)
+	e.On("Embed", mock.Anything, "test").Return([]float32{0.1}, nil)
+	s.On("Search", mock.Anything, "test", []float32{0.1}, float32(0.5), 10, 0, mock.Anything).
+		Return([]retrieval.SearchResult{}, nil)
+
+	svc := retrieval.NewService(e, s, nil, settings.NewService(setRepo), nil)
+	svc.SetDefaultExcludedTypes([]string{"config"})
+
+	// Excluded by default, alongside the caller's own filters
+	callerFilters := map[string]interface{}{"language": "go"}
+	_, err := svc.Search(context.Background(), "test", &retrieval.SearchOptions{Filters: callerFilters})
+	assert.NoError(t, err)
+	s.AssertCalled(t, "Search", mock.Anything, "test", []float32{0.1}, float32(0.5), 10, 0,
+		map[string]interface{}{"language": "go", "type": retrieval.NotIn{"config"}})
+	assert.NotContains(t, callerFilters, "type", "caller's filter map is left untouched")
+
+	// Explicitly requested
+	_, err = svc.Search(context.Background(), "test", &retrieval.SearchOptions{Filters: map[string]interface{}{"type": "config"}})
+	assert.NoError(t, err)
+	s.AssertCalled(t, "Search", mock.Anything, "test", []float32{0.1}, float32(0.5), 10, 0,
+		map[string]interface{}{"type": "config"})
+}
+
+func TestService_Search_Diversity(t *testing.T) {
+	docs := []retrieval.SearchResult{
+		{Content: "a", Score: 1.0, Vector: []float32{1, 0}},
+		{Content: "a-dup", Score: 0.95, Vector: []float32{1, 0.01}},
+		{Content: "b", Score: 0.5, Vector: []float32{0, 1}},
+	}
+	tests := []struct {
+		name   string
+		lambda float32
+		want   []string
+	}{
+		{"balanced skips near-duplicate", 0.5, []string{"a", "b"}},
+		{"relevance only keeps order", 1.0, []string{"a", "a-dup"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := new(MockEmbedder)
+			s := new(MockStore)
+			r := new(MockReranker)
+			setRepo := new(MockSettingsRepo)
+
+			setRepo.On("Get", mock.Anything).Return(&settings.Settings{SearchTopK: 2, SearchAlpha: 0.5}, nil)
+			e.On("Embed", mock.Anything, "test").Return([]float32{0.1}, nil)
+			// MMR draws from three candidates per requested result, compared
+			// by their stored vectors
+			wantsVectors := mock.MatchedBy(func(ctx context.Context) bool { return retrieval.WantsVectors(ctx) })
+			s.On("Search", wantsVectors, "test", mock.Anything, mock.Anything, 6, 0, mock.Anything).Return(docs, nil)
+
+			svc := retrieval.NewService(e, s, r, settings.NewService(setRepo), nil)
+			lambda := tt.lambda
+			res, err := svc.Search(context.Background(), "test", &retrieval.SearchOptions{Diversity: &lambda})
+
+			assert.NoError(t, err)
+			var got []string
+			for _, d := range res {
+				got = append(got, d.Content)
+			}
+			assert.Equal(t, tt.want, got)
+			r.AssertNotCalled(t, "Rerank", mock.Anything, mock.Anything, mock.Anything)
+			s.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_Search_MinScore(t *testing.T) {
+	e := new(MockEmbedder)
+	s := new(MockStore)
+	r := new(MockReranker)
+	setRepo := new(MockSettingsRepo)
+
+	setRepo.On("Get", mock.Anything).Return(&settings.Settings{SearchTopK: 10, SearchAlpha: 0.5}, nil)
+	e.On("Embed", mock.Anything, "test").Return([]float32{0.1}, nil)
+	s.On("Search", mock.Anything, "test", mock.Anything, mock.Anything, 10, 0, mock.Anything).Return([]retrieval.SearchResult{
+		{Content: "high", Score: 0.9},
+		{Content: "mid", Score: 0.3},
+		{Content: "low", Score: 0.1},
+	}, nil)
+	// The reranker reverses the survivors without rescoring them
+	r.On("Rerank", mock.Anything, "test", []string{"high", "mid"}).Return([]int{1, 0}, nil)
+
+	svc := retrieval.NewService(e, s, r, settings.NewService(setRepo), nil)
+
+	t.Run("below threshold dropped", func(t *testing.T) {
+		minScore := float32(0.25)
+		res, err := svc.Search(context.Background(), "test", &retrieval.SearchOptions{MinScore: &minScore})
+
+		assert.NoError(t, err)
+		assert.Len(t, res, 2)
+		assert.Equal(t, "mid", res[0].Content)
+		assert.Equal(t, "high", res[1].Content)
+	})
+
+	t.Run("threshold above all but one", func(t *testing.T) {
+		minScore := float32(0.5)
+		res, err := svc.Search(context.Background(), "test", &retrieval.SearchOptions{MinScore: &minScore})
+
+		assert.NoError(t, err)
+		assert.Len(t, res, 1)
+		assert.Equal(t, "high", res[0].Content)
+		assert.Equal(t, float32(0.9), res[0].Score)
+	})
+}
+
+func TestService_Search_Dedupe(t *testing.T) {
+	boilerplate := "Was this page helpful? Let us know on the forum."
+	docs := []retrieval.SearchResult{
+		{Content: boilerplate, Score: 0.8, URL: "https://docs.example.com/a"},
+		{Content: "Webhooks retry for three days.", Score: 0.7, URL: "https://docs.example.com/b"},
+		{Content: boilerplate, Score: 0.9, URL: "https://docs.example.com/c"},
+		{Content: boilerplate, Score: 0.4, URL: "https://docs.example.com/d"},
+	}
+
+	tests := []struct {
+		name   string
+		dedupe bool
+		want   []retrieval.SearchResult
+	}{
+		{"off by default", false, docs},
+		{"collapsed into highest score", true, []retrieval.SearchResult{
+			{Content: boilerplate, Score: 0.9, URL: "https://docs.example.com/c", DuplicateCount: 2},
+			docs[1],
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := new(MockEmbedder)
+			s := new(MockStore)
+			setRepo := new(MockSettingsRepo)
+
+			setRepo.On("Get", mock.Anything).Return(&settings.Settings{SearchTopK: 10, SearchAlpha: 0.5}, nil)
+			e.On("Embed", mock.Anything, "test").Return([]float32{0.1}, nil)
+			s.On("Search", mock.Anything, "test", mock.Anything, mock.Anything, 10, 0, mock.Anything).Return(docs, nil)
+
+			svc := retrieval.NewService(e, s, nil, settings.NewService(setRepo), nil)
+			res, err := svc.Search(context.Background(), "test", &retrieval.SearchOptions{Dedupe: tt.dedupe})
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, res)
+		})
+	}
+}
+
+func TestService_Search_Offset(t *testing.T) {
+	tests := []struct {
+		name      string
+		offset    int
+		limit     int
+		wantFetch int
+	}{
+		{"passed through", 20, 10, 10},
+		{"capped at search window", retrieval.MaxSearchWindow - 4, 10, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := new(MockEmbedder)
+			s := new(MockStore)
+			setRepo := new(MockSettingsRepo)
+
+			setRepo.On("Get", mock.Anything).Return(&settings.Settings{SearchTopK: 10, SearchAlpha: 0.5}, nil)
+			e.On("Embed", mock.Anything, "test").Return([]float32{0.1}, nil)
+			s.On("Search", mock.Anything, "test", mock.Anything, mock.Anything, tt.wantFetch, tt.offset, mock.Anything).
+				Return([]retrieval.SearchResult{{Content: "A", Score: 0.5}}, nil)
+
+			svc := retrieval.NewService(e, s, nil, settings.NewService(setRepo), nil)
+			offset, limit := tt.offset, tt.limit
+			res, err := svc.Search(context.Background(), "test", &retrieval.SearchOptions{Offset: &offset, Limit: &limit})
+
+			assert.NoError(t, err)
+			assert.Len(t, res, 1)
+			s.AssertExpectations(t)
+		})
+	}
+
+	t.Run("grouped pages do not overlap", func(t *testing.T) {
+		hits := []retrieval.SearchResult{
+			{Content: "A1", Score: 0.9, URL: "http://a"},
+			{Content: "A2", Score: 0.88, URL: "http://a"},
+			{Content: "B1", Score: 0.85, URL: "http://b"},
+			{Content: "A3", Score: 0.8, URL: "http://a"},
+			{Content: "C1", Score: 0.75, URL: "http://c"},
+			{Content: "D1", Score: 0.7, URL: "http://d"},
+		}
+		e := new(MockEmbedder)
+		s := new(MockStore)
+		setRepo := new(MockSettingsRepo)
+		setRepo.On("Get", mock.Anything).Return(&settings.Settings{SearchTopK: 10, SearchAlpha: 0.5}, nil)
+		e.On("Embed", mock.Anything, "test").Return([]float32{0.1}, nil)
+		// Both pages rank from the top; page 2 draws enough to cover page 1
+		s.On("Search", mock.Anything, "test", mock.Anything, mock.Anything, 6, 0, mock.Anything).Return(hits, nil).Once()
+		s.On("Search", mock.Anything, "test", mock.Anything, mock.Anything, 12, 0, mock.Anything).Return(hits, nil).Once()
+
+		svc := retrieval.NewService(e, s, nil, settings.NewService(setRepo), nil)
+		page := func(offset int) []string {
+			limit, perPage := 2, 1
+			res, err := svc.Search(context.Background(), "test", &retrieval.SearchOptions{
+				Offset: &offset, Limit: &limit, SnippetsPerPage: &perPage,
+			})
+			assert.NoError(t, err)
+			got := make([]string, len(res))
+			for i, d := range res {
+				got[i] = d.Content
+			}
+			return got
+		}
+
+		assert.Equal(t, []string{"A1", "B1"}, page(0))
+		assert.Equal(t, []string{"C1", "D1"}, page(2))
+		s.AssertExpectations(t)
+	})
+
+	t.Run("past the search window", func(t *testing.T) {
+		e := new(MockEmbedder)
+		s := new(MockStore)
+		setRepo := new(MockSettingsRepo)
+		setRepo.On("Get", mock.Anything).Return(&settings.Settings{SearchTopK: 10, SearchAlpha: 0.5}, nil)
+
+		svc := retrieval.NewService(e, s, nil, settings.NewService(setRepo), nil)
+		offset := retrieval.MaxSearchWindow
+		res, err := svc.Search(context.Background(), "test", &retrieval.SearchOptions{Offset: &offset})
+
+		assert.NoError(t, err)
+		assert.Empty(t, res)
+		e.AssertNotCalled(t, "Embed", mock.Anything, mock.Anything)
+		s.AssertNotCalled(t, "Search", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestService_Search_QueryCache(t *testing.T) {
+	e := new(MockEmbedder)
+	s := new(MockStore)
+	setRepo := new(MockSettingsRepo)
+
+	setRepo.On("Get", mock.Anything).Return(&settings.Settings{SearchTopK: 10, SearchAlpha: 0.5}, nil)
+	e.On("Embed", mock.Anything, "webhooks").Return([]float32{0.3}, nil).Once()
+	e.On("EmbedWithModel", mock.Anything, "code-model", "webhooks").Return([]float32{0.7}, nil).Once()
+	s.On("Search", mock.Anything, "webhooks", mock.Anything, float32(0.5), 10, 0, mock.Anything).
+		Return([]retrieval.SearchResult{}, nil)
+
+	svc := retrieval.NewService(e, s, nil, settings.NewService(setRepo), nil)
+	svc.SetSourceModels(stubSourceModels{"src-code": "code-model"})
+	svc.SetQueryCache(16, 0)
+
+	for i := 0; i < 2; i++ {
+		_, err := svc.Search(context.Background(), "webhooks", nil)
+		assert.NoError(t, err)
+	}
+	e.AssertNumberOfCalls(t, "Embed", 1)
+	s.AssertNumberOfCalls(t, "Search", 2)
+
+	// The same query under another model is cached separately
+	scoped := &retrieval.SearchOptions{Filters: map[string]interface{}{"sourceId": "src-code"}}
+	for i := 0; i < 2; i++ {
+		_, err := svc.Search(context.Background(), "webhooks", scoped)
+		assert.NoError(t, err)
+	}
+	e.AssertNumberOfCalls(t, "EmbedWithModel", 1)
+	s.AssertCalled(t, "Search", mock.Anything, "webhooks", []float32{0.7}, float32(0.5), 10, 0, map[string]interface{}{"sourceId": "src-code", "embeddingModel": "code-model"})
+}
+
+type MockQueryEmbedder struct{ MockEmbedder }
+
+func (m *MockQueryEmbedder) EmbedQuery(ctx context.Context, model, text string) ([]float32, error) {
+	args := m.Called(ctx, model, text)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]float32), args.Error(1)
+}
+
+func TestService_Search_QueryEmbedder(t *testing.T) {
+	e := new(MockQueryEmbedder)
+	s := new(MockStore)
+	setRepo := new(MockSettingsRepo)
+
+	setRepo.On("Get", mock.Anything).Return(&settings.Settings{SearchTopK: 10, SearchAlpha: 0.5}, nil)
+	e.On("EmbedQuery", mock.Anything, "", "webhooks").Return([]float32{0.3}, nil)
+	e.On("EmbedQuery", mock.Anything, "code-model", "webhooks").Return([]float32{0.7}, nil)
+	s.On("Search", mock.Anything, "webhooks", mock.Anything, float32(0.5), 10, 0, mock.Anything).
+		Return([]retrieval.SearchResult{}, nil)
+
+	svc := retrieval.NewService(e, s, nil, settings.NewService(setRepo), nil)
+	svc.SetSourceModels(stubSourceModels{"src-code": "code-model"})
+
+	_, err := svc.Search(context.Background(), "webhooks", nil)
+	assert.NoError(t, err)
+	_, err = svc.Search(context.Background(), "webhooks", &retrieval.SearchOptions{Filters: map[string]interface{}{"sourceId": "src-code"}})
+	assert.NoError(t, err)
+
+	e.AssertNumberOfCalls(t, "EmbedQuery", 2)
+	e.AssertNotCalled(t, "Embed", mock.Anything, mock.Anything)
+	e.AssertNotCalled(t, "EmbedWithModel", mock.Anything, mock.Anything, mock.Anything)
+	s.AssertCalled(t, "Search", mock.Anything, "webhooks", []float32{0.3}, float32(0.5), 10, 0, map[string]interface{}(nil))
+	s.AssertCalled(t, "Search", mock.Anything, "webhooks", []float32{0.7}, float32(0.5), 10, 0, map[string]interface{}{"sourceId": "src-code", "embeddingModel": "code-model"})
+}
+
+func TestParseRange(t *testing.T) {
+	r, ok := retrieval.ParseRange(map[string]interface{}{"gte": 0.0, "lt": 3.0})
+	assert.True(t, ok)
+	assert.Equal(t, retrieval.Range{GTE: 0.0, LT: 3.0}, r)
+
+	_, ok = retrieval.ParseRange(map[string]interface{}{"gte": 0.0, "team": "payments"})
+	assert.False(t, ok, "unknown keys are not a range")
+
+	_, ok = retrieval.ParseRange(map[string]interface{}{})
+	assert.False(t, ok)
+}
+
+func TestValidateFilters(t *testing.T) {
+	valid := map[string]interface{}{
+		"type":       "code",
+		"metadata":   map[string]interface{}{"team": "payments"},
+		"chunkIndex": map[string]interface{}{"gte": 0.0, "lte": 2.0},
+		"createdAt":  map[string]interface{}{"gt": "2024-01-01T00:00:00Z"},
+	}
+	assert.NoError(t, retrieval.ValidateFilters(valid))
+	assert.NoError(t, retrieval.ValidateFilters(nil))
+
+	tests := []struct {
+		name    string
+		filters map[string]interface{}
+		want    string
+	}{
+		{"unknown range key", map[string]interface{}{"chunkIndex": map[string]interface{}{"from": 1.0}}, "gt, gte, lt and lte"},
+		{"unparsable bound", map[string]interface{}{"createdAt": map[string]interface{}{"gte": "yesterday"}}, `"yesterday"`},
+		{"unsupported bound", map[string]interface{}{"chunkIndex": map[string]interface{}{"lt": true}}, "bound true"},
+		{"non-string metadata", map[string]interface{}{"metadata": map[string]interface{}{"team": 1.0}}, `"team"`},
+		{"bare number", map[string]interface{}{"chunkIndex": 3.0}, "unsupported value 3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorContains(t, retrieval.ValidateFilters(tt.filters), tt.want)
+		})
+	}
+}
+
+func TestService_Search_DefaultModelSkipsOverrideChunks(t *testing.T) {
+	e := new(MockEmbedder)
+	s := new(MockStore)
+	setRepo := new(MockSettingsRepo)
+
+	setRepo.On("Get", mock.Anything).Return(&settings.Settings{SearchAlpha: 0.5, SearchTopK: 10, EmbeddingModel: "prose-model"}, nil)
+	e.On("Embed", mock.Anything, "test").Return([]float32{0.1}, nil)
+	s.On("Search", mock.Anything, "test", mock.Anything, float32(0.5), 10, 0, mock.Anything).
+		Return([]retrieval.SearchResult{}, nil)
+
+	svc := retrieval.NewService(e, s, nil, settings.NewService(setRepo), nil)
+	svc.SetSourceModels(stubSourceModels{"src-code": "code-model"})
+	svc.SetOverrideModels([]string{"code-model", "prose-model"})
+
+	filters := map[string]interface{}{"type": "prose"}
+	_, err := svc.Search(context.Background(), "test", &retrieval.SearchOptions{Filters: filters})
+	assert.NoError(t, err)
+
+	// Chunks of the current default model still match
+	s.AssertCalled(t, "Search", mock.Anything, "test", []float32{0.1}, float32(0.5), 10, 0,
+		map[string]interface{}{"type": "prose", "embeddingModel": retrieval.NotIn{"code-model"}})
+	assert.Equal(t, map[string]interface{}{"type": "prose"}, filters)
+}
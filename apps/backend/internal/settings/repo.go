@@ -15,8 +15,8 @@ func NewPostgresRepo(db *sql.DB) *PostgresRepo {
 
 func (r *PostgresRepo) Get(ctx context.Context) (*Settings, error) {
 	s := &Settings{}
-	query := `SELECT id, rerank_provider, rerank_api_key, gemini_api_key, search_alpha, search_top_k FROM settings WHERE id = 1`
-	err := r.db.QueryRowContext(ctx, query).Scan(&s.ID, &s.RerankProvider, &s.RerankAPIKey, &s.GeminiAPIKey, &s.SearchAlpha, &s.SearchTopK)
+	query := `SELECT id, rerank_provider, rerank_api_key, gemini_api_key, search_alpha, search_top_k, embedding_model, embedding_provider, embedding_base_url, embedding_api_key FROM settings WHERE id = 1`
+	err := r.db.QueryRowContext(ctx, query).Scan(&s.ID, &s.RerankProvider, &s.RerankAPIKey, &s.GeminiAPIKey, &s.SearchAlpha, &s.SearchTopK, &s.EmbeddingModel, &s.EmbeddingProvider, &s.EmbeddingBaseURL, &s.EmbeddingAPIKey)
 	if err != nil {
 		return nil, err
 	}
@@ -26,9 +26,9 @@ func (r *PostgresRepo) Get(ctx context.Context) (*Settings, error) {
 func (r *PostgresRepo) Update(ctx context.Context, s *Settings) error {
 	query := `
 		UPDATE settings 
-		SET rerank_provider = $1, rerank_api_key = $2, gemini_api_key = $3, search_alpha = $4, search_top_k = $5, updated_at = NOW()
+		SET rerank_provider = $1, rerank_api_key = $2, gemini_api_key = $3, search_alpha = $4, search_top_k = $5, embedding_model = $6, embedding_provider = $7, embedding_base_url = $8, embedding_api_key = $9, updated_at = NOW()
 		WHERE id = 1
 	`
-	_, err := r.db.ExecContext(ctx, query, s.RerankProvider, s.RerankAPIKey, s.GeminiAPIKey, s.SearchAlpha, s.SearchTopK)
+	_, err := r.db.ExecContext(ctx, query, s.RerankProvider, s.RerankAPIKey, s.GeminiAPIKey, s.SearchAlpha, s.SearchTopK, s.EmbeddingModel, s.EmbeddingProvider, s.EmbeddingBaseURL, s.EmbeddingAPIKey)
 	return err
 }
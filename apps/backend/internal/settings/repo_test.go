@@ -18,11 +18,11 @@ func TestPostgresRepo_Get(t *testing.T) {
 	repo := settings.NewPostgresRepo(db)
 
 	t.Run("Success", func(t *testing.T) {
-		rows := sqlmock.NewRows([]string{"id", "rerank_provider", "rerank_api_key", "gemini_api_key", "search_alpha", "search_top_k"}).
-			AddRow(1, "cohere", "key1", "key2", 0.5, 10)
+		rows := sqlmock.NewRows([]string{"id", "rerank_provider", "rerank_api_key", "gemini_api_key", "search_alpha", "search_top_k", "embedding_model", "embedding_provider", "embedding_base_url", "embedding_api_key"}).
+			AddRow(1, "cohere", "key1", "key2", 0.5, 10, "text-embedding-004", "openai", "http://localhost:8080/v1", "")
 
 		// Regex matching for the query
-		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, rerank_provider, rerank_api_key, gemini_api_key, search_alpha, search_top_k FROM settings WHERE id = 1")).
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, rerank_provider, rerank_api_key, gemini_api_key, search_alpha, search_top_k, embedding_model, embedding_provider, embedding_base_url, embedding_api_key FROM settings WHERE id = 1")).
 			WillReturnRows(rows)
 
 		s, err := repo.Get(context.Background())
@@ -30,6 +30,9 @@ func TestPostgresRepo_Get(t *testing.T) {
 		assert.NotNil(t, s)
 		assert.Equal(t, "cohere", s.RerankProvider)
 		assert.Equal(t, float32(0.5), s.SearchAlpha)
+		assert.Equal(t, "text-embedding-004", s.EmbeddingModel)
+		assert.Equal(t, "openai", s.EmbeddingProvider)
+		assert.Equal(t, "http://localhost:8080/v1", s.EmbeddingBaseURL)
 	})
 
 	t.Run("Error", func(t *testing.T) {
@@ -56,10 +59,14 @@ func TestPostgresRepo_Update(t *testing.T) {
 			GeminiAPIKey:   "k2",
 			SearchAlpha:    0.7,
 			SearchTopK:     20,
+			EmbeddingModel: "text-embedding-004",
+
+			EmbeddingProvider: "openai",
+			EmbeddingBaseURL:  "http://localhost:8080/v1",
 		}
 
-		mock.ExpectExec(regexp.QuoteMeta("UPDATE settings SET rerank_provider = $1, rerank_api_key = $2, gemini_api_key = $3, search_alpha = $4, search_top_k = $5, updated_at = NOW() WHERE id = 1")).
-			WithArgs(s.RerankProvider, s.RerankAPIKey, s.GeminiAPIKey, s.SearchAlpha, s.SearchTopK).
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE settings SET rerank_provider = $1, rerank_api_key = $2, gemini_api_key = $3, search_alpha = $4, search_top_k = $5, embedding_model = $6, embedding_provider = $7, embedding_base_url = $8, embedding_api_key = $9, updated_at = NOW() WHERE id = 1")).
+			WithArgs(s.RerankProvider, s.RerankAPIKey, s.GeminiAPIKey, s.SearchAlpha, s.SearchTopK, s.EmbeddingModel, s.EmbeddingProvider, s.EmbeddingBaseURL, s.EmbeddingAPIKey).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 
 		err := repo.Update(context.Background(), s)
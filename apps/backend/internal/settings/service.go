@@ -11,6 +11,18 @@ type Settings struct {
 	GeminiAPIKey   string  `json:"gemini_api_key"`
 	SearchAlpha    float32 `json:"search_alpha"`
 	SearchTopK     int     `json:"search_top_k"`
+
+	// EmbeddingModel is the default Gemini embedding model; empty uses the
+	// built-in default. Chunks embedded with another model must be
+	// re-embedded to stay comparable with new queries.
+	EmbeddingModel string `json:"embedding_model"`
+
+	// EmbeddingProvider selects the embedding API: "gemini" (the default) or
+	// "openai" for any server speaking the OpenAI embeddings protocol, found
+	// at EmbeddingBaseURL and authenticated with EmbeddingAPIKey.
+	EmbeddingProvider string `json:"embedding_provider"`
+	EmbeddingBaseURL  string `json:"embedding_base_url"`
+	EmbeddingAPIKey   string `json:"embedding_api_key"`
 }
 
 type Repository interface {
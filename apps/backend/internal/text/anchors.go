@@ -0,0 +1,52 @@
+package text
+
+import (
+	"regexp"
+	"strings"
+)
+
+// MaxAnchorKeywords caps how many anchor texts are kept per page.
+const MaxAnchorKeywords = 50
+
+var anchorLinkRe = regexp.MustCompile(`(!?)\[([^\]\n]+)\]\([^)\n]*\)`)
+
+// genericAnchors are link texts that say nothing about the target page.
+var genericAnchors = map[string]bool{
+	"here":           true,
+	"click here":     true,
+	"read more":      true,
+	"learn more":     true,
+	"more":           true,
+	"next":           true,
+	"previous":       true,
+	"back to top":    true,
+	"edit this page": true,
+	"home":           true,
+}
+
+// ExtractAnchorKeywords returns the descriptive link texts in markdown, such as
+// "configure webhooks", deduplicated case-insensitively in document order.
+// Images, bare URLs, single words and generic labels like "read more" are
+// skipped.
+func ExtractAnchorKeywords(markdown string) []string {
+	var keywords []string
+	seen := make(map[string]bool)
+	for _, m := range anchorLinkRe.FindAllStringSubmatch(markdown, -1) {
+		if m[1] == "!" {
+			continue
+		}
+		anchor := strings.Join(strings.Fields(strings.Trim(m[2], "`*_ ")), " ")
+		lower := strings.ToLower(anchor)
+		words := len(strings.Fields(anchor))
+		if words < 2 || words > 8 || genericAnchors[lower] || seen[lower] ||
+			strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") {
+			continue
+		}
+		seen[lower] = true
+		keywords = append(keywords, anchor)
+		if len(keywords) == MaxAnchorKeywords {
+			break
+		}
+	}
+	return keywords
+}
@@ -0,0 +1,18 @@
+package text
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractAnchorKeywords(t *testing.T) {
+	md := "See [Configure webhooks](/docs/webhooks) and [read more](/more).\n" +
+		"- [Rotate API keys](/docs/keys)\n" +
+		"- [configure  Webhooks](/docs/webhooks#top)\n" +
+		"- [Home](/)\n" +
+		"![Architecture diagram](/img/arch.png)\n" +
+		"[https://example.com/docs](https://example.com/docs)\n"
+
+	assert.Equal(t, []string{"Configure webhooks", "Rotate API keys"}, ExtractAnchorKeywords(md))
+}
@@ -1,8 +1,11 @@
 package text
 
 import (
+	"encoding/json"
+	"log/slog"
 	"regexp"
 	"strings"
+	"unicode"
 )
 
 type ChunkType string
@@ -19,6 +22,38 @@ type ChunkResult struct {
 	Content  string
 	Type     ChunkType
 	Language string
+	// Heading is the path of headings the chunk sits under, e.g.
+	// "# API > ## Authentication". Empty for content before the first heading.
+	// It is kept out of Content, which holds only the page's own text.
+	Heading string
+	// Overlap is the length in bytes of the text at the start of Content
+	// repeated from the end of the previous chunk, joining space included.
+	Overlap int
+}
+
+// HeadingSeparator joins the levels of ChunkResult.Heading.
+const HeadingSeparator = " > "
+
+// headingStack tracks the headings enclosing the current position as a
+// document is walked top to bottom.
+type headingStack struct {
+	levels []int
+	titles []string
+}
+
+// push records a heading line, closing any open headings at the same or a
+// deeper level.
+func (h *headingStack) push(line string) {
+	level := len(line) - len(strings.TrimLeft(line, "#"))
+	for n := len(h.levels); n > 0 && h.levels[n-1] >= level; n-- {
+		h.levels, h.titles = h.levels[:n-1], h.titles[:n-1]
+	}
+	h.levels = append(h.levels, level)
+	h.titles = append(h.titles, strings.TrimSpace(line))
+}
+
+func (h *headingStack) path() string {
+	return strings.Join(h.titles, HeadingSeparator)
 }
 
 // CleanMarkdownNoise removes common documentation boilerplate from markdown
@@ -110,11 +145,41 @@ func filterNonEmpty(lines []string) []string {
 // preserving code blocks and identifying their language.
 // It also splits large prose blocks into smaller chunks.
 // Low-value noise chunks (install commands, nav links, etc.) are filtered out.
+// Chunk sizes are measured with DefaultTokenCounter.
 func ChunkMarkdown(text string, maxTokens, overlap int) []ChunkResult {
+	return ChunkMarkdownWithCounter(text, maxTokens, overlap, DefaultTokenCounter)
+}
+
+// ChunkMarkdownWithCounter is ChunkMarkdown measuring chunk sizes with tc.
+func ChunkMarkdownWithCounter(text string, maxTokens, overlap int, tc TokenCounter) []ChunkResult {
+	return ChunkMarkdownWithOptions(text, maxTokens, overlap, ChunkOptions{Counter: tc})
+}
+
+// ChunkOptions tunes how ChunkMarkdownWithOptions splits text.
+type ChunkOptions struct {
+	// Counter sizes chunks; nil means DefaultTokenCounter.
+	Counter TokenCounter
+	// SplitLists lets oversized prose break inside a list item like any
+	// other text. By default a list is kept whole when it fits in a chunk
+	// and otherwise split only between its items.
+	SplitLists bool
+}
+
+func (o ChunkOptions) counter() TokenCounter {
+	if o.Counter == nil {
+		return DefaultTokenCounter
+	}
+	return o.Counter
+}
+
+// ChunkMarkdownWithOptions is ChunkMarkdown tuned by opts.
+func ChunkMarkdownWithOptions(text string, maxTokens, overlap int, opts ChunkOptions) []ChunkResult {
+	tc := opts.counter()
 	// Pre-process: remove common documentation boilerplate
 	text = CleanMarkdownNoise(text)
 
 	var results []ChunkResult
+	var headings headingStack
 
 	// Regex for code fences: ```lang\n content \n```
 	// We use (?s) to allow . to match newlines
@@ -129,7 +194,7 @@ func ChunkMarkdown(text string, maxTokens, overlap int) []ChunkResult {
 		if match[0] > lastIndex {
 			prose := strings.TrimSpace(text[lastIndex:match[0]])
 			if len(prose) > 0 {
-				proseChunks := chunkProse(prose, maxTokens, overlap)
+				proseChunks := chunkProseUnder(prose, maxTokens, overlap, &headings, opts)
 				results = append(results, proseChunks...)
 			}
 		}
@@ -140,64 +205,164 @@ func ChunkMarkdown(text string, maxTokens, overlap int) []ChunkResult {
 			lang = text[match[2]:match[3]]
 		}
 		content := text[match[4]:match[5]]
-
-		cType := ChunkTypeCode
-		if lang == "yaml" || lang == "json" || lang == "toml" {
-			cType = ChunkTypeConfig
-		} else if lang == "bash" || lang == "sh" || lang == "shell" {
-			cType = ChunkTypeCmd
-		} else if lang == "http" || lang == "graphql" || lang == "openapi" || lang == "swagger" {
-			cType = ChunkTypeAPI
-		}
-
-		// Estimate tokens (approx 4 chars per token)
-		estimatedTokens := len(content) / 4
-		if estimatedTokens > maxTokens {
-			codeChunks := chunkCode(content, lang, cType, maxTokens)
-			results = append(results, codeChunks...)
-		} else {
-			fullBlock := "```" + lang + "\n" + content + "\n```"
-			results = append(results, ChunkResult{
-				Content:  fullBlock,
-				Type:     cType,
-				Language: lang,
-			})
+		if lang == "" {
+			lang = guessFenceLanguage(text[lastIndex:match[0]], content)
 		}
+		results = append(results, withHeading(codeBlockChunks(content, lang, maxTokens, tc), headings.path())...)
 
 		lastIndex = match[1]
 	}
 
 	// 3. Remaining prose after the last code block
 	if lastIndex < len(text) {
-		prose := strings.TrimSpace(text[lastIndex:])
+		rest := text[lastIndex:]
+		// An opening fence without a close (truncated page) starts a code
+		// block that runs to the end of the document
+		var tail []ChunkResult
+		if loc := openFenceRe.FindStringSubmatchIndex(rest); loc != nil {
+			lang := ""
+			if loc[2] != -1 {
+				lang = rest[loc[2]:loc[3]]
+			}
+			content := strings.TrimRight(strings.TrimPrefix(rest[loc[1]:], "\n"), " \t\n")
+			if strings.TrimSpace(content) != "" {
+				if lang == "" {
+					lang = guessFenceLanguage(rest[:loc[0]], content)
+				}
+				slog.Warn("unterminated code fence, treating remainder as code", "language", lang, "chars", len(content))
+				tail = codeBlockChunks(content, lang, maxTokens, tc)
+			}
+			rest = rest[:loc[0]]
+		}
+
+		prose := strings.TrimSpace(rest)
 		if len(prose) > 0 {
-			proseChunks := chunkProse(prose, maxTokens, overlap)
+			proseChunks := chunkProseUnder(prose, maxTokens, overlap, &headings, opts)
 			results = append(results, proseChunks...)
 		}
+		results = append(results, withHeading(tail, headings.path())...)
 	}
 
-	// Post-filter: remove noise chunks
+	return finalizeChunks(results)
+}
+
+// finalizeChunks drops noise chunks.
+func finalizeChunks(results []ChunkResult) []ChunkResult {
 	filtered := make([]ChunkResult, 0, len(results))
 	for _, chunk := range results {
-		if !IsNoiseChunk(chunk.Content) {
-			filtered = append(filtered, chunk)
+		if IsNoiseChunk(chunk.Content) {
+			continue
 		}
+		filtered = append(filtered, chunk)
 	}
-
 	return filtered
 }
 
+// withHeading sets the heading path on chunks.
+func withHeading(chunks []ChunkResult, heading string) []ChunkResult {
+	for i := range chunks {
+		chunks[i].Heading = heading
+	}
+	return chunks
+}
+
+// openFenceRe matches a line opening a code fence. ChunkMarkdown only applies
+// it after all closed fences have been consumed.
+var openFenceRe = regexp.MustCompile("(?m)^[ \t]*```([a-zA-Z0-9_]+)?[ \t]*$")
+
+// codeBlockChunks turns the body of one fenced block into chunks, typed by
+// language and split by line when it exceeds maxTokens.
+func codeBlockChunks(content, lang string, maxTokens int, tc TokenCounter) []ChunkResult {
+	cType := ChunkTypeCode
+	if lang == "yaml" || lang == "json" || lang == "toml" {
+		cType = ChunkTypeConfig
+	} else if lang == "bash" || lang == "sh" || lang == "shell" {
+		cType = ChunkTypeCmd
+	} else if lang == "http" || lang == "graphql" || lang == "openapi" || lang == "swagger" {
+		cType = ChunkTypeAPI
+	}
+
+	if tc.EstimateTokens(content) > maxTokens {
+		return chunkCode(content, lang, cType, maxTokens, tc)
+	}
+	return []ChunkResult{{
+		Content:  "```" + lang + "\n" + content + "\n```",
+		Type:     cType,
+		Language: lang,
+	}}
+}
+
+// ChunkDocument chunks a whole page. Pages whose estimated size is below
+// minTokensToSplit are kept as a single chunk so short documents don't lose
+// context across chunk boundaries. A threshold of 0 always splits.
+func ChunkDocument(text string, maxTokens, overlap, minTokensToSplit int) []ChunkResult {
+	return ChunkDocumentWithOptions(text, maxTokens, overlap, minTokensToSplit, ChunkOptions{})
+}
+
+// ChunkDocumentWithOptions is ChunkDocument tuned by opts.
+func ChunkDocumentWithOptions(text string, maxTokens, overlap, minTokensToSplit int, opts ChunkOptions) []ChunkResult {
+	if minTokensToSplit > 0 {
+		cleaned := strings.TrimSpace(CleanMarkdownNoise(text))
+		if opts.counter().EstimateTokens(cleaned) < minTokensToSplit {
+			if IsNoiseChunk(cleaned) {
+				return nil
+			}
+			return []ChunkResult{{Content: cleaned, Type: detectChunkType(cleaned)}}
+		}
+	}
+	return ChunkMarkdownWithOptions(text, maxTokens, overlap, opts)
+}
+
+// MergeAdjacentCode combines consecutive fenced code chunks of the same
+// language and type while the merged chunk stays within maxTokens. Prose
+// chunks are never merged, so a paragraph between two snippets keeps them
+// apart.
+func MergeAdjacentCode(chunks []ChunkResult, maxTokens int) []ChunkResult {
+	tc := DefaultTokenCounter
+	merged := make([]ChunkResult, 0, len(chunks))
+	for _, c := range chunks {
+		if n := len(merged); n > 0 && isFencedCode(c) && isFencedCode(merged[n-1]) {
+			prev := merged[n-1]
+			if prev.Language == c.Language && prev.Type == c.Type && tc.EstimateTokens(prev.Content)+1+tc.EstimateTokens(c.Content) <= maxTokens {
+				merged[n-1].Content = prev.Content + "\n\n" + c.Content
+				continue
+			}
+		}
+		merged = append(merged, c)
+	}
+	return merged
+}
+
+func isFencedCode(c ChunkResult) bool {
+	return c.Type != ChunkTypeProse && strings.HasPrefix(c.Content, "```")
+}
+
+// headerRe matches a markdown heading at the start of a line.
+var headerRe = regexp.MustCompile(`(?m)^#{1,6}\s`)
+
+func startsWithHeading(s string) bool {
+	loc := headerRe.FindStringIndex(s)
+	return loc != nil && loc[0] == 0
+}
+
 // chunkProse splits prose into chunks respecting structure: Headers -> Paragraphs -> Lines -> Words
-func chunkProse(text string, maxTokens, overlap int) []ChunkResult {
+func chunkProse(text string, maxTokens, overlap int, tc TokenCounter) []ChunkResult {
+	return chunkProseUnder(text, maxTokens, overlap, &headingStack{}, ChunkOptions{Counter: tc})
+}
+
+// chunkProseUnder is chunkProse continuing from the headings already open in
+// headings, which it updates as it passes new ones.
+func chunkProseUnder(text string, maxTokens, overlap int, headings *headingStack, opts ChunkOptions) []ChunkResult {
 	if text == "" {
 		return nil
 	}
+	tc := opts.counter()
 
-	// Approx chars per token
-	maxChars := maxTokens * 4
+	// Overlap is carved out of the chunk budget so a chunk plus the tail it
+	// inherits from its predecessor still fits in maxTokens.
+	overlapTokens := min(overlap, maxTokens/2)
 
 	// 1. Split by Headers (level 1-6)
-	headerRe := regexp.MustCompile(`(?m)^#{1,6}\s`)
 	headerIndices := headerRe.FindAllStringIndex(text, -1)
 
 	var sections []string
@@ -221,99 +386,230 @@ func chunkProse(text string, maxTokens, overlap int) []ChunkResult {
 			continue
 		}
 
-		if len(section) <= maxChars {
-			chunks = append(chunks, ChunkResult{Content: section, Type: detectChunkType(section)})
+		if startsWithHeading(section) {
+			line, _, _ := strings.Cut(section, "\n")
+			headings.push(line)
+		}
+		heading := headings.path()
+
+		if tc.EstimateTokens(section) <= maxTokens {
+			chunks = append(chunks, ChunkResult{Content: section, Type: detectChunkType(section), Heading: heading})
 			continue
 		}
 
 		// 2. Split by Paragraphs
-		paragraphs := strings.Split(section, "\n\n")
+		budget := maxTokens - overlapTokens
+		paragraphs := splitParagraphs(section, !opts.SplitLists)
 		var currentChunk strings.Builder
-
-		for _, para := range paragraphs {
-			para = strings.TrimSpace(para)
-			if len(para) == 0 {
-				continue
+		currentTokens := 0
+		var sectionChunks []ChunkResult
+
+		flush := func() {
+			if currentChunk.Len() > 0 {
+				sectionChunks = append(sectionChunks, ChunkResult{Content: currentChunk.String(), Type: detectChunkType(currentChunk.String())})
+				currentChunk.Reset()
+				currentTokens = 0
+			}
+		}
+		// Separators are counted as one token each
+		write := func(sep, piece string, tokens int) {
+			if currentChunk.Len() > 0 {
+				currentChunk.WriteString(sep)
 			}
+			currentChunk.WriteString(piece)
+			currentTokens += tokens + 1
+		}
 
-			// If paragraph fits in current chunk
-			if currentChunk.Len()+len(para)+2 <= maxChars {
-				if currentChunk.Len() > 0 {
-					currentChunk.WriteString("\n\n")
+		// 3. Split by Lines, then by Words (fallback)
+		writeLines := func(para string) {
+			for _, line := range strings.Split(para, "\n") {
+				lineTokens := tc.EstimateTokens(line)
+				if currentTokens+lineTokens+1 <= budget {
+					write("\n", line, lineTokens)
+					continue
 				}
-				currentChunk.WriteString(para)
-			} else {
-				// Flush current chunk if not empty
-				if currentChunk.Len() > 0 {
-					chunks = append(chunks, ChunkResult{Content: currentChunk.String(), Type: detectChunkType(currentChunk.String())})
-					currentChunk.Reset()
+				flush()
+
+				if lineTokens <= budget {
+					write("\n", line, lineTokens)
+					continue
 				}
 
-				// Handle large paragraph
-				if len(para) > maxChars {
-					// 3. Split by Lines
-					lines := strings.Split(para, "\n")
-					for _, line := range lines {
-						if currentChunk.Len()+len(line)+1 <= maxChars {
-							if currentChunk.Len() > 0 {
-								currentChunk.WriteString("\n")
-							}
-							currentChunk.WriteString(line)
-						} else {
-							if currentChunk.Len() > 0 {
-								chunks = append(chunks, ChunkResult{Content: currentChunk.String(), Type: detectChunkType(currentChunk.String())})
-								currentChunk.Reset()
-							}
-
-							// 4. Split by Words (Fallback)
-							if len(line) > maxChars {
-								words := strings.Fields(line)
-								for _, word := range words {
-									if currentChunk.Len()+len(word)+1 <= maxChars {
-										if currentChunk.Len() > 0 {
-											currentChunk.WriteString(" ")
-										}
-										currentChunk.WriteString(word)
-									} else {
-										chunks = append(chunks, ChunkResult{Content: currentChunk.String(), Type: detectChunkType(currentChunk.String())})
-										currentChunk.Reset()
-										currentChunk.WriteString(word)
-									}
-								}
-							} else {
-								currentChunk.WriteString(line)
-							}
-						}
+				for _, word := range strings.Fields(line) {
+					wordTokens := tc.EstimateTokens(word)
+					if currentTokens+wordTokens+1 > budget {
+						flush()
 					}
-				} else {
-					currentChunk.WriteString(para)
+					write(" ", word, wordTokens)
 				}
 			}
 		}
 
-		if currentChunk.Len() > 0 {
-			chunks = append(chunks, ChunkResult{Content: currentChunk.String(), Type: detectChunkType(currentChunk.String())})
+		for _, para := range paragraphs {
+			paraTokens := tc.EstimateTokens(para)
+
+			// If paragraph fits in current chunk
+			if currentTokens+paraTokens+1 <= budget {
+				write("\n\n", para, paraTokens)
+				continue
+			}
+
+			// Flush current chunk if not empty
+			flush()
+
+			if paraTokens <= budget {
+				write("\n\n", para, paraTokens)
+				continue
+			}
+
+			if opts.SplitLists || !isListBlock(para) {
+				writeLines(para)
+				continue
+			}
+
+			// An oversized list breaks only between items
+			for _, item := range listItems(para) {
+				itemTokens := tc.EstimateTokens(item)
+				if currentTokens+itemTokens+1 <= budget {
+					write("\n", item, itemTokens)
+					continue
+				}
+				flush()
+
+				if itemTokens <= budget {
+					write("\n", item, itemTokens)
+					continue
+				}
+				writeLines(item)
+			}
 		}
+
+		flush()
+		chunks = append(chunks, withHeading(withOverlap(sectionChunks, overlapTokens, tc), heading)...)
 	}
 
 	return chunks
 }
 
+// listItemRe matches a line opening an ordered or unordered list item,
+// capturing its indentation.
+var listItemRe = regexp.MustCompile(`(?m)^([ \t]*)(?:[-*+]|\d{1,9}[.)])[ \t]+\S`)
+
+// splitParagraphs splits a section on blank lines, dropping empty
+// paragraphs. With keepLists, the paragraphs of a loose list (items separated
+// by blank lines, or an item continued by an indented paragraph) are joined
+// back into one paragraph so the list is sized as a whole.
+func splitParagraphs(section string, keepLists bool) []string {
+	var paragraphs []string
+	inList := false
+	for _, raw := range strings.Split(section, "\n\n") {
+		para := strings.TrimSpace(raw)
+		if len(para) == 0 {
+			continue
+		}
+		trimmed := strings.TrimLeft(raw, "\n")
+		loc := listItemRe.FindStringIndex(trimmed)
+		continued := inList && (loc != nil && loc[0] == 0 || strings.HasPrefix(trimmed, "  ") || strings.HasPrefix(trimmed, "\t"))
+		if keepLists && continued {
+			paragraphs[len(paragraphs)-1] += "\n\n" + strings.TrimRight(trimmed, " \t\n")
+		} else {
+			paragraphs = append(paragraphs, para)
+		}
+		inList = loc != nil || continued
+	}
+	return paragraphs
+}
+
+// isListBlock reports whether para contains a list.
+func isListBlock(para string) bool {
+	return listItemRe.MatchString(para)
+}
+
+// listItems splits a list block into its top-level items. Nested items and
+// continuation lines stay with the item they belong to, and any lines
+// introducing the list stay with its first item.
+func listItems(list string) []string {
+	lines := strings.Split(list, "\n")
+	indent := len(listItemRe.FindStringSubmatch(list)[1])
+	started := false
+
+	var items []string
+	var current []string
+	for _, line := range lines {
+		if m := listItemRe.FindStringSubmatch(line); m != nil && len(m[1]) <= indent {
+			if started {
+				items = append(items, strings.TrimRight(strings.Join(current, "\n"), " \t\n"))
+				current = nil
+			}
+			started = true
+		}
+		current = append(current, line)
+	}
+	return append(items, strings.TrimRight(strings.Join(current, "\n"), " \t\n"))
+}
+
+// withOverlap prepends the last overlapTokens of each chunk, cut on a word
+// boundary, to the chunk that follows it, and records the prepended length in
+// Overlap.
+func withOverlap(chunks []ChunkResult, overlapTokens int, tc TokenCounter) []ChunkResult {
+	if overlapTokens <= 0 || len(chunks) < 2 {
+		return chunks
+	}
+
+	out := make([]ChunkResult, len(chunks))
+	out[0] = chunks[0]
+	for i := 1; i < len(chunks); i++ {
+		out[i] = chunks[i]
+		// One token of the overlap budget goes to the joining space
+		if tail := overlapTail(chunks[i-1].Content, overlapTokens-1, tc); tail != "" {
+			out[i].Content = tail + " " + chunks[i].Content
+			out[i].Overlap = len(tail) + 1
+		}
+	}
+	return out
+}
+
+// overlapTail returns the longest run of whole words ending s that fits in n
+// tokens. It never reaches back past a heading line, which belongs to the
+// chunk it opens.
+func overlapTail(s string, n int, tc TokenCounter) string {
+	if locs := headerRe.FindAllStringIndex(s, -1); len(locs) > 0 {
+		last := locs[len(locs)-1][0]
+		if end := strings.IndexByte(s[last:], '\n'); end >= 0 {
+			s = s[last+end:]
+		} else {
+			s = ""
+		}
+	}
+	s = strings.TrimSpace(s)
+	tail := ""
+	for cut := len(s); cut > 0; {
+		// Step back to the start of the previous word
+		prev := strings.LastIndexFunc(strings.TrimRightFunc(s[:cut], unicode.IsSpace), unicode.IsSpace) + 1
+		if prev >= cut {
+			break
+		}
+		if tc.EstimateTokens(s[prev:]) > n {
+			break
+		}
+		tail, cut = s[prev:], prev
+	}
+	return tail
+}
+
 // chunkCode splits a large code block into smaller chunks by line
-func chunkCode(content, lang string, cType ChunkType, maxTokens int) []ChunkResult {
+func chunkCode(content, lang string, cType ChunkType, maxTokens int, tc TokenCounter) []ChunkResult {
 	lines := strings.Split(content, "\n")
 	var chunks []ChunkResult
 
-	charsPerToken := 4
-	maxChars := maxTokens * charsPerToken
-
 	var currentChunk strings.Builder
 	currentLen := 0
 
 	for _, line := range lines {
-		lineLen := len(line) + 1
+		// The line break costs a token of its own
+		lineLen := tc.EstimateTokens(line) + 1
 
-		if currentLen+lineLen > maxChars && currentLen > 0 {
+		if currentLen+lineLen > maxTokens && currentLen > 0 {
 			chunks = append(chunks, ChunkResult{
 				Content:  "```" + lang + "\n" + currentChunk.String() + "\n```",
 				Type:     cType,
@@ -350,3 +646,95 @@ func detectChunkType(content string) ChunkType {
 	}
 	return ChunkTypeProse
 }
+
+// languageAliases maps words found in prose to a canonical fence language.
+var languageAliases = map[string]string{
+	"python":     "python",
+	"rust":       "rust",
+	"go":         "go",
+	"golang":     "go",
+	"javascript": "javascript",
+	"js":         "javascript",
+	"typescript": "typescript",
+	"ts":         "typescript",
+	"java":       "java",
+	"kotlin":     "kotlin",
+	"ruby":       "ruby",
+	"bash":       "bash",
+	"shell":      "shell",
+	"json":       "json",
+	"yaml":       "yaml",
+	"toml":       "toml",
+	"sql":        "sql",
+}
+
+var (
+	// "in Python:", "using Go", "with Rust" etc.
+	proseLangRe = regexp.MustCompile(`(?i)\b(?:in|using|with)\s+([a-z]+)\b`)
+	// A heading that is just the language name, e.g. "## Go"
+	headingLangRe = regexp.MustCompile(`(?i)^#{1,6}\s+([a-z]+)\s*$`)
+)
+
+// guessFenceLanguage infers the language of a fence without an info string.
+// The immediately preceding prose line or heading is checked for a language
+// keyword first; content heuristics are the fallback.
+func guessFenceLanguage(preceding, content string) string {
+	lines := filterNonEmpty(strings.Split(preceding, "\n"))
+	if len(lines) > 0 {
+		hint := strings.TrimSpace(lines[len(lines)-1])
+		if m := headingLangRe.FindStringSubmatch(hint); m != nil {
+			if lang, ok := languageAliases[strings.ToLower(m[1])]; ok {
+				return lang
+			}
+		}
+		for _, m := range proseLangRe.FindAllStringSubmatch(hint, -1) {
+			if lang, ok := languageAliases[strings.ToLower(m[1])]; ok {
+				return lang
+			}
+		}
+	}
+
+	return guessLanguageFromContent(content)
+}
+
+// guessLanguageFromContent recognises telltale tokens of a few common
+// languages. It is deliberately conservative and returns "" when unsure.
+func guessLanguageFromContent(content string) string {
+	trimmed := strings.TrimSpace(content)
+	switch {
+	case strings.HasPrefix(trimmed, "package "), hasLinePrefix(trimmed, "func "), goAssignRe.MatchString(trimmed) && !strings.Contains(trimmed, ";"):
+		return "go"
+	case strings.HasPrefix(trimmed, "fn ") || strings.Contains(trimmed, "\nfn main(") || strings.HasPrefix(trimmed, "use std::"):
+		return "rust"
+	case hasLinePrefix(trimmed, "def ") || pyFromImportRe.MatchString(trimmed) ||
+		(strings.HasPrefix(trimmed, "import ") && !strings.ContainsAny(trimmed, ";\"'{")):
+		return "python"
+	case strings.HasPrefix(trimmed, "#!/bin/bash") || strings.HasPrefix(trimmed, "#!/bin/sh") || strings.HasPrefix(trimmed, "$ "):
+		return "bash"
+	case (strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")) && json.Valid([]byte(trimmed)):
+		return "json"
+	case jsKeywordRe.MatchString(trimmed) && strings.ContainsAny(trimmed, ";{"):
+		return "javascript"
+	}
+	return ""
+}
+
+var (
+	// "x := 1", "res, err := call()" at the start of a line
+	goAssignRe = regexp.MustCompile(`(?m)^\s*\w+(?:,\s*\w+)*\s*:=\s`)
+	// "from pkg import name"
+	pyFromImportRe = regexp.MustCompile(`(?m)^from\s+[\w.]+\s+import\s`)
+	// Declarations and idioms that only show up in JavaScript/TypeScript here
+	jsKeywordRe = regexp.MustCompile(`(?m)^\s*(?:const|let|var|function|import|export\s+(?:default|const|function|class))\s|=>|\brequire\(|\bconsole\.`)
+)
+
+// hasLinePrefix reports whether any line of s, ignoring indentation, starts
+// with prefix.
+func hasLinePrefix(s, prefix string) bool {
+	for _, line := range strings.Split(s, "\n") {
+		if strings.HasPrefix(strings.TrimLeft(line, " \t"), prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestChunkMarkdown(t *testing.T) {
@@ -74,7 +75,7 @@ func TestChunkMarkdown(t *testing.T) {
 func TestChunkProse(t *testing.T) {
 	t.Run("Headers Split", func(t *testing.T) {
 		text := "# Header 1\nContent 1\n## Header 2\nContent 2"
-		chunks := chunkProse(text, 100, 0)
+		chunks := chunkProse(text, 100, 0, CharTokenCounter{})
 		assert.Len(t, chunks, 2)
 		assert.Contains(t, chunks[0].Content, "Header 1")
 		assert.Contains(t, chunks[1].Content, "Header 2")
@@ -89,7 +90,7 @@ func TestChunkProse(t *testing.T) {
 		// If maxTokens is small enough to force split
 		// "Short paragraph." (16) -> Chunk 1
 		// "Another short paragraph." (24) -> Split to "Another short" (13) and "paragraph." (10)
-		chunks := chunkProse(text, 5, 0) // Very small limit (approx 20 chars)
+		chunks := chunkProse(text, 5, 0, CharTokenCounter{}) // Very small limit (approx 20 chars)
 		assert.Len(t, chunks, 3)
 	})
 
@@ -99,18 +100,184 @@ func TestChunkProse(t *testing.T) {
 		line2 := "Line 2 is also long."
 		text := line1 + "\n" + line2
 
-		chunks := chunkProse(text, 5, 0)
+		chunks := chunkProse(text, 5, 0, CharTokenCounter{})
 		assert.True(t, len(chunks) >= 2)
 	})
 
 	t.Run("Word Split", func(t *testing.T) {
 		// Very long line
 		text := "VeryLongWordThatExceedsLimit AnotherWord"
-		chunks := chunkProse(text, 2, 0) // ~8 chars
+		chunks := chunkProse(text, 2, 0, CharTokenCounter{}) // ~8 chars
 		assert.True(t, len(chunks) >= 2)
 	})
 }
 
+func TestChunkProse_Overlap(t *testing.T) {
+	para1 := "Webhooks are delivered with an exponential backoff schedule. Failed deliveries are retried for up to three days before being dropped."
+	para2 := "Each delivery carries a signature header. Verify it against your endpoint secret before trusting the payload in any way."
+	para3 := "Rotating the endpoint secret invalidates older signatures immediately, so deploy the new secret before rotating it here."
+	text := para1 + "\n\n" + para2 + "\n\n" + para3
+
+	// 200 chars per chunk, 40 of them shared with the previous chunk
+	chunks := chunkProse(text, 50, 10, CharTokenCounter{})
+	require.Len(t, chunks, 3)
+
+	assert.Equal(t, para1, chunks[0].Content)
+	for i := 1; i < len(chunks); i++ {
+		prev := []string{para1, para2}[i-1]
+		head, own, ok := strings.Cut(chunks[i].Content, " "+[]string{para2, para3}[i-1])
+		require.True(t, ok, "chunk %d should end with its own paragraph", i)
+		assert.Empty(t, own)
+		assert.NotEmpty(t, head)
+		assert.LessOrEqual(t, len(head), 40)
+		// The shared text is the previous chunk's tail, cut on a word boundary
+		assert.True(t, strings.HasSuffix(prev, " "+head), "chunk %d overlap %q is not a word-aligned tail", i, head)
+		assert.LessOrEqual(t, len(chunks[i].Content), 200)
+	}
+
+	t.Run("Overlap larger than chunk is capped", func(t *testing.T) {
+		chunks := chunkProse(text, 50, 1000, CharTokenCounter{})
+		for _, c := range chunks {
+			assert.LessOrEqual(t, len(c.Content), 200)
+		}
+	})
+}
+
+func TestChunkProse_KeepsLists(t *testing.T) {
+	steps := []string{
+		"1. Create an API key in the dashboard\n   under Settings, Developers.",
+		"2. Store the key in your secret manager\n   rather than in source control.",
+		"3. Install the client library for your\n   language from its package registry.",
+		"4. Initialise the client with the key\n   read from the environment.",
+		"5. Send a test request to the ping\n   endpoint to confirm it works.",
+	}
+	list := strings.Join(steps, "\n")
+	intro := "Follow these steps to make your first authenticated request against the production API."
+	outro := "Once the ping succeeds you can move on to creating resources in the test environment first."
+	text := intro + "\n\n" + list + "\n\n" + outro
+
+	t.Run("List under the limit stays in one chunk", func(t *testing.T) {
+		// 440 chars per chunk: the list fits, the whole section does not
+		chunks := chunkProse(text, 110, 0, CharTokenCounter{})
+		require.Greater(t, len(chunks), 1)
+
+		var found bool
+		for _, c := range chunks {
+			if strings.Contains(c.Content, steps[0]) {
+				assert.Contains(t, c.Content, list)
+				found = true
+			}
+		}
+		assert.True(t, found)
+	})
+
+	t.Run("Oversized list splits between items", func(t *testing.T) {
+		// 140 chars per chunk: under two steps
+		chunks := chunkProse(text, 35, 0, CharTokenCounter{})
+		for _, step := range steps {
+			var holders int
+			for _, c := range chunks {
+				if strings.Contains(c.Content, step) {
+					holders++
+				}
+			}
+			assert.Equal(t, 1, holders, "step %q should sit whole in exactly one chunk", step)
+		}
+		for _, c := range chunks {
+			assert.False(t, strings.HasPrefix(c.Content, "   "), "chunk starts mid-item: %q", c.Content)
+		}
+	})
+
+	t.Run("Loose list items are kept together", func(t *testing.T) {
+		loose := intro + "\n\n" + strings.Join(steps, "\n\n") + "\n\n" + outro
+		chunks := chunkProse(loose, 110, 0, CharTokenCounter{})
+		var found bool
+		for _, c := range chunks {
+			if strings.Contains(c.Content, steps[0]) {
+				assert.Contains(t, c.Content, steps[4])
+				found = true
+			}
+		}
+		assert.True(t, found)
+	})
+
+	t.Run("SplitLists restores line splitting", func(t *testing.T) {
+		chunks := chunkProseUnder(text, 35, 0, &headingStack{}, ChunkOptions{Counter: CharTokenCounter{}, SplitLists: true})
+		var whole int
+		for _, step := range steps {
+			for _, c := range chunks {
+				if strings.Contains(c.Content, step) {
+					whole++
+				}
+			}
+		}
+		assert.Less(t, whole, len(steps))
+	})
+}
+
+func TestChunkMarkdown_HeadingContext(t *testing.T) {
+	text := "Read this guide before integrating with the platform for the first time.\n\n" +
+		"# API\n\nThe API is organised around REST and returns JSON-encoded responses.\n\n" +
+		"## Authentication\n\n" +
+		"Requests are authenticated with a bearer token sent in the Authorization header of each call.\n\n" +
+		"Tokens expire after one hour and must be refreshed with the refresh token issued at sign in.\n\n" +
+		"```go\nreq.Header.Set(\"Authorization\", \"Bearer \"+token)\n```\n\n" +
+		"## Errors\n\nFailed requests return a problem document describing what went wrong with the call."
+
+	chunks := ChunkMarkdown(text, 30, 0)
+
+	byHeading := map[string][]ChunkResult{}
+	for _, c := range chunks {
+		byHeading[c.Heading] = append(byHeading[c.Heading], c)
+	}
+
+	require.Len(t, byHeading[""], 1)
+	assert.True(t, strings.HasPrefix(byHeading[""][0].Content, "Read this guide"), "prose before any heading has no prefix")
+
+	auth := byHeading["# API > ## Authentication"]
+	require.Len(t, auth, 3)
+	assert.True(t, strings.HasPrefix(auth[0].Content, "## Authentication\n\nRequests"))
+	// The split-off paragraph is attributed to the heading without repeating it
+	assert.Equal(t, "Tokens expire after one hour and must be refreshed with the refresh token issued at sign in.", auth[1].Content)
+	// Code keeps its fence but is attributed to the enclosing heading
+	assert.Equal(t, ChunkTypeCode, auth[2].Type)
+	assert.True(t, strings.HasPrefix(auth[2].Content, "```go"))
+
+	// A sibling heading replaces the previous one at the same level
+	require.Len(t, byHeading["# API > ## Errors"], 1)
+	require.Len(t, byHeading["# API"], 1)
+}
+
+func TestChunkMarkdown_OverlapUnderHeading(t *testing.T) {
+	paras := []string{
+		"# Auth",
+		"Every request needs a token.",
+		"Requests are authenticated with a bearer token sent in the Authorization header of each call to the API.",
+		"Tokens expire after one hour and must be refreshed with the refresh token issued at sign in to the app.",
+	}
+	text := strings.Join(paras, "\n\n")
+
+	// 160 chars per chunk, 40 of them shared with the previous chunk
+	chunks := ChunkMarkdownWithCounter(text, 40, 10, CharTokenCounter{})
+	require.Greater(t, len(chunks), 1)
+
+	var own []string
+	for i, c := range chunks {
+		assert.Equal(t, "# Auth", c.Heading)
+		assert.LessOrEqual(t, len(c.Content), 160, "heading path must not push chunk %d over the limit", i)
+		if i == 0 {
+			assert.Zero(t, c.Overlap)
+			assert.True(t, strings.HasPrefix(c.Content, "# Auth\n\n"))
+		} else {
+			assert.Positive(t, c.Overlap)
+			assert.NotContains(t, c.Content, "Auth\n", "chunk %d repeats the heading line", i)
+		}
+		own = append(own, c.Content[c.Overlap:])
+	}
+	// Dropping the overlap gives back the page without repeated text
+	assert.Equal(t, text, strings.Join(own, "\n\n"))
+}
+
 func TestDetectChunkType(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -138,7 +305,7 @@ func TestDetectChunkType(t *testing.T) {
 func TestChunkCode(t *testing.T) {
 	t.Run("Small block fits in one chunk", func(t *testing.T) {
 		content := "line1\nline2\nline3"
-		chunks := chunkCode(content, "go", ChunkTypeCode, 100)
+		chunks := chunkCode(content, "go", ChunkTypeCode, 100, CharTokenCounter{})
 		assert.Len(t, chunks, 1)
 		assert.Contains(t, chunks[0].Content, "```go")
 		assert.Contains(t, chunks[0].Content, "line1")
@@ -154,7 +321,7 @@ func TestChunkCode(t *testing.T) {
 			lines = append(lines, "1234567890") // 10 chars each
 		}
 		content := strings.Join(lines, "\n")
-		chunks := chunkCode(content, "python", ChunkTypeCode, 10)
+		chunks := chunkCode(content, "python", ChunkTypeCode, 10, CharTokenCounter{})
 		assert.True(t, len(chunks) > 1, "should split into multiple chunks")
 		for _, c := range chunks {
 			assert.Contains(t, c.Content, "```python")
@@ -164,14 +331,14 @@ func TestChunkCode(t *testing.T) {
 	})
 
 	t.Run("Empty content returns empty", func(t *testing.T) {
-		chunks := chunkCode("", "go", ChunkTypeCode, 100)
+		chunks := chunkCode("", "go", ChunkTypeCode, 100, CharTokenCounter{})
 		// Empty string still produces one chunk with just the fences
 		assert.Len(t, chunks, 1)
 		assert.Contains(t, chunks[0].Content, "```go")
 	})
 
 	t.Run("Preserves chunk type", func(t *testing.T) {
-		chunks := chunkCode("curl http://api.example.com", "bash", ChunkTypeCmd, 100)
+		chunks := chunkCode("curl http://api.example.com", "bash", ChunkTypeCmd, 100, CharTokenCounter{})
 		assert.Len(t, chunks, 1)
 		assert.Equal(t, ChunkTypeCmd, chunks[0].Type)
 		assert.Equal(t, "bash", chunks[0].Language)
@@ -180,7 +347,7 @@ func TestChunkCode(t *testing.T) {
 	t.Run("Config type preserved", func(t *testing.T) {
 		content := `key: value
 another: setting`
-		chunks := chunkCode(content, "yaml", ChunkTypeConfig, 100)
+		chunks := chunkCode(content, "yaml", ChunkTypeConfig, 100, CharTokenCounter{})
 		assert.Len(t, chunks, 1)
 		assert.Equal(t, ChunkTypeConfig, chunks[0].Type)
 	})
@@ -287,3 +454,152 @@ func TestChunkMarkdown_NoiseFiltering(t *testing.T) {
 		assert.True(t, hasCodeBlock, "Code block with install command should be preserved")
 	})
 }
+
+func TestGuessFenceLanguage(t *testing.T) {
+	tests := []struct {
+		name      string
+		preceding string
+		content   string
+		want      string
+	}{
+		{"Prose Hint", "Example in Rust:", "fn main() {}", "rust"},
+		{"Heading Hint", "## Go", "x := 1", "go"},
+		{"Hint Wins Over Content", "Run it using Python:", "package main", "python"},
+		{"Only Last Line Counts", "Written in Ruby.\n\nThen:", "x = 1", ""},
+		{"Content Fallback", "Then:", "package main\n\nfunc main() {}", "go"},
+		{"Unknown", "See below:", "x = 1", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, guessFenceLanguage(tt.preceding, tt.content))
+		})
+	}
+}
+
+func TestGuessLanguageFromContent(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"Go Func", "func handler(w http.ResponseWriter, r *http.Request) {\n\tw.WriteHeader(200)\n}", "go"},
+		{"Go Short Assign", "client, err := qurio.NewClient(cfg)\nif err != nil {\n\treturn err\n}", "go"},
+		{"Python Def", "def search(query):\n    return client.search(query)", "python"},
+		{"Python From Import", "from qurio import Client\n\nclient = Client()", "python"},
+		{"JSON Object", "{\n  \"name\": \"qurio\",\n  \"port\": 8081\n}", "json"},
+		{"JSON Array", "[1, 2, 3]", "json"},
+		{"JavaScript", "const client = new Client({ apiKey });\nawait client.search('auth');", "javascript"},
+		{"JavaScript Import", "import { Client } from 'qurio';", "javascript"},
+		{"Ambiguous Assignment", "x = 1", ""},
+		{"Ambiguous Braces", "{ name }", ""},
+		{"Shell Export", "export PATH=${HOME}/bin:$PATH", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, guessLanguageFromContent(tt.content))
+		})
+	}
+}
+
+func TestChunkMarkdown_BareFenceLanguageFromContent(t *testing.T) {
+	text := "Then:\n```\n{\n  \"enabled\": true\n}\n```"
+	chunks := ChunkMarkdown(text, 100, 0)
+	require.Len(t, chunks, 1)
+	assert.Equal(t, "json", chunks[0].Language)
+	assert.Equal(t, ChunkTypeConfig, chunks[0].Type)
+}
+
+func TestChunkMarkdown_BareFenceLanguageFromProse(t *testing.T) {
+	text := "Example in Rust:\n```\nfn main() {\n    println!(\"hello\");\n}\n```"
+	chunks := ChunkMarkdown(text, 100, 0)
+
+	var codeChunk *ChunkResult
+	for i := range chunks {
+		if chunks[i].Type == ChunkTypeCode {
+			codeChunk = &chunks[i]
+		}
+	}
+	assert.NotNil(t, codeChunk)
+	assert.Equal(t, "rust", codeChunk.Language)
+	assert.True(t, strings.HasPrefix(codeChunk.Content, "```rust\n"))
+}
+
+func TestChunkMarkdown_UnterminatedFence(t *testing.T) {
+	text := "Install the client and create a handler for incoming events.\n\n" +
+		"```go\nfunc handle(w http.ResponseWriter, r *http.Request) {\n\tw.WriteHeader(http.StatusOK)\n}\n\n" +
+		"func main() {\n\thttp.HandleFunc(\"/\", handle)\n}"
+	chunks := ChunkMarkdown(text, 100, 0)
+
+	assert.Len(t, chunks, 2)
+	assert.Equal(t, ChunkTypeProse, chunks[0].Type)
+	assert.NotContains(t, chunks[0].Content, "func handle")
+
+	assert.Equal(t, ChunkTypeCode, chunks[1].Type)
+	assert.Equal(t, "go", chunks[1].Language)
+	assert.True(t, strings.HasPrefix(chunks[1].Content, "```go\nfunc handle"))
+	assert.Contains(t, chunks[1].Content, "http.HandleFunc")
+}
+
+func TestChunkMarkdown_UnterminatedBareFenceAfterClosedBlock(t *testing.T) {
+	text := "```json\n{\"a\": 1}\n```\n\nThen run the following script to apply the configuration:\n\n" +
+		"```\nimport os\nprint(os.getcwd())\n"
+	chunks := ChunkMarkdown(text, 100, 0)
+
+	assert.Len(t, chunks, 3)
+	assert.Equal(t, ChunkTypeConfig, chunks[0].Type)
+	assert.Equal(t, ChunkTypeProse, chunks[1].Type)
+	assert.Equal(t, ChunkTypeCode, chunks[2].Type)
+	assert.Equal(t, "python", chunks[2].Language)
+}
+
+func TestChunkDocument_MinPageTokensToSplit(t *testing.T) {
+	// ~200 tokens (approx 4 chars/token) with headers and a code block
+	para := strings.Repeat("The endpoint accepts a JSON body and returns the created resource. ", 4)
+	page := "# Create Resource\n\n" + para + "\n\n## Request\n\n" + para +
+		"\n\n```json\n{\"name\": \"example\", \"enabled\": true}\n```\n\n## Response\n\n" + para
+	assert.InDelta(t, 200, len(page)/4, 40)
+
+	whole := ChunkDocument(page, 512, 50, 512)
+	assert.Len(t, whole, 1)
+	assert.Contains(t, whole[0].Content, "## Response")
+	assert.Contains(t, whole[0].Content, "```json")
+
+	split := ChunkDocument(page, 512, 50, 50)
+	assert.Greater(t, len(split), 1)
+
+	// Threshold 0 preserves the existing behavior
+	assert.Equal(t, ChunkMarkdown(page, 512, 50), ChunkDocument(page, 512, 50, 0))
+}
+
+func TestMergeAdjacentCode(t *testing.T) {
+	text := "```go\nimport \"fmt\"\n```\n\n" +
+		"```go\nfunc hello() { fmt.Println(\"hi\") }\n```\n\n" +
+		"```go\nfunc main() { hello() }\n```\n\n" +
+		"That prints a greeting to standard output.\n\n" +
+		"```go\nvar unrelated = true\n```"
+	chunks := ChunkMarkdown(text, 512, 0)
+
+	merged := MergeAdjacentCode(chunks, 512)
+	assert.Len(t, chunks, 5)
+	assert.Len(t, merged, 3)
+	assert.Equal(t, ChunkTypeCode, merged[0].Type)
+	assert.Equal(t, "go", merged[0].Language)
+	assert.Contains(t, merged[0].Content, "import \"fmt\"")
+	assert.Contains(t, merged[0].Content, "func main()")
+	// Prose boundary keeps the last block separate
+	assert.Equal(t, ChunkTypeProse, merged[1].Type)
+	assert.Contains(t, merged[2].Content, "var unrelated")
+
+	// Over the limit nothing merges
+	assert.Len(t, MergeAdjacentCode(chunks, 10), len(chunks))
+}
+
+func TestMergeAdjacentCode_DifferentLanguages(t *testing.T) {
+	chunks := []ChunkResult{
+		{Content: "```go\nx := 1\n```", Type: ChunkTypeCode, Language: "go"},
+		{Content: "```python\nx = 1\n```", Type: ChunkTypeCode, Language: "python"},
+	}
+	assert.Equal(t, chunks, MergeAdjacentCode(chunks, 512))
+}
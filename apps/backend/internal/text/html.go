@@ -0,0 +1,172 @@
+package text
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// ChunkHTML chunks a raw HTML page. Tags are stripped and whitespace
+// collapsed; <pre> blocks become code chunks typed by the language in their
+// class attribute ("language-go", "lang-go"); <script>, <style> and similar
+// elements are dropped with their contents. Headings are kept as markdown
+// headings so the text splits and carries heading paths like ChunkMarkdown.
+func ChunkHTML(src string, maxTokens, overlap int) []ChunkResult {
+	var results []ChunkResult
+	var headings headingStack
+	var prose strings.Builder
+
+	flushProse := func() {
+		text := normalizeHTMLProse(prose.String())
+		prose.Reset()
+		if text != "" {
+			results = append(results, chunkProseUnder(text, maxTokens, overlap, &headings, ChunkOptions{})...)
+		}
+	}
+
+	z := html.NewTokenizer(strings.NewReader(src))
+	skipDepth := 0 // > 0 inside an element whose contents are dropped
+	var code *strings.Builder
+	codeLang := ""
+
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break // io.EOF or malformed input; keep what was read so far
+		}
+
+		tok := z.Token()
+		switch tt {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if htmlSkippedTags[tok.Data] {
+				if tt == html.StartTagToken {
+					skipDepth++
+				}
+				continue
+			}
+			if skipDepth > 0 {
+				continue
+			}
+			switch {
+			case tok.Data == "pre" && tt == html.StartTagToken:
+				flushProse()
+				code = &strings.Builder{}
+				codeLang = htmlCodeLanguage(tok)
+			case tok.Data == "code" && code != nil:
+				if codeLang == "" {
+					codeLang = htmlCodeLanguage(tok)
+				}
+			case code != nil:
+				if tok.Data == "br" {
+					code.WriteString("\n")
+				}
+			case headingLevel(tok.Data) > 0:
+				prose.WriteString("\n\n" + strings.Repeat("#", headingLevel(tok.Data)) + " ")
+			case tok.Data == "br":
+				prose.WriteString("\n")
+			case htmlBlockTags[tok.Data]:
+				prose.WriteString("\n\n")
+			}
+
+		case html.EndTagToken:
+			if htmlSkippedTags[tok.Data] {
+				if skipDepth > 0 {
+					skipDepth--
+				}
+				continue
+			}
+			if skipDepth > 0 {
+				continue
+			}
+			switch {
+			case tok.Data == "pre" && code != nil:
+				content := strings.Trim(code.String(), "\n")
+				if strings.TrimSpace(content) != "" {
+					lang := codeLang
+					if lang == "" {
+						lang = guessLanguageFromContent(content)
+					}
+					results = append(results, withHeading(codeBlockChunks(content, lang, maxTokens, DefaultTokenCounter), headings.path())...)
+				}
+				code = nil
+				codeLang = ""
+			case code != nil:
+			case headingLevel(tok.Data) > 0, htmlBlockTags[tok.Data]:
+				prose.WriteString("\n\n")
+			}
+
+		case html.TextToken:
+			if skipDepth > 0 {
+				continue
+			}
+			if code != nil {
+				code.WriteString(tok.Data)
+			} else {
+				// Source line breaks are layout, not content
+				prose.WriteString(htmlSpaceRe.ReplaceAllString(tok.Data, " "))
+			}
+		}
+	}
+	flushProse()
+
+	return finalizeChunks(results)
+}
+
+// htmlSkippedTags are elements whose contents never carry page text.
+var htmlSkippedTags = map[string]bool{
+	"script": true, "style": true, "noscript": true, "template": true, "head": true, "svg": true,
+}
+
+// htmlBlockTags break prose into separate paragraphs.
+var htmlBlockTags = map[string]bool{
+	"p": true, "div": true, "section": true, "article": true, "main": true,
+	"header": true, "footer": true, "nav": true, "aside": true,
+	"ul": true, "ol": true, "li": true, "dl": true, "dt": true, "dd": true,
+	"table": true, "tr": true, "blockquote": true, "hr": true,
+}
+
+// headingLevel returns n for an <hN> tag name and 0 for anything else.
+func headingLevel(tag string) int {
+	if len(tag) == 2 && tag[0] == 'h' && tag[1] >= '1' && tag[1] <= '6' {
+		return int(tag[1] - '0')
+	}
+	return 0
+}
+
+var htmlLangClassRe = regexp.MustCompile(`^(?:language|lang)-([\w+#-]+)$`)
+
+// htmlCodeLanguage reads the language from a "language-x" or "lang-x" class.
+func htmlCodeLanguage(tok html.Token) string {
+	for _, attr := range tok.Attr {
+		if attr.Key != "class" {
+			continue
+		}
+		for _, class := range strings.Fields(attr.Val) {
+			if m := htmlLangClassRe.FindStringSubmatch(class); m != nil {
+				lang := strings.ToLower(m[1])
+				if alias, ok := languageAliases[lang]; ok {
+					return alias
+				}
+				return lang
+			}
+		}
+	}
+	return ""
+}
+
+var (
+	htmlSpaceRe   = regexp.MustCompile(`\s+`)
+	inlineSpaceRe = regexp.MustCompile(`[ \t\r\f\v]+`)
+	blankLinesRe  = regexp.MustCompile(`\n{3,}`)
+)
+
+// normalizeHTMLProse collapses the whitespace left behind by stripped markup
+// while keeping the paragraph breaks inserted for block elements.
+func normalizeHTMLProse(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(inlineSpaceRe.ReplaceAllString(line, " "))
+	}
+	return strings.TrimSpace(blankLinesRe.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
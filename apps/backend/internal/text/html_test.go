@@ -0,0 +1,62 @@
+package text
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChunkHTML(t *testing.T) {
+	page := `<html>
+<head><title>Ignored</title><style>body { color: red; }</style></head>
+<body>
+  <script>window.analytics = track("page");</script>
+  <h1>Client</h1>
+  <p>The client wraps the HTTP API and
+     retries idempotent requests automatically.</p>
+  <h2>Usage</h2>
+  <p>Create a client once and share it between goroutines.</p>
+  <pre><code class="language-go">client := qurio.New(cfg)
+res, err := client.Search(ctx, &quot;auth&quot;)</code></pre>
+</body>
+</html>`
+
+	chunks := ChunkHTML(page, 100, 0)
+
+	var code, prose []ChunkResult
+	for _, c := range chunks {
+		if c.Type == ChunkTypeCode {
+			code = append(code, c)
+		} else {
+			prose = append(prose, c)
+		}
+		assert.NotContains(t, c.Content, "analytics", "script contents are dropped")
+		assert.NotContains(t, c.Content, "color: red", "style contents are dropped")
+		assert.NotContains(t, c.Content, "<")
+	}
+
+	require.Len(t, code, 1)
+	assert.Equal(t, "go", code[0].Language)
+	assert.Equal(t, "```go\nclient := qurio.New(cfg)\nres, err := client.Search(ctx, \"auth\")\n```", code[0].Content)
+	assert.Equal(t, "# Client > ## Usage", code[0].Heading)
+
+	require.Len(t, prose, 2)
+	assert.Equal(t, "# Client\n\nThe client wraps the HTTP API and retries idempotent requests automatically.", prose[0].Content)
+	assert.True(t, strings.HasPrefix(prose[1].Content, "## Usage\n\nCreate a client"))
+}
+
+func TestChunkHTML_PreWithoutClassGuessesLanguage(t *testing.T) {
+	chunks := ChunkHTML(`<p>Response body:</p><pre>{"status": "ok", "count": 3}</pre>`, 100, 0)
+
+	var code []ChunkResult
+	for _, c := range chunks {
+		if c.Language != "" {
+			code = append(code, c)
+		}
+	}
+	require.Len(t, code, 1)
+	assert.Equal(t, "json", code[0].Language)
+	assert.Equal(t, ChunkTypeConfig, code[0].Type)
+}
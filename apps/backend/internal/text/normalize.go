@@ -0,0 +1,23 @@
+package text
+
+import (
+	"regexp"
+	"strings"
+)
+
+// DefaultVolatilePatterns match content that changes between builds of a page
+// without changing what it documents: "last updated" lines and timestamps.
+var DefaultVolatilePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?im)^.*\b(?:last\s+(?:updated|modified|edited)|updated\s+on|generated\s+on)\b.*$`),
+	regexp.MustCompile(`\b\d{4}-\d{2}-\d{2}(?:[T ]\d{2}:\d{2}(?::\d{2}(?:\.\d+)?)?(?:Z|[+-]\d{2}:?\d{2})?)?\b`),
+}
+
+// NormalizeForHash strips volatile matches and collapses all whitespace runs
+// to single spaces, so change detection ignores cosmetic differences. The
+// result is only meant for hashing, never for display or embedding.
+func NormalizeForHash(content string, volatile []*regexp.Regexp) string {
+	for _, re := range volatile {
+		content = re.ReplaceAllString(content, "")
+	}
+	return strings.Join(strings.Fields(content), " ")
+}
@@ -0,0 +1,33 @@
+package text
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeForHash(t *testing.T) {
+	t.Run("Collapses Whitespace", func(t *testing.T) {
+		assert.Equal(t, "a b c", NormalizeForHash("  a\n\n b\t\tc \n", nil))
+	})
+
+	t.Run("Strips Timestamp Lines", func(t *testing.T) {
+		a := "# Guide\n\nInstall the CLI.\n\nLast updated: March 3, 2024\n"
+		b := "# Guide\n\nInstall the CLI.\n\nLast updated: April 9, 2024\n"
+		assert.Equal(t, NormalizeForHash(a, DefaultVolatilePatterns), NormalizeForHash(b, DefaultVolatilePatterns))
+	})
+
+	t.Run("Strips ISO Dates", func(t *testing.T) {
+		assert.Equal(t, "Built on", NormalizeForHash("Built on 2024-03-03T10:15:00Z", DefaultVolatilePatterns))
+	})
+
+	t.Run("Custom Pattern", func(t *testing.T) {
+		re := regexp.MustCompile(`build #\d+`)
+		assert.Equal(t, "Docs", NormalizeForHash("Docs build #1234", []*regexp.Regexp{re}))
+	})
+
+	t.Run("Keeps Real Changes", func(t *testing.T) {
+		assert.NotEqual(t, NormalizeForHash("Install v1", DefaultVolatilePatterns), NormalizeForHash("Install v2", DefaultVolatilePatterns))
+	})
+}
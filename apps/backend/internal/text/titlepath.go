@@ -0,0 +1,61 @@
+package text
+
+import (
+	"net/url"
+	"path"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// TitlePathSeparator joins the crumbs of a title path.
+const TitlePathSeparator = " > "
+
+// TitlePath derives a breadcrumb such as "Guides > Auth > Webhooks" from the
+// path of a page URL. File extensions and trailing "index" segments are
+// dropped, and dashes and underscores become spaces. Returns "" for root
+// URLs and unparsable input.
+func TitlePath(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.Join(urlCrumbs(u), TitlePathSeparator)
+}
+
+// TitleFromURL humanizes the last path segment of a page URL, e.g.
+// "/docs/getting-started" becomes "Getting Started", for pages that have no
+// title of their own. Root URLs yield the host name.
+func TitleFromURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	if crumbs := urlCrumbs(u); len(crumbs) > 0 {
+		return crumbs[len(crumbs)-1]
+	}
+	return u.Hostname()
+}
+
+// urlCrumbs humanizes each meaningful segment of u's path.
+func urlCrumbs(u *url.URL) []string {
+	var crumbs []string
+	for _, seg := range strings.Split(u.Path, "/") {
+		if seg == "" {
+			continue
+		}
+		seg = strings.TrimSuffix(seg, path.Ext(seg))
+		if strings.EqualFold(seg, "index") {
+			continue
+		}
+		words := strings.FieldsFunc(seg, func(r rune) bool { return r == '-' || r == '_' || r == ' ' })
+		for i, w := range words {
+			r, size := utf8.DecodeRuneInString(w)
+			words[i] = string(unicode.ToUpper(r)) + w[size:]
+		}
+		if len(words) > 0 {
+			crumbs = append(crumbs, strings.Join(words, " "))
+		}
+	}
+	return crumbs
+}
@@ -0,0 +1,43 @@
+package text
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTitlePath(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{"Nested", "https://docs.example.com/guides/auth/webhooks", "Guides > Auth > Webhooks"},
+		{"Separators And Extension", "https://example.com/api_reference/rate-limits.html", "Api Reference > Rate Limits"},
+		{"Index Page", "https://example.com/guides/index.html", "Guides"},
+		{"Escaped Segment", "https://example.com/guides/getting%20started/", "Guides > Getting Started"},
+		{"Root", "https://example.com/", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, TitlePath(tt.url))
+		})
+	}
+}
+
+func TestTitleFromURL(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{"Last Segment", "https://example.com/docs/getting-started", "Getting Started"},
+		{"Index Page", "https://example.com/guides/index.html", "Guides"},
+		{"Root", "https://docs.example.com/", "docs.example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, TitleFromURL(tt.url))
+		})
+	}
+}
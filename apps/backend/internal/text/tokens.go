@@ -0,0 +1,114 @@
+package text
+
+import (
+	"sort"
+	"unicode"
+	"unicode/utf8"
+)
+
+// TokenCounter estimates how many embedding-model tokens a string occupies.
+// The chunker sizes chunks with it.
+type TokenCounter interface {
+	EstimateTokens(s string) int
+}
+
+// DefaultTokenCounter is the counter ChunkMarkdown, ChunkDocument and
+// ChunkHTML size chunks with.
+var DefaultTokenCounter TokenCounter = BPETokenCounter{}
+
+// BPETokenCounter approximates a BPE tokenizer such as tiktoken's cl100k
+// without shipping its vocabulary:
+//   - common ASCII words cost one token, longer ones one per 6 letters
+//   - digits are grouped in threes
+//   - each punctuation mark or symbol is its own token
+//   - CJK, kana and hangul characters cost a token each
+//   - other non-ASCII words cost one token per 2 letters
+//   - a single space is absorbed by the following word; line breaks and
+//     indentation runs cost one token
+type BPETokenCounter struct{}
+
+func (BPETokenCounter) EstimateTokens(s string) int {
+	tokens := 0
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case unicode.IsSpace(r):
+			j, newline := i, false
+			for j < len(s) {
+				r, size := utf8.DecodeRuneInString(s[j:])
+				if !unicode.IsSpace(r) {
+					break
+				}
+				newline = newline || r == '\n'
+				j += size
+			}
+			if newline || j-i > 1 {
+				tokens++
+			}
+			i = j
+		case isIdeograph(r):
+			tokens++
+			i += size
+		case unicode.IsDigit(r):
+			j := i
+			for j < len(s) && s[j] >= '0' && s[j] <= '9' {
+				j++
+			}
+			if j == i { // non-ASCII digit
+				j = i + size
+			}
+			tokens += (j - i + 2) / 3
+			i = j
+		case unicode.IsLetter(r):
+			j, runes, ascii := i, 0, true
+			for j < len(s) {
+				r, size := utf8.DecodeRuneInString(s[j:])
+				if !unicode.IsLetter(r) || isIdeograph(r) {
+					break
+				}
+				ascii = ascii && r < utf8.RuneSelf
+				runes++
+				j += size
+			}
+			if ascii {
+				tokens += (runes + 5) / 6
+			} else {
+				tokens += (runes + 1) / 2
+			}
+			i = j
+		default:
+			tokens++
+			i += size
+		}
+	}
+	return tokens
+}
+
+// TruncateToTokens returns the longest prefix of s, cut on a rune boundary,
+// that tc estimates at no more than n tokens.
+func TruncateToTokens(s string, n int, tc TokenCounter) string {
+	runeStart := func(i int) int {
+		for i > 0 && i < len(s) && !utf8.RuneStart(s[i]) {
+			i--
+		}
+		return i
+	}
+	over := sort.Search(len(s)+1, func(i int) bool {
+		return tc.EstimateTokens(s[:runeStart(i)]) > n
+	})
+	return s[:runeStart(max(over-1, 0))]
+}
+
+// isIdeograph reports whether r belongs to a script that BPE vocabularies
+// encode roughly one character per token.
+func isIdeograph(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// CharTokenCounter is the rule of thumb of four bytes per token. It is kept
+// for callers and tests that want sizes in predictable bytes.
+type CharTokenCounter struct{}
+
+func (CharTokenCounter) EstimateTokens(s string) int {
+	return len(s) / 4
+}
@@ -0,0 +1,59 @@
+package text
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBPETokenCounter_EstimateTokens(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    int
+	}{
+		{"Empty", "", 0},
+		{"Common Words", "the quick brown fox", 4},
+		{"Long Word", "internationalization", 4},
+		{"Digits In Threes", "1234567", 3},
+		{"Punctuation", "a.b(c);", 7},
+		{"Line Break", "one\ntwo", 3},
+		{"CJK Per Character", "検索エンジン", 6},
+		{"Non-ASCII Word", "привет", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, BPETokenCounter{}.EstimateTokens(tt.content))
+		})
+	}
+}
+
+func TestTruncateToTokens(t *testing.T) {
+	tc := BPETokenCounter{}
+
+	assert.Equal(t, "the quick brown fox", TruncateToTokens("the quick brown fox", 4, tc))
+	// A single space is absorbed by the next word, so it costs nothing
+	assert.Equal(t, "the quick ", TruncateToTokens("the quick brown fox", 2, tc))
+
+	// Ideographs are a token each even though they take three bytes
+	cut := TruncateToTokens("検索エンジン", 4, tc)
+	assert.Equal(t, "検索エン", cut)
+	assert.Empty(t, TruncateToTokens("検索", 0, tc))
+}
+
+func TestChunkMarkdown_CJKSplitsByTokens(t *testing.T) {
+	// Each line is 15 characters (45 bytes): 11 tokens by the byte rule, 15 by BPE
+	line := "検索結果は関連度順に並びます。"
+	text := strings.TrimSuffix(strings.Repeat(line+"\n", 40), "\n")
+
+	naive := ChunkMarkdownWithCounter(text, 100, 0, CharTokenCounter{})
+	chunks := ChunkMarkdown(text, 100, 0)
+
+	require.NotEmpty(t, naive)
+	assert.Greater(t, len(chunks), len(naive))
+	for _, c := range chunks {
+		assert.LessOrEqual(t, DefaultTokenCounter.EstimateTokens(c.Content), 100)
+	}
+}
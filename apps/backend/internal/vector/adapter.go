@@ -30,3 +30,7 @@ func (a *WeaviateClientAdapter) GetClass(ctx context.Context, className string)
 func (a *WeaviateClientAdapter) AddProperty(ctx context.Context, className string, property *models.Property) error {
 	return a.Client.Schema().PropertyCreator().WithClassName(className).WithProperty(property).Do(ctx)
 }
+
+func (a *WeaviateClientAdapter) UpdateClass(ctx context.Context, class *models.Class) error {
+	return a.Client.Schema().ClassUpdater().WithClass(class).Do(ctx)
+}
@@ -2,6 +2,10 @@ package vector
 
 import (
 	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
 
 	"github.com/weaviate/weaviate/entities/models"
 )
@@ -12,90 +16,244 @@ type SchemaClient interface {
 	CreateClass(ctx context.Context, class *models.Class) error
 	GetClass(ctx context.Context, className string) (*models.Class, error)
 	AddProperty(ctx context.Context, className string, property *models.Property) error
+	UpdateClass(ctx context.Context, class *models.Class) error
 }
 
-// EnsureSchema checks if the required classes exist and creates them if not
-func EnsureSchema(ctx context.Context, client SchemaClient) error {
-	className := "DocumentChunk"
-	exists, err := client.ClassExists(ctx, className)
-	if err != nil {
-		return err
-	}
+const schemaClassName = "DocumentChunk"
 
-	properties := []*models.Property{
-		{
-			Name:     "content",
-			DataType: []string{"text"},
-		},
-		{
-			Name:     "sourceId",
-			DataType: []string{"string"}, // UUID as string (exact match)
-		},
-		{
-			Name:     "sourceName",
-			DataType: []string{"text"},
+// schemaMigration is one step of the DocumentChunk schema history. Steps are
+// additive: each one only adds the properties it introduces.
+type schemaMigration struct {
+	version     int
+	description string
+	properties  []*models.Property
+}
+
+// schemaMigrations must stay ordered by version. Append new steps; never edit
+// a step that has shipped.
+var schemaMigrations = []schemaMigration{
+	{
+		version:     1,
+		description: "base document chunk properties",
+		properties: []*models.Property{
+			{
+				Name:     "content",
+				DataType: []string{"text"},
+			},
+			{
+				Name:     "sourceId",
+				DataType: []string{"string"}, // UUID as string (exact match)
+			},
+			{
+				Name:     "sourceName",
+				DataType: []string{"text"},
+			},
+			{
+				Name:     "chunkIndex",
+				DataType: []string{"int"},
+			},
+			{
+				Name:     "title",
+				DataType: []string{"text"},
+			},
+			{
+				Name:     "url",
+				DataType: []string{"string"}, // URL as string (exact match)
+			},
+			{
+				Name:     "type",
+				DataType: []string{"string"},
+			},
+			{
+				Name:     "language",
+				DataType: []string{"string"},
+			},
+			{
+				Name:     "author",
+				DataType: []string{"text"},
+			},
+			{
+				Name:     "createdAt",
+				DataType: []string{"date"},
+			},
+			{
+				Name:     "pageCount",
+				DataType: []string{"int"},
+			},
 		},
-		{
-			Name:     "chunkIndex",
-			DataType: []string{"int"},
+	},
+	{
+		version:     2,
+		description: "custom source metadata",
+		properties: []*models.Property{
+			{
+				Name:     "metadata",
+				DataType: []string{"string[]"}, // Custom source metadata as "key=value" tokens
+			},
 		},
-		{
-			Name:     "title",
-			DataType: []string{"text"},
+	},
+	{
+		version:     3,
+		description: "embedding truncation flag",
+		properties: []*models.Property{
+			{
+				Name:     "truncated",
+				DataType: []string{"boolean"}, // Embedding input was cut to the model limit
+			},
 		},
-		{
-			Name:     "url",
-			DataType: []string{"string"}, // URL as string (exact match)
+	},
+	{
+		version:     4,
+		description: "originating DOM selector",
+		properties: []*models.Property{
+			{
+				Name:     "selector",
+				DataType: []string{"string"}, // CSS selector or XPath of the extracted region (HTML only)
+			},
 		},
-		{
-			Name:     "type",
-			DataType: []string{"string"},
+	},
+	{
+		version:     5,
+		description: "fetched url alias for canonical pages",
+		properties: []*models.Property{
+			{
+				Name:     "aliasUrl",
+				DataType: []string{"string"}, // Fetched URL when indexed under rel=canonical
+			},
 		},
-		{
-			Name:     "language",
-			DataType: []string{"string"},
+	},
+	{
+		version:     6,
+		description: "per-source embedding model",
+		properties: []*models.Property{
+			{
+				Name:     "embeddingModel",
+				DataType: []string{"string"}, // Model override the vector was computed with; empty = default
+			},
 		},
-		{
-			Name:     "author",
-			DataType: []string{"text"},
+	},
+	{
+		version:     7,
+		description: "page anchor text keywords",
+		properties: []*models.Property{
+			{
+				Name:     "anchorKeywords",
+				DataType: []string{"text[]"}, // Descriptive link texts from the page, matched by BM25
+			},
 		},
-		{
-			Name:     "createdAt",
-			DataType: []string{"date"},
+	},
+	{
+		version:     8,
+		description: "breadcrumb title path",
+		properties: []*models.Property{
+			{
+				Name:     "titlePath",
+				DataType: []string{"text"}, // "Guides > Auth > Webhooks", derived from the page URL
+			},
 		},
-		{
-			Name:     "pageCount",
-			DataType: []string{"int"},
+	},
+	{
+		version:     9,
+		description: "chunk heading path and overlap",
+		properties: []*models.Property{
+			{
+				Name:     "heading",
+				DataType: []string{"text"}, // "# API > ## Authentication", the headings enclosing the chunk
+			},
+			{
+				Name:     "overlap",
+				DataType: []string{"int"}, // Bytes at the start of content repeated from the previous chunk
+			},
 		},
+	},
+}
+
+// SchemaVersion is the version EnsureSchema brings the class up to.
+var SchemaVersion = schemaMigrations[len(schemaMigrations)-1].version
+
+var schemaVersionRe = regexp.MustCompile(`\(schema v(\d+)\)`)
+
+func classDescription(version int) string {
+	return fmt.Sprintf("A chunk of a document (schema v%d)", version)
+}
+
+// schemaVersionOf reads the version marker from the class description.
+// Classes created before versioning have no marker and report 0.
+func schemaVersionOf(class *models.Class) int {
+	m := schemaVersionRe.FindStringSubmatch(class.Description)
+	if m == nil {
+		return 0
+	}
+	v, err := strconv.Atoi(m[1])
+	if err != nil {
+		return 0
+	}
+	return v
+}
+
+// EnsureSchema creates the DocumentChunk class at the latest version, or
+// upgrades an existing class one migration at a time, recording the version
+// after each step so an interrupted upgrade resumes where it stopped.
+func EnsureSchema(ctx context.Context, client SchemaClient) error {
+	exists, err := client.ClassExists(ctx, schemaClassName)
+	if err != nil {
+		return err
 	}
 
 	if !exists {
+		var properties []*models.Property
+		for _, m := range schemaMigrations {
+			properties = append(properties, m.properties...)
+		}
 		class := &models.Class{
-			Class:       className,
-			Description: "A chunk of a document",
+			Class:       schemaClassName,
+			Description: classDescription(SchemaVersion),
 			Vectorizer:  "none",
 			Properties:  properties,
 		}
 		return client.CreateClass(ctx, class)
 	}
 
-	// Class exists, check for missing properties
-	class, err := client.GetClass(ctx, className)
+	class, err := client.GetClass(ctx, schemaClassName)
 	if err != nil {
 		return err
 	}
 
+	current := schemaVersionOf(class)
+	if current >= SchemaVersion {
+		return nil
+	}
+
 	existingProps := make(map[string]bool)
 	for _, p := range class.Properties {
 		existingProps[p.Name] = true
 	}
 
-	for _, p := range properties {
-		if !existingProps[p.Name] {
-			if err := client.AddProperty(ctx, className, p); err != nil {
-				return err
+	for _, m := range schemaMigrations {
+		if m.version <= current {
+			continue
+		}
+		for _, p := range m.properties {
+			if existingProps[p.Name] {
+				continue
+			}
+			if err := client.AddProperty(ctx, schemaClassName, p); err != nil {
+				return fmt.Errorf("schema migration v%d: %w", m.version, err)
 			}
+			existingProps[p.Name] = true
+		}
+
+		// Re-read so the update carries the server's view of the properties
+		class, err = client.GetClass(ctx, schemaClassName)
+		if err != nil {
+			return err
+		}
+		class.Description = classDescription(m.version)
+		if err := client.UpdateClass(ctx, class); err != nil {
+			return fmt.Errorf("record schema version v%d: %w", m.version, err)
 		}
+		slog.InfoContext(ctx, "applied weaviate schema migration", "class", schemaClassName, "from", current, "to", m.version, "description", m.description)
+		current = m.version
 	}
 
 	return nil
@@ -8,9 +8,10 @@ import (
 )
 
 type MockSchemaClient struct {
-	CreatedClass    *models.Class
-	ExistingClass   *models.Class
-	AddedProperties []*models.Property
+	CreatedClass     *models.Class
+	ExistingClass    *models.Class
+	AddedProperties  []*models.Property
+	RecordedVersions []string
 }
 
 func (m *MockSchemaClient) ClassExists(ctx context.Context, className string) (bool, error) {
@@ -31,6 +32,15 @@ func (m *MockSchemaClient) GetClass(ctx context.Context, className string) (*mod
 
 func (m *MockSchemaClient) AddProperty(ctx context.Context, className string, property *models.Property) error {
 	m.AddedProperties = append(m.AddedProperties, property)
+	if m.ExistingClass != nil {
+		m.ExistingClass.Properties = append(m.ExistingClass.Properties, property)
+	}
+	return nil
+}
+
+func (m *MockSchemaClient) UpdateClass(ctx context.Context, class *models.Class) error {
+	m.RecordedVersions = append(m.RecordedVersions, class.Description)
+	m.ExistingClass = class
 	return nil
 }
 
@@ -141,3 +151,107 @@ func TestEnsureSchema_AddsNewMetadataProperties(t *testing.T) {
 		t.Error("Missing 'pageCount' property")
 	}
 }
+
+func TestEnsureSchema_MetadataProperty(t *testing.T) {
+	client := &MockSchemaClient{}
+	if err := EnsureSchema(context.Background(), client); err != nil {
+		t.Fatalf("EnsureSchema failed: %v", err)
+	}
+
+	for _, prop := range client.CreatedClass.Properties {
+		if prop.Name == "metadata" {
+			if len(prop.DataType) == 0 || prop.DataType[0] != "string[]" {
+				t.Errorf("metadata has wrong DataType: %v", prop.DataType)
+			}
+			return
+		}
+	}
+	t.Error("Missing 'metadata' property")
+}
+
+func TestEnsureSchema_SelectorProperty(t *testing.T) {
+	client := &MockSchemaClient{}
+	if err := EnsureSchema(context.Background(), client); err != nil {
+		t.Fatalf("EnsureSchema failed: %v", err)
+	}
+
+	for _, prop := range client.CreatedClass.Properties {
+		if prop.Name == "selector" {
+			if len(prop.DataType) == 0 || prop.DataType[0] != "string" {
+				t.Errorf("selector has wrong DataType: %v", prop.DataType)
+			}
+			return
+		}
+	}
+	t.Error("Missing 'selector' property")
+}
+
+func TestEnsureSchema_CreatesClassAtLatestVersion(t *testing.T) {
+	client := &MockSchemaClient{}
+	if err := EnsureSchema(context.Background(), client); err != nil {
+		t.Fatalf("EnsureSchema failed: %v", err)
+	}
+
+	if got := schemaVersionOf(client.CreatedClass); got != SchemaVersion {
+		t.Errorf("created class at version %d, want %d", got, SchemaVersion)
+	}
+}
+
+func TestEnsureSchema_UpgradesOneStepFromPreviousVersion(t *testing.T) {
+	// A class at the previous version has every property except the last step's
+	previous := SchemaVersion - 1
+	existing := &models.Class{Class: "DocumentChunk", Description: classDescription(previous)}
+	for _, m := range schemaMigrations {
+		if m.version <= previous {
+			existing.Properties = append(existing.Properties, m.properties...)
+		}
+	}
+
+	client := &MockSchemaClient{ExistingClass: existing}
+	if err := EnsureSchema(context.Background(), client); err != nil {
+		t.Fatalf("EnsureSchema failed: %v", err)
+	}
+
+	last := schemaMigrations[len(schemaMigrations)-1]
+	if len(client.AddedProperties) != len(last.properties) {
+		t.Fatalf("added %d properties, want %d", len(client.AddedProperties), len(last.properties))
+	}
+	for i, p := range last.properties {
+		if client.AddedProperties[i].Name != p.Name {
+			t.Errorf("added %q, want %q", client.AddedProperties[i].Name, p.Name)
+		}
+	}
+	if len(client.RecordedVersions) != 1 || client.RecordedVersions[0] != classDescription(SchemaVersion) {
+		t.Errorf("recorded versions %v, want only v%d", client.RecordedVersions, SchemaVersion)
+	}
+}
+
+func TestEnsureSchema_UnversionedClassRecordsEachStep(t *testing.T) {
+	client := &MockSchemaClient{ExistingClass: &models.Class{Class: "DocumentChunk"}}
+	if err := EnsureSchema(context.Background(), client); err != nil {
+		t.Fatalf("EnsureSchema failed: %v", err)
+	}
+
+	if len(client.RecordedVersions) != len(schemaMigrations) {
+		t.Fatalf("recorded %d versions, want %d", len(client.RecordedVersions), len(schemaMigrations))
+	}
+	for i, m := range schemaMigrations {
+		if client.RecordedVersions[i] != classDescription(m.version) {
+			t.Errorf("step %d recorded %q", i, client.RecordedVersions[i])
+		}
+	}
+}
+
+func TestEnsureSchema_LatestVersionIsNoop(t *testing.T) {
+	client := &MockSchemaClient{ExistingClass: &models.Class{
+		Class:       "DocumentChunk",
+		Description: classDescription(SchemaVersion),
+	}}
+	if err := EnsureSchema(context.Background(), client); err != nil {
+		t.Fatalf("EnsureSchema failed: %v", err)
+	}
+
+	if len(client.AddedProperties) != 0 || len(client.RecordedVersions) != 0 {
+		t.Errorf("expected no changes, added %d properties and recorded %v", len(client.AddedProperties), client.RecordedVersions)
+	}
+}
@@ -0,0 +1,70 @@
+package worker
+
+import (
+	"sync"
+	"time"
+)
+
+// DeferredPublisher is implemented by publishers that can hold a message back
+// before delivery (e.g. *nsq.Producer).
+type DeferredPublisher interface {
+	DeferredPublish(topic string, delay time.Duration, body []byte) error
+}
+
+// MaxDeferral is the longest delay a deferred publish may ask for. nsqd
+// rejects longer ones; its --max-req-timeout defaults to 1h.
+const MaxDeferral = 59 * time.Minute
+
+// pacerPruneInterval is how often sources with no upcoming slot are dropped.
+const pacerPruneInterval = time.Minute
+
+// crawlPacer spaces out task enqueues per source. Each call reserves the next
+// free slot for the source and returns how long to defer the task.
+type crawlPacer struct {
+	mu     sync.Mutex
+	next   map[string]time.Time
+	pruned time.Time
+	now    func() time.Time
+}
+
+func newCrawlPacer() *crawlPacer {
+	return &crawlPacer{next: make(map[string]time.Time), now: time.Now}
+}
+
+// Delay reserves the source's next slot and returns how far away it is. A
+// slot more than MaxDeferral out is not reserved and Delay returns false.
+func (p *crawlPacer) Delay(sourceID string, interval time.Duration) (time.Duration, bool) {
+	if interval <= 0 {
+		return 0, true
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	p.prune(now)
+
+	slot := now
+	if next, ok := p.next[sourceID]; ok && next.After(now) {
+		slot = next
+	}
+	if slot.Sub(now) > MaxDeferral {
+		return 0, false
+	}
+	p.next[sourceID] = slot.Add(interval)
+	return slot.Sub(now), true
+}
+
+// prune drops sources whose next slot has already passed; their next task
+// goes at once either way.
+func (p *crawlPacer) prune(now time.Time) {
+	if now.Sub(p.pruned) < pacerPruneInterval {
+		return
+	}
+	p.pruned = now
+	for id, next := range p.next {
+		if !next.After(now) {
+			delete(p.next, id)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package worker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCrawlPacer_Delay(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	p := newCrawlPacer()
+	p.now = func() time.Time { return now }
+
+	interval := 20 * time.Minute
+	for _, want := range []time.Duration{0, 20 * time.Minute, 40 * time.Minute} {
+		d, ok := p.Delay("src1", interval)
+		assert.True(t, ok)
+		assert.Equal(t, want, d)
+	}
+
+	// The next slot is an hour out, past what nsqd accepts
+	_, ok := p.Delay("src1", interval)
+	assert.False(t, ok)
+
+	// Refusing doesn't take the slot, so it frees up as time passes
+	now = now.Add(interval)
+	d, ok := p.Delay("src1", interval)
+	assert.True(t, ok)
+	assert.Equal(t, 40*time.Minute, d)
+}
+
+func TestCrawlPacer_PrunesPastSlots(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	p := newCrawlPacer()
+	p.now = func() time.Time { return now }
+
+	p.Delay("src1", time.Second)
+	p.Delay("src2", time.Second)
+	assert.Len(t, p.next, 2)
+
+	now = now.Add(2 * pacerPruneInterval)
+	p.Delay("src3", time.Second)
+	assert.Len(t, p.next, 1)
+	assert.Contains(t, p.next, "src3")
+}
@@ -0,0 +1,93 @@
+package worker
+
+import (
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/nsqio/go-nsq"
+	"qurio/apps/backend/internal/config"
+)
+
+// DeadLetter is published to config.TopicIngestDeadLetter when a message
+// keeps failing after its last allowed attempt, or can't be decoded at all.
+type DeadLetter struct {
+	Topic    string          `json:"topic"`
+	Attempts uint16          `json:"attempts"`
+	Error    string          `json:"error"`
+	Body     json.RawMessage `json:"body,omitempty"`
+	// RawBody holds a body that is not valid JSON, as received, in place of
+	// Body.
+	RawBody  string    `json:"raw_body,omitempty"`
+	FailedAt time.Time `json:"failed_at"`
+}
+
+func newDeadLetter(topic string, m *nsq.Message, err error) DeadLetter {
+	letter := DeadLetter{
+		Topic:    topic,
+		Attempts: m.Attempts,
+		Error:    err.Error(),
+		FailedAt: time.Now().UTC(),
+	}
+	if json.Valid(m.Body) {
+		letter.Body = m.Body
+	} else {
+		letter.RawBody = string(m.Body)
+	}
+	return letter
+}
+
+// publishPoison dead-letters an undecodable message right away. Redelivery
+// can't fix it, so the message is dropped if that publish fails too.
+func publishPoison(publisher TaskPublisher, topic string, m *nsq.Message, err error) {
+	if publisher == nil {
+		slog.Error("dropping undecodable message", "topic", topic, "error", err)
+		return
+	}
+
+	letter, marshalErr := json.Marshal(newDeadLetter(topic, m, err))
+	if marshalErr != nil {
+		slog.Error("failed to marshal dead letter for undecodable message, dropping", "topic", topic, "error", marshalErr)
+		return
+	}
+	if pubErr := publisher.Publish(config.TopicIngestDeadLetter, letter); pubErr != nil {
+		slog.Error("failed to publish dead letter for undecodable message, dropping", "topic", topic, "error", pubErr)
+		return
+	}
+	slog.Warn("undecodable message dead-lettered", "topic", topic, "error", err)
+}
+
+// deadLetterPolicy caps NSQ redeliveries of a failing message. It is shared
+// by the consumers that otherwise return errors for NSQ to requeue.
+type deadLetterPolicy struct {
+	publisher   TaskPublisher
+	maxAttempts int
+}
+
+// handle passes err through for NSQ to requeue until m has used maxAttempts
+// attempts. After that the message is published to the dead-letter topic and
+// acked. If the dead letter can't be published, err is returned so the
+// message is not lost.
+func (p *deadLetterPolicy) handle(topic string, m *nsq.Message, err error) error {
+	if err == nil || p.maxAttempts <= 0 || int(m.Attempts) < p.maxAttempts {
+		return err
+	}
+
+	if p.publisher == nil {
+		slog.Error("message exhausted its attempts, dropping", "topic", topic, "attempts", m.Attempts, "error", err)
+		return nil
+	}
+
+	letter, marshalErr := json.Marshal(newDeadLetter(topic, m, err))
+	if marshalErr != nil {
+		slog.Error("failed to marshal dead letter", "topic", topic, "error", marshalErr)
+		return err
+	}
+	if pubErr := p.publisher.Publish(config.TopicIngestDeadLetter, letter); pubErr != nil {
+		slog.Error("failed to publish dead letter", "topic", topic, "error", pubErr)
+		return err
+	}
+
+	slog.Warn("message exhausted its attempts, dead-lettered", "topic", topic, "attempts", m.Attempts, "error", err)
+	return nil
+}
@@ -0,0 +1,83 @@
+package worker
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited is wrapped by embedders when the provider rejects a request
+// for exceeding its rate limit (HTTP 429 / RESOURCE_EXHAUSTED).
+var ErrRateLimited = errors.New("embedding rate limited")
+
+// EmbedBackoff pauses embedding for a source after it hits the provider rate
+// limit several times in a row, so one noisy source does not hold up the
+// shared embed queue. Other sources keep embedding while it cools down.
+type EmbedBackoff struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu          sync.Mutex
+	failures    map[string]int
+	pausedUntil map[string]time.Time
+	now         func() time.Time
+}
+
+// NewEmbedBackoff pauses a source for cooldown after threshold consecutive
+// rate-limit failures.
+func NewEmbedBackoff(threshold int, cooldown time.Duration) *EmbedBackoff {
+	return &EmbedBackoff{
+		threshold:   threshold,
+		cooldown:    cooldown,
+		failures:    make(map[string]int),
+		pausedUntil: make(map[string]time.Time),
+		now:         time.Now,
+	}
+}
+
+// RecordRateLimit counts a rate-limit failure for sourceID and reports whether
+// the source is now paused.
+func (b *EmbedBackoff) RecordRateLimit(sourceID string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures[sourceID]++
+	if b.failures[sourceID] < b.threshold {
+		return false
+	}
+	delete(b.failures, sourceID)
+	b.pausedUntil[sourceID] = b.now().Add(b.cooldown)
+	return true
+}
+
+// RecordSuccess resets the consecutive failure count for sourceID.
+func (b *EmbedBackoff) RecordSuccess(sourceID string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.failures, sourceID)
+}
+
+// Remaining returns how much longer sourceID is paused, or zero.
+func (b *EmbedBackoff) Remaining(sourceID string) time.Duration {
+	until, ok := b.PausedUntil(sourceID)
+	if !ok {
+		return 0
+	}
+	return until.Sub(b.now())
+}
+
+// PausedUntil reports when embedding resumes for sourceID, if it is paused.
+func (b *EmbedBackoff) PausedUntil(sourceID string) (time.Time, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	until, ok := b.pausedUntil[sourceID]
+	if !ok {
+		return time.Time{}, false
+	}
+	if !b.now().Before(until) {
+		delete(b.pausedUntil, sourceID)
+		return time.Time{}, false
+	}
+	return until, true
+}
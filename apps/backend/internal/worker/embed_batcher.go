@@ -0,0 +1,108 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// embedBatchTimeout bounds one batched provider call. Callers stop waiting
+// earlier if their own context ends.
+const embedBatchTimeout = 60 * time.Second
+
+// embedBatcher coalesces Embed calls from concurrent message handlers into
+// EmbedBatch calls. A batch is sent once it holds size texts, or wait after
+// its first text arrived, whichever comes first.
+type embedBatcher struct {
+	embedder BatchEmbedder
+	size     int
+	wait     time.Duration
+
+	mu      sync.Mutex
+	pending []*batchItem
+	timer   *time.Timer
+}
+
+type batchItem struct {
+	text string
+	done chan batchResult
+}
+
+type batchResult struct {
+	vector []float32
+	err    error
+}
+
+func newEmbedBatcher(e BatchEmbedder, size int, wait time.Duration) *embedBatcher {
+	return &embedBatcher{embedder: e, size: size, wait: wait}
+}
+
+// Embed queues text for the next batch and blocks until its vector is back or
+// ctx is done. A failed batch fails every text in it.
+func (b *embedBatcher) Embed(ctx context.Context, text string) ([]float32, error) {
+	item := &batchItem{text: text, done: make(chan batchResult, 1)}
+
+	b.mu.Lock()
+	b.pending = append(b.pending, item)
+	var full []*batchItem
+	if len(b.pending) >= b.size {
+		full = b.take()
+	} else if len(b.pending) == 1 {
+		b.timer = time.AfterFunc(b.wait, b.flush)
+	}
+	b.mu.Unlock()
+
+	if full != nil {
+		go b.send(full)
+	}
+
+	select {
+	case res := <-item.done:
+		return res.vector, res.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// take empties the queue. The caller holds mu.
+func (b *embedBatcher) take() []*batchItem {
+	items := b.pending
+	b.pending = nil
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	return items
+}
+
+func (b *embedBatcher) flush() {
+	b.mu.Lock()
+	items := b.take()
+	b.mu.Unlock()
+	if len(items) > 0 {
+		b.send(items)
+	}
+}
+
+func (b *embedBatcher) send(items []*batchItem) {
+	ctx, cancel := context.WithTimeout(context.Background(), embedBatchTimeout)
+	defer cancel()
+
+	texts := make([]string, len(items))
+	for i, item := range items {
+		texts[i] = item.text
+	}
+
+	vectors, err := b.embedder.EmbedBatch(ctx, texts)
+	if err == nil && len(vectors) != len(items) {
+		err = fmt.Errorf("batch embedding returned %d vectors for %d texts", len(vectors), len(items))
+	}
+	for i, item := range items {
+		if err != nil {
+			item.done <- batchResult{err: err}
+			continue
+		}
+		item.done <- batchResult{vector: vectors[i]}
+	}
+}
@@ -3,18 +3,39 @@ package worker
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"time"
 
+	"qurio/apps/backend/internal/config"
 	"qurio/apps/backend/internal/middleware"
+	"qurio/apps/backend/internal/text"
 
 	"github.com/nsqio/go-nsq"
 )
 
+// sourceSlotRetry is how long a message for a source without a free
+// SourceLimiter slot is held before it is delivered again.
+const sourceSlotRetry = 5 * time.Second
+
 type EmbedderConsumer struct {
-	embedder Embedder
-	store    VectorStore
+	embedder       Embedder
+	store          VectorStore
+	limiter        *SourceLimiter
+	backoff        *EmbedBackoff
+	maxInputTokens int
+	deadLetter     deadLetterPolicy
+	batcher        *embedBatcher
+	storeBatcher   *storeBatcher
+	pageHashes     PageHashRecorder
+	requeue        DeferredPublisher
+}
+
+// PageHashRecorder counts stored chunks towards the change-detection hash of
+// the page they came from.
+type PageHashRecorder interface {
+	RecordStoredChunk(ctx context.Context, sourceID, url, hash string) error
 }
 
 func NewEmbedderConsumer(e Embedder, s VectorStore) *EmbedderConsumer {
@@ -24,7 +45,81 @@ func NewEmbedderConsumer(e Embedder, s VectorStore) *EmbedderConsumer {
 	}
 }
 
+// SetSourceLimiter bounds how many sources this consumer processes concurrently.
+func (h *EmbedderConsumer) SetSourceLimiter(l *SourceLimiter) {
+	h.limiter = l
+}
+
+// SetEmbedBackoff pauses a source's embedding after repeated rate-limit
+// failures; its messages are requeued until the cooldown ends.
+func (h *EmbedderConsumer) SetEmbedBackoff(b *EmbedBackoff) {
+	h.backoff = b
+}
+
+// SetMaxInputTokens truncates embedding input above n estimated tokens instead
+// of letting the provider reject it. Zero disables truncation.
+func (h *EmbedderConsumer) SetMaxInputTokens(n int) {
+	h.maxInputTokens = n
+}
+
+// SetDeadLetter publishes an embed message that still fails on its
+// maxAttempts-th attempt to the dead-letter topic instead of handing it back
+// to NSQ. Zero attempts requeues indefinitely.
+func (h *EmbedderConsumer) SetDeadLetter(p TaskPublisher, maxAttempts int) {
+	h.deadLetter = deadLetterPolicy{publisher: p, maxAttempts: maxAttempts}
+}
+
+// SetEmbedBatch embeds default-model chunks from concurrently handled
+// messages together, up to size texts per provider call, waiting at most wait
+// for a batch to fill. It has no effect when the embedder cannot batch, or
+// when size is below 2; chunks are then embedded one call each.
+func (h *EmbedderConsumer) SetEmbedBatch(size int, wait time.Duration) {
+	be, ok := h.embedder.(BatchEmbedder)
+	if !ok || size < 2 {
+		if size >= 2 {
+			slog.Warn("embedder does not support batching, embedding chunks individually")
+		}
+		h.batcher = nil
+		return
+	}
+	h.batcher = newEmbedBatcher(be, size, wait)
+}
+
+// SetStoreBatch writes chunks from concurrently handled messages to the
+// vector store together, up to size chunks per request, waiting at most wait
+// for a batch to fill. It has no effect when the store cannot batch, or when
+// size is below 2; chunks are then stored one request each.
+func (h *EmbedderConsumer) SetStoreBatch(size int, wait time.Duration) {
+	bs, ok := h.store.(BatchVectorStore)
+	if !ok || size < 2 {
+		if size >= 2 {
+			slog.Warn("vector store does not support batching, storing chunks individually")
+		}
+		h.storeBatcher = nil
+		return
+	}
+	h.storeBatcher = newStoreBatcher(bs, size, wait)
+}
+
+// SetRequeuePublisher re-enqueues messages of a paused source as fresh
+// deferred messages instead of requeueing them. A requeue counts as an NSQ
+// attempt, so a long pause would otherwise exhaust the message's attempts and
+// go-nsq would drop it. Nil falls back to requeueing.
+func (h *EmbedderConsumer) SetRequeuePublisher(p DeferredPublisher) {
+	h.requeue = p
+}
+
+// SetPageHashRecorder reports each stored chunk that carries a page hash, so
+// the page is only treated as unchanged once all its chunks are stored.
+func (h *EmbedderConsumer) SetPageHashRecorder(r PageHashRecorder) {
+	h.pageHashes = r
+}
+
 func (h *EmbedderConsumer) HandleMessage(m *nsq.Message) error {
+	return h.deadLetter.handle(config.TopicIngestEmbed, m, h.handleMessage(m))
+}
+
+func (h *EmbedderConsumer) handleMessage(m *nsq.Message) error {
 	if len(m.Body) == 0 {
 		return nil
 	}
@@ -41,15 +136,53 @@ func (h *EmbedderConsumer) HandleMessage(m *nsq.Message) error {
 		ctx = middleware.WithCorrelationID(ctx, payload.CorrelationID)
 	}
 
+	if h.backoff != nil {
+		if wait := h.backoff.Remaining(payload.SourceID); wait > 0 {
+			slog.DebugContext(ctx, "source embedding paused, requeueing", "source_id", payload.SourceID, "wait", wait)
+			h.holdUntil(ctx, m, wait)
+			return nil
+		}
+	}
+
+	if h.limiter != nil {
+		if !h.limiter.TryAcquire(payload.SourceID) {
+			slog.DebugContext(ctx, "no free source slot, requeueing", "source_id", payload.SourceID, "wait", sourceSlotRetry)
+			h.holdUntil(ctx, m, sourceSlotRetry)
+			return nil
+		}
+		defer h.limiter.Release(payload.SourceID)
+	}
+
+	// Keyword-only chunks skip the embedder and are stored without a vector
+	if payload.SkipEmbedding {
+		chunk := chunkFromPayload(payload, nil)
+		if err := h.storeChunk(ctx, chunk); err != nil {
+			slog.ErrorContext(ctx, "store chunk failed", "error", err, "source_id", payload.SourceID, "url", payload.SourceURL)
+			return err // Retry
+		}
+		slog.InfoContext(ctx, "keyword-only chunk stored", "source_id", payload.SourceID, "chunk_index", payload.ChunkIndex)
+		h.recordStored(ctx, payload)
+		return nil
+	}
+
 	// Reconstruct Contextual String
 	// Embeds source context alongside chunk content to improve semantic search.
 	// SourceName is prominent to help disambiguate results from different
 	// documentation sources (e.g., Vue vs React vs Astro docs).
 	// URL and Type are omitted — they don't help the embedding model understand
 	// semantics, and remain available as Weaviate metadata for filtering.
-	contextualString := fmt.Sprintf("Documentation: %s\nTitle: %s\nSection: %s",
-		payload.SourceName, payload.Title, payload.Path)
+	// With EmbedTitlePrefix the title leads the chunk text instead, so it is
+	// embedded once.
+	titlePrefixed := payload.EmbedTitlePrefix && payload.Title != ""
+	contextualString := fmt.Sprintf("Documentation: %s", payload.SourceName)
+	if !titlePrefixed {
+		contextualString += fmt.Sprintf("\nTitle: %s", payload.Title)
+	}
+	contextualString += fmt.Sprintf("\nSection: %s", payload.Path)
 
+	if payload.Heading != "" {
+		contextualString += fmt.Sprintf("\nHeading: %s", payload.Heading)
+	}
 	if payload.Author != "" {
 		contextualString += fmt.Sprintf("\nAuthor: %s", payload.Author)
 	}
@@ -57,21 +190,115 @@ func (h *EmbedderConsumer) HandleMessage(m *nsq.Message) error {
 		contextualString += fmt.Sprintf("\nCreated: %s", payload.CreatedAt)
 	}
 
-	contextualString += fmt.Sprintf("\n---\n%s", payload.Content)
+	// Title prefix is embedded only; the stored chunk keeps clean content
+	body := payload.Content
+	if titlePrefixed {
+		body = fmt.Sprintf("Title: %s\n\n%s", payload.Title, body)
+	}
+
+	contextualString += fmt.Sprintf("\n---\n%s", body)
+
+	// Guard against provider input limits, measured like chunk sizes
+	truncated := false
+	if h.maxInputTokens > 0 {
+		if tokens := text.DefaultTokenCounter.EstimateTokens(contextualString); tokens > h.maxInputTokens {
+			slog.WarnContext(ctx, "embedding input exceeds limit, truncating",
+				"source_id", payload.SourceID, "url", payload.SourceURL, "chunk_index", payload.ChunkIndex,
+				"tokens", tokens, "max_tokens", h.maxInputTokens)
+			contextualString = text.TruncateToTokens(contextualString, h.maxInputTokens, text.DefaultTokenCounter)
+			truncated = true
+		}
+	}
 
 	// Embed with Timeout
 	// Embedder interface usually takes context.
 	embedCtx, cancel := context.WithTimeout(ctx, 60*time.Second)
 	defer cancel()
 
-	vector, err := h.embedder.Embed(embedCtx, contextualString)
+	vector, model, err := h.embed(embedCtx, payload.EmbeddingModel, contextualString)
 	if err != nil {
 		slog.ErrorContext(ctx, "embedding failed", "error", err, "source_id", payload.SourceID, "url", payload.SourceURL)
+		if h.backoff != nil && errors.Is(err, ErrRateLimited) && h.backoff.RecordRateLimit(payload.SourceID) {
+			// Requeue without NSQ backoff so other sources keep flowing
+			slog.WarnContext(ctx, "pausing source embedding after repeated rate limits", "source_id", payload.SourceID)
+			h.holdUntil(ctx, m, h.backoff.Remaining(payload.SourceID))
+			return nil
+		}
 		return err // Retry
 	}
+	if h.backoff != nil {
+		h.backoff.RecordSuccess(payload.SourceID)
+	}
 
 	// Store Chunk
-	chunk := Chunk{
+	chunk := chunkFromPayload(payload, vector)
+	chunk.Truncated = truncated
+	chunk.EmbeddingModel = model
+
+	if err := h.storeChunk(embedCtx, chunk); err != nil {
+		slog.ErrorContext(ctx, "store chunk failed", "error", err, "source_id", payload.SourceID, "url", payload.SourceURL)
+		return err // Retry
+	}
+
+	slog.InfoContext(ctx, "chunk stored successfully", "source_id", payload.SourceID, "chunk_index", payload.ChunkIndex)
+	h.recordStored(ctx, payload)
+	return nil
+}
+
+// recordStored counts a stored chunk towards its page's hash.
+func (h *EmbedderConsumer) recordStored(ctx context.Context, payload IngestEmbedPayload) {
+	if h.pageHashes == nil || payload.PageHash == "" {
+		return
+	}
+	if err := h.pageHashes.RecordStoredChunk(ctx, payload.SourceID, payload.PageURL, payload.PageHash); err != nil {
+		slog.WarnContext(ctx, "failed to record stored chunk", "error", err, "source_id", payload.SourceID, "url", payload.PageURL)
+	}
+}
+
+// holdUntil hands m back to be delivered again after wait without counting
+// as a failure. With a requeue publisher its body is published anew, deferred
+// by at most MaxDeferral, and m is finished; otherwise m is requeued.
+func (h *EmbedderConsumer) holdUntil(ctx context.Context, m *nsq.Message, wait time.Duration) {
+	if h.requeue != nil {
+		err := h.requeue.DeferredPublish(config.TopicIngestEmbed, min(wait, MaxDeferral), m.Body)
+		if err == nil {
+			return
+		}
+		slog.WarnContext(ctx, "failed to re-enqueue held message, requeueing", "error", err)
+	}
+	m.RequeueWithoutBackoff(wait)
+}
+
+// embed uses the requested model when the embedder supports overrides and
+// falls back to the default model otherwise. It returns the override actually
+// used, or "" for the default.
+func (h *EmbedderConsumer) embed(ctx context.Context, model, text string) ([]float32, string, error) {
+	if model != "" {
+		if me, ok := h.embedder.(ModelEmbedder); ok {
+			vector, err := me.EmbedWithModel(ctx, model, text)
+			return vector, model, err
+		}
+		slog.WarnContext(ctx, "embedder does not support model overrides, using default", "model", model)
+	}
+	if h.batcher != nil {
+		vector, err := h.batcher.Embed(ctx, text)
+		return vector, "", err
+	}
+	vector, err := h.embedder.Embed(ctx, text)
+	return vector, "", err
+}
+
+// storeChunk writes through the store batcher when one is set.
+func (h *EmbedderConsumer) storeChunk(ctx context.Context, chunk Chunk) error {
+	if h.storeBatcher != nil {
+		return h.storeBatcher.StoreChunk(ctx, chunk)
+	}
+	return h.store.StoreChunk(ctx, chunk)
+}
+
+func chunkFromPayload(payload IngestEmbedPayload, vector []float32) Chunk {
+	return Chunk{
+		ID:         payload.ChunkID,
 		Content:    payload.Content,
 		Vector:     vector,
 		SourceID:   payload.SourceID,
@@ -84,13 +311,13 @@ func (h *EmbedderConsumer) HandleMessage(m *nsq.Message) error {
 		Author:     payload.Author,
 		CreatedAt:  payload.CreatedAt,
 		PageCount:  payload.PageCount,
-	}
+		Metadata:   payload.Metadata,
+		Selector:   payload.Selector,
+		AliasURL:   payload.AliasURL,
 
-	if err := h.store.StoreChunk(embedCtx, chunk); err != nil {
-		slog.ErrorContext(ctx, "store chunk failed", "error", err, "source_id", payload.SourceID, "url", payload.SourceURL)
-		return err // Retry
+		AnchorKeywords: payload.AnchorKeywords,
+		TitlePath:      payload.TitlePath,
+		Heading:        payload.Heading,
+		Overlap:        payload.Overlap,
 	}
-
-	slog.InfoContext(ctx, "chunk stored successfully", "source_id", payload.SourceID, "chunk_index", payload.ChunkIndex)
-	return nil
 }
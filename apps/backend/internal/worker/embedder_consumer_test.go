@@ -1,14 +1,23 @@
 package worker_test
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"qurio/apps/backend/internal/config"
+	"qurio/apps/backend/internal/text"
 	"qurio/apps/backend/internal/worker"
 
 	"github.com/nsqio/go-nsq"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestEmbedderConsumer_HandleMessage_Success(t *testing.T) {
@@ -54,6 +63,152 @@ func TestEmbedderConsumer_HandleMessage_Success(t *testing.T) {
 	s.AssertExpectations(t)
 }
 
+func TestEmbedderConsumer_HandleMessage_EmbedTitlePrefix(t *testing.T) {
+	e := new(MockEmbedder)
+	s := new(MockVectorStore)
+
+	consumer := worker.NewEmbedderConsumer(e, s)
+
+	payload := worker.IngestEmbedPayload{
+		SourceID:         "src1",
+		SourceURL:        "http://example.com",
+		Content:          "Chunk Content",
+		Title:            "Getting Started",
+		EmbedTitlePrefix: true,
+	}
+	body, _ := json.Marshal(payload)
+	msg := &nsq.Message{Body: body}
+
+	e.On("Embed", mock.Anything, mock.MatchedBy(func(text string) bool {
+		return strings.HasSuffix(text, "\n---\nTitle: Getting Started\n\nChunk Content") &&
+			strings.Count(text, "Getting Started") == 1
+	})).Return([]float32{0.1}, nil)
+
+	s.On("StoreChunk", mock.Anything, mock.MatchedBy(func(c worker.Chunk) bool {
+		return c.Content == "Chunk Content" && c.Title == "Getting Started"
+	})).Return(nil)
+
+	err := consumer.HandleMessage(msg)
+	assert.NoError(t, err)
+
+	e.AssertExpectations(t)
+	s.AssertExpectations(t)
+}
+
+func TestEmbedderConsumer_HandleMessage_HeadingContext(t *testing.T) {
+	e := new(MockEmbedder)
+	s := new(MockVectorStore)
+
+	consumer := worker.NewEmbedderConsumer(e, s)
+
+	payload := worker.IngestEmbedPayload{
+		SourceID:  "src1",
+		SourceURL: "http://example.com",
+		Content:   "header of each call. Tokens expire after one hour.",
+		Heading:   "# API > ## Authentication",
+		Overlap:   21,
+	}
+	body, _ := json.Marshal(payload)
+	msg := &nsq.Message{Body: body}
+
+	// The heading is embedded as context, not stitched into the content
+	e.On("Embed", mock.Anything, mock.MatchedBy(func(text string) bool {
+		return strings.Contains(text, "\nHeading: # API > ## Authentication\n") &&
+			strings.HasSuffix(text, "\n---\nheader of each call. Tokens expire after one hour.")
+	})).Return([]float32{0.1}, nil)
+
+	s.On("StoreChunk", mock.Anything, mock.MatchedBy(func(c worker.Chunk) bool {
+		return c.Content == payload.Content && c.Heading == payload.Heading && c.Overlap == 21
+	})).Return(nil)
+
+	err := consumer.HandleMessage(msg)
+	assert.NoError(t, err)
+
+	e.AssertExpectations(t)
+	s.AssertExpectations(t)
+}
+
+func TestEmbedderConsumer_HandleMessage_SkipEmbedding(t *testing.T) {
+	e := new(MockEmbedder)
+	s := new(MockVectorStore)
+
+	consumer := worker.NewEmbedderConsumer(e, s)
+
+	payload := worker.IngestEmbedPayload{
+		SourceID:      "src1",
+		SourceURL:     "http://example.com",
+		Content:       "```bash\nmake build\n```",
+		ChunkType:     "cmd",
+		SkipEmbedding: true,
+	}
+	body, _ := json.Marshal(payload)
+	msg := &nsq.Message{Body: body}
+
+	s.On("StoreChunk", mock.Anything, mock.MatchedBy(func(c worker.Chunk) bool {
+		return c.Type == "cmd" && c.Vector == nil
+	})).Return(nil)
+
+	err := consumer.HandleMessage(msg)
+	assert.NoError(t, err)
+
+	e.AssertNotCalled(t, "Embed", mock.Anything, mock.Anything)
+	s.AssertExpectations(t)
+}
+
+func TestEmbedderConsumer_HandleMessage_TruncatesOversizedInput(t *testing.T) {
+	e := new(MockEmbedder)
+	s := new(MockVectorStore)
+
+	consumer := worker.NewEmbedderConsumer(e, s)
+	consumer.SetMaxInputTokens(100)
+
+	longLine := strings.Repeat("x", 2000) // ~500 tokens on a single line
+	payload := worker.IngestEmbedPayload{
+		SourceID:  "src1",
+		SourceURL: "http://example.com",
+		Content:   longLine,
+		ChunkType: "code",
+	}
+	body, _ := json.Marshal(payload)
+	msg := &nsq.Message{Body: body}
+
+	e.On("Embed", mock.Anything, mock.MatchedBy(func(input string) bool {
+		return text.DefaultTokenCounter.EstimateTokens(input) <= 100
+	})).Return([]float32{0.1}, nil)
+
+	s.On("StoreChunk", mock.Anything, mock.MatchedBy(func(c worker.Chunk) bool {
+		return c.Truncated && c.Content == longLine
+	})).Return(nil)
+
+	err := consumer.HandleMessage(msg)
+	assert.NoError(t, err)
+
+	e.AssertExpectations(t)
+	s.AssertExpectations(t)
+
+	t.Run("counts tokens, not bytes", func(t *testing.T) {
+		e := new(MockEmbedder)
+		s := new(MockVectorStore)
+		consumer := worker.NewEmbedderConsumer(e, s)
+		consumer.SetMaxInputTokens(100)
+
+		// 120 ideographs are 360 bytes but 120 tokens
+		cjk := strings.Repeat("検", 120)
+		body, _ := json.Marshal(worker.IngestEmbedPayload{SourceID: "src1", Content: cjk})
+
+		e.On("Embed", mock.Anything, mock.MatchedBy(func(input string) bool {
+			return text.DefaultTokenCounter.EstimateTokens(input) <= 100
+		})).Return([]float32{0.1}, nil)
+		s.On("StoreChunk", mock.Anything, mock.MatchedBy(func(c worker.Chunk) bool {
+			return c.Truncated && c.Content == cjk
+		})).Return(nil)
+
+		assert.NoError(t, consumer.HandleMessage(&nsq.Message{Body: body}))
+		e.AssertExpectations(t)
+		s.AssertExpectations(t)
+	})
+}
+
 func TestEmbedderConsumer_HandleMessage_EmbedError(t *testing.T) {
 	e := new(MockEmbedder)
 	s := new(MockVectorStore)
@@ -92,6 +247,35 @@ func TestEmbedderConsumer_HandleMessage_StoreError(t *testing.T) {
 	assert.Error(t, err) // Should retry
 }
 
+func TestEmbedderConsumer_HandleMessage_DeadLettersAfterMaxAttempts(t *testing.T) {
+	e := new(MockEmbedder)
+	s := new(MockVectorStore)
+	tp := new(MockTaskPublisher)
+	consumer := worker.NewEmbedderConsumer(e, s)
+	consumer.SetDeadLetter(tp, 3)
+
+	body, _ := json.Marshal(worker.IngestEmbedPayload{SourceID: "src1", Content: "content"})
+
+	e.On("Embed", mock.Anything, mock.Anything).Return([]float32{0.1}, nil)
+	s.On("StoreChunk", mock.Anything, mock.Anything).Return(assert.AnError)
+	var letter worker.DeadLetter
+	tp.On("Publish", config.TopicIngestDeadLetter, mock.Anything).Run(func(args mock.Arguments) {
+		_ = json.Unmarshal(args.Get(1).([]byte), &letter)
+	}).Return(nil)
+
+	// Attempts left: the error goes back to NSQ
+	assert.Error(t, consumer.HandleMessage(&nsq.Message{Body: body, Attempts: 2}))
+	tp.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+
+	// Last attempt: dead-lettered and acked
+	assert.NoError(t, consumer.HandleMessage(&nsq.Message{Body: body, Attempts: 3}))
+	tp.AssertNumberOfCalls(t, "Publish", 1)
+	assert.Equal(t, config.TopicIngestEmbed, letter.Topic)
+	assert.Equal(t, uint16(3), letter.Attempts)
+	assert.Equal(t, assert.AnError.Error(), letter.Error)
+	assert.JSONEq(t, string(body), string(letter.Body))
+}
+
 func TestEmbedderConsumer_HandleMessage_PoisonPill(t *testing.T) {
 	consumer := worker.NewEmbedderConsumer(nil, nil)
 	msg := &nsq.Message{Body: []byte("invalid json")}
@@ -99,3 +283,357 @@ func TestEmbedderConsumer_HandleMessage_PoisonPill(t *testing.T) {
 	err := consumer.HandleMessage(msg)
 	assert.NoError(t, err) // No retry
 }
+
+func TestEmbedderConsumer_HandleMessage_SourceEmbeddingModel(t *testing.T) {
+	e := new(MockEmbedder)
+	s := new(MockVectorStore)
+
+	consumer := worker.NewEmbedderConsumer(e, s)
+
+	payload := worker.IngestEmbedPayload{
+		SourceID:       "src1",
+		SourceURL:      "http://example.com",
+		Content:        "func main() {}",
+		ChunkType:      "code",
+		EmbeddingModel: "code-embedding-001",
+	}
+	body, _ := json.Marshal(payload)
+
+	e.On("EmbedWithModel", mock.Anything, "code-embedding-001", mock.Anything).Return([]float32{0.3}, nil)
+	s.On("StoreChunk", mock.Anything, mock.MatchedBy(func(c worker.Chunk) bool {
+		return c.EmbeddingModel == "code-embedding-001" && c.Vector[0] == 0.3
+	})).Return(nil)
+
+	err := consumer.HandleMessage(&nsq.Message{Body: body})
+	assert.NoError(t, err)
+
+	e.AssertExpectations(t)
+	e.AssertNotCalled(t, "Embed", mock.Anything, mock.Anything)
+	s.AssertExpectations(t)
+}
+
+// requeueRecorder captures requeue responses in place of an NSQ connection.
+type requeueRecorder struct {
+	requeued []time.Duration
+}
+
+func (r *requeueRecorder) OnFinish(m *nsq.Message) {}
+func (r *requeueRecorder) OnTouch(m *nsq.Message)  {}
+func (r *requeueRecorder) OnRequeue(m *nsq.Message, delay time.Duration, backoff bool) {
+	r.requeued = append(r.requeued, delay)
+}
+
+func TestEmbedderConsumer_HandleMessage_PausesRateLimitedSource(t *testing.T) {
+	e := new(MockEmbedder)
+	s := new(MockVectorStore)
+
+	consumer := worker.NewEmbedderConsumer(e, s)
+	backoff := worker.NewEmbedBackoff(3, time.Minute)
+	consumer.SetEmbedBackoff(backoff)
+
+	e.On("Embed", mock.Anything, mock.MatchedBy(func(text string) bool {
+		return strings.Contains(text, "noisy")
+	})).Return(nil, fmt.Errorf("%w: 429", worker.ErrRateLimited))
+	e.On("Embed", mock.Anything, mock.MatchedBy(func(text string) bool {
+		return strings.Contains(text, "quiet")
+	})).Return([]float32{0.1}, nil)
+	s.On("StoreChunk", mock.Anything, mock.MatchedBy(func(c worker.Chunk) bool {
+		return c.SourceID == "quiet"
+	})).Return(nil)
+
+	rec := &requeueRecorder{}
+	message := func(sourceID string) *nsq.Message {
+		body, _ := json.Marshal(worker.IngestEmbedPayload{SourceID: sourceID, SourceName: sourceID, Content: "chunk"})
+		msg := nsq.NewMessage(nsq.MessageID{}, body)
+		msg.Delegate = rec
+		return msg
+	}
+
+	// The first failures are retried normally.
+	for i := 0; i < 2; i++ {
+		assert.Error(t, consumer.HandleMessage(message("noisy")))
+	}
+	_, paused := backoff.PausedUntil("noisy")
+	assert.False(t, paused)
+
+	// The third consecutive rate limit pauses the source.
+	assert.NoError(t, consumer.HandleMessage(message("noisy")))
+	_, paused = backoff.PausedUntil("noisy")
+	assert.True(t, paused)
+
+	// While paused, its messages are requeued without calling the embedder.
+	assert.NoError(t, consumer.HandleMessage(message("noisy")))
+	e.AssertNumberOfCalls(t, "Embed", 3)
+	assert.Len(t, rec.requeued, 2)
+	for _, d := range rec.requeued {
+		assert.Greater(t, d, 50*time.Second)
+	}
+
+	// Another source keeps embedding.
+	assert.NoError(t, consumer.HandleMessage(message("quiet")))
+	s.AssertExpectations(t)
+	_, paused = backoff.PausedUntil("quiet")
+	assert.False(t, paused)
+}
+
+// embedDeferrer records deferred publishes of embed messages.
+type embedDeferrer struct {
+	bodies [][]byte
+	delays []time.Duration
+}
+
+func (d *embedDeferrer) DeferredPublish(topic string, delay time.Duration, body []byte) error {
+	if topic == config.TopicIngestEmbed {
+		d.bodies = append(d.bodies, body)
+		d.delays = append(d.delays, delay)
+	}
+	return nil
+}
+
+func TestEmbedderConsumer_HandleMessage_PausedSourceReenqueuesFreshMessage(t *testing.T) {
+	e := new(MockEmbedder)
+	s := new(MockVectorStore)
+
+	consumer := worker.NewEmbedderConsumer(e, s)
+	backoff := worker.NewEmbedBackoff(1, 2*time.Hour)
+	consumer.SetEmbedBackoff(backoff)
+	d := &embedDeferrer{}
+	consumer.SetRequeuePublisher(d)
+	e.On("Embed", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("%w: 429", worker.ErrRateLimited))
+
+	rec := &requeueRecorder{}
+	body, _ := json.Marshal(worker.IngestEmbedPayload{SourceID: "noisy", Content: "chunk"})
+	for i := 0; i < 2; i++ {
+		msg := nsq.NewMessage(nsq.MessageID{}, body)
+		msg.Delegate = rec
+		msg.Attempts = 9
+		assert.NoError(t, consumer.HandleMessage(msg))
+	}
+
+	// Neither the pausing failure nor the paused message uses up an attempt
+	assert.Empty(t, rec.requeued)
+	require.Len(t, d.bodies, 2)
+	for i := range d.bodies {
+		assert.Equal(t, body, d.bodies[i])
+		assert.LessOrEqual(t, d.delays[i], worker.MaxDeferral)
+	}
+	e.AssertNumberOfCalls(t, "Embed", 1)
+}
+
+// batchingEmbedder records each EmbedBatch call and returns vectors whose
+// first value is the text's length.
+type batchingEmbedder struct {
+	MockEmbedder
+	mu      sync.Mutex
+	batches [][]string
+}
+
+func (b *batchingEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	b.mu.Lock()
+	b.batches = append(b.batches, texts)
+	b.mu.Unlock()
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i] = []float32{float32(len(text))}
+	}
+	return vectors, nil
+}
+
+func TestEmbedderConsumer_HandleMessage_EmbedBatch(t *testing.T) {
+	e := &batchingEmbedder{}
+	s := new(MockVectorStore)
+
+	consumer := worker.NewEmbedderConsumer(e, s)
+	consumer.SetEmbedBatch(3, time.Second)
+
+	var mu sync.Mutex
+	stored := make(map[int]worker.Chunk)
+	s.On("StoreChunk", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		c := args.Get(1).(worker.Chunk)
+		mu.Lock()
+		stored[c.ChunkIndex] = c
+		mu.Unlock()
+	}).Return(nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		body, _ := json.Marshal(worker.IngestEmbedPayload{
+			SourceID:   "src1",
+			SourceURL:  "http://example.com",
+			Content:    strings.Repeat("x", i+1),
+			ChunkIndex: i,
+		})
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, consumer.HandleMessage(&nsq.Message{Body: body}))
+		}()
+	}
+	wg.Wait()
+
+	// A full batch goes out without waiting for the timer
+	require.Len(t, e.batches, 1)
+	assert.Len(t, e.batches[0], 3)
+	e.AssertNotCalled(t, "Embed", mock.Anything, mock.Anything)
+
+	// Each chunk gets the vector computed from its own text
+	require.Len(t, stored, 3)
+	base := len("Documentation: \nTitle: \nSection: \n---\n")
+	for i, c := range stored {
+		assert.Equal(t, []float32{float32(base + i + 1)}, c.Vector, "chunk %d", i)
+	}
+
+	t.Run("partial batch is sent after the wait", func(t *testing.T) {
+		consumer.SetEmbedBatch(10, 10*time.Millisecond)
+		body, _ := json.Marshal(worker.IngestEmbedPayload{SourceID: "src1", Content: "alone"})
+
+		assert.NoError(t, consumer.HandleMessage(&nsq.Message{Body: body}))
+		assert.Len(t, e.batches, 2)
+	})
+}
+
+func TestEmbedderConsumer_SetEmbedBatch_FallsBackWithoutBatching(t *testing.T) {
+	e := new(MockEmbedder)
+	s := new(MockVectorStore)
+
+	consumer := worker.NewEmbedderConsumer(e, s)
+	consumer.SetEmbedBatch(100, time.Second)
+
+	e.On("Embed", mock.Anything, mock.Anything).Return([]float32{0.1}, nil).Once()
+	s.On("StoreChunk", mock.Anything, mock.Anything).Return(nil)
+
+	body, _ := json.Marshal(worker.IngestEmbedPayload{SourceID: "src1", Content: "text"})
+	assert.NoError(t, consumer.HandleMessage(&nsq.Message{Body: body}))
+	e.AssertExpectations(t)
+}
+
+// batchingStore records each StoreChunks call.
+type batchingStore struct {
+	MockVectorStore
+	mu      sync.Mutex
+	batches [][]worker.Chunk
+}
+
+func (b *batchingStore) StoreChunks(ctx context.Context, chunks []worker.Chunk) error {
+	b.mu.Lock()
+	b.batches = append(b.batches, chunks)
+	b.mu.Unlock()
+
+	// Chunks with content "bad" are rejected individually
+	errs := worker.ChunkErrors{}
+	for i, c := range chunks {
+		if c.Content == "bad" {
+			errs[i] = errors.New("rejected")
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func TestEmbedderConsumer_HandleMessage_StoreBatch(t *testing.T) {
+	e := new(MockEmbedder)
+	s := &batchingStore{}
+
+	consumer := worker.NewEmbedderConsumer(e, s)
+	consumer.SetStoreBatch(3, time.Second)
+	e.On("Embed", mock.Anything, mock.Anything).Return([]float32{0.1}, nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		body, _ := json.Marshal(worker.IngestEmbedPayload{SourceID: "src1", Content: "text", ChunkIndex: i})
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, consumer.HandleMessage(&nsq.Message{Body: body}))
+		}()
+	}
+	wg.Wait()
+
+	// All three chunks go out in one write, never one at a time
+	require.Len(t, s.batches, 1)
+	assert.Len(t, s.batches[0], 3)
+	s.AssertNotCalled(t, "StoreChunk", mock.Anything, mock.Anything)
+}
+
+func TestEmbedderConsumer_HandleMessage_StoreBatchFailsOnlyRejectedChunk(t *testing.T) {
+	e := new(MockEmbedder)
+	s := &batchingStore{}
+
+	consumer := worker.NewEmbedderConsumer(e, s)
+	consumer.SetStoreBatch(2, time.Second)
+	e.On("Embed", mock.Anything, mock.Anything).Return([]float32{0.1}, nil)
+
+	var wg sync.WaitGroup
+	results := map[string]error{}
+	var mu sync.Mutex
+	for i, content := range []string{"good", "bad"} {
+		body, _ := json.Marshal(worker.IngestEmbedPayload{SourceID: "src1", Content: content, ChunkIndex: i})
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := consumer.HandleMessage(&nsq.Message{Body: body})
+			mu.Lock()
+			results[content] = err
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	require.Len(t, s.batches, 1)
+	assert.NoError(t, results["good"])
+	assert.Error(t, results["bad"])
+}
+
+// recordingHashes records RecordStoredChunk calls.
+type recordingHashes struct {
+	calls []string
+}
+
+func (r *recordingHashes) RecordStoredChunk(ctx context.Context, sourceID, url, hash string) error {
+	r.calls = append(r.calls, sourceID+" "+url+" "+hash)
+	return nil
+}
+
+func TestEmbedderConsumer_HandleMessage_RecordsStoredChunkForPageHash(t *testing.T) {
+	e := new(MockEmbedder)
+	s := new(MockVectorStore)
+	hashes := &recordingHashes{}
+
+	consumer := worker.NewEmbedderConsumer(e, s)
+	consumer.SetPageHashRecorder(hashes)
+	e.On("Embed", mock.Anything, mock.Anything).Return([]float32{0.1}, nil)
+	s.On("StoreChunk", mock.Anything, mock.Anything).Return(nil)
+
+	tracked, _ := json.Marshal(worker.IngestEmbedPayload{SourceID: "src1", SourceURL: "http://example.com/canonical", Content: "text", PageURL: "http://example.com/fetched", PageHash: "key"})
+	untracked, _ := json.Marshal(worker.IngestEmbedPayload{SourceID: "src1", Content: "text"})
+	require.NoError(t, consumer.HandleMessage(&nsq.Message{Body: tracked}))
+	require.NoError(t, consumer.HandleMessage(&nsq.Message{Body: untracked}))
+
+	// Counted against the fetched URL the hash is stored under
+	assert.Equal(t, []string{"src1 http://example.com/fetched key"}, hashes.calls)
+}
+
+func TestEmbedderConsumer_HandleMessage_HoldsMessageWithoutSourceSlot(t *testing.T) {
+	e := new(MockEmbedder)
+	s := new(MockVectorStore)
+
+	consumer := worker.NewEmbedderConsumer(e, s)
+	limiter := worker.NewSourceLimiter(1)
+	require.True(t, limiter.TryAcquire("busy"))
+	consumer.SetSourceLimiter(limiter)
+	d := &embedDeferrer{}
+	consumer.SetRequeuePublisher(d)
+
+	body, _ := json.Marshal(worker.IngestEmbedPayload{SourceID: "waiting", Content: "chunk"})
+	msg := nsq.NewMessage(nsq.MessageID{}, body)
+	msg.Attempts = 9
+
+	// The message is handed back as a fresh one instead of failing an attempt
+	assert.NoError(t, consumer.HandleMessage(msg))
+	e.AssertNotCalled(t, "Embed", mock.Anything, mock.Anything)
+	require.Len(t, d.bodies, 1)
+	assert.Equal(t, body, d.bodies[0])
+	assert.Equal(t, 5*time.Second, d.delays[0])
+}
@@ -0,0 +1,65 @@
+package worker
+
+import (
+	"sync"
+	"time"
+)
+
+// enqueueDedup remembers recently discovered (source, url) pairs so that two
+// pages discovering the same link at nearly the same time only create and
+// enqueue it once. It is checked before BulkCreatePages, so a skipped URL
+// never gets a page row without a task.
+type enqueueDedup struct {
+	mu     sync.Mutex
+	window time.Duration
+	seen   map[string]time.Time
+	// order lists claims oldest first so expired ones are evicted from the
+	// front without scanning seen.
+	order []dedupClaim
+	now   func() time.Time
+}
+
+type dedupClaim struct {
+	key string
+	at  time.Time
+}
+
+func newEnqueueDedup(window time.Duration) *enqueueDedup {
+	return &enqueueDedup{window: window, seen: make(map[string]time.Time), now: time.Now}
+}
+
+func dedupKey(sourceID, url string) string {
+	return sourceID + "\x00" + url
+}
+
+// Claim records sourceID/url and reports whether the caller should create and
+// publish it. It returns false if the same pair was claimed within the window.
+func (d *enqueueDedup) Claim(sourceID, url string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	for len(d.order) > 0 && now.Sub(d.order[0].at) >= d.window {
+		oldest := d.order[0]
+		if at, ok := d.seen[oldest.key]; ok && at.Equal(oldest.at) {
+			delete(d.seen, oldest.key)
+		}
+		d.order = d.order[1:]
+	}
+
+	key := dedupKey(sourceID, url)
+	if _, ok := d.seen[key]; ok {
+		return false
+	}
+	d.seen[key] = now
+	d.order = append(d.order, dedupClaim{key: key, at: now})
+	return true
+}
+
+// Release forgets a claim whose page could not be created, so a redelivery
+// of the message can claim it again.
+func (d *enqueueDedup) Release(sourceID, url string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.seen, dedupKey(sourceID, url))
+}
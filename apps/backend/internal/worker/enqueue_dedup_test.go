@@ -0,0 +1,37 @@
+package worker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnqueueDedup_Claim(t *testing.T) {
+	now := time.Unix(0, 0)
+	d := newEnqueueDedup(10 * time.Second)
+	d.now = func() time.Time { return now }
+
+	assert.True(t, d.Claim("src1", "http://example.com/a"))
+	assert.False(t, d.Claim("src1", "http://example.com/a"))
+	assert.True(t, d.Claim("src2", "http://example.com/a"), "keyed per source")
+
+	now = now.Add(10 * time.Second)
+	assert.True(t, d.Claim("src1", "http://example.com/a"), "window expired")
+	assert.Len(t, d.seen, 1, "expired claims are evicted")
+}
+
+func TestEnqueueDedup_Release(t *testing.T) {
+	now := time.Unix(0, 0)
+	d := newEnqueueDedup(10 * time.Second)
+	d.now = func() time.Time { return now }
+
+	assert.True(t, d.Claim("src1", "http://example.com/a"))
+	d.Release("src1", "http://example.com/a")
+	assert.True(t, d.Claim("src1", "http://example.com/a"), "released claims can be claimed again")
+
+	// The stale entry of the released claim must not evict the new one early
+	now = now.Add(5 * time.Second)
+	assert.True(t, d.Claim("src1", "http://example.com/b"))
+	assert.False(t, d.Claim("src1", "http://example.com/a"))
+}
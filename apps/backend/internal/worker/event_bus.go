@@ -0,0 +1,92 @@
+package worker
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	EventPageStatus   = "page_status"
+	EventSourceStatus = "source_status"
+)
+
+var ErrTooManySubscribers = errors.New("too many event subscribers")
+
+// SourceEvent describes an ingestion state transition for a source.
+type SourceEvent struct {
+	SourceID string    `json:"source_id"`
+	Type     string    `json:"type"`
+	URL      string    `json:"url,omitempty"`
+	Status   string    `json:"status"`
+	Error    string    `json:"error,omitempty"`
+	Time     time.Time `json:"time"`
+}
+
+// EventBus fans out SourceEvents to subscribers of a given source. Publishing
+// never blocks: events are dropped for subscribers that are not keeping up.
+type EventBus struct {
+	mu    sync.RWMutex
+	subs  map[string]map[chan SourceEvent]struct{}
+	count int
+	max   int
+}
+
+func NewEventBus() *EventBus {
+	return &EventBus{subs: make(map[string]map[chan SourceEvent]struct{})}
+}
+
+// SetMaxSubscribers caps the number of concurrent subscriptions across all
+// sources. Zero means unlimited.
+func (b *EventBus) SetMaxSubscribers(n int) {
+	b.mu.Lock()
+	b.max = n
+	b.mu.Unlock()
+}
+
+// Subscribe returns a channel of events for sourceID and a function that
+// must be called to unsubscribe. It fails with ErrTooManySubscribers when the
+// subscriber cap is reached.
+func (b *EventBus) Subscribe(sourceID string) (<-chan SourceEvent, func(), error) {
+	ch := make(chan SourceEvent, 64)
+
+	b.mu.Lock()
+	if b.max > 0 && b.count >= b.max {
+		b.mu.Unlock()
+		return nil, nil, ErrTooManySubscribers
+	}
+	b.count++
+	if b.subs[sourceID] == nil {
+		b.subs[sourceID] = make(map[chan SourceEvent]struct{})
+	}
+	b.subs[sourceID][ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			b.count--
+			delete(b.subs[sourceID], ch)
+			if len(b.subs[sourceID]) == 0 {
+				delete(b.subs, sourceID)
+			}
+			b.mu.Unlock()
+		})
+	}, nil
+}
+
+func (b *EventBus) Publish(ev SourceEvent) {
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for ch := range b.subs[ev.SourceID] {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
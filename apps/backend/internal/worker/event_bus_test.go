@@ -0,0 +1,136 @@
+package worker_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"qurio/apps/backend/features/source"
+	"qurio/apps/backend/internal/worker"
+
+	"github.com/nsqio/go-nsq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventBus_DeliversOnlyToSourceSubscribers(t *testing.T) {
+	bus := worker.NewEventBus()
+	a, unsubA, err := bus.Subscribe("src-a")
+	require.NoError(t, err)
+	defer unsubA()
+	b, unsubB, err := bus.Subscribe("src-b")
+	require.NoError(t, err)
+	defer unsubB()
+
+	bus.Publish(worker.SourceEvent{SourceID: "src-a", Type: worker.EventPageStatus, Status: "completed"})
+
+	select {
+	case ev := <-a:
+		assert.Equal(t, "completed", ev.Status)
+		assert.False(t, ev.Time.IsZero())
+	case <-time.After(time.Second):
+		t.Fatal("src-a subscriber did not receive event")
+	}
+
+	select {
+	case ev := <-b:
+		t.Fatalf("src-b subscriber received foreign event: %+v", ev)
+	default:
+	}
+}
+
+func TestSourceEventsStream_RejectsOverMaxSessions(t *testing.T) {
+	bus := worker.NewEventBus()
+	bus.SetMaxSubscribers(2)
+
+	h := source.NewHandler(nil, t.TempDir(), 50)
+	h.SetEventBus(bus)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /sources/{id}/events", h.Events)
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	// Open sessions up to the limit
+	for i := 0; i < 2; i++ {
+		resp, err := http.Get(ts.URL + "/sources/src1/events")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		line, err := bufio.NewReader(resp.Body).ReadString('\n')
+		require.NoError(t, err)
+		require.Equal(t, ": connected\n", line)
+	}
+
+	resp, err := http.Get(ts.URL + "/sources/src2/events")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.NotEmpty(t, resp.Header.Get("Retry-After"))
+}
+
+func TestSourceEventsStream_PageCompleted(t *testing.T) {
+	bus := worker.NewEventBus()
+
+	h := source.NewHandler(nil, t.TempDir(), 50)
+	h.SetEventBus(bus)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /sources/{id}/events", h.Events)
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/sources/src1/events")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(resp.Body)
+	// Wait for the connected comment so the subscription is registered
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, ": connected\n", line)
+
+	s := new(MockVectorStore)
+	u := new(MockUpdater)
+	sf := new(MockSourceFetcher)
+	pm := new(MockPageManager)
+	consumer := worker.NewResultConsumer(s, u, nil, sf, pm, nil)
+	consumer.SetEventBus(bus)
+
+	sf.On("GetSourceConfig", mock.Anything, "src1").Return(0, []string{}, "", "Src", nil)
+	sf.On("GetSourceOptions", mock.Anything, "src1").Return(&worker.SourceOptions{}, nil)
+	s.On("DeleteChunksByURL", mock.Anything, "src1", "http://example.com").Return(nil)
+	u.On("UpdateBodyHash", mock.Anything, "src1", mock.Anything).Return(nil)
+	pm.On("UpdatePageStatus", mock.Anything, "src1", "http://example.com", "completed", "").Return(nil)
+	pm.On("CountPendingPages", mock.Anything, "src1").Return(1, nil)
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"source_id": "src1",
+		"url":       "http://example.com",
+		"status":    "success",
+	})
+	require.NoError(t, consumer.HandleMessage(&nsq.Message{Body: body}))
+
+	var event, data string
+	for data == "" {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event: "))
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimSpace(strings.TrimPrefix(line, "data: "))
+		}
+	}
+
+	assert.Equal(t, worker.EventPageStatus, event)
+	var ev worker.SourceEvent
+	require.NoError(t, json.Unmarshal([]byte(data), &ev))
+	assert.Equal(t, "src1", ev.SourceID)
+	assert.Equal(t, "http://example.com", ev.URL)
+	assert.Equal(t, "completed", ev.Status)
+}
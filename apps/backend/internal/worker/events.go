@@ -18,5 +18,44 @@ type IngestEmbedPayload struct {
 	CreatedAt string `json:"created_at,omitempty"`
 	PageCount int    `json:"page_count,omitempty"`
 
+	// Source Metadata (user-defined, propagated to every chunk)
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// EmbedTitlePrefix prepends "Title: <title>" to the embedded text only
+	EmbedTitlePrefix bool `json:"embed_title_prefix,omitempty"`
+
+	// SkipEmbedding stores the chunk without a vector (keyword search only)
+	SkipEmbedding bool `json:"skip_embedding,omitempty"`
+
+	// Selector is the DOM region the page content came from (HTML only)
+	Selector string `json:"selector,omitempty"`
+
+	// AliasURL is the fetched URL when SourceURL is the page's canonical URL
+	AliasURL string `json:"alias_url,omitempty"`
+
+	// EmbeddingModel overrides the default embedding model for this chunk
+	EmbeddingModel string `json:"embedding_model,omitempty"`
+
+	// AnchorKeywords are the page's link texts, indexed for keyword search only
+	AnchorKeywords []string `json:"anchor_keywords,omitempty"`
+
+	// TitlePath is the page breadcrumb stored on the chunk for display and search
+	TitlePath string `json:"title_path,omitempty"`
+
+	// Heading is the heading path the chunk sits under, embedded as context
+	Heading string `json:"heading,omitempty"`
+
+	// Overlap is the length of the text at the start of Content repeated
+	// from the previous chunk
+	Overlap int `json:"overlap,omitempty"`
+
+	// ChunkID replaces an existing stored chunk instead of adding a new one
+	ChunkID string `json:"chunk_id,omitempty"`
+
+	// PageHash, when set, is the change-detection key recorded for the
+	// fetched PageURL once all of its chunks are stored
+	PageURL  string `json:"page_url,omitempty"`
+	PageHash string `json:"page_hash,omitempty"`
+
 	CorrelationID string `json:"correlation_id"`
 }
@@ -0,0 +1,73 @@
+package worker
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// HostLimiter paces crawl tasks per target host.
+type HostLimiter interface {
+	// Reserve takes the next request slot for host and returns how long the
+	// task must wait for it. When the wait would exceed limit it takes no
+	// slot and returns false.
+	Reserve(host string, limit time.Duration) (time.Duration, bool)
+}
+
+// hostPruneInterval is how often hosts with a full bucket are dropped.
+const hostPruneInterval = time.Minute
+
+// hostRateLimiter is a token bucket per host, refilled at rps tokens a second
+// with room for one request.
+type hostRateLimiter struct {
+	mu       sync.Mutex
+	limit    rate.Limit
+	limiters map[string]*rate.Limiter
+	pruned   time.Time
+	now      func() time.Time
+}
+
+// NewHostRateLimiter allows rps crawl requests per second to each host.
+func NewHostRateLimiter(rps float64) HostLimiter {
+	return &hostRateLimiter{
+		limit:    rate.Limit(rps),
+		limiters: make(map[string]*rate.Limiter),
+		now:      time.Now,
+	}
+}
+
+func (l *hostRateLimiter) Reserve(host string, limit time.Duration) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.prune(now)
+
+	lim, ok := l.limiters[host]
+	if !ok {
+		lim = rate.NewLimiter(l.limit, 1)
+		l.limiters[host] = lim
+	}
+	r := lim.ReserveN(now, 1)
+	delay := r.DelayFrom(now)
+	if delay > limit {
+		r.CancelAt(now)
+		return 0, false
+	}
+	return delay, true
+}
+
+// prune drops hosts whose bucket has refilled. A full bucket behaves like a
+// new one, so nothing is lost.
+func (l *hostRateLimiter) prune(now time.Time) {
+	if now.Sub(l.pruned) < hostPruneInterval {
+		return
+	}
+	l.pruned = now
+	for host, lim := range l.limiters {
+		if lim.TokensAt(now) >= 1 {
+			delete(l.limiters, host)
+		}
+	}
+}
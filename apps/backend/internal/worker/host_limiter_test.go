@@ -0,0 +1,67 @@
+package worker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHostRateLimiter_Reserve(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	l := NewHostRateLimiter(2).(*hostRateLimiter)
+	l.now = func() time.Time { return now }
+
+	reserve := func(host string) time.Duration {
+		d, ok := l.Reserve(host, time.Minute)
+		assert.True(t, ok)
+		return d
+	}
+
+	// The first request to a host goes at once, the rest queue 500ms apart
+	assert.Equal(t, time.Duration(0), reserve("docs.example.com"))
+	assert.Equal(t, 500*time.Millisecond, reserve("docs.example.com"))
+	assert.Equal(t, time.Second, reserve("docs.example.com"))
+
+	// Hosts have separate buckets
+	assert.Equal(t, time.Duration(0), reserve("api.example.com"))
+
+	// Tokens refill as time passes
+	now = now.Add(2 * time.Second)
+	assert.Equal(t, time.Duration(0), reserve("docs.example.com"))
+}
+
+func TestHostRateLimiter_ReserveRefusesPastLimit(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	l := NewHostRateLimiter(1).(*hostRateLimiter)
+	l.now = func() time.Time { return now }
+
+	_, ok := l.Reserve("docs.example.com", time.Second)
+	assert.True(t, ok)
+	d, ok := l.Reserve("docs.example.com", time.Second)
+	assert.True(t, ok)
+	assert.Equal(t, time.Second, d)
+
+	// A refused reservation doesn't push later ones further out
+	_, ok = l.Reserve("docs.example.com", time.Second)
+	assert.False(t, ok)
+	d, ok = l.Reserve("docs.example.com", 5*time.Second)
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Second, d)
+}
+
+func TestHostRateLimiter_PrunesIdleHosts(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	l := NewHostRateLimiter(1).(*hostRateLimiter)
+	l.now = func() time.Time { return now }
+
+	l.Reserve("a.example.com", time.Minute)
+	l.Reserve("b.example.com", time.Minute)
+	assert.Len(t, l.limiters, 2)
+
+	// Once their buckets refill, idle hosts are dropped on the next sweep
+	now = now.Add(2 * hostPruneInterval)
+	l.Reserve("c.example.com", time.Minute)
+	assert.Len(t, l.limiters, 1)
+	assert.Contains(t, l.limiters, "c.example.com")
+}
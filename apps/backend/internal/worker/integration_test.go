@@ -42,6 +42,18 @@ func (f *TestSourceFetcher) GetSourceConfig(ctx context.Context, id string) (int
 	return src.MaxDepth, src.Exclusions, "dummy-api-key", src.Name, nil
 }
 
+func (f *TestSourceFetcher) GetSourceOptions(ctx context.Context, id string) (*worker.SourceOptions, error) {
+	src, err := f.Repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	opts := &worker.SourceOptions{Metadata: src.Metadata, EmbedTitlePrefix: src.EmbedTitlePrefix, CrawlDelay: time.Duration(src.CrawlDelayMs) * time.Millisecond, KeywordOnlyTypes: src.KeywordOnlyTypes, EmbeddingModel: src.EmbeddingModel, CrawlSubdomains: src.CrawlSubdomains, Paused: src.Status == "paused"}
+	if src.RestrictToSeedPath {
+		opts.SeedPathPrefix = worker.SeedPathPrefix(src.URL)
+	}
+	return opts, nil
+}
+
 func (f *TestSourceFetcher) GetSourceDetails(ctx context.Context, id string) (string, string, error) {
 	src, err := f.Repo.Get(ctx, id)
 	if err != nil {
@@ -71,10 +83,30 @@ func (a *PageManagerAdapter) UpdatePageStatus(ctx context.Context, sourceID, url
 	return a.Repo.UpdatePageStatus(ctx, sourceID, url, status, err)
 }
 
+func (a *PageManagerAdapter) RecordPageFetch(ctx context.Context, sourceID, url string, statusCode, fetchMs int) error {
+	return a.Repo.RecordPageFetch(ctx, sourceID, url, statusCode, fetchMs)
+}
+
+func (a *PageManagerAdapter) GetPageHash(ctx context.Context, sourceID, url string) (string, error) {
+	return a.Repo.GetPageHash(ctx, sourceID, url)
+}
+
+func (a *PageManagerAdapter) UpdatePageHash(ctx context.Context, sourceID, url, hash string) error {
+	return a.Repo.UpdatePageHash(ctx, sourceID, url, hash)
+}
+
+func (a *PageManagerAdapter) BeginPageHash(ctx context.Context, sourceID, url, hash string, chunks int) error {
+	return a.Repo.BeginPageHash(ctx, sourceID, url, hash, chunks)
+}
+
 func (a *PageManagerAdapter) CountPendingPages(ctx context.Context, sourceID string) (int, error) {
 	return a.Repo.CountPendingPages(ctx, sourceID)
 }
 
+func (a *PageManagerAdapter) CountFailedPages(ctx context.Context, sourceID string) (int, error) {
+	return a.Repo.CountFailedPages(ctx, sourceID)
+}
+
 func TestIngestIntegration(t *testing.T) {
 	s := testutils.NewIntegrationSuite(t)
 	s.Setup()
@@ -3,33 +3,81 @@ package worker
 import (
 	"net/url"
 	"regexp"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// Link decisions reported by ExplainLinks.
+const (
+	LinkOK        = "ok"
+	LinkInvalid   = "invalid"
+	LinkExternal  = "external"
+	LinkExcluded  = "excluded"
+	LinkDepth     = "depth"
+	LinkDuplicate = "duplicate"
 )
 
-func DiscoverLinks(sourceID, host string, links []string, currentDepth, maxDepth int, exclusions []string) []PageDTO {
+// LinkDecision records whether a discovered link would be enqueued and why.
+type LinkDecision struct {
+	URL    string `json:"url"`
+	Reason string `json:"reason"`
+}
+
+func DiscoverLinks(sourceID, host string, links []string, currentDepth, maxDepth int, exclusions []string, subdomains bool) []PageDTO {
 	if currentDepth >= maxDepth {
 		return nil
 	}
 
 	var newPages []PageDTO
+	for _, d := range ExplainLinks(host, links, currentDepth, maxDepth, exclusions, subdomains) {
+		if d.Reason != LinkOK {
+			continue
+		}
+		newPages = append(newPages, PageDTO{
+			SourceID: sourceID,
+			URL:      d.URL,
+			Status:   "pending",
+			Depth:    currentDepth + 1,
+		})
+	}
+	return newPages
+}
+
+// ExplainLinks applies the DiscoverLinks rules to every link and reports the
+// outcome for each, in input order. Accepted and duplicate links carry their
+// normalized URL; rejected links keep the input as given. With subdomains,
+// links to any host under host's registered domain count as internal.
+func ExplainLinks(host string, links []string, currentDepth, maxDepth int, exclusions []string, subdomains bool) []LinkDecision {
+	decisions := make([]LinkDecision, 0, len(links))
 	seen := make(map[string]bool)
+	internal := internalHost(strings.ToLower(host), subdomains)
 
 	for _, link := range links {
-		// 1. External Check
 		linkU, err := url.Parse(link)
-		if err != nil || linkU.Host != host {
+		if err != nil {
+			decisions = append(decisions, LinkDecision{URL: link, Reason: LinkInvalid})
 			continue
 		}
 
-		// Scheme Check
+		// 1. Scheme Check: only pages are crawlable, never mailto:, tel:,
+		// javascript:, ftp: or data: links, even on a matching host
 		if linkU.Scheme != "http" && linkU.Scheme != "https" {
+			decisions = append(decisions, LinkDecision{URL: link, Reason: LinkInvalid})
 			continue
 		}
 
-		// Normalize: Strip Fragment
-		linkU.Fragment = ""
+		// Normalize before comparing hosts and deduplicating
+		normalizeURL(linkU)
 		normalizedLink := linkU.String()
 
-		// 2. Exclusion Check
+		// 2. External Check
+		if !internal(linkU) {
+			decisions = append(decisions, LinkDecision{URL: link, Reason: LinkExternal})
+			continue
+		}
+
+		// 3. Exclusion Check
 		excluded := false
 		for _, ex := range exclusions {
 			if matched, _ := regexp.MatchString(ex, normalizedLink); matched {
@@ -38,20 +86,101 @@ func DiscoverLinks(sourceID, host string, links []string, currentDepth, maxDepth
 			}
 		}
 		if excluded {
+			decisions = append(decisions, LinkDecision{URL: link, Reason: LinkExcluded})
+			continue
+		}
+
+		// 4. Depth Check
+		if currentDepth >= maxDepth {
+			decisions = append(decisions, LinkDecision{URL: normalizedLink, Reason: LinkDepth})
 			continue
 		}
 
 		if seen[normalizedLink] {
+			decisions = append(decisions, LinkDecision{URL: normalizedLink, Reason: LinkDuplicate})
 			continue
 		}
 		seen[normalizedLink] = true
 
-		newPages = append(newPages, PageDTO{
-			SourceID: sourceID,
-			URL:      normalizedLink,
-			Status:   "pending",
-			Depth:    currentDepth + 1,
-		})
+		decisions = append(decisions, LinkDecision{URL: normalizedLink, Reason: LinkOK})
 	}
-	return newPages
+	return decisions
+}
+
+// NormalizeURL returns raw in the form pages are stored and deduplicated
+// under: lowercase host, no default port, no fragment and no trailing slash
+// on paths other than the root. Anything but an absolute http(s) URL is
+// returned unchanged.
+func NormalizeURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return raw
+	}
+	normalizeURL(u)
+	return u.String()
+}
+
+func normalizeURL(u *url.URL) {
+	u.Host = strings.ToLower(u.Host)
+	if port := u.Port(); (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		u.Host = strings.TrimSuffix(u.Host, ":"+port)
+	}
+	u.Fragment = ""
+	u.RawFragment = ""
+	if len(u.Path) > 1 && strings.HasSuffix(u.Path, "/") {
+		u.Path = strings.TrimSuffix(u.Path, "/")
+		u.RawPath = strings.TrimSuffix(u.RawPath, "/")
+	}
+}
+
+// internalHost returns a check for whether a link stays on the crawled site:
+// the exact host, or with subdomains any host under its registered domain
+// (e.g. api.example.com and www.example.com for docs.example.com). Ports must
+// match either way.
+func internalHost(host string, subdomains bool) func(*url.URL) bool {
+	if !subdomains {
+		return func(u *url.URL) bool { return u.Host == host }
+	}
+
+	seed := &url.URL{Host: host}
+	domain, err := publicsuffix.EffectiveTLDPlusOne(seed.Hostname())
+	if err != nil {
+		// IPs, localhost and bare suffixes have no registered domain
+		domain = seed.Hostname()
+	}
+	return func(u *url.URL) bool {
+		if u.Port() != seed.Port() {
+			return false
+		}
+		h := u.Hostname()
+		return h == domain || strings.HasSuffix(h, "."+domain)
+	}
+}
+
+// SeedPathPrefix returns the directory portion of seedURL's path, e.g.
+// "/docs/product/" for "https://example.com/docs/product/intro". A path that
+// already ends in "/" is returned as is.
+func SeedPathPrefix(seedURL string) string {
+	u, err := url.Parse(seedURL)
+	if err != nil {
+		return ""
+	}
+	p := u.Path
+	if i := strings.LastIndex(p, "/"); i >= 0 {
+		return p[:i+1]
+	}
+	return "/"
+}
+
+// FilterByPathPrefix keeps only pages whose URL path starts with prefix.
+func FilterByPathPrefix(pages []PageDTO, prefix string) []PageDTO {
+	var kept []PageDTO
+	for _, p := range pages {
+		u, err := url.Parse(p.URL)
+		if err != nil || !strings.HasPrefix(u.Path, prefix) {
+			continue
+		}
+		kept = append(kept, p)
+	}
+	return kept
 }
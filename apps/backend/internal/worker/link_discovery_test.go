@@ -12,6 +12,7 @@ func TestDiscoverLinks_Comprehensive(t *testing.T) {
 		currentDepth int
 		maxDepth     int
 		exclusions   []string
+		subdomains   bool
 	}
 	tests := []struct {
 		name string
@@ -59,6 +60,39 @@ func TestDiscoverLinks_Comprehensive(t *testing.T) {
 			},
 			want: nil, // Current logic checks linkU.Host == host
 		},
+		{
+			name: "Subdomains Accepted",
+			args: args{
+				sourceID:   "src1",
+				host:       "docs.example.com",
+				links:      []string{"https://api.example.com/foo", "https://example.com/bar", "https://www.example.com/baz"},
+				maxDepth:   5,
+				subdomains: true,
+			},
+			want: []string{"https://api.example.com/foo", "https://example.com/bar", "https://www.example.com/baz"},
+		},
+		{
+			name: "Subdomains Reject Unrelated Domains",
+			args: args{
+				sourceID:   "src1",
+				host:       "example.com",
+				links:      []string{"https://notexample.com/foo", "https://example.com.evil.net/bar", "https://other.github.io/baz"},
+				maxDepth:   5,
+				subdomains: true,
+			},
+			want: nil,
+		},
+		{
+			name: "Subdomains Stop At Public Suffix",
+			args: args{
+				sourceID:   "src1",
+				host:       "mydocs.github.io",
+				links:      []string{"https://api.mydocs.github.io/foo", "https://someone-else.github.io/bar"},
+				maxDepth:   5,
+				subdomains: true,
+			},
+			want: []string{"https://api.mydocs.github.io/foo"},
+		},
 		{
 			name: "Fragment Stripping",
 			args: args{
@@ -109,11 +143,12 @@ func TestDiscoverLinks_Comprehensive(t *testing.T) {
 					"mailto:user@example.com",
 					"tel:1234567890",
 					"javascript:alert(1)",
-					"ftp://example.com/file", // Host matches, but logic doesn't filter scheme! Wait, let's verify logic.
+					"ftp://example.com/file", // Host matches; rejected for its scheme
+					"data:text/html,<p>hi</p>",
 				},
 				maxDepth: 5,
 			},
-			want: nil, // If logic only checks Host, FTP might pass if host matches!
+			want: nil,
 		},
 		{
 			name: "Malformed URLs",
@@ -155,6 +190,36 @@ func TestDiscoverLinks_Comprehensive(t *testing.T) {
 			},
 			want: nil, // "example.com:8080" != "example.com"
 		},
+		{
+			name: "Trailing Slash Equivalence",
+			args: args{
+				sourceID: "src1",
+				host:     "example.com",
+				links:    []string{"https://example.com/foo", "https://example.com/foo/", "https://example.com/"},
+				maxDepth: 5,
+			},
+			want: []string{"https://example.com/foo", "https://example.com/"}, // Root keeps its slash
+		},
+		{
+			name: "Uppercase Host Equivalence",
+			args: args{
+				sourceID: "src1",
+				host:     "Example.com",
+				links:    []string{"https://EXAMPLE.COM/Docs", "https://example.com/Docs"},
+				maxDepth: 5,
+			},
+			want: []string{"https://example.com/Docs"}, // Path case is significant
+		},
+		{
+			name: "Default Ports Dropped",
+			args: args{
+				sourceID: "src1",
+				host:     "example.com",
+				links:    []string{"https://example.com:443/a", "https://example.com/a", "http://example.com:80/b", "http://example.com:443/c"},
+				maxDepth: 5,
+			},
+			want: []string{"https://example.com/a", "http://example.com/b"}, // :443 is not default for http
+		},
 		{
 			name: "Escaped Spaces",
 			args: args{
@@ -176,6 +241,7 @@ func TestDiscoverLinks_Comprehensive(t *testing.T) {
 				tt.args.currentDepth,
 				tt.args.maxDepth,
 				tt.args.exclusions,
+				tt.args.subdomains,
 			)
 
 			if len(got) != len(tt.want) {
@@ -202,3 +268,97 @@ func TestDiscoverLinks_Comprehensive(t *testing.T) {
 		})
 	}
 }
+
+func TestSeedPathPrefix(t *testing.T) {
+	tests := map[string]string{
+		"https://example.com/docs/product/intro":  "/docs/product/",
+		"https://example.com/docs/product/":       "/docs/product/",
+		"https://example.com/docs/product/a.html": "/docs/product/",
+		"https://example.com":                     "/",
+	}
+	for seed, want := range tests {
+		if got := SeedPathPrefix(seed); got != want {
+			t.Errorf("SeedPathPrefix(%q) = %q, want %q", seed, got, want)
+		}
+	}
+}
+
+func TestFilterByPathPrefix(t *testing.T) {
+	pages := DiscoverLinks("src1", "example.com", []string{
+		"https://example.com/docs/product/setup",
+		"https://example.com/docs/product/api/auth",
+		"https://example.com/docs/pricing",
+		"https://example.com/docs/productivity",
+	}, 0, 5, nil, false)
+
+	got := FilterByPathPrefix(pages, "/docs/product/")
+	if len(got) != 2 {
+		t.Fatalf("FilterByPathPrefix() got %d items, want 2. Got: %+v", len(got), got)
+	}
+	if got[0].URL != "https://example.com/docs/product/setup" || got[1].URL != "https://example.com/docs/product/api/auth" {
+		t.Errorf("FilterByPathPrefix() = %+v", got)
+	}
+}
+
+func TestExplainLinks_Reasons(t *testing.T) {
+	got := ExplainLinks("example.com", []string{
+		"https://example.com/a#top",
+		"https://example.com/a",
+		"https://other.com/a",
+		"https://example.com/private/x",
+		"ftp://example.com/file",
+	}, 0, 1, []string{"/private/"}, false)
+
+	want := []LinkDecision{
+		{URL: "https://example.com/a", Reason: LinkOK},
+		{URL: "https://example.com/a", Reason: LinkDuplicate},
+		{URL: "https://other.com/a", Reason: LinkExternal},
+		{URL: "https://example.com/private/x", Reason: LinkExcluded},
+		{URL: "ftp://example.com/file", Reason: LinkInvalid},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("ExplainLinks() got %d items, want %d. Got: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("ExplainLinks()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestExplainLinks_NonHTTPSchemesInvalid(t *testing.T) {
+	links := []string{
+		"ftp://example.com/file",
+		"mailto:docs@example.com",
+		"tel:+15550100",
+		"javascript:void(0)",
+		"data:text/plain,hello",
+		"HTTPS://example.com/upper",
+	}
+	got := ExplainLinks("example.com", links, 0, 5, nil, true)
+
+	// ftp://example.com shares the seed host but is still rejected
+	for i, d := range got[:5] {
+		if d.Reason != LinkInvalid {
+			t.Errorf("ExplainLinks(%q) reason = %q, want %q", links[i], d.Reason, LinkInvalid)
+		}
+	}
+	if got[5].Reason != LinkOK {
+		t.Errorf("ExplainLinks(%q) reason = %q, want %q", links[5], got[5].Reason, LinkOK)
+	}
+}
+
+func TestNormalizeURL(t *testing.T) {
+	tests := map[string]string{
+		"HTTPS://Docs.Example.COM:443/Guide/#intro": "https://docs.example.com/Guide",
+		"http://example.com:8080/a/":                "http://example.com:8080/a",
+		"https://example.com/":                      "https://example.com/",
+		"https://example.com/foo%20bar/":            "https://example.com/foo%20bar",
+		"/relative/path/":                           "/relative/path/",
+	}
+	for raw, want := range tests {
+		if got := NormalizeURL(raw); got != want {
+			t.Errorf("NormalizeURL(%q) = %q, want %q", raw, got, want)
+		}
+	}
+}
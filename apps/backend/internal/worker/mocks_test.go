@@ -2,6 +2,7 @@ package worker_test
 
 import (
 	"context"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 	"qurio/apps/backend/features/job"
@@ -20,6 +21,14 @@ func (m *MockEmbedder) Embed(ctx context.Context, text string) ([]float32, error
 	return args.Get(0).([]float32), args.Error(1)
 }
 
+func (m *MockEmbedder) EmbedWithModel(ctx context.Context, model, text string) ([]float32, error) {
+	args := m.Called(ctx, model, text)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]float32), args.Error(1)
+}
+
 type MockVectorStore struct{ mock.Mock }
 
 func (m *MockVectorStore) StoreChunk(ctx context.Context, chunk worker.Chunk) error {
@@ -44,16 +53,32 @@ func (m *MockUpdater) UpdateBodyHash(ctx context.Context, id, hash string) error
 	return args.Error(0)
 }
 
-type MockJobRepo struct{ mock.Mock }
+type MockJobRepo struct {
+	mock.Mock
+	resolved []string
+}
 
 func (m *MockJobRepo) Save(ctx context.Context, j *job.Job) error {
 	args := m.Called(ctx, j)
 	return args.Error(0)
 }
-func (m *MockJobRepo) List(ctx context.Context) ([]job.Job, error)          { return nil, nil }
+func (m *MockJobRepo) List(ctx context.Context, f job.ListFilter) ([]job.Job, error) { return nil, nil }
+func (m *MockJobRepo) CountMatching(ctx context.Context, f job.ListFilter) (int, error) {
+	return 0, nil
+}
 func (m *MockJobRepo) Get(ctx context.Context, id string) (*job.Job, error) { return nil, nil }
 func (m *MockJobRepo) Delete(ctx context.Context, id string) error          { return nil }
 func (m *MockJobRepo) Count(ctx context.Context) (int, error)               { return 0, nil }
+func (m *MockJobRepo) Resolve(ctx context.Context, sourceID, url string) error {
+	m.resolved = append(m.resolved, url)
+	return nil
+}
+func (m *MockJobRepo) ClaimDue(ctx context.Context, now time.Time, limit int) ([]job.Job, error) {
+	return nil, nil
+}
+func (m *MockJobRepo) ScheduleRetry(ctx context.Context, id string, at time.Time) error { return nil }
+func (m *MockJobRepo) MarkRetried(ctx context.Context, id string) error                 { return nil }
+func (m *MockJobRepo) MarkPermanentlyFailed(ctx context.Context, id string) error       { return nil }
 
 type MockSourceFetcher struct{ mock.Mock }
 
@@ -62,6 +87,14 @@ func (m *MockSourceFetcher) GetSourceConfig(ctx context.Context, id string) (int
 	return args.Int(0), args.Get(1).([]string), args.String(2), args.String(3), args.Error(4)
 }
 
+func (m *MockSourceFetcher) GetSourceOptions(ctx context.Context, id string) (*worker.SourceOptions, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*worker.SourceOptions), args.Error(1)
+}
+
 func (m *MockSourceFetcher) GetSourceDetails(ctx context.Context, id string) (string, string, error) {
 	args := m.Called(ctx, id)
 	return args.String(0), args.String(1), args.Error(2)
@@ -82,11 +115,36 @@ func (m *MockPageManager) UpdatePageStatus(ctx context.Context, sourceID, url, s
 	return args.Error(0)
 }
 
+func (m *MockPageManager) RecordPageFetch(ctx context.Context, sourceID, url string, statusCode, fetchMs int) error {
+	args := m.Called(ctx, sourceID, url, statusCode, fetchMs)
+	return args.Error(0)
+}
+
+func (m *MockPageManager) GetPageHash(ctx context.Context, sourceID, url string) (string, error) {
+	args := m.Called(ctx, sourceID, url)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockPageManager) UpdatePageHash(ctx context.Context, sourceID, url, hash string) error {
+	args := m.Called(ctx, sourceID, url, hash)
+	return args.Error(0)
+}
+
+func (m *MockPageManager) BeginPageHash(ctx context.Context, sourceID, url, hash string, chunks int) error {
+	args := m.Called(ctx, sourceID, url, hash, chunks)
+	return args.Error(0)
+}
+
 func (m *MockPageManager) CountPendingPages(ctx context.Context, sourceID string) (int, error) {
 	args := m.Called(ctx, sourceID)
 	return args.Int(0), args.Error(1)
 }
 
+func (m *MockPageManager) CountFailedPages(ctx context.Context, sourceID string) (int, error) {
+	args := m.Called(ctx, sourceID)
+	return args.Int(0), args.Error(1)
+}
+
 type MockTaskPublisher struct{ mock.Mock }
 
 func (m *MockTaskPublisher) Publish(topic string, body []byte) error {
@@ -4,12 +4,20 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/nsqio/go-nsq"
+	"github.com/weaviate/weaviate-go-client/v5/weaviate/fault"
 	"qurio/apps/backend/features/job"
 	"qurio/apps/backend/internal/config"
 	"qurio/apps/backend/internal/middleware"
@@ -26,7 +34,12 @@ type PageDTO struct {
 type PageManager interface {
 	BulkCreatePages(ctx context.Context, pages []PageDTO) ([]string, error)
 	UpdatePageStatus(ctx context.Context, sourceID, url, status, err string) error
+	RecordPageFetch(ctx context.Context, sourceID, url string, statusCode, fetchMs int) error
+	GetPageHash(ctx context.Context, sourceID, url string) (string, error)
+	UpdatePageHash(ctx context.Context, sourceID, url, hash string) error
+	BeginPageHash(ctx context.Context, sourceID, url, hash string, chunks int) error
 	CountPendingPages(ctx context.Context, sourceID string) (int, error)
+	CountFailedPages(ctx context.Context, sourceID string) (int, error)
 }
 
 type TaskPublisher interface {
@@ -40,6 +53,26 @@ type ResultConsumer struct {
 	sourceFetcher SourceFetcher
 	pageManager   PageManager
 	publisher     TaskPublisher
+	limiter       *SourceLimiter
+	pruneGone     bool
+	canonical     bool
+	events        *EventBus
+	pacer         *crawlPacer
+	hostLimiter   HostLimiter
+	minSplit      int
+	splitLists    bool
+	mergeCode     bool
+	markPartial   bool
+	dedup         *enqueueDedup
+	robots        RobotsChecker
+	normalizeHash bool
+	skipUnchanged bool
+	hashPatterns  []*regexp.Regexp
+	anchors       bool
+	titlePath     bool
+	fallbackTitle bool
+	maxAttempts   int
+	deadLetter    deadLetterPolicy
 }
 
 func NewResultConsumer(s VectorStore, u SourceStatusUpdater, j job.Repository, sf SourceFetcher, pm PageManager, tp TaskPublisher) *ResultConsumer {
@@ -50,10 +83,312 @@ func NewResultConsumer(s VectorStore, u SourceStatusUpdater, j job.Repository, s
 		sourceFetcher: sf,
 		pageManager:   pm,
 		publisher:     tp,
+		pruneGone:     true,
+		canonical:     true,
+		pacer:         newCrawlPacer(),
+		deadLetter:    deadLetterPolicy{publisher: tp},
+		hashPatterns:  text.DefaultVolatilePatterns,
 	}
 }
 
+// SetSourceLimiter bounds how many sources this consumer processes concurrently.
+func (h *ResultConsumer) SetSourceLimiter(l *SourceLimiter) {
+	h.limiter = l
+}
+
+// SetPruneGonePages controls whether pages that come back 404/410 have their
+// chunks removed. When disabled they are treated like any other failure.
+func (h *ResultConsumer) SetPruneGonePages(enabled bool) {
+	h.pruneGone = enabled
+}
+
+// SetPreferCanonical controls whether pages that declare a different
+// same-host canonical URL are indexed under it instead of the fetched URL.
+func (h *ResultConsumer) SetPreferCanonical(enabled bool) {
+	h.canonical = enabled
+}
+
+// SetMergeAdjacentCode combines runs of small same-language code chunks into
+// one chunk, up to the chunk size limit.
+func (h *ResultConsumer) SetMergeAdjacentCode(enabled bool) {
+	h.mergeCode = enabled
+}
+
+// SetAnchorKeywords controls whether descriptive link texts from a page are
+// attached to all of its chunks as keyword-search terms. Nav lists are still
+// dropped as noise chunks; only their anchor texts are kept.
+func (h *ResultConsumer) SetAnchorKeywords(enabled bool) {
+	h.anchors = enabled
+}
+
+// SetTitlePath controls whether chunks carry a breadcrumb title path derived
+// from the page URL.
+func (h *ResultConsumer) SetTitlePath(enabled bool) {
+	h.titlePath = enabled
+}
+
+// SetFallbackTitle controls whether pages without a title of their own are
+// titled after the last segment of their URL.
+func (h *ResultConsumer) SetFallbackTitle(enabled bool) {
+	h.fallbackTitle = enabled
+}
+
+// SetMaxResultAttempts caps how many times a result message is redelivered
+// for a transient store error before it is recorded as a failed job instead.
+// Zero retries transient errors indefinitely.
+func (h *ResultConsumer) SetMaxResultAttempts(n int) {
+	h.maxAttempts = n
+}
+
+// SetMaxMessageAttempts dead-letters a result message that still fails on its
+// nth attempt instead of handing it back to NSQ. Zero requeues indefinitely.
+func (h *ResultConsumer) SetMaxMessageAttempts(n int) {
+	h.deadLetter.maxAttempts = n
+}
+
+// SetMarkPartialFailures controls whether a source whose pages all finished
+// but where at least one failed ends as "completed_with_errors" rather than
+// "completed".
+func (h *ResultConsumer) SetMarkPartialFailures(enabled bool) {
+	h.markPartial = enabled
+}
+
+// SetEnqueueDedupWindow skips creating and publishing a discovered URL that
+// was already discovered for the same source within window. Zero disables it.
+func (h *ResultConsumer) SetEnqueueDedupWindow(window time.Duration) {
+	if window <= 0 {
+		h.dedup = nil
+		return
+	}
+	h.dedup = newEnqueueDedup(window)
+}
+
+// SetHostLimiter defers crawl tasks for hosts that are over their request
+// rate instead of publishing them at once. Nil leaves hosts unlimited.
+func (h *ResultConsumer) SetHostLimiter(l HostLimiter) {
+	h.hostLimiter = l
+}
+
+// SetRobotsChecker makes link discovery skip URLs the site's robots.txt
+// disallows. Nil follows every discovered link.
+func (h *ResultConsumer) SetRobotsChecker(r RobotsChecker) {
+	h.robots = r
+}
+
+// SetNormalizeHash makes the change-detection body hash ignore whitespace
+// differences and volatile content such as "last updated" lines. Chunks are
+// still embedded from the original content.
+func (h *ResultConsumer) SetNormalizeHash(enabled bool) {
+	h.normalizeHash = enabled
+}
+
+// SetSkipUnchangedPages keeps the existing chunks of a page whose body hash
+// and chunking and model settings match those recorded when all of its chunks
+// were last stored, instead of deleting and re-embedding them.
+func (h *ResultConsumer) SetSkipUnchangedPages(enabled bool) {
+	h.skipUnchanged = enabled
+}
+
+// SetHashVolatilePatterns replaces the patterns stripped before hashing when
+// SetNormalizeHash is enabled. Defaults to text.DefaultVolatilePatterns.
+func (h *ResultConsumer) SetHashVolatilePatterns(patterns []*regexp.Regexp) {
+	h.hashPatterns = patterns
+}
+
+// Chunk size and overlap, in estimated tokens, pages are split with.
+const (
+	chunkTokens  = 512
+	chunkOverlap = 50
+)
+
+// bodyHash returns the change-detection hash of page content.
+func (h *ResultConsumer) bodyHash(content string) string {
+	if h.normalizeHash {
+		content = text.NormalizeForHash(content, h.hashPatterns)
+	}
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(content)))
+}
+
+// pageKey combines a page's body hash with the settings that shape its
+// chunks and vectors, so changing any of them re-embeds unchanged pages.
+func (h *ResultConsumer) pageKey(bodyHash string, opts *SourceOptions) string {
+	key := fmt.Sprintf("%s|chunks=%d/%d/%d|lists=%t|code=%t|model=%s|title=%t|keyword=%s",
+		bodyHash, chunkTokens, chunkOverlap, h.minSplit, !h.splitLists, h.mergeCode,
+		opts.EmbeddingModel, opts.EmbedTitlePrefix, strings.Join(opts.KeywordOnlyTypes, ","))
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(key)))
+}
+
+// SetMinPageTokensToSplit keeps pages smaller than n estimated tokens as a
+// single chunk. Zero always splits.
+func (h *ResultConsumer) SetMinPageTokensToSplit(n int) {
+	h.minSplit = n
+}
+
+// SetKeepLists controls whether chunking keeps ordered and unordered lists
+// whole, splitting oversized ones only between items. Enabled by default.
+func (h *ResultConsumer) SetKeepLists(enabled bool) {
+	h.splitLists = !enabled
+}
+
+// SetEventBus makes the consumer publish page and source status transitions.
+func (h *ResultConsumer) SetEventBus(b *EventBus) {
+	h.events = b
+}
+
+func (h *ResultConsumer) emit(ev SourceEvent) {
+	if h.events != nil {
+		h.events.Publish(ev)
+	}
+}
+
+// holdUntil hands m back to be delivered again after wait without counting
+// as a failure. A deferring publisher publishes its body anew, so the wait
+// does not use up one of m's attempts; otherwise m is requeued.
+func (h *ResultConsumer) holdUntil(ctx context.Context, m *nsq.Message, wait time.Duration) {
+	if dp, ok := h.publisher.(DeferredPublisher); ok {
+		err := dp.DeferredPublish(config.TopicIngestResult, wait, m.Body)
+		if err == nil {
+			return
+		}
+		slog.WarnContext(ctx, "failed to re-enqueue held result, requeueing", "error", err)
+	}
+	m.RequeueWithoutBackoff(wait)
+}
+
+// publishWebTask enqueues a crawl task, deferring it when the source has a
+// crawl delay or the page's host is over its request rate, so the origin is
+// hit no faster than either allows. A task that would have to wait longer
+// than MaxDeferral is not published; it returns false and the page is left
+// for the source service to release later.
+func (h *ResultConsumer) publishWebTask(sourceID, pageURL string, crawlDelay time.Duration, body []byte) (bool, error) {
+	dp, ok := h.publisher.(DeferredPublisher)
+	if !ok {
+		return true, h.publisher.Publish(config.TopicIngestWeb, body)
+	}
+	var delay time.Duration
+	if crawlDelay > 0 {
+		wait, ok := h.pacer.Delay(sourceID, crawlDelay)
+		if !ok {
+			return false, nil
+		}
+		delay = wait
+	}
+	if h.hostLimiter != nil {
+		if u, err := url.Parse(pageURL); err == nil {
+			wait, ok := h.hostLimiter.Reserve(u.Host, MaxDeferral)
+			if !ok {
+				return false, nil
+			}
+			delay = max(delay, wait)
+		}
+	}
+	if delay <= 0 {
+		return true, h.publisher.Publish(config.TopicIngestWeb, body)
+	}
+	return true, dp.DeferredPublish(config.TopicIngestWeb, delay, body)
+}
+
+// isGone reports whether a result means the page was deleted upstream, as
+// opposed to a transient error that is worth retrying. A 404 or 410 may come
+// back as a failure or as a successful crawl of the error page.
+func isGone(status string, statusCode int) bool {
+	return status == "gone" || statusCode == 404 || statusCode == 410
+}
+
+// isTransient reports whether a store error may succeed on redelivery.
+// Rejections by Weaviate (4xx other than 408/429) and Postgres data or
+// constraint errors are permanent; anything else, including network errors
+// and 5xx responses, is assumed transient.
+func isTransient(err error) bool {
+	var wErr *fault.WeaviateClientError
+	if errors.As(err, &wErr) && wErr.IsUnexpectedStatusCode {
+		switch {
+		case wErr.StatusCode == http.StatusRequestTimeout, wErr.StatusCode == http.StatusTooManyRequests:
+			return true
+		case wErr.StatusCode >= 400 && wErr.StatusCode < 500:
+			return false
+		}
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code.Class() {
+		case "22", "23", "42": // data exception, integrity violation, syntax/access rule
+			return false
+		}
+	}
+	return true
+}
+
+// storeFailed decides what happens to a result message whose store step
+// failed. Transient errors are returned so NSQ redelivers the message.
+// Permanent errors, and transient ones past the attempt cap, are acked: the
+// page is marked failed and a job is recorded so the page can be retried by
+// hand, instead of re-running chunking and link discovery in a loop.
+func (h *ResultConsumer) storeFailed(ctx context.Context, m *nsq.Message, f failedResult, err error) error {
+	if isTransient(err) && (h.maxAttempts <= 0 || int(m.Attempts) < h.maxAttempts) {
+		return err
+	}
+
+	slog.ErrorContext(ctx, "giving up on result", "source_id", f.SourceID, "url", f.URL, "attempts", m.Attempts, "error", err)
+
+	if err := h.pageManager.UpdatePageStatus(ctx, f.SourceID, f.URL, "failed", err.Error()); err != nil {
+		slog.WarnContext(ctx, "failed to update page status", "error", err)
+	}
+	h.emit(SourceEvent{SourceID: f.SourceID, Type: EventPageStatus, URL: f.URL, Status: "failed", Error: err.Error()})
+
+	if h.jobRepo != nil {
+		// Replaying the crawl task re-fetches the page and produces a fresh result
+		taskPayload := f.OriginalPayload
+		if taskPayload == nil {
+			taskPayload, _ = json.Marshal(map[string]interface{}{
+				"type":  "web",
+				"url":   f.URL,
+				"id":    f.SourceID,
+				"depth": f.Depth,
+			})
+		}
+		failedJob := &job.Job{
+			SourceID: f.SourceID,
+			URL:      f.URL,
+			Handler:  "result-consumer",
+			Payload:  taskPayload,
+			Error:    err.Error(),
+		}
+		if err := h.jobRepo.Save(ctx, failedJob); err != nil {
+			slog.ErrorContext(ctx, "failed to save failed job", "error", err)
+		} else {
+			slog.InfoContext(ctx, "saved failed job for retry", "job_id", failedJob.ID)
+		}
+	}
+
+	h.checkSourceCompletion(ctx, f.SourceID)
+	return nil
+}
+
+// resolveJob drops the failed job recorded for a page that has now
+// succeeded, so it is not retried again.
+func (h *ResultConsumer) resolveJob(ctx context.Context, sourceID, pageURL string) {
+	if h.jobRepo == nil {
+		return
+	}
+	if err := h.jobRepo.Resolve(ctx, sourceID, pageURL); err != nil {
+		slog.WarnContext(ctx, "failed to resolve failed job", "error", err, "url", pageURL)
+	}
+}
+
+// failedResult identifies the page a failed result message was for.
+type failedResult struct {
+	SourceID        string
+	URL             string
+	Depth           int
+	OriginalPayload json.RawMessage
+}
+
 func (h *ResultConsumer) HandleMessage(m *nsq.Message) error {
+	return h.deadLetter.handle(config.TopicIngestResult, m, h.handleMessage(m))
+}
+
+func (h *ResultConsumer) handleMessage(m *nsq.Message) error {
 	if len(m.Body) == 0 {
 		return nil
 	}
@@ -64,13 +399,18 @@ func (h *ResultConsumer) HandleMessage(m *nsq.Message) error {
 		Title           string                 `json:"title"`
 		Path            string                 `json:"path"`
 		URL             string                 `json:"url"`
-		Status          string                 `json:"status,omitempty"` // "success" or "failed"
+		Status          string                 `json:"status,omitempty"` // "success", "failed" or "gone"
+		StatusCode      int                    `json:"status_code,omitempty"`
 		Error           string                 `json:"error,omitempty"`
 		Links           []string               `json:"links,omitempty"`
 		Depth           int                    `json:"depth"`
 		CorrelationID   string                 `json:"correlation_id,omitempty"`
 		OriginalPayload json.RawMessage        `json:"original_payload,omitempty"`
 		Metadata        map[string]interface{} `json:"metadata,omitempty"`
+		Selector        string                 `json:"selector,omitempty"`
+		XPath           string                 `json:"xpath,omitempty"`
+		CanonicalURL    string                 `json:"canonical_url,omitempty"`
+		FetchMs         int                    `json:"fetch_ms,omitempty"`
 	}
 
 	err := json.Unmarshal(m.Body, &payload)
@@ -85,6 +425,7 @@ func (h *ResultConsumer) HandleMessage(m *nsq.Message) error {
 
 	if err != nil {
 		slog.ErrorContext(ctx, "invalid message format", "error", err)
+		publishPoison(h.publisher, config.TopicIngestResult, m, err)
 		return nil // Don't retry invalid messages
 	}
 
@@ -93,13 +434,55 @@ func (h *ResultConsumer) HandleMessage(m *nsq.Message) error {
 		return nil
 	}
 
+	failed := failedResult{SourceID: payload.SourceID, URL: payload.URL, Depth: payload.Depth, OriginalPayload: payload.OriginalPayload}
+
+	if h.limiter != nil {
+		if !h.limiter.TryAcquire(payload.SourceID) {
+			slog.DebugContext(ctx, "no free source slot, requeueing", "source_id", payload.SourceID, "wait", sourceSlotRetry)
+			h.holdUntil(ctx, m, sourceSlotRetry)
+			return nil
+		}
+		defer h.limiter.Release(payload.SourceID)
+	}
+
+	if payload.StatusCode > 0 || payload.FetchMs > 0 {
+		if err := h.pageManager.RecordPageFetch(ctx, payload.SourceID, payload.URL, payload.StatusCode, payload.FetchMs); err != nil {
+			slog.WarnContext(ctx, "failed to record page fetch stats", "error", err)
+		}
+	}
+
+	// Handle Gone (deleted upstream)
+	if h.pruneGone && isGone(payload.Status, payload.StatusCode) {
+		slog.InfoContext(ctx, "page gone upstream, removing chunks", "source_id", payload.SourceID, "url", payload.URL, "status_code", payload.StatusCode)
+
+		if err := h.store.DeleteChunksByURL(ctx, payload.SourceID, payload.URL); err != nil {
+			slog.ErrorContext(ctx, "failed to delete chunks of gone page", "error", err)
+			return h.storeFailed(ctx, m, failed, err)
+		}
+		if h.skipUnchanged {
+			// The page must be re-embedded if it comes back
+			if err := h.pageManager.UpdatePageHash(ctx, payload.SourceID, payload.URL, ""); err != nil {
+				slog.WarnContext(ctx, "failed to clear page hash", "error", err)
+			}
+		}
+		if err := h.pageManager.UpdatePageStatus(ctx, payload.SourceID, payload.URL, "removed", ""); err != nil {
+			slog.WarnContext(ctx, "failed to update page status", "error", err)
+		}
+		h.emit(SourceEvent{SourceID: payload.SourceID, Type: EventPageStatus, URL: payload.URL, Status: "removed"})
+		h.resolveJob(ctx, payload.SourceID, payload.URL)
+
+		h.checkSourceCompletion(ctx, payload.SourceID)
+		return nil
+	}
+
 	// Handle Failure
-	if payload.Status == "failed" {
+	if payload.Status == "failed" || payload.Status == "gone" {
 		slog.ErrorContext(ctx, "ingestion failed", "source_id", payload.SourceID, "url", payload.URL, "error", payload.Error)
 
 		// Update Page Status
 		if payload.URL != "" {
 			_ = h.pageManager.UpdatePageStatus(ctx, payload.SourceID, payload.URL, "failed", payload.Error)
+			h.emit(SourceEvent{SourceID: payload.SourceID, Type: EventPageStatus, URL: payload.URL, Status: "failed", Error: payload.Error})
 		}
 
 		// Check if we should fail the source (maybe not? individual page failure shouldn't fail source?)
@@ -109,12 +492,17 @@ func (h *ResultConsumer) HandleMessage(m *nsq.Message) error {
 			if err := h.updater.UpdateStatus(ctx, payload.SourceID, "failed"); err != nil {
 				slog.WarnContext(ctx, "failed to update source status to failed", "error", err)
 			}
+			h.emit(SourceEvent{SourceID: payload.SourceID, Type: EventSourceStatus, Status: "failed", Error: payload.Error})
+		} else if payload.URL != "" {
+			// A failed sub-page may be the last one outstanding.
+			h.checkSourceCompletion(ctx, payload.SourceID)
 		}
 
 		// Save Failed Job
 		if payload.OriginalPayload != nil {
 			failedJob := &job.Job{
 				SourceID: payload.SourceID,
+				URL:      payload.URL,
 				Handler:  "ingestion-worker", // Identify where it failed
 				Payload:  payload.OriginalPayload,
 				Error:    payload.Error,
@@ -138,34 +526,122 @@ func (h *ResultConsumer) HandleMessage(m *nsq.Message) error {
 		slog.WarnContext(ctx, "failed to fetch source config", "error", err)
 	}
 
+	opts, err := h.sourceFetcher.GetSourceOptions(ctx, payload.SourceID)
+	if err != nil || opts == nil {
+		if err != nil {
+			slog.WarnContext(ctx, "failed to fetch source options", "error", err)
+		}
+		opts = &SourceOptions{}
+	}
+
+	// Index under the canonical URL so aliases of a page don't compete in
+	// search; the fetched URL is kept on each chunk as an alias.
+	indexURL, aliasURL := payload.URL, ""
+	if h.canonical && isSameHostCanonical(payload.URL, payload.CanonicalURL) {
+		indexURL, aliasURL = payload.CanonicalURL, payload.URL
+		slog.InfoContext(ctx, "indexing under canonical url", "url", payload.URL, "canonical_url", indexURL)
+	}
+
+	// Skip re-embedding when the page and the settings it is indexed with are
+	// unchanged since it was last indexed
+	pageHash := h.bodyHash(payload.Content)
+	pageKey := h.pageKey(pageHash, opts)
+	unchanged := false
+	if h.skipUnchanged {
+		stored, err := h.pageManager.GetPageHash(ctx, payload.SourceID, payload.URL)
+		if err != nil {
+			slog.WarnContext(ctx, "failed to fetch page hash", "error", err)
+		}
+		if stored != "" && stored == pageKey {
+			unchanged = true
+			slog.InfoContext(ctx, "page unchanged, keeping existing chunks", "source_id", payload.SourceID, "url", payload.URL)
+		}
+	}
+
 	// 1. Delete Old Chunks (Idempotency)
-	if payload.URL != "" {
-		if err := h.store.DeleteChunksByURL(ctx, payload.SourceID, payload.URL); err != nil {
+	if payload.URL != "" && !unchanged {
+		if err := h.store.DeleteChunksByURL(ctx, payload.SourceID, indexURL); err != nil {
 			slog.ErrorContext(ctx, "failed to delete old chunks", "error", err)
-			return err
+			return h.storeFailed(ctx, m, failed, err)
+		}
+		// Drop chunks an earlier crawl stored under the alias
+		if aliasURL != "" {
+			if err := h.store.DeleteChunksByURL(ctx, payload.SourceID, aliasURL); err != nil {
+				slog.ErrorContext(ctx, "failed to delete old chunks", "error", err)
+				return h.storeFailed(ctx, m, failed, err)
+			}
 		}
 	}
 
+	// Crawlers report either a CSS selector or an XPath for the extracted region
+	selector := payload.Selector
+	if selector == "" {
+		selector = payload.XPath
+	}
+
 	// 2. Chunk and Publish
-	if payload.Content != "" {
-		chunks := text.ChunkMarkdown(payload.Content, 512, 50)
+	pendingChunks := false
+	if payload.Content != "" && !unchanged {
+		chunks := text.ChunkDocumentWithOptions(payload.Content, chunkTokens, chunkOverlap, h.minSplit, text.ChunkOptions{SplitLists: h.splitLists})
+		if h.mergeCode {
+			chunks = text.MergeAdjacentCode(chunks, chunkTokens)
+		}
+		var anchorKeywords []string
+		if h.anchors {
+			anchorKeywords = text.ExtractAnchorKeywords(payload.Content)
+		}
+		titlePath := ""
+		if h.titlePath {
+			titlePath = text.TitlePath(indexURL)
+		}
+		title := payload.Title
+		if title == "" && h.fallbackTitle {
+			title = text.TitleFromURL(indexURL)
+		}
+		// The key is only recorded once the embedder has stored every chunk,
+		// so a page missing chunks is re-embedded on the next crawl
+		if len(chunks) > 0 {
+			pendingChunks = true
+		}
+		trackKey := ""
+		if h.skipUnchanged && len(chunks) > 0 {
+			if err := h.pageManager.BeginPageHash(ctx, payload.SourceID, payload.URL, pageKey, len(chunks)); err != nil {
+				slog.WarnContext(ctx, "failed to reset page hash", "error", err)
+			} else {
+				trackKey = pageKey
+			}
+		}
 		if len(chunks) > 0 {
 			for i, c := range chunks {
 				// Construct IngestEmbedPayload
 				embedPayload := IngestEmbedPayload{
 					SourceID:   payload.SourceID,
-					SourceURL:  payload.URL,
+					SourceURL:  indexURL,
+					AliasURL:   aliasURL,
 					SourceName: sourceName,
-					Title:      payload.Title,
+					Title:      title,
 					Path:       payload.Path,
 
-					Content:    c.Content,
-					ChunkIndex: i,
-					ChunkType:  string(c.Type),
-					Language:   c.Language,
+					Content:          c.Content,
+					ChunkIndex:       i,
+					ChunkType:        string(c.Type),
+					Language:         c.Language,
+					Metadata:         opts.Metadata,
+					EmbedTitlePrefix: opts.EmbedTitlePrefix,
+					SkipEmbedding:    slices.Contains(opts.KeywordOnlyTypes, string(c.Type)),
+					Selector:         selector,
+					EmbeddingModel:   opts.EmbeddingModel,
+					AnchorKeywords:   anchorKeywords,
+					TitlePath:        titlePath,
+					Heading:          c.Heading,
+					Overlap:          c.Overlap,
 
 					CorrelationID: correlationID,
 				}
+				if trackKey != "" {
+					embedPayload.PageURL = payload.URL
+					embedPayload.PageHash = trackKey
+				}
 
 				if author, ok := payload.Metadata["author"].(string); ok {
 					embedPayload.Author = author
@@ -192,10 +668,15 @@ func (h *ResultConsumer) HandleMessage(m *nsq.Message) error {
 		}
 	}
 
+	// A page without chunks has nothing to wait for
+	if h.skipUnchanged && !unchanged && !pendingChunks {
+		if err := h.pageManager.UpdatePageHash(ctx, payload.SourceID, payload.URL, pageKey); err != nil {
+			slog.WarnContext(ctx, "failed to store page hash", "error", err)
+		}
+	}
+
 	// 3. Update Source Body Hash (Only for seed? Or aggregate? Maybe just last update)
-	hash := sha256.Sum256([]byte(payload.Content))
-	hashStr := fmt.Sprintf("%x", hash)
-	_ = h.updater.UpdateBodyHash(ctx, payload.SourceID, hashStr)
+	_ = h.updater.UpdateBodyHash(ctx, payload.SourceID, pageHash)
 
 	// 4. Distributed Crawl: Link Discovery
 	if payload.URL != "" && len(payload.Links) > 0 {
@@ -212,16 +693,49 @@ func (h *ResultConsumer) HandleMessage(m *nsq.Message) error {
 				slog.InfoContext(ctx, "processing manifest links with extended depth", "url", payload.URL)
 			}
 
-			newPages := DiscoverLinks(payload.SourceID, host, payload.Links, payload.Depth, effectiveMaxDepth, exclusions)
+			newPages := DiscoverLinks(payload.SourceID, host, payload.Links, payload.Depth, effectiveMaxDepth, exclusions, opts.CrawlSubdomains)
+			if opts.SeedPathPrefix != "" {
+				newPages = FilterByPathPrefix(newPages, opts.SeedPathPrefix)
+			}
+			if h.robots != nil {
+				newPages = FilterByRobots(ctx, h.robots, newPages)
+			}
+
+			if h.dedup != nil {
+				claimed := newPages[:0]
+				for _, page := range newPages {
+					if h.dedup.Claim(payload.SourceID, page.URL) {
+						claimed = append(claimed, page)
+					} else {
+						slog.DebugContext(ctx, "skipping recently discovered url", "source_id", payload.SourceID, "url", page.URL)
+					}
+				}
+				newPages = claimed
+			}
+
+			if opts.Paused {
+				for i := range newPages {
+					newPages[i].Status = PageHeld
+				}
+			}
 
 			if len(newPages) > 0 {
 				newURLs, err := h.pageManager.BulkCreatePages(ctx, newPages)
 				if err != nil {
 					slog.ErrorContext(ctx, "failed to bulk create pages", "error", err)
-					return err
+					if h.dedup != nil {
+						for _, page := range newPages {
+							h.dedup.Release(payload.SourceID, page.URL)
+						}
+					}
+					return h.storeFailed(ctx, m, failed, err)
 				}
 
 				slog.InfoContext(ctx, "discovered new pages", "count", len(newURLs))
+				if opts.Paused && len(newURLs) > 0 {
+					slog.InfoContext(ctx, "source paused, holding new pages", "source_id", payload.SourceID, "count", len(newURLs))
+					newURLs = nil
+				}
 				for _, newURL := range newURLs {
 					// Ensure tasks generated from llms.txt at maxDepth don't exceed maxDepth+1 endlessly
 					// Actually, DiscoverLinks sets new page depth as parent.Depth + 1.
@@ -239,9 +753,13 @@ func (h *ResultConsumer) HandleMessage(m *nsq.Message) error {
 						"gemini_api_key": apiKey,
 						"correlation_id": correlationID,
 					})
-					if err := h.publisher.Publish(config.TopicIngestWeb, taskPayload); err != nil {
+					published, err := h.publishWebTask(payload.SourceID, newURL, opts.CrawlDelay, taskPayload)
+					if err != nil {
 						slog.ErrorContext(ctx, "failed to publish task, marking page as failed", "error", err, "url", newURL)
 						_ = h.pageManager.UpdatePageStatus(ctx, payload.SourceID, newURL, "failed", fmt.Sprintf("Failed to publish task: %v", err))
+					} else if !published {
+						slog.DebugContext(ctx, "crawl slot too far out, holding page", "source_id", payload.SourceID, "url", newURL)
+						_ = h.pageManager.UpdatePageStatus(ctx, payload.SourceID, newURL, PageHeld, "")
 					}
 				}
 			} else if isManifest {
@@ -255,19 +773,51 @@ func (h *ResultConsumer) HandleMessage(m *nsq.Message) error {
 		if err := h.pageManager.UpdatePageStatus(ctx, payload.SourceID, payload.URL, "completed", ""); err != nil {
 			slog.WarnContext(ctx, "failed to update page status", "error", err)
 		}
+		h.emit(SourceEvent{SourceID: payload.SourceID, Type: EventPageStatus, URL: payload.URL, Status: "completed"})
+		h.resolveJob(ctx, payload.SourceID, payload.URL)
 	}
 
 	// 6. Check Source Completion
+	h.checkSourceCompletion(ctx, payload.SourceID)
 
-	pendingCount, err := h.pageManager.CountPendingPages(ctx, payload.SourceID)
+	return nil
+}
+
+func (h *ResultConsumer) checkSourceCompletion(ctx context.Context, sourceID string) {
+	pendingCount, err := h.pageManager.CountPendingPages(ctx, sourceID)
 	if err != nil {
 		slog.WarnContext(ctx, "failed to count pending pages", "error", err)
 	} else if pendingCount == 0 {
-		slog.InfoContext(ctx, "source ingestion completed", "source_id", payload.SourceID)
-		if err := h.updater.UpdateStatus(ctx, payload.SourceID, "completed"); err != nil {
-			slog.WarnContext(ctx, "failed to update source status to completed", "error", err)
+		status := "completed"
+		if h.markPartial {
+			failedCount, err := h.pageManager.CountFailedPages(ctx, sourceID)
+			if err != nil {
+				slog.WarnContext(ctx, "failed to count failed pages", "error", err)
+			} else if failedCount > 0 {
+				status = "completed_with_errors"
+			}
+		}
+		slog.InfoContext(ctx, "source ingestion completed", "source_id", sourceID, "status", status)
+		if err := h.updater.UpdateStatus(ctx, sourceID, status); err != nil {
+			slog.WarnContext(ctx, "failed to update source status", "status", status, "error", err)
 		}
+		h.emit(SourceEvent{SourceID: sourceID, Type: EventSourceStatus, Status: status})
 	}
+}
 
-	return nil
+// isSameHostCanonical reports whether canonical is a usable canonical URL for
+// fetched: absolute http(s), on the same host, and different from fetched.
+func isSameHostCanonical(fetched, canonical string) bool {
+	if canonical == "" || canonical == fetched {
+		return false
+	}
+	c, err := url.Parse(canonical)
+	if err != nil || (c.Scheme != "http" && c.Scheme != "https") {
+		return false
+	}
+	f, err := url.Parse(fetched)
+	if err != nil {
+		return false
+	}
+	return c.Host == f.Host
 }
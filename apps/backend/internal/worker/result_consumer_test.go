@@ -1,8 +1,11 @@
 package worker_test
 
 import (
+	"context"
 	"encoding/json"
+	"sync"
 	"testing"
+	"time"
 
 	"qurio/apps/backend/features/job"
 	"qurio/apps/backend/internal/config"
@@ -11,6 +14,8 @@ import (
 	"github.com/nsqio/go-nsq"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/weaviate/weaviate-go-client/v5/weaviate/fault"
 )
 
 func TestResultConsumer_HandleMessage_Success(t *testing.T) {
@@ -40,6 +45,7 @@ func TestResultConsumer_HandleMessage_Success(t *testing.T) {
 	// Expectations
 	// 1. Fetch Config
 	sf.On("GetSourceConfig", mock.Anything, "src1").Return(2, []string{}, "api-key", "My Source", nil)
+	sf.On("GetSourceOptions", mock.Anything, "src1").Return(&worker.SourceOptions{}, nil)
 
 	// 2. Delete Old Chunks
 	s.On("DeleteChunksByURL", mock.Anything, "src1", "http://example.com").Return(nil)
@@ -79,6 +85,8 @@ func TestResultConsumer_HandleMessage_Success(t *testing.T) {
 	pm.AssertExpectations(t)
 	tp.AssertExpectations(t)
 	u.AssertExpectations(t)
+	// A page that succeeds clears any failed job recorded for it
+	assert.Equal(t, []string{"http://example.com"}, j.resolved)
 }
 
 func TestResultConsumer_HandleMessage_Failure(t *testing.T) {
@@ -109,7 +117,7 @@ func TestResultConsumer_HandleMessage_Failure(t *testing.T) {
 	pm.On("UpdatePageStatus", mock.Anything, "src1", "http://example.com", "failed", "Some error").Return(nil)
 	u.On("UpdateStatus", mock.Anything, "src1", "failed").Return(nil) // Depth 0 -> Update Source Status
 	j.On("Save", mock.Anything, mock.MatchedBy(func(job *job.Job) bool {
-		return job.SourceID == "src1" && job.Error == "Some error"
+		return job.SourceID == "src1" && job.URL == "http://example.com" && job.Error == "Some error"
 	})).Return(nil)
 
 	err := consumer.HandleMessage(msg)
@@ -144,6 +152,7 @@ func TestResultConsumer_HandleMessage_LLMsTxt_ExtendedDepth(t *testing.T) {
 	// Normal logic: Depth 2 == Max Depth 2 -> No new links.
 	// LLMs.txt logic: Effective Max Depth = 3. -> New links allowed.
 	sf.On("GetSourceConfig", mock.Anything, "src1").Return(2, []string{}, "", "Src", nil)
+	sf.On("GetSourceOptions", mock.Anything, "src1").Return(&worker.SourceOptions{}, nil)
 	s.On("DeleteChunksByURL", mock.Anything, "src1", "http://example.com/llms.txt").Return(nil)
 	tp.On("Publish", config.TopicIngestEmbed, mock.Anything).Return(nil)
 	u.On("UpdateBodyHash", mock.Anything, "src1", mock.Anything).Return(nil)
@@ -184,6 +193,7 @@ func TestResultConsumer_HandleMessage_DeleteChunksError(t *testing.T) {
 	msg := &nsq.Message{Body: body}
 
 	sf.On("GetSourceConfig", mock.Anything, "src1").Return(2, []string{}, "", "Src", nil)
+	sf.On("GetSourceOptions", mock.Anything, "src1").Return(&worker.SourceOptions{}, nil)
 	s.On("DeleteChunksByURL", mock.Anything, "src1", "http://example.com").Return(assert.AnError)
 
 	err := consumer.HandleMessage(msg)
@@ -199,6 +209,23 @@ func TestResultConsumer_HandleMessage_PoisonPill(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestResultConsumer_HandleMessage_PoisonPillDeadLettered(t *testing.T) {
+	tp := new(MockTaskPublisher)
+	tp.On("Publish", config.TopicIngestDeadLetter, mock.Anything).Return(nil)
+
+	consumer := worker.NewResultConsumer(nil, nil, nil, nil, nil, tp)
+	err := consumer.HandleMessage(&nsq.Message{Body: []byte("{not json")})
+	assert.NoError(t, err)
+
+	tp.AssertNumberOfCalls(t, "Publish", 1)
+	var letter worker.DeadLetter
+	require.NoError(t, json.Unmarshal(tp.Calls[0].Arguments.Get(1).([]byte), &letter))
+	assert.Equal(t, config.TopicIngestResult, letter.Topic)
+	assert.Equal(t, "{not json", letter.RawBody)
+	assert.Empty(t, letter.Body)
+	assert.NotEmpty(t, letter.Error)
+}
+
 func TestResultConsumer_HandleMessage_MissingRequiredFields(t *testing.T) {
 	consumer := worker.NewResultConsumer(nil, nil, nil, nil, nil, nil)
 
@@ -230,6 +257,7 @@ func TestResultConsumer_HandleMessage_LinkDiscoveryPublishFailure(t *testing.T)
 	msg := &nsq.Message{Body: body}
 
 	sf.On("GetSourceConfig", mock.Anything, "src1").Return(5, []string{}, "", "Src", nil)
+	sf.On("GetSourceOptions", mock.Anything, "src1").Return(&worker.SourceOptions{}, nil)
 	s.On("DeleteChunksByURL", mock.Anything, "src1", "http://example.com").Return(nil)
 	tp.On("Publish", config.TopicIngestEmbed, mock.Anything).Return(nil)
 	u.On("UpdateBodyHash", mock.Anything, "src1", mock.Anything).Return(nil)
@@ -273,6 +301,7 @@ func TestResultConsumer_HandleMessage_EmptyContent_NoEmbedPublish(t *testing.T)
 	msg := &nsq.Message{Body: body}
 
 	sf.On("GetSourceConfig", mock.Anything, "src1").Return(5, []string{}, "", "Src", nil)
+	sf.On("GetSourceOptions", mock.Anything, "src1").Return(&worker.SourceOptions{}, nil)
 	s.On("DeleteChunksByURL", mock.Anything, "src1", "http://example.com").Return(nil)
 	u.On("UpdateBodyHash", mock.Anything, "src1", mock.Anything).Return(nil)
 	pm.On("UpdatePageStatus", mock.Anything, "src1", "http://example.com", "completed", "").Return(nil)
@@ -311,6 +340,7 @@ func TestResultConsumer_HandleMessage_FailureNonZeroDepth(t *testing.T) {
 	msg := &nsq.Message{Body: body}
 
 	pm.On("UpdatePageStatus", mock.Anything, "src1", "http://example.com/sub", "failed", "404 not found").Return(nil)
+	pm.On("CountPendingPages", mock.Anything, "src1").Return(1, nil)
 	// UpdateStatus should NOT be called because depth != 0
 
 	err := consumer.HandleMessage(msg)
@@ -342,6 +372,7 @@ func TestResultConsumer_HandleMessage_WithMetadata(t *testing.T) {
 	msg := &nsq.Message{Body: body}
 
 	sf.On("GetSourceConfig", mock.Anything, "src1").Return(0, []string{}, "", "Src", nil)
+	sf.On("GetSourceOptions", mock.Anything, "src1").Return(&worker.SourceOptions{}, nil)
 	s.On("DeleteChunksByURL", mock.Anything, "src1", "http://example.com/doc.pdf").Return(nil)
 
 	// Verify metadata is passed through to embed payload
@@ -376,6 +407,7 @@ func TestResultConsumer_HandleMessage_PublishEmbedError(t *testing.T) {
 	msg := &nsq.Message{Body: body}
 
 	sf.On("GetSourceConfig", mock.Anything, "src1").Return(0, []string{}, "", "Src", nil)
+	sf.On("GetSourceOptions", mock.Anything, "src1").Return(&worker.SourceOptions{}, nil)
 	s.On("DeleteChunksByURL", mock.Anything, "src1", "http://example.com").Return(nil)
 	tp.On("Publish", config.TopicIngestEmbed, mock.Anything).Return(assert.AnError)
 
@@ -423,3 +455,1007 @@ func TestResultConsumer_HandleMessage_MissingSourceID(t *testing.T) {
 	err := consumer.HandleMessage(msg)
 	assert.NoError(t, err) // Dropped gracefully
 }
+
+func TestResultConsumer_HandleMessage_GonePageRemoved(t *testing.T) {
+	// Payloads as the ingestion worker publishes them
+	tests := []struct {
+		name       string
+		statusCode int
+		payload    map[string]interface{}
+	}{
+		{
+			name:       "failed crawl",
+			statusCode: 410,
+			payload: map[string]interface{}{
+				"source_id":        "src1",
+				"correlation_id":   "src1",
+				"status":           "failed",
+				"code":             "ERR_CRAWL_GONE",
+				"error":            "[ERR_CRAWL_GONE] Page returned HTTP 410",
+				"url":              "http://example.com/old",
+				"original_payload": map[string]interface{}{"type": "web", "url": "http://example.com/old", "id": "src1", "depth": 1},
+				"status_code":      410,
+			},
+		},
+		{
+			name:       "error page crawled",
+			statusCode: 404,
+			payload: map[string]interface{}{
+				"source_id":      "src1",
+				"correlation_id": "src1",
+				"content":        "# Not Found",
+				"metadata":       map[string]interface{}{},
+				"title":          "Not Found",
+				"url":            "http://example.com/old",
+				"path":           "",
+				"status":         "success",
+				"links":          []string{"http://example.com/"},
+				"depth":          1,
+				"status_code":    404,
+				"fetch_ms":       120,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := new(MockVectorStore)
+			u := new(MockUpdater)
+			j := new(MockJobRepo)
+			sf := new(MockSourceFetcher)
+			pm := new(MockPageManager)
+			tp := new(MockTaskPublisher)
+
+			consumer := worker.NewResultConsumer(s, u, j, sf, pm, tp)
+
+			body, _ := json.Marshal(tt.payload)
+			msg := &nsq.Message{Body: body}
+
+			s.On("DeleteChunksByURL", mock.Anything, "src1", "http://example.com/old").Return(nil)
+			pm.On("RecordPageFetch", mock.Anything, "src1", "http://example.com/old", tt.statusCode, mock.Anything).Return(nil)
+			pm.On("UpdatePageStatus", mock.Anything, "src1", "http://example.com/old", "removed", "").Return(nil)
+			pm.On("CountPendingPages", mock.Anything, "src1").Return(0, nil)
+			u.On("UpdateStatus", mock.Anything, "src1", "completed").Return(nil)
+
+			err := consumer.HandleMessage(msg)
+			assert.NoError(t, err)
+
+			s.AssertExpectations(t)
+			pm.AssertExpectations(t)
+			s.AssertNotCalled(t, "StoreChunks", mock.Anything, mock.Anything)
+			j.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+			tp.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestResultConsumer_HandleMessage_ServerErrorRetries(t *testing.T) {
+	s := new(MockVectorStore)
+	u := new(MockUpdater)
+	j := new(MockJobRepo)
+	sf := new(MockSourceFetcher)
+	pm := new(MockPageManager)
+	tp := new(MockTaskPublisher)
+
+	consumer := worker.NewResultConsumer(s, u, j, sf, pm, tp)
+
+	payload := map[string]interface{}{
+		"source_id":        "src1",
+		"url":              "http://example.com/flaky",
+		"status":           "failed",
+		"status_code":      500,
+		"error":            "Internal Server Error",
+		"depth":            1,
+		"original_payload": map[string]interface{}{"url": "http://example.com/flaky"},
+	}
+	body, _ := json.Marshal(payload)
+	msg := &nsq.Message{Body: body}
+
+	pm.On("RecordPageFetch", mock.Anything, "src1", "http://example.com/flaky", 500, 0).Return(nil)
+	pm.On("UpdatePageStatus", mock.Anything, "src1", "http://example.com/flaky", "failed", "Internal Server Error").Return(nil)
+	pm.On("CountPendingPages", mock.Anything, "src1").Return(1, nil)
+	j.On("Save", mock.Anything, mock.MatchedBy(func(job *job.Job) bool {
+		return job.SourceID == "src1" && job.Error == "Internal Server Error"
+	})).Return(nil)
+
+	err := consumer.HandleMessage(msg)
+	assert.NoError(t, err)
+
+	pm.AssertExpectations(t)
+	j.AssertExpectations(t)
+	s.AssertNotCalled(t, "DeleteChunksByURL", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// deferringPublisher records the delay requested for each web task.
+type deferringPublisher struct {
+	mu     sync.Mutex
+	delays map[string][]time.Duration
+}
+
+func (p *deferringPublisher) Publish(topic string, body []byte) error {
+	return p.DeferredPublish(topic, 0, body)
+}
+
+func (p *deferringPublisher) DeferredPublish(topic string, delay time.Duration, body []byte) error {
+	if topic != config.TopicIngestWeb {
+		return nil
+	}
+	var task map[string]interface{}
+	_ = json.Unmarshal(body, &task)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	id := task["id"].(string)
+	p.delays[id] = append(p.delays[id], delay)
+	return nil
+}
+
+func TestResultConsumer_HandleMessage_CrawlDelay(t *testing.T) {
+	s := new(MockVectorStore)
+	u := new(MockUpdater)
+	sf := new(MockSourceFetcher)
+	pm := new(MockPageManager)
+	tp := &deferringPublisher{delays: make(map[string][]time.Duration)}
+
+	consumer := worker.NewResultConsumer(s, u, nil, sf, pm, tp)
+
+	crawlDelay := 500 * time.Millisecond
+	sf.On("GetSourceConfig", mock.Anything, mock.Anything).Return(2, []string{}, "", "Src", nil)
+	sf.On("GetSourceOptions", mock.Anything, "slow").Return(&worker.SourceOptions{CrawlDelay: crawlDelay}, nil)
+	sf.On("GetSourceOptions", mock.Anything, "fast").Return(&worker.SourceOptions{}, nil)
+	s.On("DeleteChunksByURL", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	u.On("UpdateBodyHash", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	pm.On("BulkCreatePages", mock.Anything, mock.Anything).Return([]string{"http://example.com/a", "http://example.com/b"}, nil)
+	pm.On("UpdatePageStatus", mock.Anything, mock.Anything, mock.Anything, "completed", "").Return(nil)
+	pm.On("CountPendingPages", mock.Anything, mock.Anything).Return(1, nil)
+
+	for _, sourceID := range []string{"slow", "fast"} {
+		body, _ := json.Marshal(map[string]interface{}{
+			"source_id": sourceID,
+			"url":       "http://example.com",
+			"status":    "success",
+			"links":     []string{"http://example.com/a", "http://example.com/b"},
+		})
+		assert.NoError(t, consumer.HandleMessage(&nsq.Message{Body: body}))
+	}
+
+	slow := tp.delays["slow"]
+	assert.Len(t, slow, 2)
+	assert.GreaterOrEqual(t, slow[1]-slow[0], crawlDelay-10*time.Millisecond)
+
+	assert.Equal(t, []time.Duration{0, 0}, tp.delays["fast"])
+}
+
+type stubHostLimiter struct {
+	delays map[string]time.Duration
+	hosts  []string
+}
+
+func (l *stubHostLimiter) Reserve(host string, limit time.Duration) (time.Duration, bool) {
+	l.hosts = append(l.hosts, host)
+	if l.delays[host] > limit {
+		return 0, false
+	}
+	return l.delays[host], true
+}
+
+func TestResultConsumer_HandleMessage_HostRateLimit(t *testing.T) {
+	s := new(MockVectorStore)
+	u := new(MockUpdater)
+	sf := new(MockSourceFetcher)
+	pm := new(MockPageManager)
+	tp := &deferringPublisher{delays: make(map[string][]time.Duration)}
+	hl := &stubHostLimiter{delays: map[string]time.Duration{"api.example.com": 2 * time.Second}}
+
+	consumer := worker.NewResultConsumer(s, u, nil, sf, pm, tp)
+	consumer.SetHostLimiter(hl)
+
+	sf.On("GetSourceConfig", mock.Anything, "src1").Return(2, []string{}, "", "Src", nil)
+	sf.On("GetSourceOptions", mock.Anything, "src1").Return(&worker.SourceOptions{CrawlSubdomains: true}, nil)
+	s.On("DeleteChunksByURL", mock.Anything, "src1", mock.Anything).Return(nil)
+	u.On("UpdateBodyHash", mock.Anything, "src1", mock.Anything).Return(nil)
+	pm.On("BulkCreatePages", mock.Anything, mock.Anything).Return([]string{"http://example.com/a", "http://api.example.com/b"}, nil)
+	pm.On("UpdatePageStatus", mock.Anything, "src1", mock.Anything, "completed", "").Return(nil)
+	pm.On("CountPendingPages", mock.Anything, "src1").Return(1, nil)
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"source_id": "src1",
+		"url":       "http://example.com",
+		"status":    "success",
+		"links":     []string{"http://example.com/a", "http://api.example.com/b"},
+	})
+	assert.NoError(t, consumer.HandleMessage(&nsq.Message{Body: body}))
+
+	// The task for the busy host is deferred, not dropped
+	assert.Equal(t, []string{"example.com", "api.example.com"}, hl.hosts)
+	assert.Equal(t, []time.Duration{0, 2 * time.Second}, tp.delays["src1"])
+}
+
+func TestResultConsumer_HandleMessage_HostRateLimitHoldsDistantSlots(t *testing.T) {
+	s := new(MockVectorStore)
+	u := new(MockUpdater)
+	sf := new(MockSourceFetcher)
+	pm := new(MockPageManager)
+	tp := &deferringPublisher{delays: make(map[string][]time.Duration)}
+	hl := &stubHostLimiter{delays: map[string]time.Duration{"api.example.com": 2 * time.Hour}}
+
+	consumer := worker.NewResultConsumer(s, u, nil, sf, pm, tp)
+	consumer.SetHostLimiter(hl)
+
+	sf.On("GetSourceConfig", mock.Anything, "src1").Return(2, []string{}, "", "Src", nil)
+	sf.On("GetSourceOptions", mock.Anything, "src1").Return(&worker.SourceOptions{CrawlSubdomains: true}, nil)
+	s.On("DeleteChunksByURL", mock.Anything, "src1", mock.Anything).Return(nil)
+	u.On("UpdateBodyHash", mock.Anything, "src1", mock.Anything).Return(nil)
+	pm.On("BulkCreatePages", mock.Anything, mock.Anything).Return([]string{"http://example.com/a", "http://api.example.com/b"}, nil)
+	pm.On("UpdatePageStatus", mock.Anything, "src1", "http://example.com", "completed", "").Return(nil)
+	pm.On("UpdatePageStatus", mock.Anything, "src1", "http://api.example.com/b", worker.PageHeld, "").Return(nil)
+	pm.On("CountPendingPages", mock.Anything, "src1").Return(2, nil)
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"source_id": "src1",
+		"url":       "http://example.com",
+		"status":    "success",
+		"links":     []string{"http://example.com/a", "http://api.example.com/b"},
+	})
+	assert.NoError(t, consumer.HandleMessage(&nsq.Message{Body: body}))
+
+	// Only the page whose slot fits in the deferral window is published
+	assert.Equal(t, []time.Duration{0}, tp.delays["src1"])
+	pm.AssertExpectations(t)
+}
+
+func TestResultConsumer_HandleMessage_KeywordOnlyTypes(t *testing.T) {
+	s := new(MockVectorStore)
+	u := new(MockUpdater)
+	sf := new(MockSourceFetcher)
+	pm := new(MockPageManager)
+	tp := new(MockTaskPublisher)
+
+	consumer := worker.NewResultConsumer(s, u, nil, sf, pm, tp)
+
+	content := "Build the project with the following command before running tests.\n\n```bash\nmake build\n```"
+	body, _ := json.Marshal(map[string]interface{}{
+		"source_id": "src1",
+		"url":       "http://example.com",
+		"content":   content,
+		"status":    "success",
+	})
+
+	sf.On("GetSourceConfig", mock.Anything, "src1").Return(0, []string{}, "", "Src", nil)
+	sf.On("GetSourceOptions", mock.Anything, "src1").Return(&worker.SourceOptions{KeywordOnlyTypes: []string{"cmd"}}, nil)
+	s.On("DeleteChunksByURL", mock.Anything, "src1", "http://example.com").Return(nil)
+	u.On("UpdateBodyHash", mock.Anything, "src1", mock.Anything).Return(nil)
+	pm.On("UpdatePageStatus", mock.Anything, "src1", "http://example.com", "completed", "").Return(nil)
+	pm.On("CountPendingPages", mock.Anything, "src1").Return(1, nil)
+
+	var published []worker.IngestEmbedPayload
+	tp.On("Publish", config.TopicIngestEmbed, mock.Anything).Run(func(args mock.Arguments) {
+		var p worker.IngestEmbedPayload
+		_ = json.Unmarshal(args.Get(1).([]byte), &p)
+		published = append(published, p)
+	}).Return(nil)
+
+	assert.NoError(t, consumer.HandleMessage(&nsq.Message{Body: body}))
+
+	assert.Len(t, published, 2)
+	for _, p := range published {
+		assert.Equal(t, p.ChunkType == "cmd", p.SkipEmbedding, "chunk type %s", p.ChunkType)
+	}
+}
+
+func TestResultConsumer_HandleMessage_RestrictToSeedPath(t *testing.T) {
+	s := new(MockVectorStore)
+	u := new(MockUpdater)
+	sf := new(MockSourceFetcher)
+	pm := new(MockPageManager)
+	tp := new(MockTaskPublisher)
+
+	consumer := worker.NewResultConsumer(s, u, nil, sf, pm, tp)
+
+	sf.On("GetSourceConfig", mock.Anything, "src1").Return(2, []string{}, "", "Src", nil)
+	sf.On("GetSourceOptions", mock.Anything, "src1").Return(&worker.SourceOptions{SeedPathPrefix: worker.SeedPathPrefix("http://example.com/docs/product/intro")}, nil)
+	s.On("DeleteChunksByURL", mock.Anything, "src1", mock.Anything).Return(nil)
+	u.On("UpdateBodyHash", mock.Anything, "src1", mock.Anything).Return(nil)
+	pm.On("BulkCreatePages", mock.Anything, mock.MatchedBy(func(pages []worker.PageDTO) bool {
+		if len(pages) != 2 {
+			return false
+		}
+		return pages[0].URL == "http://example.com/docs/product/setup" &&
+			pages[1].URL == "http://example.com/docs/product/api/auth"
+	})).Return([]string{"http://example.com/docs/product/setup", "http://example.com/docs/product/api/auth"}, nil)
+	pm.On("UpdatePageStatus", mock.Anything, "src1", mock.Anything, "completed", "").Return(nil)
+	pm.On("CountPendingPages", mock.Anything, "src1").Return(1, nil)
+	tp.On("Publish", config.TopicIngestWeb, mock.Anything).Return(nil)
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"source_id": "src1",
+		"url":       "http://example.com/docs/product/intro",
+		"status":    "success",
+		"links": []string{
+			"http://example.com/docs/product/setup",
+			"http://example.com/docs/product/api/auth",
+			"http://example.com/docs/pricing",
+			"http://example.com/docs/productivity",
+			"http://example.com/blog",
+		},
+	})
+	assert.NoError(t, consumer.HandleMessage(&nsq.Message{Body: body}))
+
+	pm.AssertExpectations(t)
+	tp.AssertNumberOfCalls(t, "Publish", 2)
+}
+
+func TestResultConsumer_SelectorSurvivesPipeline(t *testing.T) {
+	s := new(MockVectorStore)
+	u := new(MockUpdater)
+	sf := new(MockSourceFetcher)
+	pm := new(MockPageManager)
+	tp := new(MockTaskPublisher)
+
+	consumer := worker.NewResultConsumer(s, u, nil, sf, pm, tp)
+
+	sf.On("GetSourceConfig", mock.Anything, "src1").Return(0, []string{}, "", "Src", nil)
+	sf.On("GetSourceOptions", mock.Anything, "src1").Return(&worker.SourceOptions{}, nil)
+	s.On("DeleteChunksByURL", mock.Anything, "src1", mock.Anything).Return(nil)
+	u.On("UpdateBodyHash", mock.Anything, "src1", mock.Anything).Return(nil)
+	pm.On("UpdatePageStatus", mock.Anything, "src1", mock.Anything, "completed", "").Return(nil)
+	pm.On("CountPendingPages", mock.Anything, "src1").Return(1, nil)
+
+	var published [][]byte
+	tp.On("Publish", config.TopicIngestEmbed, mock.Anything).Run(func(args mock.Arguments) {
+		published = append(published, args.Get(1).([]byte))
+	}).Return(nil)
+
+	for _, page := range []map[string]interface{}{
+		{"source_id": "src1", "url": "http://example.com/html", "content": "Install the client library before configuring the HTML renderer.", "status": "success", "selector": "main > article"},
+		{"source_id": "src1", "url": "http://example.com/md", "content": "Install the client library before configuring the Markdown renderer.", "status": "success"},
+	} {
+		body, _ := json.Marshal(page)
+		assert.NoError(t, consumer.HandleMessage(&nsq.Message{Body: body}))
+	}
+	assert.Len(t, published, 2)
+
+	e := new(MockEmbedder)
+	e.On("Embed", mock.Anything, mock.Anything).Return([]float32{0.1}, nil)
+	var stored []worker.Chunk
+	s.On("StoreChunk", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		stored = append(stored, args.Get(1).(worker.Chunk))
+	}).Return(nil)
+
+	embedder := worker.NewEmbedderConsumer(e, s)
+	for _, body := range published {
+		assert.NoError(t, embedder.HandleMessage(&nsq.Message{Body: body}))
+	}
+
+	assert.Len(t, stored, 2)
+	assert.Equal(t, "main > article", stored[0].Selector)
+	assert.Empty(t, stored[1].Selector)
+}
+
+func TestResultConsumer_HandleMessage_CanonicalURL(t *testing.T) {
+	s := new(MockVectorStore)
+	u := new(MockUpdater)
+	sf := new(MockSourceFetcher)
+	pm := new(MockPageManager)
+	tp := new(MockTaskPublisher)
+
+	consumer := worker.NewResultConsumer(s, u, nil, sf, pm, tp)
+
+	fetched := "http://example.com/docs/intro?ref=nav"
+	canonical := "http://example.com/docs/intro"
+
+	sf.On("GetSourceConfig", mock.Anything, "src1").Return(0, []string{}, "", "Src", nil)
+	sf.On("GetSourceOptions", mock.Anything, "src1").Return(&worker.SourceOptions{}, nil)
+	s.On("DeleteChunksByURL", mock.Anything, "src1", canonical).Return(nil).Once()
+	s.On("DeleteChunksByURL", mock.Anything, "src1", fetched).Return(nil).Once()
+	u.On("UpdateBodyHash", mock.Anything, "src1", mock.Anything).Return(nil)
+	// Crawl bookkeeping stays on the fetched URL
+	pm.On("UpdatePageStatus", mock.Anything, "src1", fetched, "completed", "").Return(nil)
+	pm.On("CountPendingPages", mock.Anything, "src1").Return(1, nil)
+
+	var published []worker.IngestEmbedPayload
+	tp.On("Publish", config.TopicIngestEmbed, mock.Anything).Run(func(args mock.Arguments) {
+		var p worker.IngestEmbedPayload
+		_ = json.Unmarshal(args.Get(1).([]byte), &p)
+		published = append(published, p)
+	}).Return(nil)
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"source_id":     "src1",
+		"url":           fetched,
+		"canonical_url": canonical,
+		"content":       "Install the client library before configuring the renderer.",
+		"status":        "success",
+	})
+	assert.NoError(t, consumer.HandleMessage(&nsq.Message{Body: body}))
+
+	require.NotEmpty(t, published)
+	for _, p := range published {
+		assert.Equal(t, canonical, p.SourceURL)
+		assert.Equal(t, fetched, p.AliasURL)
+	}
+	s.AssertExpectations(t)
+	pm.AssertExpectations(t)
+}
+
+func TestResultConsumer_HandleMessage_CrossHostCanonicalIgnored(t *testing.T) {
+	s := new(MockVectorStore)
+	u := new(MockUpdater)
+	sf := new(MockSourceFetcher)
+	pm := new(MockPageManager)
+	tp := new(MockTaskPublisher)
+
+	consumer := worker.NewResultConsumer(s, u, nil, sf, pm, tp)
+
+	sf.On("GetSourceConfig", mock.Anything, "src1").Return(0, []string{}, "", "Src", nil)
+	sf.On("GetSourceOptions", mock.Anything, "src1").Return(&worker.SourceOptions{}, nil)
+	s.On("DeleteChunksByURL", mock.Anything, "src1", "http://example.com/a").Return(nil).Once()
+	u.On("UpdateBodyHash", mock.Anything, "src1", mock.Anything).Return(nil)
+	pm.On("UpdatePageStatus", mock.Anything, "src1", "http://example.com/a", "completed", "").Return(nil)
+	pm.On("CountPendingPages", mock.Anything, "src1").Return(1, nil)
+
+	var published []worker.IngestEmbedPayload
+	tp.On("Publish", config.TopicIngestEmbed, mock.Anything).Run(func(args mock.Arguments) {
+		var p worker.IngestEmbedPayload
+		_ = json.Unmarshal(args.Get(1).([]byte), &p)
+		published = append(published, p)
+	}).Return(nil)
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"source_id":     "src1",
+		"url":           "http://example.com/a",
+		"canonical_url": "http://mirror.example.org/a",
+		"content":       "Install the client library before configuring the renderer.",
+		"status":        "success",
+	})
+	assert.NoError(t, consumer.HandleMessage(&nsq.Message{Body: body}))
+
+	require.NotEmpty(t, published)
+	assert.Equal(t, "http://example.com/a", published[0].SourceURL)
+	assert.Empty(t, published[0].AliasURL)
+}
+
+func TestResultConsumer_HandleMessage_RecordsFetchStats(t *testing.T) {
+	s := new(MockVectorStore)
+	u := new(MockUpdater)
+	sf := new(MockSourceFetcher)
+	pm := new(MockPageManager)
+
+	consumer := worker.NewResultConsumer(s, u, nil, sf, pm, nil)
+
+	sf.On("GetSourceConfig", mock.Anything, "src1").Return(0, []string{}, "", "Src", nil)
+	sf.On("GetSourceOptions", mock.Anything, "src1").Return(&worker.SourceOptions{}, nil)
+	s.On("DeleteChunksByURL", mock.Anything, "src1", "http://example.com").Return(nil)
+	u.On("UpdateBodyHash", mock.Anything, "src1", mock.Anything).Return(nil)
+	pm.On("RecordPageFetch", mock.Anything, "src1", "http://example.com", 200, 340).Return(nil).Once()
+	pm.On("UpdatePageStatus", mock.Anything, "src1", "http://example.com", "completed", "").Return(nil)
+	pm.On("CountPendingPages", mock.Anything, "src1").Return(1, nil)
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"source_id":   "src1",
+		"url":         "http://example.com",
+		"status":      "success",
+		"status_code": 200,
+		"fetch_ms":    340,
+	})
+	assert.NoError(t, consumer.HandleMessage(&nsq.Message{Body: body}))
+
+	pm.AssertExpectations(t)
+}
+
+func TestResultConsumer_HandleMessage_CompletedWithErrors(t *testing.T) {
+	s := new(MockVectorStore)
+	u := new(MockUpdater)
+	j := new(MockJobRepo)
+	sf := new(MockSourceFetcher)
+	pm := new(MockPageManager)
+	tp := new(MockTaskPublisher)
+
+	consumer := worker.NewResultConsumer(s, u, j, sf, pm, tp)
+	consumer.SetMarkPartialFailures(true)
+
+	// Page 1 fails while page 2 is still outstanding.
+	failed, _ := json.Marshal(map[string]interface{}{
+		"source_id": "src1",
+		"url":       "http://example.com/a",
+		"status":    "failed",
+		"error":     "timeout",
+		"depth":     1,
+	})
+	pm.On("UpdatePageStatus", mock.Anything, "src1", "http://example.com/a", "failed", "timeout").Return(nil)
+	pm.On("CountPendingPages", mock.Anything, "src1").Return(1, nil).Once()
+
+	require.NoError(t, consumer.HandleMessage(&nsq.Message{Body: failed}))
+	u.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything)
+
+	// Page 2 completes and is the last one.
+	done, _ := json.Marshal(map[string]interface{}{
+		"source_id": "src1",
+		"url":       "http://example.com/b",
+		"content":   "This is a longer content string that should not be filtered as noise by the chunker.",
+		"status":    "success",
+		"depth":     1,
+	})
+	sf.On("GetSourceConfig", mock.Anything, "src1").Return(1, []string{}, "", "Src", nil)
+	sf.On("GetSourceOptions", mock.Anything, "src1").Return(&worker.SourceOptions{}, nil)
+	s.On("DeleteChunksByURL", mock.Anything, "src1", "http://example.com/b").Return(nil)
+	tp.On("Publish", config.TopicIngestEmbed, mock.Anything).Return(nil)
+	u.On("UpdateBodyHash", mock.Anything, "src1", mock.Anything).Return(nil)
+	pm.On("UpdatePageStatus", mock.Anything, "src1", "http://example.com/b", "completed", "").Return(nil)
+	pm.On("CountPendingPages", mock.Anything, "src1").Return(0, nil).Once()
+	pm.On("CountFailedPages", mock.Anything, "src1").Return(1, nil)
+	u.On("UpdateStatus", mock.Anything, "src1", "completed_with_errors").Return(nil)
+
+	require.NoError(t, consumer.HandleMessage(&nsq.Message{Body: done}))
+
+	pm.AssertExpectations(t)
+	u.AssertExpectations(t)
+	u.AssertNotCalled(t, "UpdateStatus", mock.Anything, "src1", "completed")
+}
+
+func TestResultConsumer_HandleMessage_DedupesInFlightEnqueues(t *testing.T) {
+	s := new(MockVectorStore)
+	u := new(MockUpdater)
+	sf := new(MockSourceFetcher)
+	pm := new(MockPageManager)
+	tp := new(MockTaskPublisher)
+
+	consumer := worker.NewResultConsumer(s, u, nil, sf, pm, tp)
+	consumer.SetEnqueueDedupWindow(time.Minute)
+
+	sf.On("GetSourceConfig", mock.Anything, "src1").Return(2, []string{}, "", "Src", nil)
+	sf.On("GetSourceOptions", mock.Anything, "src1").Return(&worker.SourceOptions{}, nil)
+	s.On("DeleteChunksByURL", mock.Anything, "src1", mock.Anything).Return(nil)
+	tp.On("Publish", config.TopicIngestEmbed, mock.Anything).Return(nil)
+	u.On("UpdateBodyHash", mock.Anything, "src1", mock.Anything).Return(nil)
+	pm.On("UpdatePageStatus", mock.Anything, "src1", mock.Anything, "completed", "").Return(nil)
+	pm.On("CountPendingPages", mock.Anything, "src1").Return(1, nil)
+
+	// Only the first parent creates the shared link; the second skips it
+	// before touching the pages table
+	pm.On("BulkCreatePages", mock.Anything, mock.Anything).Return([]string{"http://example.com/shared"}, nil).Once()
+	tp.On("Publish", config.TopicIngestWeb, mock.Anything).Return(nil).Once()
+
+	for _, parent := range []string{"http://example.com/a", "http://example.com/b"} {
+		body, _ := json.Marshal(map[string]interface{}{
+			"source_id": "src1",
+			"url":       parent,
+			"content":   "This is a longer content string that should not be filtered as noise by the chunker.",
+			"status":    "success",
+			"links":     []string{"http://example.com/shared"},
+			"depth":     0,
+		})
+		require.NoError(t, consumer.HandleMessage(&nsq.Message{Body: body}))
+	}
+
+	tp.AssertNumberOfCalls(t, "Publish", 3) // two embeds, one web task
+	tp.AssertExpectations(t)
+	pm.AssertNumberOfCalls(t, "BulkCreatePages", 1)
+}
+
+type stubRobots map[string]bool
+
+func (r stubRobots) Allowed(ctx context.Context, sourceID, rawURL string) bool {
+	return !r[rawURL]
+}
+
+func TestResultConsumer_HandleMessage_RespectsRobots(t *testing.T) {
+	s := new(MockVectorStore)
+	u := new(MockUpdater)
+	sf := new(MockSourceFetcher)
+	pm := new(MockPageManager)
+	tp := new(MockTaskPublisher)
+
+	consumer := worker.NewResultConsumer(s, u, nil, sf, pm, tp)
+	consumer.SetRobotsChecker(stubRobots{"http://example.com/admin": true})
+
+	sf.On("GetSourceConfig", mock.Anything, "src1").Return(2, []string{}, "", "Src", nil)
+	sf.On("GetSourceOptions", mock.Anything, "src1").Return(&worker.SourceOptions{}, nil)
+	s.On("DeleteChunksByURL", mock.Anything, "src1", mock.Anything).Return(nil)
+	u.On("UpdateBodyHash", mock.Anything, "src1", mock.Anything).Return(nil)
+	pm.On("BulkCreatePages", mock.Anything, mock.MatchedBy(func(pages []worker.PageDTO) bool {
+		return len(pages) == 1 && pages[0].URL == "http://example.com/docs"
+	})).Return([]string{"http://example.com/docs"}, nil)
+	pm.On("UpdatePageStatus", mock.Anything, "src1", mock.Anything, "completed", "").Return(nil)
+	pm.On("CountPendingPages", mock.Anything, "src1").Return(1, nil)
+	tp.On("Publish", config.TopicIngestWeb, mock.Anything).Return(nil)
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"source_id": "src1",
+		"url":       "http://example.com/",
+		"status":    "success",
+		"links":     []string{"http://example.com/docs", "http://example.com/admin"},
+	})
+	assert.NoError(t, consumer.HandleMessage(&nsq.Message{Body: body}))
+
+	pm.AssertExpectations(t)
+	tp.AssertNumberOfCalls(t, "Publish", 1)
+}
+
+func TestResultConsumer_HandleMessage_NormalizedBodyHash(t *testing.T) {
+	s := new(MockVectorStore)
+	u := new(MockUpdater)
+	sf := new(MockSourceFetcher)
+	pm := new(MockPageManager)
+	tp := new(MockTaskPublisher)
+
+	consumer := worker.NewResultConsumer(s, u, nil, sf, pm, tp)
+	consumer.SetNormalizeHash(true)
+
+	sf.On("GetSourceConfig", mock.Anything, "src1").Return(0, []string{}, "", "Src", nil)
+	sf.On("GetSourceOptions", mock.Anything, "src1").Return(&worker.SourceOptions{}, nil)
+	s.On("DeleteChunksByURL", mock.Anything, "src1", "http://example.com").Return(nil)
+	pm.On("UpdatePageStatus", mock.Anything, "src1", "http://example.com", "completed", "").Return(nil)
+	pm.On("CountPendingPages", mock.Anything, "src1").Return(1, nil)
+
+	var embedded []string
+	tp.On("Publish", config.TopicIngestEmbed, mock.Anything).Run(func(args mock.Arguments) {
+		var p worker.IngestEmbedPayload
+		_ = json.Unmarshal(args.Get(1).([]byte), &p)
+		embedded = append(embedded, p.Content)
+	}).Return(nil)
+
+	var hashes []string
+	u.On("UpdateBodyHash", mock.Anything, "src1", mock.Anything).Run(func(args mock.Arguments) {
+		hashes = append(hashes, args.String(2))
+	}).Return(nil)
+
+	for _, stamp := range []string{"Last updated: 2024-03-03 10:15", "Last updated:   2024-04-09 08:00"} {
+		body, _ := json.Marshal(map[string]interface{}{
+			"source_id": "src1",
+			"url":       "http://example.com",
+			"content":   "This guide explains how to install the command line tool on every platform.\n\n" + stamp + "\n",
+			"status":    "success",
+		})
+		require.NoError(t, consumer.HandleMessage(&nsq.Message{Body: body}))
+	}
+
+	require.Len(t, hashes, 2)
+	assert.Equal(t, hashes[0], hashes[1])
+	// Embedding still sees the original content
+	require.NotEmpty(t, embedded)
+	assert.Contains(t, embedded[0], "Last updated: 2024-03-03 10:15")
+}
+
+func TestResultConsumer_HandleMessage_AnchorKeywords(t *testing.T) {
+	s := new(MockVectorStore)
+	u := new(MockUpdater)
+	sf := new(MockSourceFetcher)
+	pm := new(MockPageManager)
+	tp := new(MockTaskPublisher)
+
+	consumer := worker.NewResultConsumer(s, u, nil, sf, pm, tp)
+	consumer.SetAnchorKeywords(true)
+
+	sf.On("GetSourceConfig", mock.Anything, "src1").Return(0, []string{}, "", "Src", nil)
+	sf.On("GetSourceOptions", mock.Anything, "src1").Return(&worker.SourceOptions{}, nil)
+	s.On("DeleteChunksByURL", mock.Anything, "src1", "http://example.com").Return(nil)
+	u.On("UpdateBodyHash", mock.Anything, "src1", mock.Anything).Return(nil)
+	pm.On("UpdatePageStatus", mock.Anything, "src1", "http://example.com", "completed", "").Return(nil)
+	pm.On("CountPendingPages", mock.Anything, "src1").Return(1, nil)
+
+	var published []worker.IngestEmbedPayload
+	tp.On("Publish", config.TopicIngestEmbed, mock.Anything).Run(func(args mock.Arguments) {
+		var p worker.IngestEmbedPayload
+		_ = json.Unmarshal(args.Get(1).([]byte), &p)
+		published = append(published, p)
+	}).Return(nil)
+
+	content := "# Webhooks\n\nWebhooks notify your service whenever an event happens in your account, so you never have to poll the API.\n\n" +
+		"## See also\n\n- [Configure webhooks](/docs/webhooks)\n- [Rotate API keys](/docs/keys)\n- [Home](/)\n"
+	body, _ := json.Marshal(map[string]interface{}{
+		"source_id": "src1",
+		"url":       "http://example.com",
+		"content":   content,
+		"status":    "success",
+	})
+	require.NoError(t, consumer.HandleMessage(&nsq.Message{Body: body}))
+
+	require.NotEmpty(t, published)
+	for _, p := range published {
+		// The nav list is filtered as a noise chunk...
+		assert.NotContains(t, p.Content, "[Rotate API keys]")
+		// ...but its anchor texts ride along on every chunk of the page
+		assert.Equal(t, []string{"Configure webhooks", "Rotate API keys"}, p.AnchorKeywords)
+	}
+}
+
+func TestResultConsumer_HandleMessage_TitlePath(t *testing.T) {
+	s := new(MockVectorStore)
+	u := new(MockUpdater)
+	sf := new(MockSourceFetcher)
+	pm := new(MockPageManager)
+	tp := new(MockTaskPublisher)
+
+	consumer := worker.NewResultConsumer(s, u, nil, sf, pm, tp)
+	consumer.SetTitlePath(true)
+
+	pageURL := "https://docs.example.com/guides/auth/webhooks.html"
+	sf.On("GetSourceConfig", mock.Anything, "src1").Return(0, []string{}, "", "Src", nil)
+	sf.On("GetSourceOptions", mock.Anything, "src1").Return(&worker.SourceOptions{}, nil)
+	s.On("DeleteChunksByURL", mock.Anything, "src1", pageURL).Return(nil)
+	u.On("UpdateBodyHash", mock.Anything, "src1", mock.Anything).Return(nil)
+	pm.On("UpdatePageStatus", mock.Anything, "src1", pageURL, "completed", "").Return(nil)
+	pm.On("CountPendingPages", mock.Anything, "src1").Return(1, nil)
+
+	var published []worker.IngestEmbedPayload
+	tp.On("Publish", config.TopicIngestEmbed, mock.Anything).Run(func(args mock.Arguments) {
+		var p worker.IngestEmbedPayload
+		_ = json.Unmarshal(args.Get(1).([]byte), &p)
+		published = append(published, p)
+	}).Return(nil)
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"source_id": "src1",
+		"url":       pageURL,
+		"content":   "# Webhooks\n\nWebhooks notify your service whenever an event happens in your account, so you never have to poll the API.",
+		"status":    "success",
+	})
+	require.NoError(t, consumer.HandleMessage(&nsq.Message{Body: body}))
+
+	require.NotEmpty(t, published)
+	for _, p := range published {
+		assert.Equal(t, "Guides > Auth > Webhooks", p.TitlePath)
+	}
+}
+
+func TestResultConsumer_HandleMessage_StoreErrors(t *testing.T) {
+	newConsumer := func(deleteErr error) (*worker.ResultConsumer, *MockJobRepo, *MockPageManager) {
+		s := new(MockVectorStore)
+		u := new(MockUpdater)
+		j := new(MockJobRepo)
+		sf := new(MockSourceFetcher)
+		pm := new(MockPageManager)
+		tp := new(MockTaskPublisher)
+
+		sf.On("GetSourceConfig", mock.Anything, "src1").Return(0, []string{}, "", "Src", nil)
+		sf.On("GetSourceOptions", mock.Anything, "src1").Return(&worker.SourceOptions{}, nil)
+		s.On("DeleteChunksByURL", mock.Anything, "src1", "http://example.com/a").Return(deleteErr)
+		pm.On("UpdatePageStatus", mock.Anything, "src1", "http://example.com/a", "failed", deleteErr.Error()).Return(nil)
+		pm.On("CountPendingPages", mock.Anything, "src1").Return(1, nil)
+		j.On("Save", mock.Anything, mock.Anything).Return(nil)
+
+		consumer := worker.NewResultConsumer(s, u, j, sf, pm, tp)
+		consumer.SetMaxResultAttempts(3)
+		return consumer, j, pm
+	}
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"source_id":        "src1",
+		"url":              "http://example.com/a",
+		"content":          "# Title\n\nSome content.",
+		"depth":            1,
+		"original_payload": map[string]interface{}{"type": "web", "url": "http://example.com/a", "id": "src1", "depth": 1},
+	})
+
+	t.Run("Transient error is retried", func(t *testing.T) {
+		storeErr := &fault.WeaviateClientError{IsUnexpectedStatusCode: true, StatusCode: 503, Msg: "unavailable"}
+		consumer, j, pm := newConsumer(storeErr)
+
+		err := consumer.HandleMessage(&nsq.Message{Body: body, Attempts: 1})
+		assert.ErrorIs(t, err, storeErr)
+		j.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+		pm.AssertNotCalled(t, "UpdatePageStatus", mock.Anything, "src1", "http://example.com/a", "failed", mock.Anything)
+	})
+
+	t.Run("Transient error past max attempts is acked", func(t *testing.T) {
+		storeErr := &fault.WeaviateClientError{IsUnexpectedStatusCode: true, StatusCode: 503, Msg: "unavailable"}
+		consumer, j, _ := newConsumer(storeErr)
+
+		err := consumer.HandleMessage(&nsq.Message{Body: body, Attempts: 3})
+		assert.NoError(t, err)
+		j.AssertNumberOfCalls(t, "Save", 1)
+	})
+
+	t.Run("Permanent error is acked with a job recorded", func(t *testing.T) {
+		storeErr := &fault.WeaviateClientError{IsUnexpectedStatusCode: true, StatusCode: 422, Msg: "invalid filter"}
+		consumer, j, pm := newConsumer(storeErr)
+
+		err := consumer.HandleMessage(&nsq.Message{Body: body, Attempts: 1})
+		assert.NoError(t, err)
+
+		pm.AssertCalled(t, "UpdatePageStatus", mock.Anything, "src1", "http://example.com/a", "failed", storeErr.Error())
+		j.AssertCalled(t, "Save", mock.Anything, mock.MatchedBy(func(saved *job.Job) bool {
+			var task map[string]interface{}
+			_ = json.Unmarshal(saved.Payload, &task)
+			return saved.SourceID == "src1" && saved.Error == storeErr.Error() && task["url"] == "http://example.com/a"
+		}))
+	})
+}
+
+func TestResultConsumer_HandleMessage_DeadLettersAfterMaxAttempts(t *testing.T) {
+	s := new(MockVectorStore)
+	u := new(MockUpdater)
+	sf := new(MockSourceFetcher)
+	pm := new(MockPageManager)
+	tp := new(MockTaskPublisher)
+
+	consumer := worker.NewResultConsumer(s, u, nil, sf, pm, tp)
+	consumer.SetMaxMessageAttempts(5)
+
+	sf.On("GetSourceConfig", mock.Anything, "src1").Return(0, []string{}, "", "Src", nil)
+	sf.On("GetSourceOptions", mock.Anything, "src1").Return(&worker.SourceOptions{}, nil)
+	s.On("DeleteChunksByURL", mock.Anything, "src1", "http://example.com").Return(assert.AnError)
+	tp.On("Publish", config.TopicIngestDeadLetter, mock.Anything).Return(nil)
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"source_id": "src1",
+		"url":       "http://example.com",
+		"content":   "# Title\n\nSome content.",
+	})
+
+	err := consumer.HandleMessage(&nsq.Message{Body: body, Attempts: 6})
+	assert.NoError(t, err)
+	tp.AssertCalled(t, "Publish", config.TopicIngestDeadLetter, mock.MatchedBy(func(b []byte) bool {
+		var letter worker.DeadLetter
+		return json.Unmarshal(b, &letter) == nil && letter.Topic == config.TopicIngestResult && letter.Attempts == 6
+	}))
+}
+
+func TestResultConsumer_HandleMessage_FallbackTitle(t *testing.T) {
+	s := new(MockVectorStore)
+	u := new(MockUpdater)
+	sf := new(MockSourceFetcher)
+	pm := new(MockPageManager)
+	tp := new(MockTaskPublisher)
+
+	consumer := worker.NewResultConsumer(s, u, nil, sf, pm, tp)
+	consumer.SetFallbackTitle(true)
+
+	pageURL := "https://docs.example.com/docs/getting-started"
+	sf.On("GetSourceConfig", mock.Anything, "src1").Return(0, []string{}, "", "Src", nil)
+	sf.On("GetSourceOptions", mock.Anything, "src1").Return(&worker.SourceOptions{}, nil)
+	s.On("DeleteChunksByURL", mock.Anything, "src1", pageURL).Return(nil)
+	u.On("UpdateBodyHash", mock.Anything, "src1", mock.Anything).Return(nil)
+	pm.On("UpdatePageStatus", mock.Anything, "src1", pageURL, "completed", "").Return(nil)
+	pm.On("CountPendingPages", mock.Anything, "src1").Return(1, nil)
+
+	var published []worker.IngestEmbedPayload
+	tp.On("Publish", config.TopicIngestEmbed, mock.Anything).Run(func(args mock.Arguments) {
+		var p worker.IngestEmbedPayload
+		_ = json.Unmarshal(args.Get(1).([]byte), &p)
+		published = append(published, p)
+	}).Return(nil)
+
+	// No title extracted by the crawler
+	body, _ := json.Marshal(map[string]interface{}{
+		"source_id": "src1",
+		"url":       pageURL,
+		"content":   "Install the CLI, create a project and run your first crawl against a documentation site of your choice.",
+		"status":    "success",
+	})
+	require.NoError(t, consumer.HandleMessage(&nsq.Message{Body: body}))
+
+	require.NotEmpty(t, published)
+	for _, p := range published {
+		assert.Equal(t, "Getting Started", p.Title)
+	}
+}
+
+func TestResultConsumer_HandleMessage_SkipsUnchangedPage(t *testing.T) {
+	content := "This page has not changed since the last crawl, so its chunks are still current."
+
+	newConsumer := func(stored string, opts *worker.SourceOptions) (*worker.ResultConsumer, *MockVectorStore, *MockPageManager, *MockTaskPublisher) {
+		s := new(MockVectorStore)
+		u := new(MockUpdater)
+		sf := new(MockSourceFetcher)
+		pm := new(MockPageManager)
+		tp := new(MockTaskPublisher)
+
+		sf.On("GetSourceConfig", mock.Anything, "src1").Return(0, []string{}, "", "Src", nil)
+		sf.On("GetSourceOptions", mock.Anything, "src1").Return(opts, nil)
+		u.On("UpdateBodyHash", mock.Anything, "src1", mock.Anything).Return(nil)
+		pm.On("GetPageHash", mock.Anything, "src1", "http://example.com").Return(stored, nil)
+		pm.On("UpdatePageStatus", mock.Anything, "src1", "http://example.com", "completed", "").Return(nil)
+		pm.On("CountPendingPages", mock.Anything, "src1").Return(1, nil)
+
+		consumer := worker.NewResultConsumer(s, u, nil, sf, pm, tp)
+		consumer.SetSkipUnchangedPages(true)
+		return consumer, s, pm, tp
+	}
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"source_id": "src1",
+		"url":       "http://example.com",
+		"content":   content,
+		"status":    "success",
+	})
+
+	// reindex handles body as a changed page and returns the key it is
+	// recorded under once its chunks are stored
+	reindex := func(t *testing.T, stored string, opts *worker.SourceOptions) string {
+		consumer, s, pm, tp := newConsumer(stored, opts)
+		var key string
+		s.On("DeleteChunksByURL", mock.Anything, "src1", "http://example.com").Return(nil)
+		pm.On("BeginPageHash", mock.Anything, "src1", "http://example.com", mock.Anything, 1).Run(func(args mock.Arguments) {
+			key = args.String(3)
+		}).Return(nil)
+		var embed worker.IngestEmbedPayload
+		tp.On("Publish", config.TopicIngestEmbed, mock.Anything).Run(func(args mock.Arguments) {
+			json.Unmarshal(args.Get(1).([]byte), &embed)
+		}).Return(nil)
+
+		require.NoError(t, consumer.HandleMessage(&nsq.Message{Body: body}))
+
+		s.AssertExpectations(t)
+		pm.AssertExpectations(t)
+		// Not recorded until the embedder has stored the chunk
+		pm.AssertNotCalled(t, "UpdatePageHash", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		require.NotEmpty(t, key)
+		assert.Equal(t, key, embed.PageHash)
+		assert.Equal(t, "http://example.com", embed.PageURL)
+		return key
+	}
+
+	key := reindex(t, "stale-hash", &worker.SourceOptions{})
+
+	t.Run("unchanged", func(t *testing.T) {
+		consumer, s, pm, tp := newConsumer(key, &worker.SourceOptions{})
+
+		require.NoError(t, consumer.HandleMessage(&nsq.Message{Body: body}))
+
+		s.AssertNotCalled(t, "DeleteChunksByURL", mock.Anything, mock.Anything, mock.Anything)
+		tp.AssertNotCalled(t, "Publish", config.TopicIngestEmbed, mock.Anything)
+		pm.AssertNotCalled(t, "UpdatePageHash", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		pm.AssertCalled(t, "UpdatePageStatus", mock.Anything, "src1", "http://example.com", "completed", "")
+	})
+
+	t.Run("embedding model changed", func(t *testing.T) {
+		newKey := reindex(t, key, &worker.SourceOptions{EmbeddingModel: "text-embedding-004"})
+		assert.NotEqual(t, key, newKey)
+	})
+}
+
+func TestResultConsumer_HandleMessage_PausedSourceHoldsNewPages(t *testing.T) {
+	s := new(MockVectorStore)
+	u := new(MockUpdater)
+	sf := new(MockSourceFetcher)
+	pm := new(MockPageManager)
+	tp := new(MockTaskPublisher)
+
+	consumer := worker.NewResultConsumer(s, u, nil, sf, pm, tp)
+
+	sf.On("GetSourceConfig", mock.Anything, "src1").Return(2, []string{}, "", "Src", nil)
+	sf.On("GetSourceOptions", mock.Anything, "src1").Return(&worker.SourceOptions{Paused: true}, nil)
+	s.On("DeleteChunksByURL", mock.Anything, "src1", "http://example.com").Return(nil)
+	tp.On("Publish", config.TopicIngestEmbed, mock.Anything).Return(nil)
+	u.On("UpdateBodyHash", mock.Anything, "src1", mock.Anything).Return(nil)
+	// Discovered pages are recorded as held, so a resume picks them up
+	pm.On("BulkCreatePages", mock.Anything, mock.MatchedBy(func(pages []worker.PageDTO) bool {
+		return len(pages) == 1 && pages[0].Status == worker.PageHeld
+	})).Return([]string{"http://example.com/next"}, nil)
+	pm.On("UpdatePageStatus", mock.Anything, "src1", "http://example.com", "completed", "").Return(nil)
+	pm.On("CountPendingPages", mock.Anything, "src1").Return(1, nil)
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"source_id": "src1",
+		"url":       "http://example.com",
+		"content":   "Content of a page crawled just before the source was paused.",
+		"status":    "success",
+		"links":     []string{"http://example.com/next"},
+	})
+	require.NoError(t, consumer.HandleMessage(&nsq.Message{Body: body}))
+
+	pm.AssertExpectations(t)
+	tp.AssertNotCalled(t, "Publish", config.TopicIngestWeb, mock.Anything)
+	u.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestResultConsumer_HandleMessage_RequeuesWithoutSourceSlot(t *testing.T) {
+	sf := new(MockSourceFetcher)
+	tp := new(MockTaskPublisher)
+
+	consumer := worker.NewResultConsumer(nil, nil, nil, sf, nil, tp)
+	limiter := worker.NewSourceLimiter(1)
+	require.True(t, limiter.TryAcquire("busy"))
+	consumer.SetSourceLimiter(limiter)
+
+	body, _ := json.Marshal(map[string]interface{}{"source_id": "waiting", "url": "http://example.com", "status": "success"})
+	rec := &requeueRecorder{}
+	msg := nsq.NewMessage(nsq.MessageID{}, body)
+	msg.Delegate = rec
+
+	assert.NoError(t, consumer.HandleMessage(msg))
+	assert.Equal(t, []time.Duration{5 * time.Second}, rec.requeued)
+	sf.AssertNotCalled(t, "GetSourceConfig", mock.Anything, mock.Anything)
+	tp.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+}
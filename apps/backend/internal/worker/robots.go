@@ -0,0 +1,254 @@
+package worker
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultRobotsUserAgent is the product token matched against robots.txt
+// User-agent lines.
+const DefaultRobotsUserAgent = "Qurio"
+
+// robotsCacheTTL bounds how long a source's robots.txt is reused, so a crawl
+// fetches it once per host but a later re-sync sees changes.
+const robotsCacheTTL = time.Hour
+
+// defaultRobotsTimeout bounds a robots.txt fetch. A host that is slow to
+// answer is treated as having no robots.txt rather than stalling the crawl.
+const defaultRobotsTimeout = 5 * time.Second
+
+// maxRobotsSize is how much of a robots.txt is read; RFC 9309 asks crawlers
+// to parse at least 500 KiB.
+const maxRobotsSize = 512 << 10
+
+// RobotsChecker reports whether a discovered URL may be crawled for a source.
+type RobotsChecker interface {
+	Allowed(ctx context.Context, sourceID, rawURL string) bool
+}
+
+// HTTPRobotsChecker fetches each host's robots.txt once per source crawl and
+// applies the rules for its user agent. A robots.txt that is missing or
+// cannot be fetched allows everything.
+type HTTPRobotsChecker struct {
+	userAgent string
+	client    *http.Client
+
+	mu    sync.Mutex
+	cache map[string]robotsEntry
+	now   func() time.Time
+}
+
+type robotsEntry struct {
+	rules   robotsRules
+	fetched time.Time
+}
+
+func NewHTTPRobotsChecker(userAgent string) *HTTPRobotsChecker {
+	if userAgent == "" {
+		userAgent = DefaultRobotsUserAgent
+	}
+	return &HTTPRobotsChecker{
+		userAgent: userAgent,
+		client:    &http.Client{Timeout: defaultRobotsTimeout},
+		cache:     make(map[string]robotsEntry),
+		now:       time.Now,
+	}
+}
+
+// SetTimeout bounds each robots.txt fetch; zero or less keeps the default.
+func (c *HTTPRobotsChecker) SetTimeout(d time.Duration) {
+	if d > 0 {
+		c.client.Timeout = d
+	}
+}
+
+// Allowed reports whether rawURL is allowed by its host's robots.txt.
+// Unparseable URLs are allowed and left to the crawler to reject.
+func (c *HTTPRobotsChecker) Allowed(ctx context.Context, sourceID, rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return true
+	}
+	path := u.EscapedPath()
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return c.rules(ctx, sourceID, u).allowed(path)
+}
+
+func (c *HTTPRobotsChecker) rules(ctx context.Context, sourceID string, u *url.URL) robotsRules {
+	key := sourceID + "\x00" + u.Scheme + "://" + u.Host
+
+	c.mu.Lock()
+	now := c.now()
+	for k, e := range c.cache {
+		if now.Sub(e.fetched) >= robotsCacheTTL {
+			delete(c.cache, k)
+		}
+	}
+	e, ok := c.cache[key]
+	c.mu.Unlock()
+	if ok {
+		return e.rules
+	}
+
+	rules := c.fetch(ctx, u)
+
+	c.mu.Lock()
+	c.cache[key] = robotsEntry{rules: rules, fetched: now}
+	c.mu.Unlock()
+	return rules
+}
+
+func (c *HTTPRobotsChecker) fetch(ctx context.Context, u *url.URL) robotsRules {
+	robotsURL := u.Scheme + "://" + u.Host + "/robots.txt"
+	req, err := http.NewRequestWithContext(ctx, "GET", robotsURL, nil)
+	if err != nil {
+		return nil
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+
+	resp, err := c.client.Do(req) // #nosec G704 -- robots.txt of a host the source is already crawling
+	if err != nil {
+		slog.WarnContext(ctx, "failed to fetch robots.txt, allowing all", "url", robotsURL, "error", err)
+		return nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		slog.DebugContext(ctx, "no robots.txt, allowing all", "url", robotsURL, "status", resp.StatusCode)
+		return nil
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxRobotsSize))
+	if err != nil {
+		slog.WarnContext(ctx, "failed to read robots.txt, allowing all", "url", robotsURL, "error", err)
+		return nil
+	}
+	return parseRobots(string(body), c.userAgent)
+}
+
+// FilterByRobots keeps only pages robots allows.
+func FilterByRobots(ctx context.Context, robots RobotsChecker, pages []PageDTO) []PageDTO {
+	var kept []PageDTO
+	for _, p := range pages {
+		if !robots.Allowed(ctx, p.SourceID, p.URL) {
+			slog.DebugContext(ctx, "skipping url disallowed by robots.txt", "source_id", p.SourceID, "url", p.URL)
+			continue
+		}
+		kept = append(kept, p)
+	}
+	return kept
+}
+
+// robotsRule is one Allow or Disallow line.
+type robotsRule struct {
+	pattern string
+	allow   bool
+}
+
+// robotsRules are the rules of the group that applies to our user agent.
+type robotsRules []robotsRule
+
+// allowed applies the most specific (longest) matching rule, with Allow
+// winning ties. A path no rule matches is allowed.
+func (r robotsRules) allowed(path string) bool {
+	if path == "" {
+		path = "/"
+	}
+	best, allow := -1, true
+	for _, rule := range r {
+		if !robotsMatch(rule.pattern, path) {
+			continue
+		}
+		if n := len(rule.pattern); n > best || (n == best && rule.allow) {
+			best, allow = n, rule.allow
+		}
+	}
+	return allow
+}
+
+// parseRobots returns the rules of the group naming userAgent, or of the "*"
+// group when none does.
+func parseRobots(body, userAgent string) robotsRules {
+	agent := strings.ToLower(userAgent)
+
+	var specific, wildcard robotsRules
+	var foundSpecific bool
+	var groupAgents []string
+	inRules := false
+
+	for _, line := range strings.Split(body, "\n") {
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		field, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		field = strings.ToLower(strings.TrimSpace(field))
+		value = strings.TrimSpace(value)
+
+		switch field {
+		case "user-agent":
+			// A user-agent line after rules starts a new group
+			if inRules {
+				groupAgents, inRules = nil, false
+			}
+			a := strings.ToLower(value)
+			if a != "" && a != "*" && strings.Contains(agent, a) {
+				foundSpecific = true
+			}
+			groupAgents = append(groupAgents, a)
+		case "allow", "disallow":
+			inRules = true
+			if value == "" {
+				// An empty Disallow allows everything; it adds no rule
+				continue
+			}
+			rule := robotsRule{pattern: value, allow: field == "allow"}
+			for _, a := range groupAgents {
+				if a == "*" {
+					wildcard = append(wildcard, rule)
+				} else if a != "" && strings.Contains(agent, a) {
+					specific = append(specific, rule)
+				}
+			}
+		}
+	}
+
+	if foundSpecific {
+		return specific
+	}
+	return wildcard
+}
+
+// robotsMatch reports whether path matches a robots.txt pattern, where "*"
+// matches any run of characters and a trailing "$" anchors the end.
+func robotsMatch(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	pos := len(parts[0])
+	for i, part := range parts[1:] {
+		if anchored && i == len(parts)-2 {
+			// The last part must end the path
+			return strings.HasSuffix(path[pos:], part)
+		}
+		idx := strings.Index(path[pos:], part)
+		if idx < 0 {
+			return false
+		}
+		pos += idx + len(part)
+	}
+	return !anchored || pos == len(path)
+}
@@ -0,0 +1,84 @@
+package worker
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPRobotsChecker_Allowed(t *testing.T) {
+	fetches := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/robots.txt" {
+			http.NotFound(w, r)
+			return
+		}
+		fetches++
+		w.Write([]byte(`# Example
+User-agent: *
+Disallow: /private/
+Allow: /private/public-docs
+Disallow: /*.pdf$
+
+User-agent: OtherBot
+Disallow: /
+`))
+	}))
+	defer ts.Close()
+
+	c := NewHTTPRobotsChecker("")
+	ctx := context.Background()
+
+	assert.False(t, c.Allowed(ctx, "src1", ts.URL+"/private/keys"), "disallowed path")
+	assert.True(t, c.Allowed(ctx, "src1", ts.URL+"/docs/intro"), "allowed path")
+	assert.True(t, c.Allowed(ctx, "src1", ts.URL+"/private/public-docs/setup"), "longer Allow wins")
+	assert.False(t, c.Allowed(ctx, "src1", ts.URL+"/files/guide.pdf"), "anchored wildcard")
+	assert.True(t, c.Allowed(ctx, "src1", ts.URL+"/files/guide.pdf.html"))
+	assert.Equal(t, 1, fetches, "robots.txt is fetched once per crawl")
+
+	// A later crawl of the same source fetches it again
+	c.now = func() time.Time { return time.Now().Add(robotsCacheTTL) }
+	c.Allowed(ctx, "src1", ts.URL+"/docs/intro")
+	assert.Equal(t, 2, fetches)
+}
+
+func TestHTTPRobotsChecker_MissingRobotsAllowsAll(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	defer ts.Close()
+
+	c := NewHTTPRobotsChecker("")
+	assert.True(t, c.Allowed(context.Background(), "src1", ts.URL+"/private/keys"))
+}
+
+func TestHTTPRobotsChecker_TimeoutAllowsAll(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Write([]byte("User-agent: *\nDisallow: /\n"))
+	}))
+	defer ts.Close()
+	defer close(release)
+
+	c := NewHTTPRobotsChecker("")
+	c.SetTimeout(50 * time.Millisecond)
+
+	start := time.Now()
+	assert.True(t, c.Allowed(context.Background(), "src1", ts.URL+"/private/keys"))
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestParseRobots_SpecificAgentGroup(t *testing.T) {
+	body := `User-agent: *
+Disallow: /
+
+User-agent: qurio
+Disallow:
+`
+	// A group naming us replaces the * group, even with no rules
+	assert.True(t, parseRobots(body, "Qurio").allowed("/docs"))
+	assert.False(t, parseRobots(body, "SomeoneElse").allowed("/docs"))
+}
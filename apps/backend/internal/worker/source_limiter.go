@@ -0,0 +1,48 @@
+package worker
+
+import "sync"
+
+// SourceLimiter caps how many distinct sources are processed at the same time.
+// Messages for a source that already holds a slot proceed immediately; messages
+// for a new source are turned away until one of the active sources has drained.
+type SourceLimiter struct {
+	max    int
+	mu     sync.Mutex
+	active map[string]int
+}
+
+func NewSourceLimiter(max int) *SourceLimiter {
+	return &SourceLimiter{
+		max:    max,
+		active: make(map[string]int),
+	}
+}
+
+// TryAcquire takes a slot for sourceID, or shares the one it already holds.
+// It reports false when every slot is held by other sources.
+func (l *SourceLimiter) TryAcquire(sourceID string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.active[sourceID]; ok || len(l.active) < l.max {
+		l.active[sourceID]++
+		return true
+	}
+	return false
+}
+
+// Release marks one in-flight message for sourceID as done. The source gives up
+// its slot once it has no in-flight messages left.
+func (l *SourceLimiter) Release(sourceID string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	n, ok := l.active[sourceID]
+	if !ok {
+		return
+	}
+	if n > 1 {
+		l.active[sourceID] = n - 1
+		return
+	}
+	delete(l.active, sourceID)
+}
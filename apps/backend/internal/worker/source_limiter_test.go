@@ -0,0 +1,22 @@
+package worker_test
+
+import (
+	"testing"
+
+	"qurio/apps/backend/internal/worker"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSourceLimiter_TryAcquire(t *testing.T) {
+	l := worker.NewSourceLimiter(1)
+
+	assert.True(t, l.TryAcquire("src-a"))
+	assert.True(t, l.TryAcquire("src-a"), "an active source shares its slot")
+	assert.False(t, l.TryAcquire("src-b"))
+
+	l.Release("src-a")
+	assert.False(t, l.TryAcquire("src-b"), "the slot is held until the last message is released")
+	l.Release("src-a")
+	assert.True(t, l.TryAcquire("src-b"))
+}
@@ -0,0 +1,104 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// storeBatchTimeout bounds one batched store call. Callers stop waiting
+// earlier if their own context ends.
+const storeBatchTimeout = 60 * time.Second
+
+// storeBatcher coalesces StoreChunk calls from concurrent message handlers
+// into StoreChunks calls. A batch is sent once it holds size chunks, or wait
+// after its first chunk arrived, whichever comes first.
+type storeBatcher struct {
+	store BatchVectorStore
+	size  int
+	wait  time.Duration
+
+	mu      sync.Mutex
+	pending []*storeItem
+	timer   *time.Timer
+}
+
+type storeItem struct {
+	chunk Chunk
+	done  chan error
+}
+
+func newStoreBatcher(s BatchVectorStore, size int, wait time.Duration) *storeBatcher {
+	return &storeBatcher{store: s, size: size, wait: wait}
+}
+
+// StoreChunk queues chunk for the next batch and blocks until the batch is
+// written or ctx is done. When the store reports which chunks failed, only
+// those fail; any other error fails every chunk in the batch.
+func (b *storeBatcher) StoreChunk(ctx context.Context, chunk Chunk) error {
+	item := &storeItem{chunk: chunk, done: make(chan error, 1)}
+
+	b.mu.Lock()
+	b.pending = append(b.pending, item)
+	var full []*storeItem
+	if len(b.pending) >= b.size {
+		full = b.take()
+	} else if len(b.pending) == 1 {
+		b.timer = time.AfterFunc(b.wait, b.flush)
+	}
+	b.mu.Unlock()
+
+	if full != nil {
+		go b.send(full)
+	}
+
+	select {
+	case err := <-item.done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// take empties the queue. The caller holds mu.
+func (b *storeBatcher) take() []*storeItem {
+	items := b.pending
+	b.pending = nil
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	return items
+}
+
+func (b *storeBatcher) flush() {
+	b.mu.Lock()
+	items := b.take()
+	b.mu.Unlock()
+	if len(items) > 0 {
+		b.send(items)
+	}
+}
+
+func (b *storeBatcher) send(items []*storeItem) {
+	ctx, cancel := context.WithTimeout(context.Background(), storeBatchTimeout)
+	defer cancel()
+
+	chunks := make([]Chunk, len(items))
+	for i, item := range items {
+		chunks[i] = item.chunk
+	}
+
+	err := b.store.StoreChunks(ctx, chunks)
+	var chunkErrs ChunkErrors
+	if errors.As(err, &chunkErrs) {
+		for i, item := range items {
+			item.done <- chunkErrs[i]
+		}
+		return
+	}
+	for _, item := range items {
+		item.done <- err
+	}
+}
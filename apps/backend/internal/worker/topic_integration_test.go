@@ -22,6 +22,10 @@ func (m *MockChunkStore) GetChunks(ctx context.Context, sourceID string, limit,
 	return nil, nil
 }
 
+func (m *MockChunkStore) GetPageChunks(ctx context.Context, sourceID, url string) ([]worker.Chunk, error) {
+	return nil, nil
+}
+
 func (m *MockChunkStore) DeleteChunksBySourceID(ctx context.Context, sourceID string) error {
 	return nil
 }
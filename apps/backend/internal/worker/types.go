@@ -2,9 +2,15 @@ package worker
 
 import (
 	"context"
+	"fmt"
+	"time"
 )
 
 type Chunk struct {
+	// ID is the vector store object ID. Set only for chunks read back from
+	// the store; storing a chunk with an ID replaces that object.
+	ID string `json:"id,omitempty"`
+
 	Content    string    `json:"content"`
 	Vector     []float32 `json:"vector"`
 	SourceURL  string    `json:"source_url"`
@@ -17,23 +23,119 @@ type Chunk struct {
 	Author     string    `json:"author"`
 	CreatedAt  string    `json:"created_at"`
 	PageCount  int       `json:"page_count"`
+
+	// Metadata is the owning source's custom key/value metadata.
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// Truncated is set when the embedding was computed from a truncated input.
+	Truncated bool `json:"truncated,omitempty"`
+
+	// Selector is the CSS selector or XPath of the DOM region the page
+	// content was extracted from. Empty for non-HTML sources.
+	Selector string `json:"selector,omitempty"`
+
+	// AliasURL is the URL the page was fetched from when the chunk is
+	// indexed under the page's canonical URL.
+	AliasURL string `json:"alias_url,omitempty"`
+
+	// EmbeddingModel is the source's model override the vector was computed
+	// with. Empty means the default model.
+	EmbeddingModel string `json:"embedding_model,omitempty"`
+
+	// AnchorKeywords are descriptive link texts from the chunk's page, stored
+	// for keyword search only.
+	AnchorKeywords []string `json:"anchor_keywords,omitempty"`
+
+	// TitlePath is the page's breadcrumb, e.g. "Guides > Auth > Webhooks".
+	TitlePath string `json:"title_path,omitempty"`
+
+	// Heading is the path of headings the chunk sits under on its page,
+	// e.g. "# API > ## Authentication".
+	Heading string `json:"heading,omitempty"`
+
+	// Overlap is the length in bytes of the text at the start of Content
+	// repeated from the end of the previous chunk of the page.
+	Overlap int `json:"overlap,omitempty"`
 }
 
 type Embedder interface {
 	Embed(ctx context.Context, text string) ([]float32, error)
 }
 
+// ModelEmbedder is implemented by embedders that can embed with a model other
+// than their default.
+type ModelEmbedder interface {
+	EmbedWithModel(ctx context.Context, model, text string) ([]float32, error)
+}
+
+// BatchEmbedder is implemented by embedders that can embed several texts in
+// one provider call. Vectors are returned in input order.
+type BatchEmbedder interface {
+	EmbedBatch(ctx context.Context, texts []string) ([][]float32, error)
+}
+
 type VectorStore interface {
 	StoreChunk(ctx context.Context, chunk Chunk) error
 	DeleteChunksByURL(ctx context.Context, sourceID, url string) error
 }
 
+// BatchVectorStore is implemented by stores that can write several chunks in
+// one request. When only some chunks fail, StoreChunks returns ChunkErrors so
+// callers can tell which ones; any other error applies to all of them.
+type BatchVectorStore interface {
+	StoreChunks(ctx context.Context, chunks []Chunk) error
+}
+
+// ChunkErrors maps the index of each chunk a StoreChunks call failed to store
+// to its error. Chunks without an entry were stored.
+type ChunkErrors map[int]error
+
+func (e ChunkErrors) Error() string {
+	first := -1
+	for i := range e {
+		if first < 0 || i < first {
+			first = i
+		}
+	}
+	if len(e) == 1 {
+		return fmt.Sprintf("chunk %d: %v", first, e[first])
+	}
+	return fmt.Sprintf("%d chunks failed, first chunk %d: %v", len(e), first, e[first])
+}
+
 type SourceStatusUpdater interface {
 	UpdateStatus(ctx context.Context, id, status string) error
 	UpdateBodyHash(ctx context.Context, id, hash string) error
 }
 
+// SourceOptions carries optional per-source ingestion settings that are
+// propagated alongside the crawl config.
+type SourceOptions struct {
+	Metadata         map[string]string
+	EmbedTitlePrefix bool
+	CrawlDelay       time.Duration
+	KeywordOnlyTypes []string
+	// SeedPathPrefix, when set, restricts discovered links to URLs whose
+	// path starts with it.
+	SeedPathPrefix string
+	// CrawlSubdomains accepts links to other subdomains of the page's
+	// registered domain.
+	CrawlSubdomains bool
+	// EmbeddingModel, when set, is used instead of the default model for
+	// this source's chunks.
+	EmbeddingModel string
+	// Paused holds back tasks for newly discovered pages; they are recorded
+	// as PageHeld until the crawl is resumed.
+	Paused bool
+}
+
+// PageHeld is the status of a page that was discovered but not enqueued,
+// because its source was paused or its crawl slot lay beyond MaxDeferral.
+// Held pages are published later by the source service.
+const PageHeld = "held"
+
 type SourceFetcher interface {
 	GetSourceDetails(ctx context.Context, id string) (string, string, error)
 	GetSourceConfig(ctx context.Context, id string) (int, []string, string, string, error)
+	GetSourceOptions(ctx context.Context, id string) (*SourceOptions, error)
 }
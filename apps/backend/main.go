@@ -52,13 +52,24 @@ func run(ctx context.Context, cfg *config.Config, logger *slog.Logger) error {
 		return fmt.Errorf("failed to initialize app: %w", err)
 	}
 
+	// Open the readiness gate once the vector schema is confirmed
+	go func() {
+		if err := application.AwaitSchema(ctx); err != nil {
+			slog.Error("search routes will stay unavailable", "error", err)
+		}
+	}()
+
 	// 4. Worker (Result Consumer) Setup
 	nsqCfg := nsq.NewConfig()
 	// nsqCfg.MaxMsgSize = cfg.NSQMaxMsgSize // Field undefined in go-nsq v1.1.0
+	// Consumers dead-letter a message on its last attempt; go-nsq would
+	// otherwise drop it silently after its own default of 5.
+	nsqCfg.MaxAttempts = uint16(cfg.MaxMessageAttempts)
 	consumer, err := nsq.NewConsumer(config.TopicIngestResult, "backend", nsqCfg)
 	if err != nil {
 		slog.Error("failed to create NSQ consumer for results", "error", err)
 	} else {
+		application.AddConsumer(consumer)
 		// Use AddConcurrentHandlers
 		consumer.AddConcurrentHandlers(nsq.HandlerFunc(func(m *nsq.Message) error {
 			return application.ResultConsumer.HandleMessage(m)
@@ -82,13 +93,19 @@ func run(ctx context.Context, cfg *config.Config, logger *slog.Logger) error {
 
 	// 5. Worker (Embedder Consumer) Setup
 	if application.EmbedderConsumer != nil {
-		consumer, err := nsq.NewConsumer(config.TopicIngestEmbed, "backend-embedder", nsqCfg)
+		// Batched embedding needs a full batch of messages in flight at once
+		embedConcurrency := max(cfg.IngestionConcurrency, cfg.EmbedBatchSize)
+		embedCfg := nsq.NewConfig()
+		embedCfg.MaxAttempts = nsqCfg.MaxAttempts
+		embedCfg.MaxInFlight = embedConcurrency
+		consumer, err := nsq.NewConsumer(config.TopicIngestEmbed, "backend-embedder", embedCfg)
 		if err != nil {
 			slog.Error("failed to create NSQ consumer for embed", "error", err)
 		} else {
+			application.AddConsumer(consumer)
 			consumer.AddConcurrentHandlers(nsq.HandlerFunc(func(m *nsq.Message) error {
 				return application.EmbedderConsumer.HandleMessage(m)
-			}), cfg.IngestionConcurrency)
+			}), embedConcurrency)
 
 			if cfg.NSQLookupd != "" {
 				if err := consumer.ConnectToNSQLookupd(cfg.NSQLookupd); err != nil {
@@ -122,6 +139,38 @@ func run(ctx context.Context, cfg *config.Config, logger *slog.Logger) error {
 		}
 	}()
 
+	// Held Page Releaser
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := application.SourceService.ReleaseHeldPages(context.Background()); err != nil {
+					slog.Error("failed to release held pages", "error", err)
+				}
+			}
+		}
+	}()
+
+	// Failed Job Retrier
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := application.JobService.RetryDue(context.Background()); err != nil {
+					slog.Error("failed to retry due jobs", "error", err)
+				}
+			}
+		}
+	}()
+
 	// 5. Start Server
 	if cfg.EnableAPI {
 		if err := application.Run(ctx); err != nil {
@@ -130,6 +179,7 @@ func run(ctx context.Context, cfg *config.Config, logger *slog.Logger) error {
 	} else {
 		slog.Info("API disabled, running in worker mode")
 		<-ctx.Done()
+		application.StopConsumers()
 	}
 	return nil
 }
@@ -0,0 +1 @@
+ALTER TABLE sources ADD COLUMN metadata JSONB NOT NULL DEFAULT '{}';
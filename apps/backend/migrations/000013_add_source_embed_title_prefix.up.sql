@@ -0,0 +1 @@
+ALTER TABLE sources ADD COLUMN embed_title_prefix BOOLEAN NOT NULL DEFAULT FALSE;
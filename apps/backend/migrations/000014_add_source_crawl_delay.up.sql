@@ -0,0 +1 @@
+ALTER TABLE sources ADD COLUMN crawl_delay_ms INTEGER NOT NULL DEFAULT 0;
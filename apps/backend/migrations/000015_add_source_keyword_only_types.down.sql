@@ -0,0 +1 @@
+ALTER TABLE sources DROP COLUMN keyword_only_types;
@@ -0,0 +1 @@
+ALTER TABLE sources ADD COLUMN keyword_only_types TEXT[] NOT NULL DEFAULT '{}';
@@ -0,0 +1 @@
+ALTER TABLE sources DROP COLUMN restrict_to_seed_path;
@@ -0,0 +1 @@
+ALTER TABLE sources ADD COLUMN restrict_to_seed_path BOOLEAN NOT NULL DEFAULT FALSE;
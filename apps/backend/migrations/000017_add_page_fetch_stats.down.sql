@@ -0,0 +1,2 @@
+ALTER TABLE source_pages DROP COLUMN fetch_ms;
+ALTER TABLE source_pages DROP COLUMN status_code;
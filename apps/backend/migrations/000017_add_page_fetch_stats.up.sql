@@ -0,0 +1,2 @@
+ALTER TABLE source_pages ADD COLUMN status_code INTEGER;
+ALTER TABLE source_pages ADD COLUMN fetch_ms INTEGER;
@@ -0,0 +1 @@
+ALTER TABLE sources ADD COLUMN embedding_model TEXT NOT NULL DEFAULT '';
@@ -0,0 +1 @@
+ALTER TABLE settings ADD COLUMN embedding_model TEXT NOT NULL DEFAULT '';
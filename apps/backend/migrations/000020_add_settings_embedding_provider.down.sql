@@ -0,0 +1,3 @@
+ALTER TABLE settings DROP COLUMN embedding_api_key;
+ALTER TABLE settings DROP COLUMN embedding_base_url;
+ALTER TABLE settings DROP COLUMN embedding_provider;
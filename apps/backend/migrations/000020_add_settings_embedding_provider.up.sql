@@ -0,0 +1,3 @@
+ALTER TABLE settings ADD COLUMN embedding_provider TEXT NOT NULL DEFAULT 'gemini';
+ALTER TABLE settings ADD COLUMN embedding_base_url TEXT NOT NULL DEFAULT '';
+ALTER TABLE settings ADD COLUMN embedding_api_key TEXT NOT NULL DEFAULT '';
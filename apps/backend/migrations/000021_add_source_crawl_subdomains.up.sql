@@ -0,0 +1 @@
+ALTER TABLE sources ADD COLUMN crawl_subdomains BOOLEAN NOT NULL DEFAULT FALSE;
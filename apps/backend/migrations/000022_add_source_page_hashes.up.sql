@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS source_page_hashes (
+    source_id UUID NOT NULL REFERENCES sources(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    content_hash TEXT NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (source_id, url)
+);
@@ -0,0 +1,4 @@
+ALTER TABLE failed_jobs
+    DROP COLUMN IF EXISTS status,
+    DROP COLUMN IF EXISTS next_retry_at,
+    DROP COLUMN IF EXISTS attempts;
@@ -0,0 +1,4 @@
+ALTER TABLE failed_jobs
+    ADD COLUMN IF NOT EXISTS attempts INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS next_retry_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'failed';
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_failed_jobs_source_url;
+ALTER TABLE failed_jobs
+    DROP COLUMN IF EXISTS url;
@@ -0,0 +1,4 @@
+ALTER TABLE failed_jobs
+    ADD COLUMN IF NOT EXISTS url TEXT NOT NULL DEFAULT '';
+-- One job per failing page, so its attempt count carries over between failures
+CREATE UNIQUE INDEX IF NOT EXISTS idx_failed_jobs_source_url ON failed_jobs (source_id, url) WHERE url <> '';
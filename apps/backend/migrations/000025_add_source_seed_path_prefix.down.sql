@@ -0,0 +1 @@
+ALTER TABLE sources DROP COLUMN seed_path_prefix;
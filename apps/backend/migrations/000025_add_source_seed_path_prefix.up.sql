@@ -0,0 +1 @@
+ALTER TABLE sources ADD COLUMN seed_path_prefix TEXT NOT NULL DEFAULT '';
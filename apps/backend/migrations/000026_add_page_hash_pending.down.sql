@@ -0,0 +1,3 @@
+ALTER TABLE source_page_hashes
+    DROP COLUMN IF EXISTS pending_chunks,
+    DROP COLUMN IF EXISTS pending_hash;
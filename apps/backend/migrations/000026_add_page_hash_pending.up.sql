@@ -0,0 +1,3 @@
+ALTER TABLE source_page_hashes
+    ADD COLUMN IF NOT EXISTS pending_hash TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS pending_chunks INT NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+ALTER TABLE failed_jobs
+    DROP COLUMN IF EXISTS claimed_at;
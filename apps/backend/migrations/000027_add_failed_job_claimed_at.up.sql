@@ -0,0 +1,2 @@
+ALTER TABLE failed_jobs
+    ADD COLUMN IF NOT EXISTS claimed_at TIMESTAMP WITH TIME ZONE;
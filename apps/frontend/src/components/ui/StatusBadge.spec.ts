@@ -27,6 +27,14 @@ describe("StatusBadge", () => {
     expect(wrapper.text().toLowerCase()).toBe("failed");
   });
 
+  it("renders warning variant for completed_with_errors status", () => {
+    const wrapper = mount(StatusBadge, {
+      props: { status: "completed_with_errors" },
+    });
+    expect(wrapper.findComponent(Badge).props("variant")).toBe("warning");
+    expect(wrapper.text().toLowerCase()).toBe("completed_with_errors");
+  });
+
   it("renders correct variant for in_progress status", () => {
     const wrapper = mount(StatusBadge, {
       props: { status: "in_progress" },
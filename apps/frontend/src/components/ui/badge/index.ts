@@ -14,6 +14,8 @@ export const badgeVariants = cva(
           "border-transparent bg-secondary text-secondary-foreground hover:bg-secondary/80",
         destructive:
           "border-transparent bg-destructive text-destructive-foreground shadow hover:bg-destructive/80",
+        warning:
+          "border-transparent bg-amber-500 text-white shadow hover:bg-amber-500/80",
         outline: "text-foreground",
       },
     },
@@ -112,6 +112,9 @@ describe("Settings Store", () => {
           rerank_provider: "jina",
           rerank_api_key: "rk-123",
           gemini_api_key: "gk-456",
+          embedding_model: "text-embedding-004",
+          embedding_provider: "openai",
+          embedding_base_url: "http://localhost:8080/v1",
           search_alpha: 0.7,
           search_top_k: 30,
         },
@@ -123,6 +126,9 @@ describe("Settings Store", () => {
     expect(store.rerankProvider).toBe("jina");
     expect(store.rerankApiKey).toBe("rk-123");
     expect(store.geminiApiKey).toBe("gk-456");
+    expect(store.embeddingModel).toBe("text-embedding-004");
+    expect(store.embeddingProvider).toBe("openai");
+    expect(store.embeddingBaseUrl).toBe("http://localhost:8080/v1");
     expect(store.searchAlpha).toBe(0.7);
     expect(store.searchTopK).toBe(30);
   });
@@ -5,6 +5,10 @@ export const useSettingsStore = defineStore("settings", () => {
   const rerankProvider = ref("none");
   const rerankApiKey = ref("");
   const geminiApiKey = ref("");
+  const embeddingModel = ref("");
+  const embeddingProvider = ref("gemini");
+  const embeddingBaseUrl = ref("");
+  const embeddingApiKey = ref("");
   const searchAlpha = ref(0.5);
   const searchTopK = ref(20);
   const isLoading = ref(false);
@@ -22,6 +26,10 @@ export const useSettingsStore = defineStore("settings", () => {
       rerankProvider.value = data.rerank_provider || "none";
       rerankApiKey.value = data.rerank_api_key || "";
       geminiApiKey.value = data.gemini_api_key || "";
+      embeddingModel.value = data.embedding_model || "";
+      embeddingProvider.value = data.embedding_provider || "gemini";
+      embeddingBaseUrl.value = data.embedding_base_url || "";
+      embeddingApiKey.value = data.embedding_api_key || "";
       searchAlpha.value = data.search_alpha ?? 0.5;
       searchTopK.value = data.search_top_k ?? 20;
     } catch (e: unknown) {
@@ -47,6 +55,10 @@ export const useSettingsStore = defineStore("settings", () => {
           rerank_provider: rerankProvider.value,
           rerank_api_key: rerankApiKey.value,
           gemini_api_key: geminiApiKey.value,
+          embedding_model: embeddingModel.value,
+          embedding_provider: embeddingProvider.value,
+          embedding_base_url: embeddingBaseUrl.value,
+          embedding_api_key: embeddingApiKey.value,
           search_alpha: searchAlpha.value,
           search_top_k: searchTopK.value,
         }),
@@ -69,6 +81,10 @@ export const useSettingsStore = defineStore("settings", () => {
     rerankProvider,
     rerankApiKey,
     geminiApiKey,
+    embeddingModel,
+    embeddingProvider,
+    embeddingBaseUrl,
+    embeddingApiKey,
     searchAlpha,
     searchTopK,
     isLoading,
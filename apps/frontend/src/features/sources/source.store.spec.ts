@@ -28,6 +28,37 @@ describe("Source Store", () => {
     expect(store.error).toBeNull();
   });
 
+  it("fetchSources follows pages until meta.count is reached", async () => {
+    const store = useSourceStore();
+    const firstPage = Array.from({ length: 100 }, (_, i) => ({
+      id: String(i),
+      name: `Source ${i}`,
+    }));
+    const secondPage = [{ id: "100", name: "Source 100" }];
+
+    fetchMock
+      .mockResolvedValueOnce({
+        ok: true,
+        json: async () => ({ data: firstPage, meta: { count: 100, total: 101 } }),
+      })
+      .mockResolvedValueOnce({
+        ok: true,
+        json: async () => ({ data: secondPage, meta: { count: 1, total: 101 } }),
+      });
+
+    await store.fetchSources();
+
+    expect(fetchMock).toHaveBeenNthCalledWith(
+      1,
+      "/api/sources?limit=100&offset=0",
+    );
+    expect(fetchMock).toHaveBeenNthCalledWith(
+      2,
+      "/api/sources?limit=100&offset=100",
+    );
+    expect(store.sources).toHaveLength(101);
+  });
+
   it("fetchSources handles error", async () => {
     const store = useSourceStore();
 
@@ -37,6 +37,9 @@ export interface SourcePage {
   updated_at: string;
 }
 
+// SOURCES_PAGE_SIZE is the largest page GET /sources returns.
+const SOURCES_PAGE_SIZE = 100;
+
 export const useSourceStore = defineStore("sources", () => {
   const sources = ref<Source[]>([]);
   const isLoading = ref(false);
@@ -47,12 +50,26 @@ export const useSourceStore = defineStore("sources", () => {
     if (!background) isLoading.value = true;
     error.value = null;
     try {
-      const res = await fetch("/api/sources");
-      if (!res.ok) {
-        throw new Error(`Failed to fetch sources: ${res.statusText}`);
+      // The API pages its results; the list shows every source
+      const all: Source[] = [];
+      for (;;) {
+        const res = await fetch(
+          `/api/sources?limit=${SOURCES_PAGE_SIZE}&offset=${all.length}`,
+        );
+        if (!res.ok) {
+          throw new Error(`Failed to fetch sources: ${res.statusText}`);
+        }
+        const json = await res.json();
+        const page: Source[] = json.data || [];
+        all.push(...page);
+        if (
+          page.length < SOURCES_PAGE_SIZE ||
+          all.length >= (json.meta?.total ?? 0)
+        ) {
+          break;
+        }
       }
-      const json = await res.json();
-      sources.value = json.data || [];
+      sources.value = all;
     } catch (e: unknown) {
       const message = e instanceof Error ? e.message : "Unknown error";
       error.value = message;
@@ -5,6 +5,7 @@ export interface Stats {
   sources: number;
   documents: number;
   failed_jobs: number;
+  warnings?: string[];
 }
 
 export const useStatsStore = defineStore("stats", () => {
@@ -216,6 +216,37 @@ describe("SourceDetailView", () => {
     expect(store.pollSourceStatus).not.toHaveBeenCalled();
   });
 
+  it("stops polling when source completes with errors", async () => {
+    const source = {
+      id: "src-1",
+      name: "Test",
+      status: "in_progress",
+      url: "http://example.com",
+      type: "web",
+      total_chunks: 0,
+      chunks: [],
+    };
+    const { store } = mountView(source, []);
+    await flushPromises();
+
+    vi.spyOn(store, "pollSourceStatus").mockResolvedValue({
+      id: "src-1",
+      name: "Test",
+      status: "completed_with_errors",
+      total_chunks: 5,
+    } as any); // eslint-disable-line @typescript-eslint/no-explicit-any
+
+    vi.advanceTimersByTime(2000);
+    await flushPromises();
+
+    (store.pollSourceStatus as Mock).mockClear();
+
+    vi.advanceTimersByTime(2000);
+    await flushPromises();
+
+    expect(store.pollSourceStatus).not.toHaveBeenCalled();
+  });
+
   it("clears polling interval on unmount", async () => {
     const source = {
       id: "src-1",
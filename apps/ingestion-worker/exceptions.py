@@ -1,6 +1,7 @@
 class IngestionError(Exception):
-    def __init__(self, code, message):
+    def __init__(self, code, message, status_code=None):
         self.code = code
+        self.status_code = status_code
         super().__init__(message)
 
 
@@ -15,6 +16,7 @@ ERR_CRAWL_TIMEOUT = "ERR_CRAWL_TIMEOUT"
 ERR_CRAWL_DNS = "ERR_CRAWL_DNS"
 ERR_CRAWL_REFUSED = "ERR_CRAWL_REFUSED"
 ERR_CRAWL_BLOCKED = "ERR_CRAWL_BLOCKED"
+ERR_CRAWL_GONE = "ERR_CRAWL_GONE"
 
 # Transient error codes (eligible for automatic retry)
 TRANSIENT_ERRORS = {ERR_TIMEOUT, ERR_CRAWL_TIMEOUT, ERR_CRAWL_DNS, ERR_CRAWL_REFUSED}
@@ -16,6 +16,7 @@ from exceptions import (
     ERR_CRAWL_DNS,
     ERR_CRAWL_REFUSED,
     ERR_CRAWL_BLOCKED,
+    ERR_CRAWL_GONE,
     TRANSIENT_ERRORS,
 )
 
@@ -135,6 +136,32 @@ def _get_raw_markdown(result: Any) -> str:
     return str(md) if md else ""
 
 
+_CANONICAL_RE = re.compile(
+    r"<link\b[^>]*\brel=[\"']?canonical[\"']?[^>]*>", re.IGNORECASE
+)
+_HREF_RE = re.compile(r"\bhref=[\"']([^\"']+)[\"']", re.IGNORECASE)
+
+
+def extract_canonical_url(result: Any, url: str) -> str:
+    """
+    Returns the absolute <link rel="canonical"> URL of the page, or "" when the
+    page declares none or it matches the fetched URL.
+    """
+    html = getattr(result, "html", None)
+    if not isinstance(html, str) or not html:
+        return ""
+    tag = _CANONICAL_RE.search(html)
+    if not tag:
+        return ""
+    href = _HREF_RE.search(tag.group(0))
+    if not href:
+        return ""
+    canonical = urljoin(url, href.group(1).strip())
+    if urlparse(canonical).scheme not in ("http", "https") or canonical == url:
+        return ""
+    return canonical
+
+
 def extract_web_metadata(result, url: str) -> dict:
     """
     Extracts metadata (title, path, links) from a crawl result.
@@ -225,6 +252,13 @@ async def _crawl_single_page(crawler: Any, url: str, config: Any) -> Any:
     )
 
     if not result.success:
+        # A deleted page won't come back on retry; report its status so the
+        # backend can remove it from the index.
+        status_code = getattr(result, "status_code", None)
+        if status_code in (404, 410):
+            raise IngestionError(
+                ERR_CRAWL_GONE, f"Page returned HTTP {status_code}", status_code
+            )
         raise _classify_crawl_error(result.error_message)
 
     return result
@@ -331,6 +365,7 @@ async def handle_web_task(
 
             content = _get_embedding_content(result)
             elapsed_ms = (time_mod.monotonic() - start) * 1000
+            status_code = getattr(result, "status_code", None)
             logger.info(
                 "crawl_completed",
                 operation="handle_web_task",
@@ -350,6 +385,9 @@ async def handle_web_task(
                     "content": content,
                     "links": meta["links"],
                     "metadata": {},  # Web pages have no doc-level metadata
+                    "canonical_url": extract_canonical_url(result, result.url),
+                    "status_code": status_code if isinstance(status_code, int) else 0,
+                    "fetch_ms": int(elapsed_ms),
                 }
             ]
 
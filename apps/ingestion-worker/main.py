@@ -191,6 +191,12 @@ async def process_message(message):
                     "links": res.get("links", []),
                     "depth": data.get("depth", 0),
                 }
+                if res.get("canonical_url"):
+                    result_payload["canonical_url"] = res["canonical_url"]
+                if res.get("status_code"):
+                    result_payload["status_code"] = res["status_code"]
+                if res.get("fetch_ms"):
+                    result_payload["fetch_ms"] = res["fetch_ms"]
 
                 try:
                     producer.pub(
@@ -275,6 +281,8 @@ async def process_message(message):
                 "url": data.get("url", "") or data.get("path", ""),
                 "original_payload": data,
             }
+            if e.status_code:
+                fail_payload["status_code"] = e.status_code
             try:
                 producer.pub(
                     settings.nsq_topic_result,
@@ -67,3 +67,28 @@ async def test_process_message_failure():
         assert payload["status"] == "failed"
         assert payload["code"] == ERR_ENCRYPTED
         assert payload["error"] == "[ERR_ENCRYPTED] Encrypted"
+
+
+@pytest.mark.asyncio
+async def test_process_message_failure_reports_status_code():
+    msg = MagicMock()
+    msg.body = json.dumps(
+        {"id": "123", "type": "web", "url": "http://example.com/removed"}
+    ).encode("utf-8")
+
+    err = IngestionError("ERR_CRAWL_GONE", "Page returned HTTP 404", 404)
+    with (
+        patch("main.handle_web_task", new_callable=AsyncMock, side_effect=err),
+        patch("main.get_crawler", new_callable=AsyncMock, return_value=MagicMock()),
+    ):
+        main.producer = MagicMock()
+        main.producer.pub = MagicMock()
+
+        await main.process_message(msg)
+
+        args, kwargs = main.producer.pub.call_args
+        payload = json.loads(args[1])
+        assert payload["status"] == "failed"
+        assert payload["code"] == "ERR_CRAWL_GONE"
+        assert payload["status_code"] == 404
+        assert payload["url"] == "http://example.com/removed"
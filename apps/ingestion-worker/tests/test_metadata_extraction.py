@@ -1,7 +1,7 @@
 import pytest
 from unittest.mock import MagicMock
 from handlers.file import extract_metadata_from_doc
-from handlers.web import extract_web_metadata
+from handlers.web import extract_web_metadata, extract_canonical_url
 
 # --- File Metadata Tests ---
 
@@ -173,3 +173,24 @@ def test_web_metadata_extraction_logic(crawl_result_mock, url, expected_meta):
     assert meta["path"] == expected_meta["path"]
     if "links_count" in expected_meta:
         assert len(meta["links"]) == expected_meta["links_count"]
+
+
+@pytest.mark.parametrize(
+    "html, url, expected",
+    [
+        (
+            '<head><link rel="canonical" href="/docs/intro"></head>',
+            "https://example.com/docs/intro?ref=nav",
+            "https://example.com/docs/intro",
+        ),
+        (
+            "<link href='https://example.com/a' rel=canonical />",
+            "https://example.com/a",
+            "",
+        ),
+        ('<link rel="stylesheet" href="/a.css">', "https://example.com/a", ""),
+        ("", "https://example.com/a", ""),
+    ],
+)
+def test_extract_canonical_url(html, url, expected):
+    assert extract_canonical_url(MagicMock(html=html), url) == expected
@@ -110,6 +110,28 @@ async def test_handle_web_task_failure():
             await handle_web_task("http://example.com", crawler=mock_crawler)
 
 
+@pytest.mark.asyncio
+async def test_handle_web_task_gone_page_not_retried():
+    from handlers.web import handle_web_task
+    from exceptions import IngestionError, ERR_CRAWL_GONE
+
+    mock_result = MagicMock()
+    mock_result.success = False
+    mock_result.status_code = 410
+    mock_result.error_message = "Failed on navigating ACS-GOTO"
+
+    mock_crawler = AsyncMock()
+    mock_crawler.arun.return_value = mock_result
+
+    with patch("handlers.web.asyncio.sleep", new_callable=AsyncMock):
+        with pytest.raises(IngestionError) as exc_info:
+            await handle_web_task("http://example.com/old", crawler=mock_crawler)
+
+    assert exc_info.value.code == ERR_CRAWL_GONE
+    assert exc_info.value.status_code == 410
+    assert mock_crawler.arun.call_count == 1
+
+
 @pytest.mark.asyncio
 async def test_handle_web_task_internal_links():
     from handlers.web import handle_web_task